@@ -190,8 +190,8 @@ document.getElementById('policyForm').onsubmit = savePolicy;
 
 // Init
 const hash = window.location.hash.replace('#', '');
-if (hash === 'monitoring') {
-    switchTab('monitoring');
+if (hash === 'monitoring' || hash === 'alerts') {
+    switchTab(hash);
 } else {
     loadPolicy(); // Default
 }
@@ -216,12 +216,173 @@ function switchTab(tab, target) {
 
     if (tab === 'monitoring') fetchMetrics();
     if (tab === 'policy') loadPolicy();
+    if (tab === 'alerts') loadAlerts();
 }
 
 document.querySelectorAll('.nav-tab').forEach(el => {
     el.addEventListener('click', (e) => switchTab(e.target.dataset.tab, e.target));
 });
 
+// --- Alerts Logic ---
+
+const alertUnits = {
+    no_leader: 's',
+    leader_gap: 'ms',
+    node_missing: 's',
+    latency_p95: 'ms',
+    disk_usage: '%',
+};
+let alertData = { rules: [], active: [], history: [], nodes: {} };
+
+async function loadAlerts() {
+    try {
+        const res = await fetch('/api/admin/alerts');
+        if (!res.ok) throw new Error(res.statusText);
+        alertData = await res.json();
+        renderAlerts();
+    } catch (e) {
+        showStatus('Failed to load alerts: ' + e.message, true);
+    }
+}
+
+function formatAlert(a) {
+    const unit = alertUnits[a.kind] || '';
+    return `${escapeHtml(a.rule)} on ${escapeHtml(a.subject)}: ${a.value.toFixed(1)}${unit} (threshold ${a.threshold}${unit})`;
+}
+
+function renderAlerts() {
+    const active = document.getElementById('activeAlerts');
+    active.innerHTML = '';
+    (alertData.active || []).forEach(a => {
+        const div = document.createElement('div');
+        div.className = 'list-item error';
+        div.innerHTML = `<span>${formatAlert(a)}</span><span>since ${new Date(a.since * 1000).toLocaleString()}</span>`;
+        active.appendChild(div);
+    });
+    if (active.children.length === 0) {
+        active.innerHTML = '<div class="list-item">No active alerts</div>';
+    }
+
+    const rules = document.getElementById('alertRulesList');
+    rules.innerHTML = '';
+    (alertData.rules || []).forEach(r => {
+        const unit = alertUnits[r.kind] || '';
+        const targets = [r.url, ...(r.email || [])].filter(Boolean).map(escapeHtml).join(', ');
+        const div = document.createElement('div');
+        div.className = 'user-row';
+        div.innerHTML = `
+            <div>${escapeHtml(r.name)}${r.disabled ? ' (disabled)' : ''}</div>
+            <div>${r.kind}${r.nodeId ? ' on ' + escapeHtml(r.nodeId) : ''}</div>
+            <div>&gt; ${r.threshold}${unit}${r.for ? ' for ' + r.for + 's' : ''}</div>
+            <div style="overflow-wrap:anywhere;">${targets}</div>
+            <div>
+                <button type="button" class="btn btn-add" style="background:#f59e0b; margin-right:5px;" data-action="edit-rule" data-id="${escapeHtml(r.id)}">Edit</button>
+                <button type="button" class="btn btn-danger" data-action="delete-rule" data-id="${escapeHtml(r.id)}">Remove</button>
+            </div>
+        `;
+        rules.appendChild(div);
+    });
+
+    const nodeSelect = document.getElementById('alertNode');
+    const selected = nodeSelect.value;
+    nodeSelect.innerHTML = '<option value="">All nodes</option>';
+    Object.keys(alertData.nodes || {}).sort().forEach(id => {
+        const opt = document.createElement('option');
+        opt.value = id;
+        opt.textContent = id;
+        nodeSelect.appendChild(opt);
+    });
+    nodeSelect.value = selected;
+
+    const history = document.getElementById('alertHistory');
+    history.innerHTML = '';
+    (alertData.history || []).forEach(a => {
+        const div = document.createElement('div');
+        div.className = 'list-item';
+        div.innerHTML = `<span>[${a.state}] ${formatAlert(a)}</span><span>${new Date(a.timestamp * 1000).toLocaleString()}</span>`;
+        history.appendChild(div);
+    });
+}
+
+function resetAlertForm() {
+    document.getElementById('alertForm').reset();
+    document.getElementById('alertSecret').textContent = '';
+    document.getElementById('alertId').value = '';
+    document.getElementById('btnSaveAlert').textContent = 'Add Rule';
+}
+
+function editAlertRule(id) {
+    const r = (alertData.rules || []).find(r => r.id === id);
+    if (!r) return;
+    document.getElementById('alertId').value = r.id;
+    document.getElementById('alertName').value = r.name;
+    document.getElementById('alertKind').value = r.kind;
+    document.getElementById('alertThreshold').value = r.threshold;
+    document.getElementById('alertWindow').value = r.window || '';
+    document.getElementById('alertFor').value = r.for || '';
+    document.getElementById('alertNode').value = r.nodeId || '';
+    document.getElementById('alertUrl').value = r.url || '';
+    document.getElementById('alertEmail').value = (r.email || []).join(', ');
+    document.getElementById('alertDisabled').checked = !!r.disabled;
+    document.getElementById('btnSaveAlert').textContent = 'Update Rule';
+    document.getElementById('alertName').scrollIntoView({behavior: "smooth"});
+}
+
+async function saveAlertRule(e) {
+    e.preventDefault();
+    const rule = {
+        id: document.getElementById('alertId').value,
+        name: document.getElementById('alertName').value.trim(),
+        kind: document.getElementById('alertKind').value,
+        threshold: parseFloat(document.getElementById('alertThreshold').value) || 0,
+        window: parseInt(document.getElementById('alertWindow').value) || 0,
+        for: parseInt(document.getElementById('alertFor').value) || 0,
+        nodeId: document.getElementById('alertNode').value,
+        url: document.getElementById('alertUrl').value.trim(),
+        email: document.getElementById('alertEmail').value.split(',').map(s => s.trim()).filter(Boolean),
+        disabled: document.getElementById('alertDisabled').checked,
+    };
+    try {
+        const res = await fetch('/api/admin/alerts', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(rule)
+        });
+        if (!res.ok) throw new Error((await res.text()) || res.statusText);
+        const saved = await res.json();
+        showStatus('Rule saved successfully!', false);
+        resetAlertForm();
+        // The signing secret is only returned when the rule's webhook is first set up.
+        if (saved.secret) {
+            document.getElementById('alertSecret').textContent = 'Webhook signing secret for "' + saved.name + '": ' + saved.secret;
+        }
+        loadAlerts();
+    } catch (e) {
+        showStatus('Failed to save rule: ' + e.message, true);
+    }
+}
+
+async function deleteAlertRule(id) {
+    try {
+        const res = await fetch('/api/admin/alerts/' + encodeURIComponent(id), { method: 'DELETE' });
+        if (!res.ok) throw new Error(res.statusText);
+        loadAlerts();
+    } catch (e) {
+        showStatus('Failed to delete rule: ' + e.message, true);
+    }
+}
+
+document.getElementById('alertRulesList').addEventListener('click', (e) => {
+    const action = e.target.dataset.action;
+    if (action === 'edit-rule') {
+        editAlertRule(e.target.dataset.id);
+    } else if (action === 'delete-rule') {
+        deleteAlertRule(e.target.dataset.id);
+    }
+});
+document.getElementById('alertForm').onsubmit = saveAlertRule;
+document.getElementById('btnRefreshAlerts').addEventListener('click', loadAlerts);
+
 // --- Monitoring Logic ---
 
 let metricData = null;
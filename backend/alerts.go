@@ -0,0 +1,614 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+)
+
+// Alert rule kinds.
+const (
+	AlertNoLeader    = "no_leader"    // Seconds this node has been without a leader
+	AlertLeaderGap   = "leader_gap"   // Leader gap (ms) accumulated over the window
+	AlertNodeMissing = "node_missing" // Seconds since a node last reported metrics
+	AlertLatencyP95  = "latency_p95"  // p95 request latency (ms) of a node over the window
+	AlertDiskUsage   = "disk_usage"   // Percentage of a node's data volume in use
+)
+
+// Alert states.
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+const (
+	alertsFile             = "alerts.json"
+	alertEvalInterval      = 30 * time.Second
+	alertDefaultWindow     = 5 * time.Minute
+	alertHistorySize       = 50
+	alertMaxAttempts       = 3
+	alertDeliveryTimeout   = 10 * time.Second
+	alertDefaultRetryBase  = 5 * time.Second
+	alertClusterSubject    = "cluster"
+	alertWebhookEventType  = "alert"
+	alertMaxRecipients     = 10
+	alertMaxNameLength     = 100
+	alertMaxThresholdValue = 1e9
+)
+
+var alertKinds = []string{AlertNoLeader, AlertLeaderGap, AlertNodeMissing, AlertLatencyP95, AlertDiskUsage}
+
+// AlertRule is a replicated condition on cluster health. The rule fires when
+// the observed value stays above Threshold for For seconds, and notifies its
+// webhook and email recipients when it fires and when it resolves.
+type AlertRule struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Threshold float64  `json:"threshold"`        // Unit depends on Kind: seconds, ms or percent
+	Window    int64    `json:"window,omitempty"` // Seconds of history for leader_gap and latency_p95 (default 300)
+	For       int64    `json:"for,omitempty"`    // Seconds the condition must hold before firing
+	NodeID    string   `json:"nodeId,omitempty"` // Only check this node; empty means all nodes
+	URL       string   `json:"url,omitempty"`    // Webhook receiving alert notifications
+	Secret    string   `json:"secret,omitempty"` // Signs webhook notifications
+	Email     []string `json:"email,omitempty"`  // Recipients, sent through the SMTP relay
+	Disabled  bool     `json:"disabled,omitempty"`
+	CreatedBy string   `json:"createdBy"`
+	CreatedAt int64    `json:"createdAt"`
+}
+
+// Redacted returns a copy of the rule without its signing secret.
+func (a AlertRule) Redacted() AlertRule {
+	a.Secret = ""
+	a.Email = append([]string(nil), a.Email...)
+	return a
+}
+
+// Validate checks that the rule is well formed.
+func (a *AlertRule) Validate() error {
+	if a.ID == "" {
+		return fmt.Errorf("missing alert rule id")
+	}
+	if a.Name == "" || len(a.Name) > alertMaxNameLength || strings.ContainsFunc(a.Name, isControlRune) {
+		return fmt.Errorf("invalid name")
+	}
+	if !isAlertKind(a.Kind) {
+		return fmt.Errorf("unknown kind %q", a.Kind)
+	}
+	if a.Threshold < 0 || a.Threshold > alertMaxThresholdValue {
+		return fmt.Errorf("invalid threshold")
+	}
+	if a.Kind == AlertDiskUsage && a.Threshold > 100 {
+		return fmt.Errorf("disk usage threshold is a percentage")
+	}
+	if a.Window < 0 || a.For < 0 {
+		return fmt.Errorf("window and for must not be negative")
+	}
+	if a.Window > int64((24 * time.Hour).Seconds()) {
+		return fmt.Errorf("window must be at most one day")
+	}
+	if a.URL == "" && len(a.Email) == 0 {
+		return fmt.Errorf("missing url or email")
+	}
+	if a.URL != "" {
+		u, err := url.Parse(a.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url")
+		}
+	}
+	if len(a.Email) > alertMaxRecipients {
+		return fmt.Errorf("too many email recipients")
+	}
+	for _, e := range a.Email {
+		if addr, err := mail.ParseAddress(e); err != nil || addr.Address != e {
+			return fmt.Errorf("invalid email %q", e)
+		}
+	}
+	return nil
+}
+
+// window returns the history the rule looks at, in seconds.
+func (a *AlertRule) window() int64 {
+	if a.Window > 0 {
+		return a.Window
+	}
+	return int64(alertDefaultWindow.Seconds())
+}
+
+func isAlertKind(k string) bool {
+	for _, e := range alertKinds {
+		if e == k {
+			return true
+		}
+	}
+	return false
+}
+
+func isControlRune(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// AlertSample is one observed value of a rule, for a node or the whole cluster.
+type AlertSample struct {
+	Subject string  // Node ID, or "cluster"
+	Value   float64 // In the unit of the rule's threshold
+}
+
+// Alert is a state change of a rule for one subject. It is the JSON body of
+// webhook notifications.
+type Alert struct {
+	RuleID    string  `json:"ruleId"`
+	Rule      string  `json:"rule"`
+	Kind      string  `json:"kind"`
+	State     string  `json:"state"` // "firing" or "resolved"
+	Subject   string  `json:"subject"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Since     int64   `json:"since"`     // When the condition started to hold (Unix seconds)
+	Timestamp int64   `json:"timestamp"` // When the state changed (Unix seconds)
+	NodeID    string  `json:"nodeId"`    // Node that evaluated the rule
+}
+
+// Message returns a one-line human readable description of the alert.
+func (a Alert) Message() string {
+	var unit string
+	switch a.Kind {
+	case AlertNoLeader, AlertNodeMissing:
+		unit = "s"
+	case AlertLeaderGap, AlertLatencyP95:
+		unit = "ms"
+	case AlertDiskUsage:
+		unit = "%"
+	}
+	return fmt.Sprintf("[%s] %s on %s: %s is %.1f%s (threshold %.1f%s)",
+		strings.ToUpper(a.State), a.Rule, a.Subject, a.Kind, a.Value, unit, a.Threshold, unit)
+}
+
+type alertState struct {
+	since  time.Time
+	value  float64
+	firing bool
+}
+
+// AlertManager holds the replicated alert rules, evaluates them and sends
+// notifications. Rules arrive through the FSM; evaluation state lives only
+// on the node that evaluates the rules, see RaftManager.evaluateAlerts.
+type AlertManager struct {
+	storage *storage.Storage
+
+	mu    sync.RWMutex
+	rules map[string]*AlertRule
+
+	evalMu  sync.Mutex
+	state   map[string]*alertState // <ruleID>/<subject>
+	history []Alert
+
+	smtpRelay string
+	smtpFrom  string
+	sendMail  func(addr, from string, to []string, msg []byte) error
+
+	client        *http.Client
+	allowLoopback atomic.Bool
+	retryBase     time.Duration
+}
+
+// NewAlertManager creates an AlertManager and loads persisted rules from storage.
+func NewAlertManager(s *storage.Storage) *AlertManager {
+	m := &AlertManager{
+		storage:   s,
+		rules:     make(map[string]*AlertRule),
+		state:     make(map[string]*alertState),
+		sendMail:  sendMailToRelay,
+		retryBase: alertDefaultRetryBase,
+	}
+	m.client = newWebhookClient(&m.allowLoopback)
+	m.client.Timeout = alertDeliveryTimeout
+	m.load()
+	return m
+}
+
+// AllowLoopback allows alert webhooks to be sent to loopback addresses, which
+// are refused by default. It is meant for tests.
+func (m *AlertManager) AllowLoopback(allow bool) {
+	m.allowLoopback.Store(allow)
+}
+
+// ConfigureSMTP sets the relay, "host:port", that email notifications are
+// sent through, and their From address.
+func (m *AlertManager) ConfigureSMTP(relay, from string) {
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+	m.smtpRelay = relay
+	m.smtpFrom = from
+}
+
+func (m *AlertManager) load() {
+	if m.storage == nil {
+		return
+	}
+	var rules map[string]*AlertRule
+	if err := m.storage.ReadDataFile(alertsFile, &rules); err != nil {
+		if !os.IsNotExist(err) {
+			alertLog.Error("failed to read alert rules", "file", alertsFile, "err", err)
+		}
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rules != nil {
+		m.rules = rules
+	}
+}
+
+// saveLocked persists the rule map. m.mu must be held.
+func (m *AlertManager) saveLocked() error {
+	if m.storage == nil {
+		return nil
+	}
+	if err := m.storage.SaveDataFile(alertsFile, m.rules); err != nil {
+		return fmt.Errorf("failed to save alert rules: %w", err)
+	}
+	return nil
+}
+
+// Put inserts or replaces a rule.
+func (m *AlertManager) Put(a *AlertRule) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	c := *a
+	c.Email = append([]string(nil), a.Email...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules[c.ID] = &c
+	return m.saveLocked()
+}
+
+// Delete removes a rule.
+func (m *AlertManager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rules, id)
+	return m.saveLocked()
+}
+
+// Replace swaps the full rule set (used by snapshot restore).
+func (m *AlertManager) Replace(rules map[string]*AlertRule) error {
+	if rules == nil {
+		rules = make(map[string]*AlertRule)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+	return m.saveLocked()
+}
+
+// Get returns a copy of the rule with the given ID.
+func (m *AlertManager) Get(id string) (AlertRule, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	a, ok := m.rules[id]
+	if !ok {
+		return AlertRule{}, false
+	}
+	return *a, true
+}
+
+// List returns all rules, sorted by creation time.
+func (m *AlertManager) List() []AlertRule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]AlertRule, 0, len(m.rules))
+	for _, a := range m.rules {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Active returns the alerts that are currently firing on this node.
+func (m *AlertManager) Active() []Alert {
+	rules := make(map[string]*AlertRule)
+	for _, r := range m.List() {
+		rules[r.ID] = &r
+	}
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+	out := make([]Alert, 0)
+	for key, st := range m.state {
+		if !st.firing {
+			continue
+		}
+		ruleID, subject, _ := strings.Cut(key, "/")
+		if r, ok := rules[ruleID]; ok {
+			out = append(out, newAlert(r, subject, AlertFiring, st, st.since, ""))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Since < out[j].Since })
+	return out
+}
+
+// History returns the most recent state changes on this node, newest first.
+func (m *AlertManager) History() []Alert {
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+	out := make([]Alert, len(m.history))
+	for i := range m.history {
+		out[i] = m.history[len(m.history)-1-i]
+	}
+	return out
+}
+
+// Reset forgets the evaluation state, e.g. when this node stops evaluating
+// rules. Alerts that were firing are not resolved.
+func (m *AlertManager) Reset() {
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+	clear(m.state)
+}
+
+func newAlert(r *AlertRule, subject, state string, st *alertState, now time.Time, nodeID string) Alert {
+	return Alert{
+		RuleID:    r.ID,
+		Rule:      r.Name,
+		Kind:      r.Kind,
+		State:     state,
+		Subject:   subject,
+		Value:     st.value,
+		Threshold: r.Threshold,
+		Since:     st.since.Unix(),
+		Timestamp: now.Unix(),
+		NodeID:    nodeID,
+	}
+}
+
+// Evaluate checks every enabled rule against the samples returned by sample,
+// which reports false for rules that this node does not evaluate. It returns
+// the alerts that changed state and sends their notifications.
+func (m *AlertManager) Evaluate(now time.Time, nodeID string, sample func(r *AlertRule) ([]AlertSample, bool)) []Alert {
+	rules := m.List()
+
+	m.evalMu.Lock()
+	var changed []Alert
+	seen := make(map[string]bool)
+	for i := range rules {
+		r := &rules[i]
+		if r.Disabled {
+			continue
+		}
+		samples, ok := sample(r)
+		if !ok {
+			continue
+		}
+		for _, s := range samples {
+			key := r.ID + "/" + s.Subject
+			st := m.state[key]
+			if s.Value <= r.Threshold {
+				continue
+			}
+			seen[key] = true
+			if st == nil {
+				st = &alertState{since: now}
+				m.state[key] = st
+			}
+			st.value = s.Value
+			if !st.firing && now.Sub(st.since) >= time.Duration(r.For)*time.Second {
+				st.firing = true
+				changed = append(changed, newAlert(r, s.Subject, AlertFiring, st, now, nodeID))
+			}
+		}
+		// Subjects that are no longer above the threshold, or no longer
+		// observed (e.g. a node that left), resolve.
+		for key, st := range m.state {
+			ruleID, subject, _ := strings.Cut(key, "/")
+			if ruleID != r.ID || seen[key] {
+				continue
+			}
+			delete(m.state, key)
+			if st.firing {
+				var v float64
+				for _, s := range samples {
+					if s.Subject == subject {
+						v = s.Value
+					}
+				}
+				st.value = v
+				changed = append(changed, newAlert(r, subject, AlertResolved, st, now, nodeID))
+			}
+		}
+		seen[r.ID] = true
+	}
+	// Forget rules that were deleted, disabled or are not evaluated here.
+	for key := range m.state {
+		ruleID, _, _ := strings.Cut(key, "/")
+		if !seen[ruleID] {
+			delete(m.state, key)
+		}
+	}
+	m.history = append(m.history, changed...)
+	if len(m.history) > alertHistorySize {
+		m.history = m.history[len(m.history)-alertHistorySize:]
+	}
+	relay, from := m.smtpRelay, m.smtpFrom
+	m.evalMu.Unlock()
+
+	for _, a := range changed {
+		alertLog.Warn("alert "+a.State, "rule", a.Rule, "kind", a.Kind, "subject", a.Subject, "value", a.Value, "threshold", a.Threshold)
+		r, ok := m.Get(a.RuleID)
+		if !ok {
+			continue
+		}
+		go m.notify(r, a, relay, from)
+	}
+	return changed
+}
+
+// notify sends an alert to the rule's webhook and email recipients.
+func (m *AlertManager) notify(r AlertRule, a Alert, relay, from string) {
+	if r.URL != "" {
+		m.notifyWebhook(r, a)
+	}
+	if len(r.Email) > 0 {
+		if relay == "" {
+			alertLog.Warn("no SMTP relay configured, not sending email", "rule", r.Name)
+			return
+		}
+		if err := m.sendMail(relay, from, r.Email, alertEmail(a, from, r.Email)); err != nil {
+			alertLog.Error("failed to send alert email", "rule", r.Name, "relay", relay, "err", err)
+		}
+	}
+}
+
+func (m *AlertManager) notifyWebhook(r AlertRule, a Alert) {
+	body, err := json.Marshal(a)
+	if err != nil {
+		alertLog.Error("failed to marshal alert", "rule", r.Name, "err", err)
+		return
+	}
+	for attempt := 1; ; attempt++ {
+		err = m.postAlert(r, body)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, errWebhookAddrBlocked) || attempt >= alertMaxAttempts {
+			alertLog.Error("giving up on alert webhook", "rule", r.Name, "attempts", attempt, "err", err)
+			return
+		}
+		time.Sleep(m.retryBase << (attempt - 1))
+	}
+}
+
+func (m *AlertManager) postAlert(r AlertRule, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Skorekeeper-Webhook/"+CurrentAppVersion)
+	req.Header.Set("X-Skorekeeper-Event", alertWebhookEventType)
+	if r.Secret != "" {
+		req.Header.Set("X-Skorekeeper-Signature", signWebhookBody(r.Secret, body))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// alertEmail formats an alert as a plain text email.
+func alertEmail(a Alert, from string, to []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: Skorekeeper alert: %s\r\n", a.Message())
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Unix(a.Timestamp, 0).UTC().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n", a.Message())
+	fmt.Fprintf(&b, "Rule:      %s (%s)\r\n", a.Rule, a.RuleID)
+	fmt.Fprintf(&b, "Subject:   %s\r\n", a.Subject)
+	fmt.Fprintf(&b, "Since:     %s\r\n", time.Unix(a.Since, 0).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Evaluated: %s on node %s\r\n", time.Unix(a.Timestamp, 0).UTC().Format(time.RFC3339), a.NodeID)
+	return []byte(b.String())
+}
+
+// sendMailToRelay sends a message through an unauthenticated local relay.
+func sendMailToRelay(addr, from string, to []string, msg []byte) error {
+	return smtp.SendMail(addr, nil, from, to, msg)
+}
+
+// alertSamples computes the samples of a metrics-based rule. nodes are the
+// cluster members, and start is when this node began evaluating rules, so that
+// a node that never reported is only considered missing after the rule's
+// threshold has passed since then. s must not be modified concurrently.
+func alertSamples(s *MetricsStore, nodes []string, r *AlertRule, now, start int64) []AlertSample {
+	var out []AlertSample
+	from := now - r.window()
+	switch r.Kind {
+	case AlertLeaderGap:
+		var sum float64
+		if series, ok := s.ClusterMetrics["leaderGapMs"]; ok {
+			for _, p := range series.Buffers["1m"].Range(from, now) {
+				sum += p.Value
+			}
+		}
+		out = append(out, AlertSample{Subject: alertClusterSubject, Value: sum})
+	case AlertNodeMissing:
+		for _, id := range nodes {
+			last := start
+			if series, ok := s.NodeMetrics[id]; ok {
+				if pts := series.Buffers["1m"].GetPoints(); len(pts) > 0 && pts[len(pts)-1].Timestamp > last {
+					last = pts[len(pts)-1].Timestamp
+				}
+			}
+			out = append(out, AlertSample{Subject: id, Value: float64(now - last)})
+		}
+	case AlertLatencyP95:
+		for _, id := range nodes {
+			series, ok := s.NodeLatencies[id]
+			if !ok {
+				continue
+			}
+			var h Histogram
+			for _, p := range series.Buffers["1m"].Range(from, now) {
+				h.Merge(&p.Value)
+			}
+			if h.Count == 0 {
+				continue
+			}
+			out = append(out, AlertSample{Subject: id, Value: h.Quantile(0.95)})
+		}
+	case AlertDiskUsage:
+		for _, id := range nodes {
+			if pct, ok := s.NodeDisk[id]; ok {
+				out = append(out, AlertSample{Subject: id, Value: pct})
+			}
+		}
+	}
+	if r.NodeID != "" {
+		filtered := out[:0]
+		for _, smp := range out {
+			if smp.Subject == r.NodeID {
+				filtered = append(filtered, smp)
+			}
+		}
+		out = filtered
+	}
+	return out
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
+)
+
+func TestAlertRuleValidate(t *testing.T) {
+	ok := AlertRule{ID: "x", Name: "Disk", Kind: AlertDiskUsage, Threshold: 90, Email: []string{"ops@example.com"}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid rule rejected: %v", err)
+	}
+	for name, a := range map[string]AlertRule{
+		"kind":    {ID: "x", Name: "n", Kind: "cpu", URL: "https://example.com"},
+		"name":    {ID: "x", Name: "a\r\nBcc: x@example.com", Kind: AlertNoLeader, URL: "https://example.com"},
+		"percent": {ID: "x", Name: "n", Kind: AlertDiskUsage, Threshold: 120, URL: "https://example.com"},
+		"target":  {ID: "x", Name: "n", Kind: AlertNoLeader},
+		"email":   {ID: "x", Name: "n", Kind: AlertNoLeader, Email: []string{"Ops <ops@example.com>"}},
+		"scheme":  {ID: "x", Name: "n", Kind: AlertNoLeader, URL: "ftp://example.com"},
+	} {
+		if err := a.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestAlertEvaluate(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), s, us)
+
+	var mu sync.Mutex
+	var posted []Alert
+	var signatures []string
+	var mails []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var a Alert
+		json.Unmarshal(body, &a)
+		mu.Lock()
+		defer mu.Unlock()
+		if sig := r.Header.Get("X-Skorekeeper-Signature"); sig != signWebhookBody("s3cret", body) {
+			signatures = append(signatures, sig)
+		}
+		posted = append(posted, a)
+	}))
+	defer srv.Close()
+
+	m := fsm.Alerts()
+	m.AllowLoopback(true)
+	m.ConfigureSMTP("relay:25", "alerts@example.com")
+	m.sendMail = func(addr, from string, to []string, msg []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if addr != "relay:25" || from != "alerts@example.com" || len(to) != 1 || to[0] != "ops@example.com" {
+			t.Errorf("unexpected mail envelope: %s %s %v", addr, from, to)
+		}
+		mails = append(mails, string(msg))
+		return nil
+	}
+
+	apply := func(cmd RaftCommand) {
+		t.Helper()
+		data, _ := json.Marshal(cmd)
+		if resp := fsm.Apply(&raft.Log{Data: data}); resp != nil {
+			if err, ok := resp.(error); ok && err != nil {
+				t.Fatalf("Apply %s failed: %v", cmd.Type, err)
+			}
+		}
+	}
+	apply(RaftCommand{Type: CmdUpdateAlertRule, AlertRule: &AlertRule{
+		ID:        "rule-1",
+		Name:      "Slow node",
+		Kind:      AlertLatencyP95,
+		Threshold: 500,
+		For:       60,
+		URL:       srv.URL,
+		Secret:    "s3cret",
+		Email:     []string{"ops@example.com"},
+	}})
+
+	values := map[string]float64{"n1": 800, "n2": 100}
+	sample := func(r *AlertRule) ([]AlertSample, bool) {
+		var out []AlertSample
+		for _, id := range []string{"n1", "n2"} {
+			out = append(out, AlertSample{Subject: id, Value: values[id]})
+		}
+		return out, true
+	}
+
+	now := time.Unix(1700000000, 0)
+	if got := m.Evaluate(now, "leader", sample); len(got) != 0 {
+		t.Errorf("alert fired before its duration: %+v", got)
+	}
+	got := m.Evaluate(now.Add(time.Minute), "leader", sample)
+	if len(got) != 1 || got[0].State != AlertFiring || got[0].Subject != "n1" || got[0].Value != 800 || got[0].Since != now.Unix() {
+		t.Fatalf("expected n1 to fire: %+v", got)
+	}
+	if got := m.Evaluate(now.Add(90*time.Second), "leader", sample); len(got) != 0 {
+		t.Errorf("firing alert notified again: %+v", got)
+	}
+	if a := m.Active(); len(a) != 1 || a[0].Subject != "n1" {
+		t.Errorf("unexpected active alerts: %+v", a)
+	}
+
+	values["n1"] = 200
+	got = m.Evaluate(now.Add(2*time.Minute), "leader", sample)
+	if len(got) != 1 || got[0].State != AlertResolved || got[0].Value != 200 {
+		t.Fatalf("expected n1 to resolve: %+v", got)
+	}
+	if h := m.History(); len(h) != 2 || h[0].State != AlertResolved {
+		t.Errorf("unexpected history: %+v", h)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(posted) + len(mails)
+		mu.Unlock()
+		if n >= 4 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posted) != 2 || len(mails) != 2 || len(signatures) != 0 {
+		t.Fatalf("expected 2 signed webhooks and 2 emails, got %d (bad signatures %v) and %d", len(posted), signatures, len(mails))
+	}
+	for _, msg := range mails {
+		if !strings.Contains(msg, "Subject: Skorekeeper alert: [") || !strings.Contains(msg, "Slow node on n1") {
+			t.Errorf("unexpected email:\n%s", msg)
+		}
+	}
+
+	// Rules are persisted, and deleting a rule forgets its state.
+	if r, ok := NewAlertManager(s).Get("rule-1"); !ok || r.Secret != "s3cret" {
+		t.Errorf("rule not persisted: %+v", r)
+	}
+	values["n1"] = 800
+	m.Evaluate(now.Add(3*time.Minute), "leader", sample)
+	apply(RaftCommand{Type: CmdDeleteAlertRule, ID: "rule-1"})
+	if got := m.Evaluate(now.Add(5*time.Minute), "leader", sample); len(got) != 0 || len(m.state) != 0 {
+		t.Errorf("deleted rule still evaluated: %+v", got)
+	}
+}
+
+func TestAlertWebhookBlockedAddress(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	m := NewAlertManager(nil)
+	for _, u := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/"} {
+		if err := m.postAlert(AlertRule{URL: u}, []byte("{}")); !errors.Is(err, errWebhookAddrBlocked) {
+			t.Errorf("postAlert(%q) = %v, want errWebhookAddrBlocked", u, err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("loopback server received %d alerts", n)
+	}
+}
+
+func TestAlertSamples(t *testing.T) {
+	const now = 1700000040 // On a minute boundary
+	s := NewMetricsStore()
+	s.GetNodeSeries("n1").Ingest(now-60, 1)
+	s.GetNodeSeries("n2").Ingest(now-600, 1)
+	var h Histogram
+	for i := 0; i < 90; i++ {
+		h.Add(20 * time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.Add(time.Second)
+	}
+	s.GetNodeLatencySeries("n1").Ingest(now-120, &h)
+	s.GetNodeLatencySeries("n1").Ingest(now-3600, &Histogram{Count: 1000, Buckets: [LatencyBuckets]uint64{100: 1000}})
+	s.GetClusterSeries("leaderGapMs").Ingest(now-120, 1500)
+	s.GetClusterSeries("leaderGapMs").Ingest(now-60, 2500)
+	s.NodeDisk = map[string]float64{"n1": 42.5}
+	nodes := []string{"n1", "n2", "n3"}
+
+	check := func(r AlertRule, want []AlertSample) {
+		t.Helper()
+		got := alertSamples(s, nodes, &r, now, now-300)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %+v, want %+v", r.Kind, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s: got %+v, want %+v", r.Kind, got, want)
+			}
+		}
+	}
+	check(AlertRule{Kind: AlertLeaderGap}, []AlertSample{{"cluster", 4000}})
+	check(AlertRule{Kind: AlertLeaderGap, Window: 90}, []AlertSample{{"cluster", 2500}})
+	check(AlertRule{Kind: AlertNodeMissing}, []AlertSample{{"n1", 60}, {"n2", 300}, {"n3", 300}})
+	check(AlertRule{Kind: AlertNodeMissing, NodeID: "n2"}, []AlertSample{{"n2", 300}})
+	check(AlertRule{Kind: AlertLatencyP95}, []AlertSample{{"n1", 1025}})
+	check(AlertRule{Kind: AlertDiskUsage}, []AlertSample{{"n1", 42.5}})
+}
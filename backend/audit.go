@@ -0,0 +1,589 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/c2FmZQ/storage"
+)
+
+const (
+	auditDir       = "audit"
+	auditSystemLog = "audit/system"
+
+	// auditSegmentSize is the number of entries per segment file. An append
+	// only rewrites the newest segment, so its cost does not grow with the log.
+	auditSegmentSize = 256
+	// auditSystemSegments caps the system log, and auditLogSegments the log
+	// of each game and team. The oldest segment is dropped when a new one
+	// would exceed the cap.
+	auditSystemSegments = 64
+	auditLogSegments    = 16
+)
+
+// AuditAction identifies a game action carried by an APPLY_ACTION command.
+type AuditAction struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// AuditEntry records one applied FSM command.
+// All fields are derived from the replicated log entry, so every node records the same history.
+type AuditEntry struct {
+	Index     uint64        `json:"index"`
+	Timestamp int64         `json:"timestamp"` // Proposal time (Unix ms)
+	UserID    string        `json:"userId,omitempty"`
+	NodeID    string        `json:"nodeId,omitempty"` // Node that proposed the command
+	Command   CommandType   `json:"command"`
+	Target    string        `json:"target,omitempty"` // Node, webhook, or user affected by a system command
+	Actions   []AuditAction `json:"actions,omitempty"`
+	Changes   []string      `json:"changes,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuditLog is the append-only history of a single game, team, or the system,
+// or one segment of it.
+type AuditLog struct {
+	LastIndex uint64       `json:"lastIndex"`
+	Entries   []AuditEntry `json:"entries"`
+}
+
+// AuditStore persists audit logs in the FSM storage directory.
+// Logs outlive the resources they describe so deletions remain attributable.
+//
+// Each log is a directory of segment files holding segmentSize entries each,
+// named after the index of their first entry. Appends go to the newest
+// segment in memory, which is written when it is full or by FlushAll, like
+// dirty games. Entries lost in a crash are recorded again when the Raft log
+// is replayed.
+type AuditStore struct {
+	storage *storage.Storage
+	mu      sync.Mutex
+
+	tails map[string]*auditTail // Logs appended to since the last FlushAll
+	dirty map[string]bool       // Logs whose newest segment is not saved
+
+	segmentSize    int
+	systemSegments int
+	logSegments    int
+}
+
+// auditTail is the in-memory state of a log that is being appended to.
+type auditTail struct {
+	files []string  // Segment files, oldest first. The last one is open.
+	open  *AuditLog // Contents of the open segment
+}
+
+// NewAuditStore creates an AuditStore backed by s.
+func NewAuditStore(s *storage.Storage) *AuditStore {
+	return &AuditStore{
+		storage:        s,
+		tails:          make(map[string]*auditTail),
+		dirty:          make(map[string]bool),
+		segmentSize:    auditSegmentSize,
+		systemSegments: auditSystemSegments,
+		logSegments:    auditLogSegments,
+	}
+}
+
+func auditGameLog(id string) string {
+	return fmt.Sprintf("%s/games/%s", auditDir, url.PathEscape(id))
+}
+
+func auditTeamLog(id string) string {
+	return fmt.Sprintf("%s/teams/%s", auditDir, url.PathEscape(id))
+}
+
+func auditSegmentFile(log string, firstIndex uint64) string {
+	return fmt.Sprintf("%s/%020d.json", log, firstIndex)
+}
+
+// auditLogForCommand returns the log a command is recorded in.
+func auditLogForCommand(cmd RaftCommand) string {
+	switch cmd.Type {
+	case CmdSaveGame, CmdDeleteGame, CmdRestoreGame, CmdPurgeGame:
+		return auditGameLog(cmd.ID)
+	case CmdApplyAction:
+		if cmd.Action != nil {
+			return auditGameLog(cmd.Action.GameID)
+		}
+	case CmdSaveTeam, CmdDeleteTeam, CmdRestoreTeam, CmdPurgeTeam:
+		return auditTeamLog(cmd.ID)
+	}
+	return auditSystemLog
+}
+
+// isAuditedCommand reports whether a command type is recorded.
+// Periodic metrics reports and webhook acknowledgements carry no user intent
+// and would drown out the system log.
+func isAuditedCommand(t CommandType) bool {
+	return t != CmdMetricsUpdate && t != CmdAckWebhooks
+}
+
+// segments returns the segment files of a log, oldest first.
+func (a *AuditStore) segments(log string) ([]string, error) {
+	des, err := os.ReadDir(filepath.Join(a.storage.Dir(), filepath.FromSlash(log)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []string
+	for _, de := range des {
+		if !de.IsDir() && strings.HasSuffix(de.Name(), ".json") {
+			files = append(files, log+"/"+de.Name())
+		}
+	}
+	return files, nil
+}
+
+func (a *AuditStore) readSegment(file string) (*AuditLog, error) {
+	var l AuditLog
+	if err := a.storage.ReadDataFile(file, &l); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", file, err)
+	}
+	return &l, nil
+}
+
+// view returns the segment files of a log and the contents of the newest
+// one, including entries that are not flushed yet. a.mu must be held.
+func (a *AuditStore) view(log string) ([]string, *AuditLog, error) {
+	if t, ok := a.tails[log]; ok {
+		return t.files, t.open, nil
+	}
+	files, err := a.segments(log)
+	if err != nil || len(files) == 0 {
+		return nil, nil, err
+	}
+	newest, err := a.readSegment(files[len(files)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, newest, nil
+}
+
+// Read returns a whole audit log. A missing log is empty.
+func (a *AuditStore) Read(log string) (*AuditLog, error) {
+	l := &AuditLog{}
+	if a.storage == nil {
+		return l, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	files, newest, err := a.view(log)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		s := newest
+		if i < len(files)-1 {
+			if s, err = a.readSegment(file); err != nil {
+				return nil, err
+			}
+		}
+		l.Entries = append(l.Entries, s.Entries...)
+		l.LastIndex = s.LastIndex
+	}
+	return l, nil
+}
+
+// ReadPage returns up to limit entries of a log, newest first, skipping the
+// newest offset entries, and the total number of entries. Only the segments
+// covering the page are read.
+func (a *AuditStore) ReadPage(log string, offset, limit int) ([]AuditEntry, int, error) {
+	page := make([]AuditEntry, 0, limit)
+	if a.storage == nil {
+		return page, 0, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	files, newest, err := a.view(log)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(files) == 0 {
+		return page, 0, nil
+	}
+	// Every segment but the newest is full.
+	total := (len(files)-1)*a.segmentSize + len(newest.Entries)
+
+	skip := offset
+	for i := len(files) - 1; i >= 0 && len(page) < limit; i-- {
+		s := newest
+		if i < len(files)-1 {
+			if skip >= a.segmentSize {
+				skip -= a.segmentSize
+				continue
+			}
+			if s, err = a.readSegment(files[i]); err != nil {
+				return nil, 0, err
+			}
+		}
+		for j := len(s.Entries) - 1; j >= 0 && len(page) < limit; j-- {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			page = append(page, s.Entries[j])
+		}
+	}
+	return page, total, nil
+}
+
+// tail returns the in-memory state of a log, loading its newest segment on
+// first use. a.mu must be held.
+func (a *AuditStore) tail(log string) (*auditTail, error) {
+	if t, ok := a.tails[log]; ok {
+		return t, nil
+	}
+	files, err := a.segments(log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log %s: %w", log, err)
+	}
+	t := &auditTail{files: files, open: &AuditLog{}}
+	if len(files) > 0 {
+		if t.open, err = a.readSegment(files[len(files)-1]); err != nil {
+			return nil, err
+		}
+	}
+	a.tails[log] = t
+	return t, nil
+}
+
+// flush saves the open segment of a log if it is dirty. a.mu must be held.
+func (a *AuditStore) flush(log string, t *auditTail) error {
+	if !a.dirty[log] {
+		return nil
+	}
+	file := t.files[len(t.files)-1]
+	if err := a.storage.SaveDataFile(file, t.open); err != nil {
+		return fmt.Errorf("failed to save audit log %s: %w", file, err)
+	}
+	delete(a.dirty, log)
+	return nil
+}
+
+// Append adds entries to a log. Entries at or below the log's last index were
+// recorded before (log replay after restart) and are skipped. Only full
+// segments are written; the newest one is written by FlushAll.
+func (a *AuditStore) Append(log string, entries ...AuditEntry) error {
+	if a.storage == nil || len(entries) == 0 {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t, err := a.tail(log)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Index <= t.open.LastIndex {
+			continue
+		}
+		if len(t.files) == 0 || len(t.open.Entries) >= a.segmentSize {
+			if err := a.flush(log, t); err != nil {
+				return err
+			}
+			t.files = append(t.files, auditSegmentFile(log, e.Index))
+			t.open = &AuditLog{}
+		}
+		t.open.Entries = append(t.open.Entries, e)
+		t.open.LastIndex = e.Index
+		a.dirty[log] = true
+	}
+	keep := a.logSegments
+	if log == auditSystemLog {
+		keep = a.systemSegments
+	}
+	if len(t.files) > keep {
+		for _, old := range t.files[:len(t.files)-keep] {
+			if err := os.Remove(filepath.Join(a.storage.Dir(), filepath.FromSlash(old))); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove audit log %s: %w", old, err)
+			}
+		}
+		t.files = t.files[len(t.files)-keep:]
+	}
+	return nil
+}
+
+// FlushAll saves the open segments of all logs, and forgets them until they
+// are appended to again.
+func (a *AuditStore) FlushAll() error {
+	if a.storage == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for log, t := range a.tails {
+		if err := a.flush(log, t); err != nil {
+			return err
+		}
+		delete(a.tails, log)
+	}
+	return nil
+}
+
+// Reset removes all audit logs before a snapshot is restored, so segments
+// of the local history cannot mix with the restored ones.
+func (a *AuditStore) Reset() error {
+	if a.storage == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tails = make(map[string]*auditTail)
+	a.dirty = make(map[string]bool)
+	return os.RemoveAll(filepath.Join(a.storage.Dir(), auditDir))
+}
+
+// Restore overwrites a log segment with the copy from a snapshot.
+func (a *AuditStore) Restore(file string, l *AuditLog) error {
+	if a.storage == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.storage.SaveDataFile(file, l)
+}
+
+// ListFiles returns the paths of all audit log segments on disk, relative to
+// the storage directory. Call FlushAll first to include the newest entries.
+func (a *AuditStore) ListFiles() ([]string, error) {
+	if a.storage == nil {
+		return nil, nil
+	}
+	root := a.storage.Dir()
+	var files []string
+	err := filepath.Walk(filepath.Join(root, auditDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// newAuditEntry builds the entry for a command. Changes are filled in by the
+// caller, which knows the state the command was applied to.
+func newAuditEntry(cmd RaftCommand, index uint64) AuditEntry {
+	e := AuditEntry{
+		Index:     index,
+		Timestamp: cmd.Timestamp,
+		UserID:    cmd.UserID,
+		NodeID:    cmd.NodeID,
+		Command:   cmd.Type,
+	}
+	if e.UserID == "" && cmd.Action != nil {
+		e.UserID = cmd.Action.UserID
+	}
+	switch cmd.Type {
+	case CmdApplyAction:
+		if cmd.Action != nil {
+			raw := cmd.Action.Actions
+			if len(raw) == 0 && len(cmd.Action.Action) > 0 {
+				raw = []json.RawMessage{cmd.Action.Action}
+			}
+			for _, r := range raw {
+				var a BaseAction
+				if err := json.Unmarshal(r, &a); err == nil {
+					e.Actions = append(e.Actions, AuditAction{ID: a.ID, Type: a.Type})
+				}
+			}
+		}
+	case CmdNodeMeta, CmdNodeLeft:
+		if cmd.NodeMeta != nil {
+			e.Target = cmd.NodeMeta.NodeID
+		}
+	case CmdUpdateWebhook:
+		if cmd.Webhook != nil {
+			e.Target = cmd.Webhook.ID
+		}
+	case CmdDeleteWebhook:
+		e.Target = cmd.ID
+	case CmdUpdateAlertRule:
+		if cmd.AlertRule != nil {
+			e.Target = cmd.AlertRule.ID
+		}
+	case CmdDeleteAlertRule:
+		e.Target = cmd.ID
+	case CmdPutSavedSearch:
+		if cmd.SavedSearch != nil {
+			e.Target = cmd.SavedSearch.ID
+		}
+	case CmdDeleteSavedSearch:
+		e.Target = cmd.ID
+	case CmdSetActiveSchemaVersion:
+		e.Target = strconv.Itoa(cmd.SchemaVersion)
+	case CmdDeleteAllUser:
+		if cmd.Action != nil {
+			e.Target = cmd.Action.UserID
+		}
+	}
+	return e
+}
+
+// gameChanges summarizes what a SAVE_GAME replaced.
+func gameChanges(prev, next *Game) []string {
+	if prev == nil || (prev.OwnerID == "" && len(prev.ActionLog) == 0) {
+		return []string{"created"}
+	}
+	var out []string
+	diff := func(field, a, b string) {
+		if a != b {
+			out = append(out, fmt.Sprintf("%s: %q -> %q", field, a, b))
+		}
+	}
+	diff("owner", prev.OwnerID, next.OwnerID)
+	diff("pendingOwner", prev.PendingOwner, next.PendingOwner)
+	diff("status", prev.Status, next.Status)
+	diff("away", prev.Away, next.Away)
+	diff("home", prev.Home, next.Home)
+	diff("awayTeamId", prev.AwayTeamID, next.AwayTeamID)
+	diff("homeTeamId", prev.HomeTeamID, next.HomeTeamID)
+	diff("date", prev.Date, next.Date)
+	diff("public", prev.Permissions.Public, next.Permissions.Public)
+	out = append(out, diffGrants(prev.Permissions.Users, next.Permissions.Users)...)
+	if len(prev.ActionLog) != len(next.ActionLog) {
+		out = append(out, fmt.Sprintf("actions: %d -> %d", len(prev.ActionLog), len(next.ActionLog)))
+	}
+	return out
+}
+
+func diffGrants(prev, next map[string]string) []string {
+	var out []string
+	for u, p := range next {
+		if old, ok := prev[u]; !ok {
+			out = append(out, fmt.Sprintf("+%s %s", p, u))
+		} else if old != p {
+			out = append(out, fmt.Sprintf("%s: %s -> %s", u, old, p))
+		}
+	}
+	for u, p := range prev {
+		if _, ok := next[u]; !ok {
+			out = append(out, fmt.Sprintf("-%s %s", p, u))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// teamChanges summarizes what a SAVE_TEAM replaced, including role membership.
+func teamChanges(prev, next *Team) []string {
+	if prev == nil || prev.OwnerID == "" {
+		return []string{"created"}
+	}
+	var out []string
+	if prev.OwnerID != next.OwnerID {
+		out = append(out, fmt.Sprintf("owner: %q -> %q", prev.OwnerID, next.OwnerID))
+	}
+	if prev.PendingOwner != next.PendingOwner {
+		out = append(out, fmt.Sprintf("pendingOwner: %q -> %q", prev.PendingOwner, next.PendingOwner))
+	}
+	if prev.Name != next.Name {
+		out = append(out, fmt.Sprintf("name: %q -> %q", prev.Name, next.Name))
+	}
+	out = append(out, diffMembers("admin", prev.Roles.Admins, next.Roles.Admins)...)
+	out = append(out, diffMembers("scorekeeper", prev.Roles.Scorekeepers, next.Roles.Scorekeepers)...)
+	out = append(out, diffMembers("spectator", prev.Roles.Spectators, next.Roles.Spectators)...)
+	if len(prev.Roster) != len(next.Roster) {
+		out = append(out, fmt.Sprintf("roster: %d -> %d players", len(prev.Roster), len(next.Roster)))
+	}
+	if prev.CalendarToken != next.CalendarToken {
+		if next.CalendarToken == "" {
+			out = append(out, "calendar token revoked")
+		} else {
+			out = append(out, "calendar token rotated")
+		}
+	}
+	if prev.Status != next.Status {
+		out = append(out, fmt.Sprintf("status: %q -> %q", prev.Status, next.Status))
+	}
+	return out
+}
+
+func diffMembers(role string, prev, next []string) []string {
+	var out []string
+	old := make(map[string]bool, len(prev))
+	for _, u := range prev {
+		old[normalizeEmail(u)] = true
+	}
+	cur := make(map[string]bool, len(next))
+	for _, u := range next {
+		u = normalizeEmail(u)
+		cur[u] = true
+		if !old[u] {
+			out = append(out, fmt.Sprintf("+%s %s", role, u))
+		}
+	}
+	for _, u := range prev {
+		if u = normalizeEmail(u); !cur[u] {
+			out = append(out, fmt.Sprintf("-%s %s", role, u))
+		}
+	}
+	return out
+}
+
+// policyChanges summarizes an access policy update.
+func policyChanges(prev, next *UserAccessPolicy) []string {
+	if prev == nil {
+		prev = &UserAccessPolicy{}
+	}
+	var out []string
+	if prev.DefaultPolicy != next.DefaultPolicy {
+		out = append(out, fmt.Sprintf("defaultPolicy: %q -> %q", prev.DefaultPolicy, next.DefaultPolicy))
+	}
+	if prev.DefaultMaxGames != next.DefaultMaxGames {
+		out = append(out, fmt.Sprintf("defaultMaxGames: %d -> %d", prev.DefaultMaxGames, next.DefaultMaxGames))
+	}
+	if prev.DefaultMaxTeams != next.DefaultMaxTeams {
+		out = append(out, fmt.Sprintf("defaultMaxTeams: %d -> %d", prev.DefaultMaxTeams, next.DefaultMaxTeams))
+	}
+	out = append(out, diffMembers("admin", prev.Admins, next.Admins)...)
+	var users []string
+	for u, o := range next.Users {
+		if p, ok := prev.Users[u]; !ok || p != o {
+			users = append(users, fmt.Sprintf("user %s: access=%s maxGames=%d maxTeams=%d", u, o.Access, o.MaxGames, o.MaxTeams))
+		}
+	}
+	for u := range prev.Users {
+		if _, ok := next.Users[u]; !ok {
+			users = append(users, fmt.Sprintf("user %s: override removed", u))
+		}
+	}
+	sort.Strings(users)
+	return append(out, users...)
+}
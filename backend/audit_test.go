@@ -0,0 +1,233 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
+)
+
+func TestAuditLog(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gs, ts, us, true)
+	fsm := NewFSM(gs, ts, reg, NewHubManager(), s, us)
+
+	owner := "owner@example.com"
+	teamId := "team-audit"
+	gameId := "game-audit"
+
+	var index uint64
+	logFor := func(cmd RaftCommand) *raft.Log {
+		index++
+		cmd.NodeID = "node-1"
+		cmd.Timestamp = int64(1000 + index)
+		data, _ := json.Marshal(cmd)
+		return &raft.Log{Index: index, Type: raft.LogCommand, Data: data}
+	}
+	raw := func(v any) *json.RawMessage {
+		b, _ := json.Marshal(v)
+		m := json.RawMessage(b)
+		return &m
+	}
+
+	// Single-entry path.
+	fsm.Apply(logFor(RaftCommand{Type: CmdSaveTeam, ID: teamId, UserID: owner, TeamData: raw(Team{ID: teamId, OwnerID: owner})}))
+	fsm.Apply(logFor(RaftCommand{Type: CmdSaveTeam, ID: teamId, UserID: owner, TeamData: raw(Team{
+		ID:      teamId,
+		OwnerID: owner,
+		Roles:   TeamRoles{Scorekeepers: []string{"Scorer@example.com"}},
+	})}))
+
+	// Batch path.
+	start := json.RawMessage(`{"id":"a1","type":"GAME_START","payload":{"id":"` + gameId + `","ownerId":"` + owner + `"}}`)
+	pitch := json.RawMessage(`{"id":"a2","type":"PITCH","payload":{}}`)
+	batch := []*raft.Log{
+		logFor(RaftCommand{Type: CmdSaveGame, ID: gameId, UserID: owner, GameData: raw(Game{ID: gameId, OwnerID: owner})}),
+		logFor(RaftCommand{Type: CmdApplyAction, Action: &ActionPayload{GameID: gameId, Actions: []json.RawMessage{start, pitch}, UserID: "scorer@example.com"}}),
+		logFor(RaftCommand{Type: CmdSaveGame, ID: gameId, UserID: owner, Force: true, GameData: raw(Game{ID: gameId, OwnerID: owner, Status: "final"})}),
+		logFor(RaftCommand{Type: CmdMetricsUpdate, MetricsPayload: &MetricsPayload{Timestamp: 1}}),
+	}
+	fsm.ApplyBatch(batch)
+	fsm.Apply(logFor(RaftCommand{Type: CmdDeleteGame, ID: gameId, UserID: owner}))
+
+	teamLog, err := fsm.Audit().Read(auditTeamLog(teamId))
+	if err != nil {
+		t.Fatalf("Read team log: %v", err)
+	}
+	if len(teamLog.Entries) != 2 {
+		t.Fatalf("expected 2 team entries, got %+v", teamLog.Entries)
+	}
+	if got := teamLog.Entries[1].Changes; !reflect.DeepEqual(got, []string{"+scorekeeper scorer@example.com"}) {
+		t.Errorf("unexpected team changes: %v", got)
+	}
+	if e := teamLog.Entries[0]; e.UserID != owner || e.NodeID != "node-1" || e.Timestamp != 1001 || e.Command != CmdSaveTeam {
+		t.Errorf("unexpected attribution: %+v", e)
+	}
+
+	gameLog, err := fsm.Audit().Read(auditGameLog(gameId))
+	if err != nil {
+		t.Fatalf("Read game log: %v", err)
+	}
+	var commands []CommandType
+	for _, e := range gameLog.Entries {
+		commands = append(commands, e.Command)
+	}
+	if want := []CommandType{CmdSaveGame, CmdApplyAction, CmdSaveGame, CmdDeleteGame}; !reflect.DeepEqual(commands, want) {
+		t.Fatalf("game commands = %v, want %v", commands, want)
+	}
+	action := gameLog.Entries[1]
+	if action.UserID != "scorer@example.com" || len(action.Actions) != 2 || action.Actions[1] != (AuditAction{ID: "a2", Type: "PITCH"}) {
+		t.Errorf("unexpected action entry: %+v", action)
+	}
+	if got := gameLog.Entries[2].Changes; !reflect.DeepEqual(got, []string{`status: "" -> "final"`, "actions: 2 -> 0"}) {
+		t.Errorf("unexpected overwrite changes: %v", got)
+	}
+
+	sysLog, _ := fsm.Audit().Read(auditSystemLog)
+	if len(sysLog.Entries) != 0 {
+		t.Errorf("metrics updates should not be audited: %+v", sysLog.Entries)
+	}
+
+	// Replaying the log after a restart does not duplicate entries.
+	fsm.ApplyBatch(batch)
+	gameLog, _ = fsm.Audit().Read(auditGameLog(gameId))
+	if len(gameLog.Entries) != 4 {
+		t.Errorf("replay duplicated entries: %d", len(gameLog.Entries))
+	}
+
+	if err := fsm.FlushAll(); err != nil {
+		t.Fatalf("FlushAll: %v", err)
+	}
+	files, err := fsm.Audit().ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if want := []string{auditSegmentFile(auditGameLog(gameId), 3), auditSegmentFile(auditTeamLog(teamId), 1)}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ListFiles = %v, want %v", files, want)
+	}
+}
+
+func TestAuditSegments(t *testing.T) {
+	tempDir := t.TempDir()
+	a := NewAuditStore(storage.New(tempDir, nil))
+	a.segmentSize = 3
+	a.systemSegments = 2
+	a.logSegments = 3
+
+	entries := func(from, to uint64) []AuditEntry {
+		var out []AuditEntry
+		for i := from; i <= to; i++ {
+			out = append(out, AuditEntry{Index: i, Command: CmdSaveGame})
+		}
+		return out
+	}
+	indexes := func(es []AuditEntry) []uint64 {
+		var out []uint64
+		for _, e := range es {
+			out = append(out, e.Index)
+		}
+		return out
+	}
+
+	log := auditGameLog("g1")
+	if err := a.Append(log, entries(1, 2)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := a.Append(log, entries(2, 7)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// Full segments are written right away, the newest one on FlushAll.
+	files, _ := a.ListFiles()
+	want := []string{auditSegmentFile(log, 1), auditSegmentFile(log, 4)}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("ListFiles = %v, want %v", files, want)
+	}
+	l, err := a.Read(log)
+	if err != nil || !reflect.DeepEqual(indexes(l.Entries), []uint64{1, 2, 3, 4, 5, 6, 7}) || l.LastIndex != 7 {
+		t.Errorf("Read = %v, %v", l, err)
+	}
+	if err := a.FlushAll(); err != nil {
+		t.Fatalf("FlushAll: %v", err)
+	}
+	files, _ = a.ListFiles()
+	want = append(want, auditSegmentFile(log, 7))
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("ListFiles = %v, want %v", files, want)
+	}
+	l, err = NewAuditStore(a.storage).Read(log)
+	if err != nil || !reflect.DeepEqual(indexes(l.Entries), []uint64{1, 2, 3, 4, 5, 6, 7}) || l.LastIndex != 7 {
+		t.Errorf("Read after FlushAll = %v, %v", l, err)
+	}
+
+	for _, tc := range []struct {
+		offset, limit int
+		want          []uint64
+	}{
+		{0, 2, []uint64{7, 6}},
+		{2, 3, []uint64{5, 4, 3}},
+		{4, 10, []uint64{3, 2, 1}},
+		{7, 10, nil},
+	} {
+		page, total, err := a.ReadPage(log, tc.offset, tc.limit)
+		if err != nil || total != 7 || !reflect.DeepEqual(indexes(page), tc.want) {
+			t.Errorf("ReadPage(%d, %d) = %v, %d, %v, want %v", tc.offset, tc.limit, indexes(page), total, err, tc.want)
+		}
+	}
+
+	// Logs keep their newest segments only.
+	if err := a.Append(auditSystemLog, entries(1, 8)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	l, _ = a.Read(auditSystemLog)
+	if got := indexes(l.Entries); !reflect.DeepEqual(got, []uint64{4, 5, 6, 7, 8}) {
+		t.Errorf("system log = %v", got)
+	}
+	if _, total, _ := a.ReadPage(auditSystemLog, 0, 1); total != 5 {
+		t.Errorf("system log total = %d", total)
+	}
+	if err := a.Append(log, entries(8, 10)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	l, _ = a.Read(log)
+	if got := indexes(l.Entries); !reflect.DeepEqual(got, []uint64{4, 5, 6, 7, 8, 9, 10}) {
+		t.Errorf("game log = %v", got)
+	}
+}
+
+func TestPolicyChanges(t *testing.T) {
+	prev := &UserAccessPolicy{DefaultPolicy: "allow", Admins: []string{"a@example.com"}}
+	next := &UserAccessPolicy{
+		DefaultPolicy: "deny",
+		Admins:        []string{"b@example.com"},
+		Users:         map[string]UserOverride{"u@example.com": {Access: "allow", MaxGames: 5}},
+	}
+	want := []string{
+		`defaultPolicy: "allow" -> "deny"`,
+		"+admin b@example.com",
+		"-admin a@example.com",
+		"user u@example.com: access=allow maxGames=5 maxTeams=0",
+	}
+	if got := policyChanges(prev, next); !reflect.DeepEqual(got, want) {
+		t.Errorf("policyChanges = %v, want %v", got, want)
+	}
+}
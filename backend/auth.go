@@ -15,7 +15,8 @@
 package backend
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/base64"
 	"net/http"
 	"strings"
 )
@@ -54,6 +55,15 @@ func maskEmail(email string) string {
 	return string(parts[0][0]) + "***@" + parts[1]
 }
 
+// newSecretToken generates a random URL-safe secret (calendar feeds, webhook signing keys).
+func newSecretToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 type AccessLevel int
 
 const (
@@ -68,10 +78,9 @@ func GetGameAccess(userId string, game Game, tStore *TeamStore) AccessLevel {
 	userId = normalizeEmail(userId)
 	ownerId := normalizeEmail(game.OwnerID)
 
-	log.Printf("[AUTH] Checking access for user=%s, gameId=%s, gameOwner=%s", maskEmail(userId), game.ID, maskEmail(ownerId))
+	authLog.Debug("checking game access", "user", maskEmail(userId), "gameId", game.ID, "owner", maskEmail(ownerId))
 	// 1. Owner has full access
 	if userId != "" && ownerId == userId {
-		log.Printf("[AUTH] User is owner")
 		return AccessAdmin
 	}
 
@@ -17,7 +17,6 @@ package backend
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 
@@ -29,7 +28,7 @@ import (
 type jwksLogger struct{}
 
 func (l jwksLogger) Errorf(format string, args ...any) {
-	log.Printf("JWKS Error: "+format, args...)
+	authLog.Error("JWKS error", "err", fmt.Sprintf(format, args...))
 }
 
 // jwtAuthMiddleware handles JWT authentication using JWKS.
@@ -44,7 +43,7 @@ func jwtAuthMiddleware(opts Options, next http.Handler) http.Handler {
 		issuers := parseJWKSConfig(opts.AuthJWKSURL)
 		remote.SetIssuers(issuers)
 	} else {
-		log.Println("Warning: No AuthJWKSURL provided. JWT validation will fail unless MockAuth is used.")
+		authLog.Warn("no AuthJWKSURL provided; JWT validation will fail unless MockAuth is used")
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -89,12 +88,10 @@ func jwtAuthMiddleware(opts Options, next http.Handler) http.Handler {
 			if expectedIss, ok := remote.IssuerForKey(kid); ok && expectedIss != "" {
 				iss, err := token.Claims.GetIssuer()
 				if err != nil || iss != expectedIss {
-					if opts.Debug {
-						if err != nil {
-							log.Printf("JWT Validation failed: token is missing issuer claim (or it's invalid), but expected '%s' for key %s. err: %v", expectedIss, kid, err)
-						} else {
-							log.Printf("JWT Validation failed: issuer mismatch. Got '%s', expected '%s'", iss, expectedIss)
-						}
+					if err != nil {
+						authLog.DebugContext(r.Context(), "JWT validation failed: missing or invalid issuer claim", "expected", expectedIss, "kid", kid, "err", err)
+					} else {
+						authLog.DebugContext(r.Context(), "JWT validation failed: issuer mismatch", "issuer", iss, "expected", expectedIss)
 					}
 					next.ServeHTTP(w, r)
 					return
@@ -110,9 +107,7 @@ func jwtAuthMiddleware(opts Options, next http.Handler) http.Handler {
 				}
 			}
 		} else {
-			if opts.Debug {
-				log.Printf("JWT Validation failed: %v", err)
-			}
+			authLog.DebugContext(r.Context(), "JWT validation failed", "err", err)
 		}
 
 		// Anonymous
@@ -0,0 +1,555 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c2FmZQ/storage/crypto"
+	"github.com/hashicorp/raft"
+)
+
+// Backups are encrypted exports of a Raft snapshot (manifest.json and every
+// entity, see LinkSnapshotStore.Open) stored off-node. Each archive is
+// encrypted with its own key, which is itself encrypted with the backup key.
+// The backup key is stored in the target as backupKeyName, encrypted with the
+// backup passphrase, so that a backup can be restored with nothing but the
+// target and the passphrase.
+const (
+	backupPrefix     = "skorekeeper-"
+	backupSuffix     = ".bak"
+	backupTimeFormat = "20060102T150405Z"
+	backupKeyName    = "backup.key"
+	backupMagic      = "SKBACKUP1\n"
+)
+
+// backupCheckInterval is how often the leader checks whether a backup is due.
+var backupCheckInterval = time.Minute
+
+// BackupConfig configures scheduled backups.
+type BackupConfig struct {
+	Target     string        // Directory, or s3://bucket/prefix
+	Interval   time.Duration // Time between backups; 0 disables scheduled backups
+	Keep       int           // Number of backups to keep; 0 keeps all
+	MaxAge     time.Duration // Backups older than this are deleted; 0 keeps all
+	Passphrase string        // Encrypts the backup key
+	TempDir    string        // Where archives are staged before upload
+
+	// S3 options, used when Target is an s3:// URL.
+	S3Endpoint  string // e.g. http://localhost:9000 for MinIO; defaults to AWS
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// BackupTarget stores backup archives.
+type BackupTarget interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, name string) error
+	String() string
+}
+
+// errBackupNotFound is returned by BackupTarget.Get for missing objects.
+var errBackupNotFound = errors.New("backup not found")
+
+// BackupStatus reports the outcome of the last backup attempt.
+type BackupStatus struct {
+	Target      string `json:"target"`
+	LastBackup  string `json:"lastBackup,omitempty"`  // Name of the last successful backup
+	LastSuccess int64  `json:"lastSuccess,omitempty"` // Unix ms
+	LastAttempt int64  `json:"lastAttempt,omitempty"` // Unix ms
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// BackupManager writes, lists, prunes, and reads backups in a target.
+type BackupManager struct {
+	cfg    BackupConfig
+	target BackupTarget
+
+	mu     sync.Mutex
+	key    crypto.MasterKey
+	latest time.Time // Time of the newest backup known to be in the target
+	status BackupStatus
+}
+
+// NewBackupManager returns a BackupManager for cfg.
+func NewBackupManager(cfg BackupConfig) (*BackupManager, error) {
+	if cfg.Passphrase == "" {
+		return nil, fmt.Errorf("a backup passphrase is required")
+	}
+	if cfg.Keep < 0 || cfg.MaxAge < 0 || cfg.Interval < 0 {
+		return nil, fmt.Errorf("backup interval and retention must not be negative")
+	}
+	var target BackupTarget
+	if rest, ok := strings.CutPrefix(cfg.Target, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid backup target %q: missing bucket", cfg.Target)
+		}
+		target = newS3Target(cfg.S3Endpoint, cfg.S3Region, bucket, prefix, cfg.S3AccessKey, cfg.S3SecretKey)
+	} else {
+		dir := strings.TrimPrefix(cfg.Target, "file://")
+		if dir == "" {
+			return nil, fmt.Errorf("no backup target")
+		}
+		target = dirTarget(dir)
+	}
+	return &BackupManager{cfg: cfg, target: target, status: BackupStatus{Target: target.String()}}, nil
+}
+
+// Status returns the outcome of the last backup attempt.
+func (m *BackupManager) Status() BackupStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// backupName returns the name of a backup of the snapshot at index taken at t.
+// Names sort in chronological order.
+func backupName(t time.Time, index uint64) string {
+	return fmt.Sprintf("%s%s-%020d%s", backupPrefix, t.UTC().Format(backupTimeFormat), index, backupSuffix)
+}
+
+// backupTime returns the time encoded in a backup name.
+func backupTime(name string) (time.Time, bool) {
+	s, ok := strings.CutPrefix(name, backupPrefix)
+	if !ok || !strings.HasSuffix(s, backupSuffix) || len(s) < len(backupTimeFormat) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeFormat, s[:len(backupTimeFormat)])
+	return t, err == nil
+}
+
+// List returns the names of the backups in the target, oldest first.
+func (m *BackupManager) List(ctx context.Context) ([]string, error) {
+	names, err := m.target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names = slices.DeleteFunc(names, func(n string) bool {
+		_, ok := backupTime(n)
+		return !ok
+	})
+	slices.Sort(names)
+	return names, nil
+}
+
+// backupKey returns the backup key, reading it from the target or, if create
+// is set and the target has none, creating it.
+func (m *BackupManager) backupKey(ctx context.Context, create bool) (crypto.MasterKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.key != nil {
+		return m.key, nil
+	}
+	tmp, err := os.CreateTemp(m.cfg.TempDir, "backup-key-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rc, err := m.target.Get(ctx, backupKeyName)
+	switch {
+	case err == nil:
+		_, err = io.Copy(tmp, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup key: %w", err)
+		}
+		if m.key, err = crypto.ReadMasterKey([]byte(m.cfg.Passphrase), tmp.Name()); err != nil {
+			return nil, fmt.Errorf("failed to decrypt backup key (wrong passphrase?): %w", err)
+		}
+	case errors.Is(err, errBackupNotFound) && create:
+		backupLog.Info("creating backup key", "target", m.target.String())
+		key, err := crypto.CreateMasterKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := key.Save([]byte(m.cfg.Passphrase), tmp.Name()); err != nil {
+			return nil, err
+		}
+		fi, err := tmp.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if err := m.target.Put(ctx, backupKeyName, tmp, fi.Size()); err != nil {
+			return nil, fmt.Errorf("failed to store backup key: %w", err)
+		}
+		m.key = key
+	default:
+		return nil, fmt.Errorf("failed to read backup key: %w", err)
+	}
+	return m.key, nil
+}
+
+// Backup encrypts the snapshot read from src and stores it in the target, then
+// applies the retention policy. It returns the name of the new backup.
+func (m *BackupManager) Backup(ctx context.Context, index uint64, src io.Reader) (name string, err error) {
+	now := time.Now()
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.status.LastAttempt = now.UnixMilli()
+		if err != nil {
+			m.status.LastError = err.Error()
+			return
+		}
+		m.status.LastError = ""
+		m.status.LastBackup = name
+		m.status.LastSuccess = time.Now().UnixMilli()
+		m.latest = now
+	}()
+
+	key, err := m.backupKey(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(m.cfg.TempDir, "backup-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Closing the encrypted stream closes tmp.
+	if err := encryptBackup(key, tmp, src); err != nil {
+		return "", err
+	}
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := fi.Size()
+	name = backupName(now, index)
+	if err := m.target.Put(ctx, name, f, size); err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	backupLog.Info("backup complete", "name", name, "size", size, "target", m.target.String())
+
+	if err := m.Prune(ctx, now); err != nil {
+		backupLog.Warn("failed to apply backup retention", "err", err)
+	}
+	return name, nil
+}
+
+// encryptBackup writes the archive header, a new archive key encrypted with
+// key, and src encrypted with the archive key.
+func encryptBackup(key crypto.MasterKey, w io.Writer, src io.Reader) error {
+	ak, err := key.NewKey()
+	if err != nil {
+		return err
+	}
+	defer ak.Wipe()
+	if _, err := io.WriteString(w, backupMagic); err != nil {
+		return err
+	}
+	if err := ak.WriteEncryptedKey(w); err != nil {
+		return err
+	}
+	sw, err := ak.StartWriter([]byte(backupMagic), w)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(sw, src); err != nil {
+		sw.Close()
+		return err
+	}
+	return sw.Close()
+}
+
+// Prune deletes the backups that the retention policy no longer keeps. The
+// newest backup is always kept.
+func (m *BackupManager) Prune(ctx context.Context, now time.Time) error {
+	names, err := m.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i, name := range names[:max(len(names)-1, 0)] {
+		t, _ := backupTime(name)
+		tooMany := m.cfg.Keep > 0 && i < len(names)-m.cfg.Keep
+		tooOld := m.cfg.MaxAge > 0 && now.Sub(t) > m.cfg.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := m.target.Delete(ctx, name); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", name, err)
+		}
+		backupLog.Info("deleted old backup", "name", name)
+	}
+	return nil
+}
+
+// Open returns the decrypted snapshot stored in the named backup, or in the
+// newest backup if name is "latest", and the name of that backup.
+func (m *BackupManager) Open(ctx context.Context, name string) (io.ReadCloser, string, error) {
+	if name == "latest" {
+		names, err := m.List(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(names) == 0 {
+			return nil, "", fmt.Errorf("no backups in %s", m.target.String())
+		}
+		name = names[len(names)-1]
+	}
+	key, err := m.backupKey(ctx, false)
+	if err != nil {
+		return nil, "", err
+	}
+	rc, err := m.target.Get(ctx, name)
+	if err != nil {
+		return nil, "", err
+	}
+	br := bufio.NewReader(rc)
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != backupMagic {
+		rc.Close()
+		return nil, "", fmt.Errorf("%s is not a backup", name)
+	}
+	ak, err := key.ReadEncryptedKey(br)
+	if err != nil {
+		rc.Close()
+		return nil, "", fmt.Errorf("failed to decrypt %s: %w", name, err)
+	}
+	sr, err := ak.StartReader([]byte(backupMagic), br)
+	if err != nil {
+		ak.Wipe()
+		rc.Close()
+		return nil, "", err
+	}
+	return &backupReader{Reader: sr, key: ak, closers: []io.Closer{sr, rc}}, name, nil
+}
+
+type backupReader struct {
+	io.Reader
+	key     crypto.EncryptionKey
+	closers []io.Closer
+}
+
+func (r *backupReader) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+	r.key.Wipe()
+	return errors.Join(errs...)
+}
+
+// due reports whether a scheduled backup is due at now. When the last backup
+// this node knows of is older than the interval, it checks the target, since
+// another node may have made one while it was leader.
+func (m *BackupManager) due(ctx context.Context, now time.Time) bool {
+	if m.cfg.Interval <= 0 {
+		return false
+	}
+	m.mu.Lock()
+	latest := m.latest
+	m.mu.Unlock()
+	if now.Sub(latest) < m.cfg.Interval {
+		return false
+	}
+	names, err := m.List(ctx)
+	if err != nil {
+		backupLog.Warn("failed to list backups", "target", m.target.String(), "err", err)
+		return true
+	}
+	if len(names) > 0 {
+		latest, _ = backupTime(names[len(names)-1])
+		m.mu.Lock()
+		m.latest = latest
+		m.mu.Unlock()
+	}
+	return now.Sub(latest) >= m.cfg.Interval
+}
+
+// dirTarget stores backups in a local directory, typically a mounted network
+// or removable volume.
+type dirTarget string
+
+func (d dirTarget) String() string { return string(d) }
+
+func (d dirTarget) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	if err := os.MkdirAll(string(d), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(string(d), name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(string(d), name))
+}
+
+func (d dirTarget) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(string(d), name))
+	if os.IsNotExist(err) {
+		return nil, errBackupNotFound
+	}
+	return f, err
+}
+
+func (d dirTarget) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d dirTarget) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(string(d), name))
+}
+
+// monitorBackups makes the scheduled backups while this node is the leader.
+func (rm *RaftManager) monitorBackups() {
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rm.shutdownCh:
+			return
+		case <-ticker.C:
+			if rm.Raft.State() != raft.Leader || !rm.Backups.due(context.Background(), time.Now()) {
+				continue
+			}
+			if _, err := rm.Backup(context.Background()); err != nil {
+				backupLog.Error("scheduled backup failed", "err", err)
+			}
+		}
+	}
+}
+
+// Backup takes a snapshot of the cluster state and stores it in the backup
+// target. It returns the name of the backup.
+func (rm *RaftManager) Backup(ctx context.Context) (string, error) {
+	if rm.Backups == nil {
+		return "", fmt.Errorf("backups are not configured")
+	}
+	if rm.snapStoreEnc == nil {
+		return "", fmt.Errorf("backups require an encrypted data directory (SK_MASTER_KEY)")
+	}
+	var (
+		meta *raft.SnapshotMeta
+		rc   io.ReadCloser
+	)
+	f := rm.Raft.Snapshot()
+	err := f.Error()
+	switch {
+	case err == nil:
+		meta, rc, err = f.Open()
+	case errors.Is(err, raft.ErrNothingNewToSnapshot):
+		// Nothing was applied since the last snapshot, which is current.
+		var snaps []*raft.SnapshotMeta
+		if snaps, err = rm.snapStoreEnc.List(); err == nil && len(snaps) > 0 {
+			meta, rc, err = rm.snapStoreEnc.Open(snaps[0].ID)
+		} else if err == nil {
+			err = fmt.Errorf("no snapshot to back up")
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot: %w", err)
+	}
+	defer rc.Close()
+	return rm.Backups.Backup(ctx, meta.Index, rc)
+}
+
+// restoreFromBackup initializes a new cluster from the backup named by
+// rm.RestoreBackup. It restores the FSM, then writes the state as the first
+// Raft snapshot with this node as the only voter, so that nodes that join
+// later receive it with InstallSnapshot. It does nothing if this node already
+// has Raft state, and reports whether it restored a backup.
+func (rm *RaftManager) restoreFromBackup(logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore, trans raft.Transport) (bool, error) {
+	if rm.Backups == nil {
+		return false, fmt.Errorf("cannot restore %q: backups are not configured", rm.RestoreBackup)
+	}
+	if rm.snapStoreEnc == nil {
+		return false, fmt.Errorf("restoring a backup requires an encrypted data directory (SK_MASTER_KEY)")
+	}
+	if exists, err := raft.HasExistingState(logs, stable, snaps); err != nil {
+		return false, err
+	} else if exists {
+		raftLog.Warn("raft state exists, not restoring backup", "backup", rm.RestoreBackup)
+		return false, nil
+	}
+
+	ctx := context.Background()
+	rc, name, err := rm.Backups.Open(ctx, rm.RestoreBackup)
+	if err != nil {
+		return false, err
+	}
+	raftLog.Info("restoring backup", "backup", name)
+	if err := rm.FSM.Restore(rc); err != nil {
+		return false, fmt.Errorf("failed to restore %s: %w", name, err)
+	}
+	// The nodes of the old cluster are gone. This node publishes its own
+	// metadata once it is leader.
+	rm.FSM.resetNodes()
+
+	index := max(rm.FSM.LastAppliedIndex(), 1)
+	configuration := raft.Configuration{
+		Servers: []raft.Server{{ID: raft.ServerID(rm.NodeID), Address: trans.LocalAddr()}},
+	}
+	snap, err := rm.FSM.Snapshot()
+	if err != nil {
+		return false, err
+	}
+	sink, err := snaps.Create(raft.SnapshotVersionMax, index, 1, configuration, index, trans)
+	if err != nil {
+		return false, err
+	}
+	if err := snap.Persist(sink); err != nil {
+		return false, fmt.Errorf("failed to persist restored snapshot: %w", err)
+	}
+	raftLog.Info("restored backup", "backup", name, "index", index)
+	return true, nil
+}
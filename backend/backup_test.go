@@ -0,0 +1,317 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/c2FmZQ/storage/crypto"
+	"github.com/hashicorp/raft"
+)
+
+// fakeS3 is a minimal in-memory S3 stand-in. It lists at most two keys per
+// page to exercise pagination.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte // "bucket/key" -> data
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Date") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if k, ok := strings.CutPrefix(k, bucket+"/"); ok && strings.HasPrefix(k, prefix) && !strings.Contains(k[len(prefix):], "/") {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		if token := r.URL.Query().Get("continuation-token"); token != "" {
+			keys = keys[slices.Index(keys, token):]
+		}
+		type content struct{ Key string }
+		var result struct {
+			XMLName               xml.Name `xml:"ListBucketResult"`
+			Contents              []content
+			IsTruncated           bool
+			NextContinuationToken string `xml:",omitempty"`
+		}
+		for i, k := range keys {
+			if i == 2 {
+				result.IsTruncated = true
+				result.NextContinuationToken = k
+				break
+			}
+			result.Contents = append(result.Contents, content{k})
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(data)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		f.objects[path] = data
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// The expected signature was computed independently from the canonical
+	// request GET / with the host and x-amz-date headers and an empty body.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazon.com/", nil)
+	now, _ := time.Parse(backupTimeFormat, "20150830T123600Z")
+	signV4(req, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", hexSHA256(nil), now)
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=7ab4567ae243ee168f6bf18206b2b40b61ce08277323168138fa113ed23c538e"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestBackupRetention(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewBackupManager(BackupConfig{Target: dir, Keep: 3, MaxAge: 48 * time.Hour, Passphrase: "pw", TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	var names []string
+	for i, age := range []time.Duration{100, 72, 30, 20, 10, 1} {
+		name := backupName(now.Add(-age*time.Hour), uint64(i+1))
+		names = append(names, name)
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0600)
+	}
+	os.WriteFile(filepath.Join(dir, backupKeyName), []byte("key"), 0600)
+
+	if err := m.Prune(context.Background(), now); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	got, _ := m.List(context.Background())
+	if want := names[3:]; !slices.Equal(got, want) {
+		t.Errorf("after Prune(keep=3) = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, backupKeyName)); err != nil {
+		t.Error("Prune deleted the backup key")
+	}
+
+	// The newest backup is kept even when it is too old.
+	m.cfg.Keep = 0
+	if err := m.Prune(context.Background(), now.Add(1000*time.Hour)); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if got, _ := m.List(context.Background()); !slices.Equal(got, names[5:]) {
+		t.Errorf("after Prune(maxAge) = %v, want %v", got, names[5:])
+	}
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	s3 := httptest.NewServer(&fakeS3{objects: make(map[string][]byte)})
+	defer s3.Close()
+
+	for _, target := range []string{t.TempDir(), "s3://backups/cluster-1"} {
+		t.Run(strings.SplitN(target, ":", 2)[0], func(t *testing.T) {
+			cfg := BackupConfig{
+				Target:      target,
+				Passphrase:  "correct horse",
+				TempDir:     t.TempDir(),
+				S3Endpoint:  s3.URL,
+				S3AccessKey: "AKID",
+				S3SecretKey: "secret",
+			}
+			m, err := NewBackupManager(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Snapshot an FSM with a few games.
+			tmpDir := t.TempDir()
+			raftDir := filepath.Join(tmpDir, "raft")
+			mk, _ := crypto.CreateAESMasterKeyForTest()
+			s := storage.New(tmpDir, mk)
+			gs := NewGameStore(tmpDir, s)
+			ts := NewTeamStore(tmpDir, s)
+			us := NewUserIndexStore(tmpDir, s, nil)
+			fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), nil, storage.New(raftDir, mk), us)
+			for i := range 3 {
+				gs.SaveGame(&Game{ID: fmt.Sprintf("game-%d", i), ActionLog: []json.RawMessage{}, SchemaVersion: 3})
+			}
+			snap, err := fsm.Snapshot()
+			if err != nil {
+				t.Fatalf("Snapshot: %v", err)
+			}
+			inner, _ := raft.NewFileSnapshotStore(raftDir, 1, io.Discard)
+			linkStore := NewLinkSnapshotStore(raftDir, tmpDir, inner, NewKeyRing(mk, "test-key"), mk)
+			sink, _ := linkStore.Create(1, 10, 1, raft.Configuration{}, 1, nil)
+			if err := snap.Persist(sink); err != nil {
+				t.Fatalf("Persist: %v", err)
+			}
+			_, rc, err := linkStore.Open(sink.ID())
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			name, err := m.Backup(context.Background(), 10, rc)
+			rc.Close()
+			if err != nil {
+				t.Fatalf("Backup: %v", err)
+			}
+			if st := m.Status(); st.LastBackup != name || st.LastError != "" {
+				t.Errorf("Status = %+v", st)
+			}
+
+			// A new manager must find the key in the target.
+			m2, _ := NewBackupManager(cfg)
+			rc, got, err := m2.Open(context.Background(), "latest")
+			if err != nil {
+				t.Fatalf("Open(latest): %v", err)
+			}
+			defer rc.Close()
+			if got != name {
+				t.Errorf("Open(latest) opened %q, want %q", got, name)
+			}
+			tmpDir2 := t.TempDir()
+			s2 := storage.New(tmpDir2, nil)
+			gs2 := NewGameStore(tmpDir2, s2)
+			ts2 := NewTeamStore(tmpDir2, s2)
+			us2 := NewUserIndexStore(tmpDir2, s2, nil)
+			fsm2 := NewFSM(gs2, ts2, NewRegistry(gs2, ts2, us2, true), nil, storage.New(filepath.Join(tmpDir2, "raft"), nil), us2)
+			if err := fsm2.Restore(rc); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			for i := range 3 {
+				if _, err := gs2.LoadGame(fmt.Sprintf("game-%d", i)); err != nil {
+					t.Errorf("game-%d missing after restore: %v", i, err)
+				}
+			}
+
+			cfg.Passphrase = "wrong"
+			m3, _ := NewBackupManager(cfg)
+			if _, _, err := m3.Open(context.Background(), name); err == nil {
+				t.Error("Open with the wrong passphrase succeeded")
+			}
+		})
+	}
+}
+
+func TestRaftBackupRestore(t *testing.T) {
+	backupDir := t.TempDir()
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+
+	startNode := func(restore string) *RaftManager {
+		dataDir := t.TempDir()
+		raftDir := filepath.Join(dataDir, "raft")
+		s := storage.New(dataDir, mk)
+		gs := NewGameStore(dataDir, s)
+		ts := NewTeamStore(dataDir, s)
+		us := NewUserIndexStore(dataDir, s, nil)
+		fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), storage.New(raftDir, mk), us)
+
+		l, _ := net.Listen("tcp", "127.0.0.1:0")
+		raftAddr := l.Addr().String()
+		l.Close()
+		rm := NewRaftManager(raftDir, raftAddr, raftAddr, "127.0.0.1:8080", "127.0.0.1:8080", "secret", mk, fsm)
+		backups, err := NewBackupManager(BackupConfig{Target: backupDir, Passphrase: "pw", TempDir: dataDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rm.Backups = backups
+		rm.RestoreBackup = restore
+		if err := rm.Start(true); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		for deadline := time.Now().Add(10 * time.Second); rm.Raft.State() != raft.Leader; time.Sleep(50 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("no leader")
+			}
+		}
+		return rm
+	}
+	createGame := func(rm *RaftManager, id string) {
+		raw := json.RawMessage(fmt.Sprintf(`{"id":%q,"actionLog":[],"schemaVersion":%d}`, id, CurrentSchemaVersion))
+		if _, err := rm.Propose(RaftCommand{Type: CmdSaveGame, ID: id, GameData: &raw}); err != nil {
+			t.Fatalf("propose %s: %v", id, err)
+		}
+	}
+
+	rm1 := startNode("")
+	createGame(rm1, "g1")
+	name, err := rm1.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	// Nothing new to snapshot: the latest snapshot is backed up again.
+	if _, err := rm1.Backup(context.Background()); err != nil {
+		t.Fatalf("second Backup: %v", err)
+	}
+	oldID := rm1.NodeID
+	rm1.Shutdown()
+
+	rm2 := startNode("latest")
+	defer rm2.Shutdown()
+	gs, _ := rm2.FSM.GetStores()
+	if _, err := gs.LoadGame("g1"); err != nil {
+		t.Fatalf("g1 missing after restore from %s: %v", name, err)
+	}
+	if rm2.FSM.GetNodeMeta(oldID) != nil {
+		t.Error("restored cluster still lists the old node")
+	}
+	cfg := rm2.Raft.GetConfiguration()
+	if err := cfg.Error(); err != nil || len(cfg.Configuration().Servers) != 1 || cfg.Configuration().Servers[0].ID != raft.ServerID(rm2.NodeID) {
+		t.Errorf("configuration = %+v, %v", cfg.Configuration(), err)
+	}
+
+	createGame(rm2, "g2")
+	g2, err := gs.LoadGame("g2")
+	if err != nil {
+		t.Fatalf("g2: %v", err)
+	}
+	if g1, _ := gs.LoadGame("g1"); g2.LastRaftIndex <= g1.LastRaftIndex {
+		t.Errorf("new entries must follow the restored index: g1=%d g2=%d", g1.LastRaftIndex, g2.LastRaftIndex)
+	}
+}
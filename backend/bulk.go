@@ -0,0 +1,296 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bulk operations accepted by /api/games/bulk.
+const (
+	BulkOpDelete      = "delete"
+	BulkOpLink        = "link"
+	BulkOpPermissions = "permissions"
+	BulkOpPublic      = "public"
+	BulkOpFinalize    = "finalize"
+
+	bulkMaxItems = 500
+)
+
+// BulkOperation applies one change to a list of games.
+type BulkOperation struct {
+	Op      string            `json:"op"`
+	GameIDs []string          `json:"gameIds"`
+	TeamID  string            `json:"teamId,omitempty"` // link: the team, or "" to unlink
+	Side    string            `json:"side,omitempty"`   // link: "away" or "home"
+	Users   map[string]string `json:"users,omitempty"`  // permissions: email -> "read", "write", or "" to remove
+	Public  string            `json:"public,omitempty"` // public: "read" or "none"
+}
+
+// BulkResult is the outcome of one operation on one game. Status is an HTTP
+// status code.
+type BulkResult struct {
+	Op     string `json:"op"`
+	GameID string `json:"gameId"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bulkItem is an authorized change to one game. Deletions have no action.
+type bulkItem struct {
+	result       int
+	gameId       string
+	action       json.RawMessage
+	baseRevision string
+}
+
+// validateBulk checks the shape of a bulk request before anything is loaded.
+func validateBulk(ops []BulkOperation) error {
+	n := 0
+	for i, op := range ops {
+		if len(op.GameIDs) == 0 {
+			return fmt.Errorf("operation %d: no gameIds", i)
+		}
+		n += len(op.GameIDs)
+		switch op.Op {
+		case BulkOpDelete, BulkOpFinalize:
+		case BulkOpLink:
+			if op.Side != "away" && op.Side != "home" {
+				return fmt.Errorf("operation %d: side must be away or home", i)
+			}
+			if op.TeamID != "" && !isValidUUID(op.TeamID) {
+				return fmt.Errorf("operation %d: invalid teamId", i)
+			}
+		case BulkOpPermissions:
+			if len(op.Users) == 0 {
+				return fmt.Errorf("operation %d: no users", i)
+			}
+			for email, role := range op.Users {
+				if !isValidEmail(normalizeEmail(email)) {
+					return fmt.Errorf("operation %d: invalid email %q", i, email)
+				}
+				if role != "" && role != "read" && role != "write" {
+					return fmt.Errorf("operation %d: invalid role %q", i, role)
+				}
+			}
+		case BulkOpPublic:
+			if op.Public != "read" && op.Public != "none" {
+				return fmt.Errorf("operation %d: public must be read or none", i)
+			}
+		default:
+			return fmt.Errorf("operation %d: unknown op %q", i, op.Op)
+		}
+	}
+	if n == 0 {
+		return errors.New("no operations")
+	}
+	if n > bulkMaxItems {
+		return fmt.Errorf("too many items (max %d)", bulkMaxItems)
+	}
+	return nil
+}
+
+// planBulk authorizes every item of a bulk request for userId and builds the
+// change to make. Items that fail are only recorded in the results. Each
+// operation sees the games as left by the operations before it, so a request
+// can, for example, link and then finalize the same game. Actions that need a
+// schema version newer than activeVersion are refused with 409.
+func planBulk(userId string, ops []BulkOperation, gs *GameStore, ts *TeamStore, now int64, activeVersion int) ([]BulkResult, []bulkItem) {
+	var results []BulkResult
+	var items []bulkItem
+	games := make(map[string]*Game)
+
+	for _, op := range ops {
+		// Linking a game shares it with the team, so it needs write access to the team.
+		teamStatus, teamErr := http.StatusOK, ""
+		if op.Op == BulkOpLink && op.TeamID != "" {
+			if t, err := ts.LoadTeam(op.TeamID); err != nil || t.Status == "deleted" || GetTeamAccess(userId, *t) < AccessRead {
+				teamStatus, teamErr = http.StatusNotFound, "Team not found"
+			} else if GetTeamAccess(userId, *t) < AccessWrite {
+				teamStatus, teamErr = http.StatusForbidden, "Forbidden: You do not have write access to this team"
+			}
+		}
+
+		for _, gameId := range op.GameIDs {
+			results = append(results, BulkResult{Op: op.Op, GameID: gameId, Status: http.StatusOK})
+			res := &results[len(results)-1]
+			fail := func(status int, msg string) {
+				res.Status = status
+				res.Error = msg
+			}
+
+			if !isValidUUID(gameId) {
+				fail(http.StatusBadRequest, "Invalid gameId")
+				continue
+			}
+			g, ok := games[gameId]
+			if !ok {
+				loaded, err := gs.LoadGame(gameId)
+				if err == nil {
+					g = loaded
+				}
+				games[gameId] = g
+			}
+			if g == nil || g.Status == "deleted" {
+				fail(http.StatusNotFound, "Game not found")
+				continue
+			}
+			access := GetGameAccess(userId, *g, ts)
+			need := AccessAdmin
+			if op.Op == BulkOpFinalize {
+				need = AccessWrite
+			}
+			if access < AccessRead {
+				fail(http.StatusNotFound, "Game not found")
+				continue
+			}
+			if access < need {
+				fail(http.StatusForbidden, "Forbidden: Insufficient access to this game")
+				continue
+			}
+			if teamStatus != http.StatusOK {
+				fail(teamStatus, teamErr)
+				continue
+			}
+
+			item := bulkItem{result: len(results) - 1, gameId: gameId, baseRevision: getCurrentRevision(g.ActionLog)}
+			if op.Op == BulkOpDelete {
+				g.Status = "deleted"
+				items = append(items, item)
+				continue
+			}
+			actionType, payload := bulkAction(op, g, now)
+			if actionType == "" {
+				continue // Nothing to change
+			}
+			raw, err := json.Marshal(BaseAction{ID: uuid.NewString(), Type: actionType, Payload: payload, Timestamp: now})
+			if err == nil {
+				err = ValidateAction(raw)
+			}
+			if err != nil {
+				fail(http.StatusBadRequest, err.Error())
+				continue
+			}
+			if err := checkActionSchemaVersion(raw, activeVersion); err != nil {
+				fail(http.StatusConflict, err.Error())
+				continue
+			}
+			ApplyAction(g, raw)
+			item.action = raw
+			items = append(items, item)
+		}
+	}
+	return results, items
+}
+
+// bulkAction returns the action that applies op to g, or "" if g already
+// has the requested state.
+func bulkAction(op BulkOperation, g *Game, now int64) (string, json.RawMessage) {
+	var p map[string]any
+	switch op.Op {
+	case BulkOpLink:
+		current := g.AwayTeamID
+		if op.Side == "home" {
+			current = g.HomeTeamID
+		}
+		if current == op.TeamID {
+			return "", nil
+		}
+		p = map[string]any{"id": g.ID, op.Side + "TeamId": op.TeamID}
+	case BulkOpPermissions, BulkOpPublic:
+		perms := Permissions{Public: g.Permissions.Public, Users: maps.Clone(g.Permissions.Users)}
+		if perms.Users == nil {
+			perms.Users = make(map[string]string)
+		}
+		if op.Op == BulkOpPublic {
+			perms.Public = op.Public
+		}
+		for email, role := range op.Users {
+			email = normalizeEmail(email)
+			if role == "" {
+				delete(perms.Users, email)
+			} else {
+				perms.Users[email] = role
+			}
+		}
+		if perms.Public == g.Permissions.Public && maps.Equal(perms.Users, g.Permissions.Users) {
+			return "", nil
+		}
+		p = map[string]any{"id": g.ID, "permissions": perms}
+	case BulkOpFinalize:
+		if g.Status == "final" {
+			return "", nil
+		}
+		// The server does not score games, so there is no finalScore.
+		p = map[string]any{"stats": map[string]any{}, "timestamp": now}
+	}
+	payload, _ := json.Marshal(p)
+	if op.Op == BulkOpFinalize {
+		return ActionGameFinalize, payload
+	}
+	return ActionGameMetadataUpdate, payload
+}
+
+// applyBulkStandalone applies planned bulk items without Raft. Actions go
+// through each game's Hub, so connected clients see them.
+func applyBulkStandalone(ctx context.Context, userId string, items []bulkItem, results []BulkResult, hm *HubManager, gs *GameStore, ts *TeamStore, r *Registry) {
+	for _, item := range items {
+		res := &results[item.result]
+		if item.action == nil {
+			if err := gs.TrashGame(item.gameId, 0, time.Now()); err != nil {
+				httpLog.ErrorContext(ctx, "bulk delete failed", "gameId", item.gameId, "err", err)
+				res.Status, res.Error = http.StatusInternalServerError, "Internal Server Error"
+				continue
+			}
+			r.DeleteGame(item.gameId)
+			continue
+		}
+
+		hub := hm.GetHub(item.gameId, false, gs, ts, r)
+		status, msg := hub.submitAction(ctx, HubRequest{
+			UserId:  userId,
+			Message: Message{Type: MsgTypeAction, GameId: item.gameId, BaseRevision: item.baseRevision, Action: item.action},
+		})
+		if status != 0 {
+			res.Status, res.Error = status, msg
+		}
+	}
+}
+
+// bulkCommands returns the Raft commands for planned bulk items.
+func bulkCommands(userId string, items []bulkItem) []RaftCommand {
+	cmds := make([]RaftCommand, len(items))
+	for i, item := range items {
+		if item.action == nil {
+			cmds[i] = RaftCommand{Type: CmdDeleteGame, ID: item.gameId, UserID: userId}
+			continue
+		}
+		cmds[i] = RaftCommand{
+			Type:   CmdApplyAction,
+			ID:     item.gameId,
+			Action: &ActionPayload{GameID: item.gameId, Action: item.action, UserID: userId},
+			UserID: userId,
+		}
+	}
+	return cmds
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+)
+
+func TestBulkEndpoint(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+
+	owner := "owner@example.com"
+	scorer := "scorer@example.com"
+	g1 := "11111111-1111-4111-8111-111111111111"
+	g2 := "22222222-2222-4222-8222-222222222222"
+	g3 := "33333333-3333-4333-8333-333333333333"
+	other := "44444444-4444-4444-8444-444444444444"
+	teamId := "55555555-5555-4555-8555-555555555555"
+	for _, g := range []*Game{
+		{ID: g1, SchemaVersion: DocumentSchemaVersion, OwnerID: owner, Away: "Owls", Home: "Bears"},
+		{ID: g2, SchemaVersion: DocumentSchemaVersion, OwnerID: owner, Away: "Hawks", Home: "Bears", Status: "final"},
+		{ID: g3, SchemaVersion: DocumentSchemaVersion, OwnerID: owner, Permissions: Permissions{Users: map[string]string{scorer: "write"}}},
+		{ID: other, SchemaVersion: DocumentSchemaVersion, OwnerID: "other@example.com"},
+	} {
+		if err := gs.SaveGame(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ts.SaveTeam(&Team{ID: teamId, SchemaVersion: DocumentSchemaVersion, Name: "Bears", OwnerID: owner}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, handler := NewServerHandler(Options{
+		DataDir:     tempDir,
+		Storage:     s,
+		GameStore:   gs,
+		TeamStore:   ts,
+		UseMockAuth: true,
+	})
+	doReq := func(user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/games/bulk", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"operations":[]}`,
+		`{"operations":[{"op":"archive","gameIds":["` + g1 + `"]}]}`,
+		`{"operations":[{"op":"link","gameIds":["` + g1 + `"],"teamId":"` + teamId + `","side":"left"}]}`,
+		`{"operations":[{"op":"public","gameIds":["` + g1 + `"],"public":"write"}]}`,
+		`{"operations":[{"op":"permissions","gameIds":["` + g1 + `"],"users":{"not-an-email":"read"}}]}`,
+	} {
+		if rec := doReq(owner, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d", body, rec.Code)
+		}
+	}
+
+	rec := doReq(owner, `{"operations":[
+		{"op":"link","gameIds":["`+g1+`","`+g2+`","`+other+`"],"teamId":"`+teamId+`","side":"home"},
+		{"op":"public","gameIds":["`+g1+`"],"public":"read"},
+		{"op":"permissions","gameIds":["`+g1+`","`+g3+`"],"users":{"Coach@Example.com":"read","`+scorer+`":""}},
+		{"op":"finalize","gameIds":["`+g1+`","`+g2+`"]},
+		{"op":"delete","gameIds":["`+g3+`","not-a-uuid"]},
+		{"op":"finalize","gameIds":["`+g3+`"]}
+	]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bulk: %d %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data []BulkResult `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{200, 200, 404, 200, 200, 200, 200, 200, 200, 400, 404}
+	if len(resp.Data) != len(want) {
+		t.Fatalf("results = %+v", resp.Data)
+	}
+	for i, r := range resp.Data {
+		if r.Status != want[i] {
+			t.Errorf("result %d = %+v, want status %d", i, r, want[i])
+		}
+	}
+
+	loaded, err := gs.LoadGame(g1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HomeTeamID != teamId || loaded.Status != "final" || loaded.Permissions.Public != "read" || loaded.Permissions.Users["coach@example.com"] != "read" {
+		t.Errorf("g1 = %+v", loaded)
+	}
+	if len(loaded.ActionLog) != 4 {
+		t.Errorf("g1 has %d actions, want 4", len(loaded.ActionLog))
+	}
+	if away, home, ok := finalScore(loaded); ok {
+		t.Errorf("finalScore of bulk-finalized game = %d-%d", away, home)
+	}
+	// g2 was already final, so only the link was recorded.
+	if loaded, _ := gs.LoadGame(g2); loaded.HomeTeamID != teamId || len(loaded.ActionLog) != 1 {
+		t.Errorf("g2 = %+v", loaded)
+	}
+	if loaded, _ := gs.LoadGame(g3); loaded.Status != "deleted" {
+		t.Errorf("g3 status = %q", loaded.Status)
+	}
+	if loaded, _ := gs.LoadGame(other); loaded.HomeTeamID != "" {
+		t.Errorf("game of another user was linked")
+	}
+
+	// A writer can finalize but not change metadata.
+	g4 := "66666666-6666-4666-8666-666666666666"
+	gs.SaveGame(&Game{ID: g4, SchemaVersion: DocumentSchemaVersion, OwnerID: owner, Permissions: Permissions{Users: map[string]string{scorer: "write"}}})
+	rec = doReq(scorer, `{"operations":[{"op":"public","gameIds":["`+g4+`"],"public":"read"},{"op":"finalize","gameIds":["`+g4+`"]}]}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Data) != 2 || resp.Data[0].Status != http.StatusForbidden || resp.Data[1].Status != http.StatusOK {
+		t.Errorf("writer bulk = %s", rec.Body)
+	}
+}
+
+func TestRaftProposeBatch(t *testing.T) {
+	dataDir := t.TempDir()
+	raftDir := filepath.Join(dataDir, "raft")
+	s := storage.New(dataDir, nil)
+	gs := NewGameStore(dataDir, s)
+	ts := NewTeamStore(dataDir, s)
+	us := NewUserIndexStore(dataDir, s, nil)
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), storage.New(raftDir, nil), us)
+	rm := startTestRaft(t, raftDir, fsm)
+
+	owner := "owner@example.com"
+	g1 := "11111111-1111-4111-8111-111111111111"
+	g2 := "22222222-2222-4222-8222-222222222222"
+	for _, id := range []string{g1, g2} {
+		data, _ := json.Marshal(Game{ID: id, SchemaVersion: DocumentSchemaVersion, OwnerID: owner})
+		raw := json.RawMessage(data)
+		if _, err := rm.Propose(RaftCommand{Type: CmdSaveGame, ID: id, GameData: &raw, UserID: owner}); err != nil {
+			t.Fatalf("Propose: %v", err)
+		}
+	}
+
+	// Scoreless finalization needs schema version 4 to be active.
+	if results, items := planBulk(owner, []BulkOperation{
+		{Op: BulkOpFinalize, GameIDs: []string{g1}},
+	}, gs, ts, time.Now().UnixMilli(), SchemaVersionV3); len(items) != 0 || results[0].Status != http.StatusConflict {
+		t.Errorf("planBulk at v3 = %+v", results)
+	}
+
+	results, items := planBulk(owner, []BulkOperation{
+		{Op: BulkOpFinalize, GameIDs: []string{g1}},
+		{Op: BulkOpDelete, GameIDs: []string{g2}},
+	}, gs, ts, time.Now().UnixMilli(), CurrentSchemaVersion)
+	if len(items) != 2 || results[0].Status != http.StatusOK || results[1].Status != http.StatusOK {
+		t.Fatalf("planBulk = %+v", results)
+	}
+
+	cmds := append(bulkCommands(owner, items), RaftCommand{
+		Type:   CmdApplyAction,
+		ID:     g1,
+		Action: &ActionPayload{GameID: g1, Action: json.RawMessage(`"malformed"`), UserID: owner},
+	})
+	errs, err := rm.ProposeBatch(t.Context(), cmds)
+	if err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if len(errs) != 3 || errs[0] != nil || errs[1] != nil || errs[2] == nil {
+		t.Fatalf("errs = %v", errs)
+	}
+	if g, _ := gs.LoadGame(g1); g.Status != "final" {
+		t.Errorf("finalized game status = %q", g.Status)
+	}
+	if g, _ := gs.LoadGame(g2); g.Status != "deleted" {
+		t.Errorf("deleted game status = %q", g.Status)
+	}
+}
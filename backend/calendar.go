@@ -0,0 +1,180 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// calendarEventDuration is the assumed length of a game when only a start time is known.
+const calendarEventDuration = 2 * time.Hour
+
+// checkCalendarToken reports whether the supplied token matches the team's feed secret,
+// as generated by newSecretToken. A team without a token has no feed.
+func checkCalendarToken(t *Team, token string) bool {
+	if t.CalendarToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(t.CalendarToken), []byte(token)) == 1
+}
+
+// finalScore extracts the final score from the most recent GAME_FINALIZE action.
+func finalScore(g *Game) (away, home int, ok bool) {
+	if g.Status != "final" {
+		return 0, 0, false
+	}
+	for i := len(g.ActionLog) - 1; i >= 0; i-- {
+		var action BaseAction
+		if err := json.Unmarshal(g.ActionLog[i], &action); err != nil || action.Type != ActionGameFinalize {
+			continue
+		}
+		var p struct {
+			FinalScore *struct {
+				Away int `json:"away"`
+				Home int `json:"home"`
+			} `json:"finalScore"`
+		}
+		// Games finalized in bulk have no score.
+		if err := json.Unmarshal(action.Payload, &p); err != nil || p.FinalScore == nil {
+			return 0, 0, false
+		}
+		return p.FinalScore.Away, p.FinalScore.Home, true
+	}
+	return 0, 0, false
+}
+
+// buildTeamCalendar renders the games of a team as an iCalendar (RFC 5545) document.
+// Games without a parseable date are omitted.
+func buildTeamCalendar(team *Team, games []*Game, now time.Time) []byte {
+	sorted := make([]*Game, len(games))
+	copy(sorted, games)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date < sorted[j].Date
+	})
+
+	var buf bytes.Buffer
+	writeLine := func(s string) {
+		buf.WriteString(foldICSLine(s))
+		buf.WriteString("\r\n")
+	}
+
+	name := team.Name
+	if name == "" {
+		name = team.ShortName
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Skorekeeper//Team Calendar//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICSText(name))
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, g := range sorted {
+		start, allDay, ok := parseGameDate(g.Date)
+		if !ok {
+			continue
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + g.ID + "@skorekeeper")
+		writeLine("DTSTAMP:" + stamp)
+		if allDay {
+			writeLine("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+			writeLine("DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			writeLine("DTSTART:" + start.UTC().Format("20060102T150405Z"))
+			writeLine("DTEND:" + start.Add(calendarEventDuration).UTC().Format("20060102T150405Z"))
+		}
+
+		summary := fmt.Sprintf("%s @ %s", g.Away, g.Home)
+		if g.Event != "" {
+			summary = g.Event + ": " + summary
+		}
+		writeLine("SUMMARY:" + escapeICSText(summary))
+		if g.Location != "" {
+			writeLine("LOCATION:" + escapeICSText(g.Location))
+		}
+
+		if away, home, ok := finalScore(g); ok {
+			writeLine("DESCRIPTION:" + escapeICSText(fmt.Sprintf("Final: %s %d, %s %d", g.Away, away, g.Home, home)))
+			writeLine("STATUS:CONFIRMED")
+		} else {
+			writeLine("STATUS:TENTATIVE")
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// parseGameDate accepts the date formats produced by the client (RFC 3339 or a plain date).
+func parseGameDate(s string) (time.Time, bool, bool) {
+	if s == "" {
+		return time.Time{}, false, false
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04", s); err == nil {
+		return t, false, true
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, true
+	}
+	return time.Time{}, false, false
+}
+
+var icsEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", "",
+)
+
+// escapeICSText escapes a TEXT property value.
+func escapeICSText(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// foldICSLine folds content lines longer than 75 octets without splitting UTF-8 sequences.
+func foldICSLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	lineLen := 0
+	for _, r := range s {
+		n := len(string(r))
+		if lineLen+n > limit {
+			b.WriteString("\r\n ")
+			lineLen = 1
+		}
+		b.WriteRune(r)
+		lineLen += n
+	}
+	return b.String()
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+)
+
+func TestBuildTeamCalendar(t *testing.T) {
+	team := &Team{ID: "tttttttt-0000-4000-8000-000000000001", Name: "Sharks"}
+	games := []*Game{
+		{
+			ID:       "22222222-0000-4000-8000-000000000002",
+			Date:     "2025-06-02T18:30:00Z",
+			Location: "Field 1, North",
+			Event:    "Summer League",
+			Away:     "Sharks",
+			Home:     "Jets",
+			Status:   "final",
+			ActionLog: []json.RawMessage{
+				json.RawMessage(`{"id":"a","type":"GAME_START","payload":{}}`),
+				json.RawMessage(`{"id":"b","type":"GAME_FINALIZE","payload":{"finalScore":{"away":5,"home":3},"stats":{}}}`),
+			},
+		},
+		{
+			ID:   "11111111-0000-4000-8000-000000000001",
+			Date: "2025-06-01",
+			Away: "Bears",
+			Home: "Sharks",
+		},
+		{
+			ID:   "33333333-0000-4000-8000-000000000003",
+			Date: "not a date",
+		},
+	}
+
+	out := string(buildTeamCalendar(team, games, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Sharks\r\n",
+		"UID:11111111-0000-4000-8000-000000000001@skorekeeper\r\n",
+		"DTSTART;VALUE=DATE:20250601\r\n",
+		"DTEND;VALUE=DATE:20250602\r\n",
+		"SUMMARY:Bears @ Sharks\r\n",
+		"DTSTART:20250602T183000Z\r\n",
+		"DTEND:20250602T203000Z\r\n",
+		"SUMMARY:Summer League: Sharks @ Jets\r\n",
+		"LOCATION:Field 1\\, North\r\n",
+		"DESCRIPTION:Final: Sharks 5\\, Jets 3\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("calendar missing %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "33333333") {
+		t.Errorf("game with invalid date should be omitted")
+	}
+	if strings.Index(out, "11111111") > strings.Index(out, "22222222") {
+		t.Errorf("events should be sorted by date")
+	}
+	if strings.Count(out, "DESCRIPTION:") != 1 {
+		t.Errorf("only finalized games should have a score description")
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 80)
+	folded := foldICSLine(line)
+	for _, l := range strings.Split(folded, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("folded line too long: %d", len(l))
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Errorf("unfolding did not restore original line")
+	}
+}
+
+func TestCalendarFeedHandler(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gStore := NewGameStore(tempDir, s)
+	tStore := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gStore, tStore, us, true)
+
+	_, _, handler := NewServerHandler(Options{
+		GameStore:      gStore,
+		TeamStore:      tStore,
+		Storage:        s,
+		Registry:       reg,
+		UserIndexStore: us,
+		UseMockAuth:    true,
+	})
+
+	owner := "owner@example.com"
+	spectator := "fan@example.com"
+	teamId := "44444444-0000-4000-8000-000000000004"
+	gameId := "55555555-0000-4000-8000-000000000005"
+
+	team := Team{
+		ID:      teamId,
+		Name:    "Sharks",
+		OwnerID: owner,
+		Roles:   TeamRoles{Spectators: []string{spectator}},
+	}
+	if err := tStore.SaveTeam(&team); err != nil {
+		t.Fatalf("SaveTeam: %v", err)
+	}
+	reg.UpdateTeam(team)
+
+	game := Game{
+		ID:         gameId,
+		Date:       "2025-06-01T18:00:00Z",
+		Away:       "Sharks",
+		Home:       "Jets",
+		AwayTeamID: teamId,
+		OwnerID:    owner,
+	}
+	if err := gStore.SaveGame(&game); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	reg.UpdateGame(game)
+
+	do := func(method, url, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		if user != "" {
+			req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	feedURL := "/api/teams/" + teamId + "/calendar.ics"
+
+	t.Run("NoTokenConfigured", func(t *testing.T) {
+		if w := do("GET", feedURL+"?token=anything", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("SpectatorCannotRotate", func(t *testing.T) {
+		if w := do("POST", "/api/teams/"+teamId+"/calendar-token", spectator); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	var token string
+	t.Run("OwnerRotates", func(t *testing.T) {
+		w := do("POST", "/api/teams/"+teamId+"/calendar-token", owner)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string]string
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		token = resp["token"]
+		if token == "" || !strings.Contains(resp["url"], token) {
+			t.Fatalf("unexpected response: %v", resp)
+		}
+	})
+
+	t.Run("FeedWithToken", func(t *testing.T) {
+		w := do("GET", feedURL+"?token="+token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if !strings.Contains(w.Body.String(), "UID:"+gameId+"@skorekeeper") {
+			t.Errorf("feed missing game:\n%s", w.Body.String())
+		}
+	})
+
+	t.Run("FeedWithWrongToken", func(t *testing.T) {
+		if w := do("GET", feedURL+"?token=wrong", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("TokenHiddenFromSpectators", func(t *testing.T) {
+		w := do("GET", "/api/load-team/"+teamId, spectator)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), token) {
+			t.Errorf("spectator should not see calendar token")
+		}
+		w = do("GET", "/api/load-team/"+teamId, owner)
+		if !strings.Contains(w.Body.String(), token) {
+			t.Errorf("owner should see calendar token")
+		}
+	})
+
+	t.Run("SaveTeamPreservesToken", func(t *testing.T) {
+		body, _ := json.Marshal(Team{ID: teamId, Name: "Sharks II", CalendarToken: "attacker"})
+		req := httptest.NewRequest("POST", "/api/save-team", strings.NewReader(string(body)))
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: owner})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("save-team failed: %d", w.Code)
+		}
+		loaded, _ := tStore.LoadTeam(teamId)
+		if loaded.CalendarToken != token {
+			t.Errorf("calendar token changed by save-team: %q", loaded.CalendarToken)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		if w := do("DELETE", "/api/teams/"+teamId+"/calendar-token", owner); w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		if w := do("GET", feedURL+"?token="+token, ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 after revoke, got %d", w.Code)
+		}
+	})
+}
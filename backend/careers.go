@@ -0,0 +1,382 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+)
+
+// PlayerCareer is the profile of a player across the teams that know them:
+// a line for every game they played in and their career totals.
+type PlayerCareer struct {
+	Player TeamPlayer   `json:"player"`
+	Teams  []CareerTeam `json:"teams"`
+	Games  []CareerGame `json:"games"`
+	Totals CareerTotals `json:"totals"`
+}
+
+// CareerTeam is a team the player is in the registry of.
+type CareerTeam struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Number string `json:"number"`
+	Active bool   `json:"active"`
+}
+
+// CareerGame is the player's line in one game. Batting and Pitching are nil
+// until the game is finalized with statistics, and Pitching is nil if the
+// player did not pitch.
+type CareerGame struct {
+	GameID   string        `json:"gameId"`
+	Date     string        `json:"date,omitempty"`
+	Event    string        `json:"event,omitempty"`
+	TeamID   string        `json:"teamId"`
+	Side     string        `json:"side"` // "away" or "home"
+	Opponent string        `json:"opponent"`
+	Number   string        `json:"number,omitempty"`
+	Status   string        `json:"status"`
+	Batting  *BattingLine  `json:"batting,omitempty"`
+	Pitching *PitchingLine `json:"pitching,omitempty"`
+}
+
+// BattingLine holds the batting statistics of a player, with the field
+// names of the client's StatsEngine.
+type BattingLine struct {
+	PA      int `json:"pa"`
+	AB      int `json:"ab"`
+	R       int `json:"r"`
+	H       int `json:"h"`
+	Singles int `json:"singles"`
+	Doubles int `json:"doubles"`
+	Triples int `json:"triples"`
+	HR      int `json:"hr"`
+	RBI     int `json:"rbi"`
+	BB      int `json:"bb"`
+	K       int `json:"k"`
+	HBP     int `json:"hbp"`
+	SF      int `json:"sf"`
+	SH      int `json:"sh"`
+	SB      int `json:"sb"`
+	ROE     int `json:"roe"`
+}
+
+func (b *BattingLine) add(o BattingLine) {
+	b.PA += o.PA
+	b.AB += o.AB
+	b.R += o.R
+	b.H += o.H
+	b.Singles += o.Singles
+	b.Doubles += o.Doubles
+	b.Triples += o.Triples
+	b.HR += o.HR
+	b.RBI += o.RBI
+	b.BB += o.BB
+	b.K += o.K
+	b.HBP += o.HBP
+	b.SF += o.SF
+	b.SH += o.SH
+	b.SB += o.SB
+	b.ROE += o.ROE
+}
+
+// PitchingLine holds the pitching statistics of a player, with the field
+// names of the client's StatsEngine. Innings pitched are counted in outs.
+type PitchingLine struct {
+	IPOuts  int `json:"ipOuts"`
+	BF      int `json:"bf"`
+	H       int `json:"h"`
+	BB      int `json:"bb"`
+	K       int `json:"k"`
+	HBP     int `json:"hbp"`
+	ER      int `json:"er"`
+	Pitches int `json:"pitches"`
+	Strikes int `json:"strikes"`
+	Balls   int `json:"balls"`
+}
+
+func (p *PitchingLine) add(o PitchingLine) {
+	p.IPOuts += o.IPOuts
+	p.BF += o.BF
+	p.H += o.H
+	p.BB += o.BB
+	p.K += o.K
+	p.HBP += o.HBP
+	p.ER += o.ER
+	p.Pitches += o.Pitches
+	p.Strikes += o.Strikes
+	p.Balls += o.Balls
+}
+
+// CareerTotals sums the lines of the player's games. Games counts the games
+// with statistics.
+type CareerTotals struct {
+	Games    int          `json:"games"`
+	Batting  BattingLine  `json:"batting"`
+	Pitching PitchingLine `json:"pitching"`
+	AVG      float64      `json:"avg"`
+	OBP      float64      `json:"obp"`
+	SLG      float64      `json:"slg"`
+}
+
+// rate returns n/d rounded to three decimals, or 0 if d is 0.
+func rate(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*1000) / 1000
+}
+
+// finalStats extracts the statistics the scorer attached to the most recent
+// GAME_FINALIZE action.
+func finalStats(g *Game) (batting map[string]batterStats, pitching map[string]pitcherStats, ok bool) {
+	if g.Status != "final" {
+		return nil, nil, false
+	}
+	for i := len(g.ActionLog) - 1; i >= 0; i-- {
+		var action BaseAction
+		if err := json.Unmarshal(g.ActionLog[i], &action); err != nil || action.Type != ActionGameFinalize {
+			continue
+		}
+		var p struct {
+			Stats *struct {
+				PlayerStats  map[string]batterStats  `json:"playerStats"`
+				PitcherStats map[string]pitcherStats `json:"pitcherStats"`
+			} `json:"stats"`
+		}
+		// Games finalized in bulk have no statistics.
+		if err := json.Unmarshal(action.Payload, &p); err != nil || p.Stats == nil {
+			return nil, nil, false
+		}
+		return p.Stats.PlayerStats, p.Stats.PitcherStats, true
+	}
+	return nil, nil, false
+}
+
+// batterStats is an entry of the client's playerStats, which are keyed by
+// player ID.
+type batterStats struct {
+	BattingLine
+	Team string `json:"team"`
+}
+
+// pitcherStats is an entry of the client's pitcherStats, which are keyed by
+// the pitcher as entered by the scorer (usually the jersey number).
+type pitcherStats struct {
+	PitchingLine
+	Team string `json:"team"`
+}
+
+// sidePlayers returns the players of one side of a game: the lineup,
+// substitutes and bench as stored and as set by the action log.
+func (g *Game) sidePlayers(side string) []Player {
+	var out []Player
+	for _, slot := range g.Roster[side] {
+		out = append(out, slot.Starter, slot.Current)
+		out = append(out, slot.History...)
+	}
+	out = append(out, g.Subs[side]...)
+	for _, a := range effectiveActions(g.ActionLog) {
+		switch a.Type {
+		case ActionGameStart:
+			var p struct {
+				InitialRosters map[string][]Player `json:"initialRosters"`
+				InitialSubs    map[string][]Player `json:"initialSubs"`
+			}
+			if json.Unmarshal(a.Payload, &p) == nil {
+				out = append(out, p.InitialRosters[side]...)
+				out = append(out, p.InitialSubs[side]...)
+			}
+		case ActionLineupUpdate:
+			var p struct {
+				Team   string       `json:"team"`
+				Roster []RosterSlot `json:"roster"`
+				Subs   []Player     `json:"subs"`
+			}
+			if json.Unmarshal(a.Payload, &p) != nil || p.Team != side {
+				continue
+			}
+			for _, slot := range p.Roster {
+				out = append(out, slot.Starter, slot.Current)
+				out = append(out, slot.History...)
+			}
+			out = append(out, p.Subs...)
+		case ActionSubstitution:
+			var p struct {
+				Team      string `json:"team"`
+				SubParams Player `json:"subParams"`
+			}
+			if json.Unmarshal(a.Payload, &p) == nil && p.Team == side {
+				out = append(out, p.SubParams)
+			}
+		}
+	}
+	return out
+}
+
+// careerGame returns the line of the player with the given registry IDs on
+// one side of g, and whether they played in it. Batting lines are keyed by
+// player ID. Pitching lines are keyed by what the scorer entered as the
+// pitcher, so they are matched by the player's number in the game.
+func careerGame(g *Game, teamId, side string, ids map[string]bool) (CareerGame, bool) {
+	played := false
+	number := ""
+	for _, p := range g.sidePlayers(side) {
+		if ids[p.ID] {
+			played = true
+			if n := strings.TrimSpace(p.Number); n != "" {
+				number = n
+			}
+		}
+	}
+	teamName, opponent := g.Away, g.Home
+	if side == "home" {
+		teamName, opponent = g.Home, g.Away
+	}
+	cg := CareerGame{
+		GameID:   g.ID,
+		Date:     g.Date,
+		Event:    g.Event,
+		TeamID:   teamId,
+		Side:     side,
+		Opponent: opponent,
+		Number:   number,
+		Status:   g.Status,
+	}
+
+	batting, pitching, ok := finalStats(g)
+	if !ok {
+		return cg, played
+	}
+	var line BattingLine
+	for id := range ids {
+		if b, ok := batting[id]; ok && (b.Team == "" || b.Team == teamName) {
+			line.add(b.BattingLine)
+			played = true
+		}
+	}
+	if !played {
+		return cg, false
+	}
+	cg.Batting = &line
+	if ps, ok := pitching[number]; ok && number != "" && (ps.Team == "" || ps.Team == teamName) {
+		cg.Pitching = &ps.PitchingLine
+	}
+	return cg, true
+}
+
+// indexTeamPlayers adds teamId to the teams of each of its players. Teams are
+// never removed: PlayerCareer checks that they still have the player.
+func (r *Registry) indexTeamPlayers(teamId string, playerIds []string) {
+	for _, id := range playerIds {
+		idx, err := r.userStore.GetPlayerTeams(id)
+		if err != nil || idx.TeamIDs[teamId] {
+			continue
+		}
+		idx.TeamIDs[teamId] = true
+		r.userStore.SetPlayerTeams(idx)
+	}
+}
+
+// PlayerCareer assembles the career of a player from the teams whose
+// registry holds playerId and the games of those teams userId can read,
+// checked with HasGameAccess. The IDs of duplicates merged into the player
+// count as theirs. Teams are listed if userId can read them or one of the
+// player's games on them. It returns errPlayerNotFound if there is nothing
+// userId may see.
+func (r *Registry) PlayerCareer(userId, playerId string) (*PlayerCareer, error) {
+	career := &PlayerCareer{Teams: []CareerTeam{}, Games: []CareerGame{}}
+	found := false
+	pt, err := r.userStore.GetPlayerTeams(playerId)
+	if err != nil {
+		return nil, err
+	}
+	for teamId := range pt.TeamIDs {
+		t, err := r.teamStore.LoadTeam(teamId)
+		if err != nil || t.Status == "deleted" {
+			continue
+		}
+		// Teams saved before the registry existed derive it from the roster.
+		t.syncPlayers(t.UpdatedAt)
+		p := t.player(playerId)
+		if p == nil {
+			continue
+		}
+		ids := map[string]bool{}
+		for _, rec := range t.Players {
+			if q := t.player(rec.ID); q != nil && q.ID == p.ID {
+				ids[rec.ID] = true
+			}
+		}
+
+		visible := GetTeamAccess(userId, *t) >= AccessRead
+		tg, _ := r.userStore.GetTeamGames(t.ID)
+		for gameId := range tg.GameIDs {
+			if !r.HasGameAccess(userId, gameId) {
+				continue
+			}
+			g, err := r.gameStore.LoadGame(gameId)
+			if err != nil || g.Status == "deleted" {
+				continue
+			}
+			for _, side := range []string{"away", "home"} {
+				if (side == "away" && g.AwayTeamID != t.ID) || (side == "home" && g.HomeTeamID != t.ID) {
+					continue
+				}
+				if cg, ok := careerGame(g, t.ID, side, ids); ok {
+					career.Games = append(career.Games, cg)
+					visible = true
+				}
+			}
+		}
+		if !visible {
+			continue
+		}
+		if !found || p.UpdatedAt > career.Player.UpdatedAt {
+			career.Player = *p
+		}
+		found = true
+		career.Teams = append(career.Teams, CareerTeam{ID: t.ID, Name: t.Name, Number: p.Number, Active: p.Active})
+	}
+	if !found {
+		return nil, errPlayerNotFound
+	}
+
+	slices.SortFunc(career.Teams, func(a, b CareerTeam) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	slices.SortFunc(career.Games, func(a, b CareerGame) int {
+		return cmp.Or(cmp.Compare(a.Date, b.Date), cmp.Compare(a.GameID, b.GameID), cmp.Compare(a.Side, b.Side))
+	})
+	tot := &career.Totals
+	for _, g := range career.Games {
+		if g.Batting == nil {
+			continue
+		}
+		tot.Games++
+		tot.Batting.add(*g.Batting)
+		if g.Pitching != nil {
+			tot.Pitching.add(*g.Pitching)
+		}
+	}
+	b := tot.Batting
+	tot.AVG = rate(b.H, b.AB)
+	tot.OBP = rate(b.H+b.BB+b.HBP, b.AB+b.BB+b.HBP+b.SF)
+	tot.SLG = rate(b.Singles+2*b.Doubles+3*b.Triples+4*b.HR, b.AB)
+	return career, nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c2FmZQ/storage"
+)
+
+// careerGameFixture returns a game of the Bears at home against the Owls.
+// The Bears start Ann (#1) and Bo under the ID of his duplicate (#22). A
+// non-empty stats finalizes the game with it.
+func careerGameFixture(id, date, owner, teamId, stats string) *Game {
+	log := []json.RawMessage{
+		json.RawMessage(fmt.Sprintf(`{"id":"c1000000-0000-4000-8000-000000000001","type":"GAME_START","payload":{"id":%q,"date":%q,"away":"Owls","home":"Bears","initialRosters":{"home":[{"id":%q,"name":"Ann","number":"1"},{"id":%q,"name":"Bo","number":"22"}]}}}`, id, date, playerAnn, playerBo2)),
+	}
+	status := "ongoing"
+	if stats != "" {
+		status = "final"
+		log = append(log, json.RawMessage(fmt.Sprintf(`{"id":"c1000000-0000-4000-8000-000000000002","type":"GAME_FINALIZE","payload":{"finalScore":{"away":2,"home":5},"stats":%s}}`, stats)))
+	}
+	return &Game{
+		ID:            id,
+		SchemaVersion: CurrentSchemaVersion,
+		OwnerID:       owner,
+		Date:          date,
+		Away:          "Owls",
+		Home:          "Bears",
+		HomeTeamID:    teamId,
+		Status:        status,
+		Permissions:   Permissions{Public: "none", Users: map[string]string{}},
+		ActionLog:     log,
+	}
+}
+
+var careerStats = `{
+	"playerStats": {
+		"` + playerAnn + `": {"pa":4,"ab":3,"h":2,"singles":1,"hr":1,"bb":1,"rbi":3,"name":"Ann","team":"Bears"},
+		"` + playerBo2 + `": {"pa":3,"ab":3,"h":1,"doubles":1,"name":"Bo","team":"Bears"}
+	},
+	"pitcherStats": {
+		"1": {"ipOuts":9,"bf":12,"k":4,"h":2,"team":"Bears"},
+		"22": {"ipOuts":3,"bf":5,"k":1,"team":"Owls"}
+	}
+}`
+
+func TestCareerGame(t *testing.T) {
+	g := careerGameFixture("11111111-1111-4111-8111-111111111111", "2025-06-01", "owner@example.com", "", careerStats)
+
+	ann, ok := careerGame(g, "", "home", map[string]bool{playerAnn: true})
+	if !ok || ann.Number != "1" || ann.Opponent != "Owls" || ann.Batting == nil || ann.Batting.HR != 1 {
+		t.Fatalf("ann = %+v", ann)
+	}
+	if ann.Pitching == nil || ann.Pitching.IPOuts != 9 {
+		t.Errorf("ann pitching = %+v", ann.Pitching)
+	}
+
+	// The Owls' #22 is not Bo.
+	bo, ok := careerGame(g, "", "home", map[string]bool{playerBo: true, playerBo2: true})
+	if !ok || bo.Batting == nil || bo.Batting.Doubles != 1 || bo.Pitching != nil {
+		t.Errorf("bo = %+v", bo)
+	}
+
+	if _, ok := careerGame(g, "", "away", map[string]bool{playerAnn: true}); ok {
+		t.Error("ann played for the Owls")
+	}
+	// Bulk-finalized games have no statistics.
+	g.ActionLog[1] = json.RawMessage(`{"id":"c1000000-0000-4000-8000-000000000002","type":"GAME_FINALIZE","payload":{}}`)
+	if ann, ok := careerGame(g, "", "home", map[string]bool{playerAnn: true}); !ok || ann.Batting != nil {
+		t.Errorf("ann without stats = %+v", ann)
+	}
+}
+
+func TestPlayerCareerEndpoint(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+
+	owner := "owner@example.com"
+	parent := "parent@example.com"
+	teamId := "55555555-5555-4555-8555-555555555555"
+	final1 := careerGameFixture("11111111-1111-4111-8111-111111111111", "2025-06-01", owner, teamId, careerStats)
+	final2 := careerGameFixture("22222222-2222-4222-8222-222222222222", "2025-06-08", owner, teamId, `{"playerStats":{"`+playerBo2+`":{"pa":4,"ab":4,"h":2,"singles":2}},"pitcherStats":{}}`)
+	ongoing := careerGameFixture("33333333-3333-4333-8333-333333333333", "2025-06-15", owner, teamId, "")
+	ongoing.Permissions.Users[parent] = "read"
+	for _, g := range []*Game{final1, final2, ongoing} {
+		if err := gs.SaveGame(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	team := &Team{
+		ID:            teamId,
+		SchemaVersion: CurrentSchemaVersion,
+		Name:          "Bears",
+		OwnerID:       owner,
+		Roster:        []Player{{ID: playerAnn, Name: "Ann", Number: "1"}, {ID: playerBo, Name: "Bo", Number: "2"}},
+		Players: []TeamPlayer{
+			{ID: playerAnn, Name: "Ann", Number: "1", Active: true},
+			{ID: playerBo, Name: "Bo", Number: "2", Active: true},
+			{ID: playerBo2, Name: "Bo", Number: "22", MergedInto: playerBo},
+		},
+	}
+	if err := ts.SaveTeam(team); err != nil {
+		t.Fatal(err)
+	}
+
+	_, reg, handler := NewServerHandler(Options{
+		DataDir:      tempDir,
+		Storage:      s,
+		GameStore:    gs,
+		TeamStore:    ts,
+		UseMockAuth:  true,
+		ForceRebuild: true,
+	})
+	// Careers find the teams of a player through the index, merged duplicates included.
+	for _, id := range []string{playerAnn, playerBo, playerBo2} {
+		if pt, err := reg.userStore.GetPlayerTeams(id); err != nil || !pt.TeamIDs[teamId] {
+			t.Errorf("GetPlayerTeams(%s) = %+v, %v", id, pt, err)
+		}
+	}
+	get := func(id, user string) (int, PlayerCareer) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/players/"+id, nil)
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp struct {
+			Data PlayerCareer `json:"data"`
+		}
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("career: %s", rec.Body)
+			}
+		}
+		return rec.Code, resp.Data
+	}
+
+	// The duplicate's ID resolves to Bo, and his games under it are his.
+	code, c := get(playerBo2, owner)
+	if code != http.StatusOK || c.Player.ID != playerBo || len(c.Teams) != 1 || c.Teams[0].Name != "Bears" {
+		t.Fatalf("bo = %d %+v", code, c)
+	}
+	if len(c.Games) != 3 || c.Games[0].GameID != final1.ID || c.Games[2].Batting != nil || c.Games[2].Number != "22" {
+		t.Fatalf("bo games = %+v", c.Games)
+	}
+	if c.Totals.Games != 2 || c.Totals.Batting.H != 3 || c.Totals.AVG != 0.429 || c.Totals.SLG != 0.571 {
+		t.Errorf("bo totals = %+v", c.Totals)
+	}
+
+	code, c = get(playerAnn, owner)
+	if code != http.StatusOK || c.Totals.Games != 2 || c.Totals.Pitching.K != 4 || c.Totals.OBP != 0.75 || c.Totals.SLG != 1.667 {
+		t.Errorf("ann = %d %+v", code, c.Totals)
+	}
+
+	// A parent with access to one game sees that game only.
+	code, c = get(playerBo, parent)
+	if code != http.StatusOK || len(c.Teams) != 1 || len(c.Games) != 1 || c.Games[0].GameID != ongoing.ID || c.Totals.Games != 0 {
+		t.Errorf("bo for parent = %d %+v", code, c)
+	}
+
+	if code, _ := get(playerBo, "stranger@example.com"); code != http.StatusNotFound {
+		t.Errorf("bo for stranger: %d", code)
+	}
+	if code, _ := get("a0000000-0000-4000-8000-000000000009", owner); code != http.StatusNotFound {
+		t.Errorf("unknown player: %d", code)
+	}
+	if code, _ := get("not-a-uuid", owner); code != http.StatusBadRequest {
+		t.Errorf("invalid id: %d", code)
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Change operations.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+	ChangeAccess  = "access" // The user's access level changed
+)
+
+// changeFeedMaxPerUser is the number of changes kept for each user. Older
+// changes are dropped; clients asking for them must sync in full.
+const changeFeedMaxPerUser = 5000
+
+// ChangeLog is a user's change feed: a ring buffer of the user's latest
+// changes, in the order they were recorded.
+type ChangeLog struct {
+	UserID  string   `json:"userId"`
+	Changes []Change `json:"changes"`
+	Next    int      `json:"next"`  // Position of the oldest change once the buffer is full
+	Floor   uint64   `json:"floor"` // Highest index of the dropped changes
+}
+
+// add appends a change to the log, dropping the oldest change once it holds
+// size changes. Repeated changes of the same game or team, like the actions
+// of a game being scored, replace each other.
+func (l *ChangeLog) add(c Change, size int) {
+	if n := len(l.Changes); n > 0 {
+		last := &l.Changes[(l.Next+n-1)%n]
+		if last.Type == c.Type && last.ID == c.ID {
+			*last = c
+			return
+		}
+	}
+	if len(l.Changes) < size {
+		l.Changes = append(l.Changes, c)
+		return
+	}
+	l.Floor = max(l.Floor, l.Changes[l.Next].Index)
+	l.Changes[l.Next] = c
+	l.Next = (l.Next + 1) % len(l.Changes)
+}
+
+// Change is an entry of a user's change feed: the latest change of a game or
+// team the user can access, or could access before the change.
+type Change struct {
+	Index  uint64 `json:"index"` // Raft index of the change
+	Type   string `json:"type"`  // "game" or "team"
+	ID     string `json:"id"`
+	Op     string `json:"op"`
+	Access string `json:"access"` // The user's access level after the change
+	Time   int64  `json:"time"`   // Unix ms
+}
+
+// ChangeFeed is a page of a user's change feed.
+type ChangeFeed struct {
+	Changes []Change
+	// Index is the index of the last change included. Changes after it are
+	// requested with since=Index.
+	Index uint64
+	// More is set when more changes follow Index.
+	More bool
+	// Reset is set when changes after since were dropped: the client must
+	// list all its games and teams again.
+	Reset bool
+}
+
+// accessName returns the name of an access level in the change feed.
+func accessName(level AccessLevel) string {
+	switch level {
+	case AccessRead:
+		return "read"
+	case AccessWrite:
+		return "write"
+	case AccessAdmin:
+		return "admin"
+	}
+	return "none"
+}
+
+// TrackChanges numbers the changes of the Registry with the Raft log: the FSM
+// calls SetChangeIndex before applying entries, and applied reports the index
+// of the last applied entry. Without it, changes are numbered locally.
+func (r *Registry) TrackChanges(applied func() uint64) {
+	r.changesApplied.Store(&applied)
+}
+
+// SetChangeIndex sets the index and time of the changes recorded until the
+// next call, i.e. the Raft index and proposal time of the entries being
+// applied.
+func (r *Registry) SetChangeIndex(index uint64, now time.Time) {
+	r.changeIndex.Store(index)
+	r.changeTime.Store(now.UnixMilli())
+}
+
+// currentChangeTime returns the time of a new change, in Unix ms.
+func (r *Registry) currentChangeTime() int64 {
+	if r.changesApplied.Load() != nil {
+		return r.changeTime.Load()
+	}
+	return time.Now().UnixMilli()
+}
+
+// nextChangeIndex returns the index of a new change. Standalone servers use a
+// clock-based sequence, so indexes keep increasing across restarts.
+func (r *Registry) nextChangeIndex() uint64 {
+	if r.changesApplied.Load() != nil {
+		return r.changeIndex.Load()
+	}
+	for {
+		last := r.changeIndex.Load()
+		next := max(last+1, uint64(time.Now().UnixMilli()))
+		if r.changeIndex.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// lastChangeIndex returns the index of the last complete change. Standalone
+// servers return the current time, and number later changes after it.
+func (r *Registry) lastChangeIndex() uint64 {
+	if applied := r.changesApplied.Load(); applied != nil {
+		return (*applied)()
+	}
+	for {
+		last := r.changeIndex.Load()
+		index := max(last, uint64(time.Now().UnixMilli()))
+		if r.changeIndex.CompareAndSwap(last, index) {
+			return index
+		}
+	}
+}
+
+// recordChange appends a change to the change logs of users.
+func (r *Registry) recordChange(users map[string]bool, typ, id, op string, access func(userId string) AccessLevel) {
+	if len(users) == 0 {
+		return
+	}
+	index := r.nextChangeIndex()
+	now := r.currentChangeTime()
+
+	r.changesMu.Lock()
+	defer r.changesMu.Unlock()
+	for u := range users {
+		if u == "" {
+			// Anonymous access has no feed.
+			continue
+		}
+		l, err := r.userStore.GetChangeLog(u)
+		if err != nil {
+			continue
+		}
+		l.add(Change{Index: index, Type: typ, ID: id, Op: op, Access: accessName(access(u)), Time: now}, changeFeedMaxPerUser)
+		r.userStore.SetChangeLog(l)
+	}
+}
+
+// Changes returns the changes of the games and teams of a user after since,
+// oldest first. since is the Index of the previous page, or 0 to get the
+// current index only.
+func (r *Registry) Changes(userId string, since uint64, limit int) (ChangeFeed, error) {
+	feed := ChangeFeed{Changes: []Change{}, Index: r.lastChangeIndex()}
+	if since == 0 {
+		return feed, nil
+	}
+	l, err := r.userStore.GetChangeLog(userId)
+	if err != nil {
+		return feed, err
+	}
+
+	// Only the latest change of each game and team is listed.
+	latest := make(map[string]Change)
+	r.changesMu.Lock()
+	feed.Reset = since < l.Floor
+	if !feed.Reset {
+		for _, c := range l.Changes {
+			key := c.Type + ":" + c.ID
+			if c.Index > since && c.Index <= feed.Index && c.Index >= latest[key].Index {
+				latest[key] = c
+			}
+		}
+	}
+	r.changesMu.Unlock()
+	feed.Changes = slices.AppendSeq(feed.Changes, maps.Values(latest))
+
+	slices.SortFunc(feed.Changes, func(a, b Change) int {
+		return cmp.Or(cmp.Compare(a.Index, b.Index), cmp.Compare(a.Type, b.Type), cmp.Compare(a.ID, b.ID))
+	})
+	if len(feed.Changes) > limit {
+		// The changes of one index are never split, so that the next page
+		// can start after the last index of this one.
+		last := feed.Changes[limit-1].Index
+		n := slices.IndexFunc(feed.Changes, func(c Change) bool { return c.Index > last })
+		if n >= 0 {
+			feed.Changes = feed.Changes[:n]
+			feed.Index = last
+			feed.More = true
+		}
+	}
+	return feed, nil
+}
+
+// recordGameChange records a change of a game for the users with direct
+// access to it before or after the change, and the members of its teams.
+// Users whose direct access changed get a ChangeAccess instead of
+// ChangeUpdated.
+func (r *Registry) recordGameChange(m GameMetadata, op string, users, accessChanged map[string]bool) {
+	followers := maps.Clone(users)
+	for _, teamId := range []string{m.AwayTeamID, m.HomeTeamID} {
+		if teamId == "" {
+			continue
+		}
+		tu, _ := r.userStore.GetTeamUsers(teamId)
+		maps.Copy(followers, tu.UserIDs)
+	}
+	access := func(userId string) AccessLevel {
+		if op == ChangeDeleted {
+			return AccessNone
+		}
+		return r.GetAccessLevel(userId, m.ID)
+	}
+	if op == ChangeUpdated && len(accessChanged) > 0 {
+		maps.DeleteFunc(followers, func(u string, _ bool) bool { return accessChanged[u] })
+		r.recordChange(accessChanged, "game", m.ID, ChangeAccess, access)
+	}
+	r.recordChange(followers, "game", m.ID, op, access)
+}
+
+// recordTeamChange records a change of a team for its members before or after
+// the change. Members whose access changed get a ChangeAccess instead of
+// ChangeUpdated, and a ChangeAccess for each game of the team.
+func (r *Registry) recordTeamChange(teamId, op string, members, accessChanged map[string]bool) {
+	access := func(userId string) AccessLevel {
+		if op == ChangeDeleted {
+			return AccessNone
+		}
+		idx, err := r.userStore.GetUserIndex(userId)
+		if err != nil {
+			return AccessNone
+		}
+		return idx.TeamAccess[teamId]
+	}
+	members = maps.Clone(members)
+	if op == ChangeUpdated && len(accessChanged) > 0 {
+		maps.DeleteFunc(members, func(u string, _ bool) bool { return accessChanged[u] })
+		r.recordChange(accessChanged, "team", teamId, ChangeAccess, access)
+	}
+	r.recordChange(members, "team", teamId, op, access)
+
+	if len(accessChanged) == 0 {
+		return
+	}
+	tg, _ := r.userStore.GetTeamGames(teamId)
+	for gameId := range tg.GameIDs {
+		if r.IsGameDeleted(gameId) {
+			continue
+		}
+		r.recordChange(accessChanged, "game", gameId, ChangeAccess, func(userId string) AccessLevel {
+			return r.GetAccessLevel(userId, gameId)
+		})
+	}
+}
+
+// deletedGameMetadata returns the metadata of a game being deleted, from the
+// cache or from the game retained by its tombstone, for the teams it belonged
+// to.
+func (r *Registry) deletedGameMetadata(gameId string) GameMetadata {
+	if m, ok := r.gameMetadata.Peek(gameId); ok && m.Status != "deleted" {
+		return m
+	}
+	if g, err := r.gameStore.LoadGame(gameId); err == nil && len(g.Trashed) > 0 {
+		var trashed Game
+		if json.Unmarshal(g.Trashed, &trashed) == nil {
+			return *trashed.Metadata()
+		}
+	}
+	return GameMetadata{ID: gameId}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
+)
+
+func TestRegistry_Changes(t *testing.T) {
+	tmpDir := t.TempDir()
+	s := storage.New(tmpDir, nil)
+	gs := NewGameStore(tmpDir, s)
+	ts := NewTeamStore(tmpDir, s)
+	us := NewUserIndexStore(tmpDir, s, nil)
+	r := NewRegistry(gs, ts, us, true)
+	defer r.StopGC()
+
+	owner := "owner@example.com"
+	reader := "reader@example.com"
+	fan := "fan@example.com"
+
+	type entry struct{ typ, id, op, access string }
+	since := map[string]uint64{}
+	for _, u := range []string{owner, reader, fan} {
+		feed, _ := r.Changes(u, 0, 100)
+		if len(feed.Changes) != 0 {
+			t.Fatalf("Changes(%s, 0) = %v, want none", u, feed.Changes)
+		}
+		since[u] = feed.Index
+	}
+	check := func(user string, want ...entry) {
+		t.Helper()
+		feed, err := r.Changes(user, since[user], 100)
+		if err != nil {
+			t.Fatalf("Changes: %v", err)
+		}
+		var got []entry
+		for _, c := range feed.Changes {
+			got = append(got, entry{c.Type, c.ID, c.Op, c.Access})
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Changes(%s) = %v, want %v", user, got, want)
+		}
+		since[user] = feed.Index
+	}
+
+	// A new game shared with a reader.
+	g1 := &Game{ID: "g1", SchemaVersion: CurrentSchemaVersion, OwnerID: owner,
+		Permissions: Permissions{Users: map[string]string{reader: "read"}}}
+	gs.SaveGame(g1)
+	r.UpdateGame(*g1)
+	check(owner, entry{"game", "g1", ChangeCreated, "admin"})
+	check(reader, entry{"game", "g1", ChangeCreated, "read"})
+
+	// Unsharing it.
+	g1.Permissions.Users = nil
+	g1.Event = "Opener"
+	gs.SaveGame(g1)
+	r.UpdateGame(*g1)
+	check(owner, entry{"game", "g1", ChangeUpdated, "admin"})
+	check(reader, entry{"game", "g1", ChangeAccess, "none"})
+
+	// A team game, and a new spectator of the team.
+	team := &Team{ID: "t1", SchemaVersion: CurrentSchemaVersion, OwnerID: owner, Name: "Hawks"}
+	ts.SaveTeam(team)
+	r.UpdateTeam(*team)
+	g2 := &Game{ID: "g2", SchemaVersion: CurrentSchemaVersion, OwnerID: owner, HomeTeamID: team.ID}
+	gs.SaveGame(g2)
+	r.UpdateGame(*g2)
+	check(owner, entry{"team", "t1", ChangeCreated, "admin"}, entry{"game", "g2", ChangeCreated, "admin"})
+
+	team.Roles.Spectators = []string{fan}
+	ts.SaveTeam(team)
+	r.UpdateTeam(*team)
+	check(owner, entry{"team", "t1", ChangeUpdated, "admin"})
+	check(fan, entry{"team", "t1", ChangeAccess, "read"}, entry{"game", "g2", ChangeAccess, "read"})
+
+	// Team members follow the changes of its games.
+	gs.SaveGame(g2)
+	r.UpdateGame(*g2)
+	check(fan, entry{"game", "g2", ChangeUpdated, "read"})
+
+	gs.TrashGame(g2.ID, 0, time.Now())
+	r.DeleteGame(g2.ID)
+	check(owner, entry{"game", "g2", ChangeDeleted, "none"})
+	check(fan, entry{"game", "g2", ChangeDeleted, "none"})
+	check(reader)
+
+	// Pages don't split the changes of one index.
+	feed, _ := r.Changes(owner, 1, 1)
+	if len(feed.Changes) != 1 || !feed.More || feed.Index != feed.Changes[0].Index {
+		t.Errorf("Changes(limit=1) = %+v", feed)
+	}
+
+	// Dropped changes require a full sync.
+	l, _ := us.GetChangeLog(owner)
+	l.Floor = since[owner]
+	if feed, _ := r.Changes(owner, since[owner]-1, 100); !feed.Reset {
+		t.Errorf("Changes before the floor = %+v, want Reset", feed)
+	}
+}
+
+func TestFSM_ChangeIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gs, ts, us, true)
+	defer reg.StopGC()
+	fsm := NewFSM(gs, ts, reg, NewHubManager(), s, us)
+
+	owner := "owner@example.com"
+	for i, id := range []string{"g1", "g2"} {
+		data, _ := json.Marshal(Game{ID: id, SchemaVersion: CurrentSchemaVersion, OwnerID: owner})
+		raw := json.RawMessage(data)
+		cmd, _ := json.Marshal(RaftCommand{Type: CmdSaveGame, ID: id, GameData: &raw, Timestamp: int64(1000 + i)})
+		if err, _ := fsm.ApplyBatch([]*raft.Log{{Index: uint64(10 + i), Type: raft.LogCommand, Data: cmd}})[0].(error); err != nil {
+			t.Fatalf("ApplyBatch: %v", err)
+		}
+	}
+
+	feed, _ := reg.Changes(owner, 10, 100)
+	if feed.Index != 11 || len(feed.Changes) != 1 || feed.Changes[0].ID != "g2" || feed.Changes[0].Index != 11 || feed.Changes[0].Time != 1001 {
+		t.Errorf("Changes(10) = %+v", feed)
+	}
+}
+
+func TestChangeLog(t *testing.T) {
+	var l ChangeLog
+	for i, id := range []string{"a", "b", "b", "c", "d", "e"} {
+		l.add(Change{Index: uint64(i + 1), Type: "game", ID: id}, 3)
+	}
+	var got []uint64
+	for i := range l.Changes {
+		got = append(got, l.Changes[(l.Next+i)%len(l.Changes)].Index)
+	}
+	// b@2 was replaced by b@3, then a and b were dropped.
+	if want := []uint64{4, 5, 6}; !slices.Equal(got, want) || l.Floor != 3 {
+		t.Errorf("ChangeLog = %v floor %d, want %v floor 3", got, l.Floor, want)
+	}
+}
@@ -26,6 +26,7 @@
         const clusterInfo = document.getElementById('cluster-info');
 
         let raftSecret = '';
+        let drainRefreshTimer = null;
 
         btnAccessDashboard.addEventListener('click', () => {
             raftSecret = document.getElementById('secret').value;
@@ -90,7 +91,8 @@
                 if (!res.ok) throw new Error(await res.text());
 
                 const data = await res.json();
-                showDashboard(data);
+                const drains = await loadDrainStatus(data.nodes || []);
+                showDashboard(data, drains);
             } catch (err) {
                 console.error(err);
                 if (!raftSecret) {
@@ -110,7 +112,33 @@
             }
         }
 
-        function showDashboard(data) {
+        // Fetches the drain progress of every node, keyed by node ID. Nodes that
+        // cannot be reached are left out.
+        async function loadDrainStatus(nodes) {
+            const drains = {};
+            await Promise.all(nodes.map(async node => {
+                try {
+                    const res = await fetch(`/api/cluster/drain?nodeId=${encodeURIComponent(node.id)}`, {
+                        headers: { 'X-Raft-Secret': raftSecret }
+                    });
+                    if (res.ok) drains[node.id] = await res.json();
+                } catch (err) {
+                    console.error(err);
+                }
+            }));
+            return drains;
+        }
+
+        function describeDrain(drain) {
+            if (!drain || !drain.state) return '';
+            let text = `Drain: ${drain.state}`;
+            if (drain.step) text += ` (${drain.step})`;
+            text += `, ${drain.clientsMigrated} clients migrated, ${drain.connections} connected`;
+            if (drain.error) text += ` - ${drain.error}`;
+            return text;
+        }
+
+        function showDashboard(data, drains = {}) {
             loginSection.classList.add('hidden');
             dashboardSection.classList.remove('hidden');
 
@@ -164,6 +192,14 @@
                 const role = isLeader ? 'Leader' : 'Follower';
                 const suffrage = node.suffrage ? ` (${node.suffrage})` : '';
                 tdRole.textContent = role + suffrage;
+                const drain = drains[node.id];
+                if (drain && drain.state) {
+                    tdRole.appendChild(document.createElement('br'));
+                    const spanDrain = document.createElement('span');
+                    spanDrain.className = drain.state === 'failed' ? 'error' : 'status-unknown';
+                    spanDrain.textContent = describeDrain(drain);
+                    tdRole.appendChild(spanDrain);
+                }
                 tr.appendChild(tdRole);
 
                 const tdVer = document.createElement('td');
@@ -179,6 +215,17 @@
                 tr.appendChild(tdVer);
 
                 const tdActions = document.createElement('td');
+                const btnDrain = document.createElement('button');
+                btnDrain.className = 'secondary btn-sm';
+                if (drain && drain.state) {
+                    btnDrain.textContent = 'Undrain';
+                    btnDrain.onclick = () => window.drainNode(node.id, false);
+                } else {
+                    btnDrain.textContent = 'Drain';
+                    btnDrain.onclick = () => window.drainNode(node.id, true);
+                }
+                tdActions.appendChild(btnDrain);
+                tdActions.appendChild(document.createTextNode(' '));
                 const btn = document.createElement('button');
                 btn.className = 'danger btn-sm';
                 btn.textContent = 'Remove';
@@ -188,8 +235,30 @@
 
                 nodesTableBody.appendChild(tr);
             });
+
+            // Follow drains in progress.
+            clearTimeout(drainRefreshTimer);
+            if (Object.values(drains).some(d => d.state === 'draining')) {
+                drainRefreshTimer = setTimeout(loadStatus, 2000);
+            }
         }
 
+        window.drainNode = async (nodeId, drain) => {
+            if (drain && !confirm(`Drain node ${nodeId}? It will hand off leadership and send its clients to other nodes.`)) return;
+
+            try {
+                const res = await fetch(`/api/cluster/drain?nodeId=${encodeURIComponent(nodeId)}`, {
+                    method: drain ? 'POST' : 'DELETE',
+                    headers: { 'X-Raft-Secret': raftSecret }
+                });
+
+                if (!res.ok) throw new Error(await res.text());
+                loadStatus();
+            } catch (err) {
+                alert('Failed to update drain: ' + err.message);
+            }
+        };
+
         window.removeNode = async (nodeId) => {
             if (!confirm(`Are you sure you want to remove node ${nodeId}? This action is destructive.`)) return;
             
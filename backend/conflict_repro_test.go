@@ -15,6 +15,7 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -64,7 +65,7 @@ func TestConflictResolution_PrefixMatch(t *testing.T) {
 	}
 
 	// 3. Process
-	resp, _, err := hub.processAction(msg, "user1")
+	resp, _, err := hub.processAction(context.Background(), msg, "user1")
 	if err != nil {
 		t.Fatalf("processAction failed: %v", err)
 	}
@@ -116,7 +117,7 @@ func TestConflictResolution_PartialOverlap(t *testing.T) {
 	}
 
 	// Process
-	resp, _, err := hub.processAction(msg, "user1")
+	resp, _, err := hub.processAction(context.Background(), msg, "user1")
 	if err != nil {
 		t.Fatalf("processAction failed: %v", err)
 	}
@@ -171,7 +172,7 @@ func TestConflictResolution_Divergence(t *testing.T) {
 	}
 
 	// Process
-	resp, _, err := hub.processAction(msg, "user1")
+	resp, _, err := hub.processAction(context.Background(), msg, "user1")
 	if err != nil {
 		t.Fatalf("processAction failed: %v", err)
 	}
@@ -224,7 +225,7 @@ func TestConflictFix_StaleCache(t *testing.T) {
 	// Should match Base B.
 	// Should apply C.
 
-	resp, _, err := hub.processAction(msg, "user1")
+	resp, _, err := hub.processAction(context.Background(), msg, "user1")
 	if err != nil {
 		t.Fatalf("processAction failed: %v", err)
 	}
@@ -18,6 +18,7 @@ package backend
 const (
 	SchemaVersionV2 = 2
 	SchemaVersionV3 = 3
+	SchemaVersionV4 = 4
 )
 
 // Pitch Types
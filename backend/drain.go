@@ -0,0 +1,235 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// Drain states reported by DrainStatus. The state is empty when the node is
+// not draining.
+const (
+	DrainDraining = "draining"
+	DrainDrained  = "drained"
+	DrainFailed   = "failed"
+)
+
+// drainTimeout bounds how long a drain waits for clients and hubs.
+const drainTimeout = 2 * time.Minute
+
+// DrainStatus reports the progress of a node drain.
+type DrainStatus struct {
+	NodeID                string `json:"nodeId"`
+	State                 string `json:"state,omitempty"`
+	Step                  string `json:"step,omitempty"`       // Current step while draining
+	StartedAt             int64  `json:"startedAt,omitempty"`  // Unix ms
+	FinishedAt            int64  `json:"finishedAt,omitempty"` // Unix ms
+	LeadershipTransferred bool   `json:"leadershipTransferred,omitempty"`
+	ClientsMigrated       int    `json:"clientsMigrated"`
+	Connections           int    `json:"connections"` // Open WebSocket connections
+	Error                 string `json:"error,omitempty"`
+}
+
+// DrainStatus returns the drain progress of this node.
+func (rm *RaftManager) DrainStatus() DrainStatus {
+	rm.drainMu.Lock()
+	s := rm.drain
+	rm.drainMu.Unlock()
+	s.NodeID = rm.NodeID
+	if hm := rm.hubs(); hm != nil {
+		s.Connections = hm.GetTotalConnectionCount()
+	}
+	return s
+}
+
+// Draining reports whether a drain was started and not cancelled.
+func (rm *RaftManager) Draining() bool {
+	rm.drainMu.Lock()
+	defer rm.drainMu.Unlock()
+	return rm.drain.State != ""
+}
+
+func (rm *RaftManager) hubs() *HubManager {
+	if rm.FSM == nil {
+		return nil
+	}
+	return rm.FSM.hm
+}
+
+// StartDrain prepares the node for maintenance in the background: it refuses
+// new WebSocket connections, transfers leadership away, tells the connected
+// clients to reconnect to another node and waits for the hubs to finish their
+// work. A drain that is in progress or done is left alone; a failed one is
+// restarted.
+func (rm *RaftManager) StartDrain() {
+	rm.drainMu.Lock()
+	defer rm.drainMu.Unlock()
+	if rm.drain.State == DrainDraining || rm.drain.State == DrainDrained {
+		return
+	}
+	if rm.drainCancel != nil {
+		rm.drainCancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	rm.drainGen++
+	rm.drainCancel = cancel
+	rm.drain = DrainStatus{State: DrainDraining, StartedAt: time.Now().UnixMilli()}
+	raftLog.Info("drain started")
+	go rm.runDrain(ctx, rm.drainGen)
+}
+
+// StopDrain cancels a drain and accepts WebSocket connections again.
+func (rm *RaftManager) StopDrain() {
+	rm.drainMu.Lock()
+	defer rm.drainMu.Unlock()
+	if rm.drainCancel != nil {
+		rm.drainCancel()
+		rm.drainCancel = nil
+	}
+	rm.drainGen++
+	rm.drain = DrainStatus{}
+	if hm := rm.hubs(); hm != nil {
+		hm.SetDraining(false)
+	}
+	raftLog.Info("drain cancelled")
+}
+
+// updateDrain applies fn to the status of drain gen, unless it was cancelled
+// or restarted since.
+func (rm *RaftManager) updateDrain(gen int, fn func(*DrainStatus)) bool {
+	rm.drainMu.Lock()
+	defer rm.drainMu.Unlock()
+	if gen != rm.drainGen {
+		return false
+	}
+	fn(&rm.drain)
+	return true
+}
+
+func (rm *RaftManager) failDrain(gen int, err error) {
+	if rm.updateDrain(gen, func(s *DrainStatus) {
+		s.State = DrainFailed
+		s.Error = err.Error()
+		s.FinishedAt = time.Now().UnixMilli()
+	}) {
+		raftLog.Error("drain failed", "err", err)
+	}
+}
+
+func (rm *RaftManager) runDrain(ctx context.Context, gen int) {
+	step := func(name string) bool {
+		return rm.updateDrain(gen, func(s *DrainStatus) { s.Step = name })
+	}
+	hm := rm.hubs()
+	if hm != nil {
+		hm.SetDraining(true)
+	}
+
+	if rm.Raft.State() == raft.Leader {
+		if !step("transferring leadership") {
+			return
+		}
+		if err := rm.Raft.LeadershipTransfer().Error(); err != nil {
+			rm.failDrain(gen, fmt.Errorf("leadership transfer failed: %w", err))
+			return
+		}
+		rm.updateDrain(gen, func(s *DrainStatus) { s.LeadershipTransferred = true })
+	}
+
+	if hm != nil {
+		if !step("migrating clients") {
+			return
+		}
+		// Connections accepted just before SetDraining may register
+		// after the first pass.
+		for {
+			n, err := hm.Drain(ctx)
+			rm.updateDrain(gen, func(s *DrainStatus) { s.ClientsMigrated += n })
+			if err != nil {
+				rm.failDrain(gen, fmt.Errorf("waiting for hubs: %w", err))
+				return
+			}
+			if hm.GetTotalConnectionCount() == 0 {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				rm.failDrain(gen, fmt.Errorf("waiting for clients: %w", ctx.Err()))
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+
+	if rm.updateDrain(gen, func(s *DrainStatus) {
+		s.State = DrainDrained
+		s.Step = ""
+		s.FinishedAt = time.Now().UnixMilli()
+	}) {
+		raftLog.Info("drain complete", "clientsMigrated", rm.DrainStatus().ClientsMigrated)
+	}
+}
+
+// handleDrain starts (POST), reports (GET) or cancels (DELETE) the drain of
+// the node given by the nodeId query parameter, this node by default.
+func (rm *RaftManager) handleDrain(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPost, http.MethodDelete:
+	default:
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	secret := r.Header.Get("X-Raft-Secret")
+	if rm.Secret == "" || secret != rm.Secret {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if id := r.URL.Query().Get("nodeId"); id != "" && id != rm.NodeID {
+		for _, f := range strings.Split(r.Header.Get("X-Raft-Forwarded"), ",") {
+			if strings.TrimSpace(f) == rm.NodeID {
+				http.Error(w, "Forwarding loop detected", http.StatusLoopDetected)
+				return
+			}
+		}
+		addr := rm.FSM.GetNodeAddr(id)
+		if addr == "" {
+			http.Error(w, "Unknown node", http.StatusNotFound)
+			return
+		}
+		// The query is not forwarded, so the target drains itself.
+		rm.forwardRequest(w, r, addr, "RaftManager.forwardRequestToNode")
+		return
+	}
+
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodPost:
+		rm.StartDrain()
+		status = http.StatusAccepted
+	case http.MethodDelete:
+		rm.StopDrain()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rm.DrainStatus())
+}
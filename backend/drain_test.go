@@ -0,0 +1,153 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/raft"
+)
+
+func TestHubManagerDrain(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gs, ts, us, true)
+	hm := NewHubManager()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWS(gs, ts, reg, hm, w, r)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	u.Scheme = "ws"
+	u.RawQuery = url.Values{"gameId": {"10000000-0000-4000-8000-000000000002"}}.Encode()
+
+	var conns []*websocket.Conn
+	for range 2 {
+		conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		defer conn.Close()
+		conn.WriteJSON(Message{Type: MsgTypeJoin})
+		var ack Message
+		if err := conn.ReadJSON(&ack); err != nil || ack.Type != MsgTypeAck {
+			t.Fatalf("JOIN: %+v %v", ack, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	hm.SetDraining(true)
+	if _, resp, err := websocket.DefaultDialer.Dial(u.String(), nil); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("connection accepted while draining: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := hm.Drain(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Drain() = %d, %v, want 2 clients", n, err)
+	}
+	if c := hm.GetTotalConnectionCount(); c != 0 {
+		t.Errorf("%d connections left after drain", c)
+	}
+	for i, conn := range conns {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != MsgTypeReconnect {
+			t.Errorf("client %d: got %+v %v, want RECONNECT", i, msg, err)
+		}
+		if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNoStatusReceived, websocket.CloseNormalClosure) {
+			t.Errorf("client %d: connection not closed: %v", i, err)
+		}
+	}
+}
+
+func TestRaftDrainSingleNode(t *testing.T) {
+	dataDir := t.TempDir()
+	raftDir := filepath.Join(dataDir, "raft")
+	s := storage.New(dataDir, nil)
+	gs := NewGameStore(dataDir, s)
+	ts := NewTeamStore(dataDir, s)
+	us := NewUserIndexStore(dataDir, s, nil)
+	hm := NewHubManager()
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), hm, storage.New(raftDir, nil), us)
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	raftAddr := l.Addr().String()
+	l.Close()
+	rm := NewRaftManager(raftDir, raftAddr, raftAddr, "127.0.0.1:8080", "127.0.0.1:8080", "secret", nil, fsm)
+	if err := rm.Start(true); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer rm.Shutdown()
+	for deadline := time.Now().Add(10 * time.Second); rm.Raft.State() != raft.Leader; time.Sleep(50 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no leader")
+		}
+	}
+
+	call := func(method string) (int, DrainStatus) {
+		req := httptest.NewRequest(method, "/api/cluster/drain", nil)
+		req.Header.Set("X-Raft-Secret", "secret")
+		w := httptest.NewRecorder()
+		rm.handleDrain(w, req)
+		var s DrainStatus
+		json.NewDecoder(w.Body).Decode(&s)
+		return w.Code, s
+	}
+
+	if code, _ := call(http.MethodPost); code != http.StatusAccepted {
+		t.Fatalf("POST drain: %d", code)
+	}
+	// Without another voter, leadership cannot be transferred.
+	var status DrainStatus
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if _, status = call(http.MethodGet); status.State != DrainDraining {
+			break
+		}
+	}
+	if status.State != DrainFailed || !strings.Contains(status.Error, "leadership transfer") || status.NodeID != rm.NodeID {
+		t.Errorf("unexpected drain status: %+v", status)
+	}
+	if !hm.Draining() {
+		t.Error("failed drain accepts new connections")
+	}
+
+	if _, status = call(http.MethodDelete); status.State != "" || hm.Draining() || rm.Draining() {
+		t.Errorf("drain not cancelled: %+v", status)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/cluster/drain?nodeId=unknown", nil)
+	req.Header.Set("X-Raft-Secret", "secret")
+	w := httptest.NewRecorder()
+	rm.handleDrain(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("drain of unknown node: %d", w.Code)
+	}
+}
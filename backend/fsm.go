@@ -16,14 +16,16 @@ package backend
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -31,6 +33,8 @@ import (
 
 	"github.com/c2FmZQ/storage"
 	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var ErrConflict = errors.New("conflict detected")
@@ -45,12 +49,24 @@ type FSM struct {
 	storage     *storage.Storage
 	initialized atomic.Bool
 	rm          *RaftManager
+	readOnly    bool // Set on read replicas, which have no rm, see SetReadOnly
 
-	metricsMu sync.RWMutex
-	metrics   *MetricsStore // Monitoring Data
+	metricsMu          sync.RWMutex
+	metrics            *MetricsStore // Monitoring Data
+	metricsPersistedAt int64         // Timestamp of the update last written to metrics.json
 
-	nodeMap          sync.Map // map[string]*NodeMeta
-	lastAppliedIndex atomic.Uint64
+	webhooks *WebhookManager
+	alerts   *AlertManager
+	audit    *AuditStore
+
+	nodeMap             sync.Map // map[string]*NodeMeta
+	lastAppliedIndex    atomic.Uint64
+	activeSchemaVersion atomic.Int64 // 0 until first raised, see ActiveSchemaVersion
+
+	// Exposed on /metrics.
+	applyLatency    *durationHistogram
+	snapshotLatency *durationHistogram
+	restoreLatency  *durationHistogram
 }
 
 // NewFSM creates a new FSM.
@@ -63,6 +79,16 @@ func NewFSM(gs *GameStore, ts *TeamStore, r *Registry, hm *HubManager, s *storag
 		hm:      hm,
 		storage: s,
 		metrics: NewMetricsStore(),
+
+		applyLatency:    newDurationHistogram(.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1),
+		snapshotLatency: newDurationHistogram(.01, .05, .1, .5, 1, 5, 10, 30, 60),
+		restoreLatency:  newDurationHistogram(.01, .05, .1, .5, 1, 5, 10, 30, 60),
+	}
+	f.webhooks = NewWebhookManager(s)
+	f.alerts = NewAlertManager(s)
+	f.audit = NewAuditStore(s)
+	if r != nil {
+		r.TrackChanges(f.LastAppliedIndex)
 	}
 	if s != nil {
 		// We still need to check for existence using os.Stat because storage might not expose it easily.
@@ -71,10 +97,19 @@ func NewFSM(gs *GameStore, ts *TeamStore, r *Registry, hm *HubManager, s *storag
 		}
 		f.loadNodes()
 		f.loadAccessPolicy()
+		f.loadMetrics()
+		f.loadClusterVersion()
 	}
 	return f
 }
 
+// SetReadOnly marks the FSM as the state of a read replica. The replica
+// applies the cluster's log, but leaves its side effects, such as webhook
+// deliveries, to the cluster leader.
+func (f *FSM) SetReadOnly() {
+	f.readOnly = true
+}
+
 // LastAppliedIndex returns the index of the last applied log entry.
 func (f *FSM) LastAppliedIndex() uint64 {
 	return f.lastAppliedIndex.Load()
@@ -86,6 +121,49 @@ func (f *FSM) GetMetricsJSON() map[string]interface{} {
 	return f.metrics.ToJSON()
 }
 
+// QueryMetrics returns the monitoring history between from and to (Unix seconds) at resolution res.
+func (f *FSM) QueryMetrics(from, to int64, res string) (*MetricsRange, error) {
+	f.metricsMu.RLock()
+	defer f.metricsMu.RUnlock()
+	return f.metrics.Query(from, to, res)
+}
+
+// LastNodeTotal returns the last cumulative request count replicated for nodeID.
+func (f *FSM) LastNodeTotal(nodeID string) (uint64, bool) {
+	f.metricsMu.RLock()
+	defer f.metricsMu.RUnlock()
+	total, ok := f.metrics.NodeTotals[nodeID]
+	return total, ok
+}
+
+// Webhooks returns the webhook manager.
+func (f *FSM) Webhooks() *WebhookManager {
+	return f.webhooks
+}
+
+// Alerts returns the alert manager.
+func (f *FSM) Alerts() *AlertManager {
+	return f.alerts
+}
+
+// AlertSamples computes the samples of a metrics-based alert rule at now,
+// for a leader that started evaluating rules at start.
+func (f *FSM) AlertSamples(r *AlertRule, now, start int64) []AlertSample {
+	var nodes []string
+	for id := range f.GetAllNodes() {
+		nodes = append(nodes, id)
+	}
+	sort.Strings(nodes)
+	f.metricsMu.RLock()
+	defer f.metricsMu.RUnlock()
+	return alertSamples(f.metrics, nodes, r, now, start)
+}
+
+// Audit returns the audit log store.
+func (f *FSM) Audit() *AuditStore {
+	return f.audit
+}
+
 func (f *FSM) GetTotalGames() int {
 	return f.r.CountTotalGames()
 }
@@ -155,7 +233,7 @@ func (f *FSM) loadNodes() {
 	var nodes map[string]*NodeMeta
 	if err := f.storage.ReadDataFile("nodes.json", &nodes); err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("FSM Error: failed to read nodes.json: %v", err)
+			fsmLog.Error("failed to read nodes.json", "err", err)
 		}
 		return
 	}
@@ -164,6 +242,21 @@ func (f *FSM) loadNodes() {
 	}
 }
 
+// loadMetrics loads the monitoring history persisted by the last run. Raft
+// then replays the updates applied after it was written.
+func (f *FSM) loadMetrics() {
+	var m MetricsStore
+	if err := f.storage.ReadDataFile("metrics.json", &m); err != nil {
+		if !os.IsNotExist(err) {
+			fsmLog.Error("failed to read metrics.json", "err", err)
+		}
+		return
+	}
+	m.Hydrate()
+	f.metrics = &m
+	f.metricsPersistedAt = m.LastUpdate
+}
+
 func (f *FSM) loadAccessPolicy() {
 	if f.storage == nil {
 		return
@@ -171,7 +264,7 @@ func (f *FSM) loadAccessPolicy() {
 	var policy UserAccessPolicy
 	if err := f.storage.ReadDataFile("sys_access_policy", &policy); err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("FSM Error: failed to read sys_access_policy: %v", err)
+			fsmLog.Error("failed to read sys_access_policy", "err", err)
 		}
 		return
 	}
@@ -188,7 +281,7 @@ func (f *FSM) saveNodes() {
 		return true
 	})
 	if err := f.storage.SaveDataFile("nodes.json", nodes); err != nil {
-		log.Printf("FSM Error: failed to save nodes.json: %v", err)
+		fsmLog.Error("failed to save nodes.json", "err", err)
 	}
 }
 
@@ -203,7 +296,7 @@ func (f *FSM) setInitialized() {
 	}
 	if f.storage != nil {
 		if err := f.storage.SaveDataFile("initialized", "true"); err != nil {
-			log.Printf("FSM Error: failed to save initialized state: %v", err)
+			fsmLog.Error("failed to save initialized state", "err", err)
 		}
 	}
 }
@@ -213,6 +306,7 @@ func (f *FSM) Apply(l *raft.Log) interface{} {
 	if len(l.Data) == 0 {
 		return nil
 	}
+	defer func(start time.Time) { f.applyLatency.Observe(time.Since(start)) }(time.Now())
 	var cmd RaftCommand
 	var err error
 
@@ -224,15 +318,106 @@ func (f *FSM) Apply(l *raft.Log) interface{} {
 	}
 
 	if err != nil {
-		log.Printf("FSM Apply Error: failed to decode command (gob=%v): %v", f.rm != nil && f.rm.UseGob, err)
+		fsmLog.Error("failed to decode command", "index", l.Index, "gob", f.rm != nil && f.rm.UseGob, "err", err)
 		return err
 	}
 
-	res := f.applyCommand(cmd, l.Index)
+	ctx, span := f.startApplySpan(cmd, l.Index)
+	entry := newAuditEntry(cmd, l.Index)
+	f.auditChanges(&entry, cmd)
+	if f.r != nil {
+		f.r.SetChangeIndex(l.Index, commandTime(cmd))
+	}
+	res := f.applyCommand(ctx, cmd, l.Index)
+	f.recordAudit(cmd, entry, res)
 	f.lastAppliedIndex.Store(l.Index)
+	if span != nil {
+		err, _ := res.(error)
+		endSpan(span, err)
+	}
 	return res
 }
 
+// startApplySpan continues the trace recorded in cmd, if any. The span is nil
+// for untraced commands, such as periodic metrics updates.
+func (f *FSM) startApplySpan(cmd RaftCommand, index uint64) (context.Context, trace.Span) {
+	if len(cmd.Trace) == 0 {
+		return cmd.traceContext(), nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("skorekeeper.command", string(cmd.Type)),
+		attribute.Int64("raft.index", int64(index)),
+	}
+	if f.rm != nil {
+		attrs = append(attrs, attribute.String("skorekeeper.node_id", f.rm.NodeID))
+	}
+	return tracer().Start(cmd.traceContext(), "FSM.Apply", trace.WithAttributes(attrs...))
+}
+
+// auditChanges describes how a command modifies the current state.
+// It must run before the command is applied.
+func (f *FSM) auditChanges(e *AuditEntry, cmd RaftCommand) {
+	if e == nil {
+		return
+	}
+	switch cmd.Type {
+	case CmdSaveGame:
+		var next Game
+		if cmd.GameData == nil || json.Unmarshal(*cmd.GameData, &next) != nil {
+			return
+		}
+		prev, err := f.gs.LoadGame(cmd.ID)
+		if err != nil {
+			prev = nil
+		}
+		e.Changes = gameChanges(prev, &next)
+	case CmdSaveTeam:
+		var next Team
+		if cmd.TeamData == nil || json.Unmarshal(*cmd.TeamData, &next) != nil {
+			return
+		}
+		prev, err := f.ts.LoadTeam(cmd.ID)
+		if err != nil {
+			prev = nil
+		}
+		e.Changes = teamChanges(prev, &next)
+	case CmdUpdateAccessPolicy:
+		if cmd.PolicyData != nil {
+			e.Changes = policyChanges(f.r.GetAccessPolicy(), cmd.PolicyData)
+		}
+	}
+}
+
+// recordAudit appends the entry of an applied command to its audit log.
+func (f *FSM) recordAudit(cmd RaftCommand, e AuditEntry, res interface{}) {
+	f.recordAuditBatch([]RaftCommand{cmd}, []*AuditEntry{&e}, []interface{}{res})
+}
+
+// recordAuditBatch appends the entries of applied commands to their audit logs.
+// results[i] is the FSM result of cmds[i]. Each log is written once.
+func (f *FSM) recordAuditBatch(cmds []RaftCommand, entries []*AuditEntry, results []interface{}) {
+	var logs []string
+	byLog := make(map[string][]AuditEntry)
+	for i, e := range entries {
+		if e == nil || !isAuditedCommand(cmds[i].Type) {
+			continue
+		}
+		if err, ok := results[i].(error); ok && err != nil {
+			e.Error = err.Error()
+		}
+		log := auditLogForCommand(cmds[i])
+		if _, ok := byLog[log]; !ok {
+			logs = append(logs, log)
+		}
+		byLog[log] = append(byLog[log], *e)
+	}
+	for _, log := range logs {
+		if err := f.audit.Append(log, byLog[log]...); err != nil {
+			fsmLog.Error("failed to append audit entries", "log", log, "err", err)
+		}
+	}
+}
+
 func (f *FSM) GetHubManager() *HubManager {
 	return f.hm
 }
@@ -245,19 +430,24 @@ func (f *FSM) GetStores() (*GameStore, *TeamStore) {
 	return f.gs, f.ts
 }
 
+// GetNodeCount returns the number of cluster nodes, excluding read replicas.
 func (f *FSM) GetNodeCount() int {
 	count := 0
-	f.nodeMap.Range(func(_, _ interface{}) bool {
-		count++
+	f.nodeMap.Range(func(_, value interface{}) bool {
+		if meta, ok := value.(*NodeMeta); !ok || !meta.Replica {
+			count++
+		}
 		return true
 	})
 	return count
 }
 
+// GetAllNodes returns the HTTP addresses of the cluster nodes, excluding read
+// replicas.
 func (f *FSM) GetAllNodes() map[string]string {
 	nodes := make(map[string]string)
 	f.nodeMap.Range(func(key, value interface{}) bool {
-		if meta, ok := value.(*NodeMeta); ok {
+		if meta, ok := value.(*NodeMeta); ok && !meta.Replica {
 			nodes[key.(string)] = meta.HttpAddr
 		}
 		return true
@@ -265,6 +455,18 @@ func (f *FSM) GetAllNodes() map[string]string {
 	return nodes
 }
 
+// GetReplicas returns the metadata of the registered read replicas.
+func (f *FSM) GetReplicas() map[string]*NodeMeta {
+	replicas := make(map[string]*NodeMeta)
+	f.nodeMap.Range(func(key, value interface{}) bool {
+		if meta, ok := value.(*NodeMeta); ok && meta.Replica {
+			replicas[key.(string)] = meta
+		}
+		return true
+	})
+	return replicas
+}
+
 func (f *FSM) GetNodeAddr(nodeID string) string {
 	if val, ok := f.nodeMap.Load(nodeID); ok {
 		if meta, ok := val.(*NodeMeta); ok {
@@ -305,7 +507,7 @@ func (f *FSM) applyNodeMeta(nodeID string, nodeInfo []byte) error {
 	return nil
 }
 
-func (f *FSM) applyAction(gameId string, data []byte, index uint64) error {
+func (f *FSM) applyAction(ctx context.Context, gameId string, data []byte, index uint64) error {
 	g, err := f.gs.LoadGame(gameId)
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
@@ -341,15 +543,18 @@ func (f *FSM) applyAction(gameId string, data []byte, index uint64) error {
 	}
 	newBytes, _ := json.Marshal(g)
 	f.r.UpdateGame(*g)
-	f.broadcastGameUpdate(gameId, newBytes, false, 1) // false = broadcast action
+	f.broadcastGameUpdate(ctx, gameId, newBytes, false, 1) // false = broadcast action
+	if changed {
+		f.queueWebhooks(index, g, 1)
+	}
 	return nil
 }
 
-func (f *FSM) broadcastGameUpdate(gameId string, data []byte, skipBroadcast bool, numActions int) {
-	f.hm.BroadcastToGame(gameId, data, skipBroadcast, numActions)
+func (f *FSM) broadcastGameUpdate(ctx context.Context, gameId string, data []byte, skipBroadcast bool, numActions int) {
+	f.hm.BroadcastToGame(ctx, gameId, data, skipBroadcast, numActions)
 }
 
-func (f *FSM) applyActions(gameId string, actions []json.RawMessage, index uint64) error {
+func (f *FSM) applyActions(ctx context.Context, gameId string, actions []json.RawMessage, index uint64) error {
 	g, err := f.gs.LoadGame(gameId)
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
@@ -384,10 +589,73 @@ func (f *FSM) applyActions(gameId string, actions []json.RawMessage, index uint6
 	newBytes, _ := json.Marshal(g)
 
 	f.r.UpdateGame(*g)
-	f.broadcastGameUpdate(gameId, newBytes, false, len(actions))
+	f.broadcastGameUpdate(ctx, gameId, newBytes, false, len(actions))
+	if changed {
+		f.queueWebhooks(index, g, len(actions))
+	}
 	return nil
 }
 
+// deliversWebhooks reports whether this node may deliver webhooks. Read
+// replicas and a cluster being recovered replay entries without side effects.
+func (f *FSM) deliversWebhooks() bool {
+	return !f.readOnly && (f.rm == nil || !f.rm.recovering.Load())
+}
+
+// queueWebhooks derives the lifecycle events of the last numActions actions
+// of a game, applied from the log entry at index.
+//
+// Every node records the same events in the replicated webhook outbox, and
+// the leader delivers them and proposes ACK_WEBHOOKS once they are delivered
+// (RaftManager.drainWebhooks). Delivery does not depend on the proposer: a
+// new leader delivers the entries its predecessor did not acknowledge, so
+// events are delivered at least once. Entries are only recorded if a webhook
+// subscribes to them. A standalone FSM delivers right away.
+func (f *FSM) queueWebhooks(index uint64, g *Game, numActions int) {
+	if f.webhooks == nil || numActions <= 0 {
+		return
+	}
+	standalone := f.rm == nil || f.rm.Raft == nil || index == 0
+	if standalone && !f.deliversWebhooks() {
+		return
+	}
+	events := extractWebhookEvents(g, numActions)
+	if len(events) == 0 {
+		return
+	}
+	e := WebhookOutboxEntry{Index: index, Game: *g, Events: events}
+	e.Game.ActionLog = nil
+	e.Game.Permissions.Users = maps.Clone(g.Permissions.Users)
+	for i := range e.Events {
+		e.Events[i].Index = index
+	}
+	if standalone {
+		f.dispatchWebhooks(e, nil)
+		return
+	}
+	if !f.webhooks.Matches(&e.Game, f.webhookUserAccess(e.Game)) {
+		return
+	}
+	if err := f.webhooks.AddToOutbox(e); err != nil {
+		fsmLog.Error("failed to queue webhook events", "index", index, "err", err)
+	}
+}
+
+// webhookUserAccess returns whether a user-scoped webhook may see a game.
+// User webhooks follow games the user is a member of, not every public game.
+func (f *FSM) webhookUserAccess(g Game) func(userId string) bool {
+	g.Permissions.Public = ""
+	return func(userId string) bool {
+		return GetGameAccess(userId, g, f.ts) >= AccessRead
+	}
+}
+
+// dispatchWebhooks delivers the events of an outbox entry. done, if not nil,
+// is called once all deliveries are finished, see WebhookManager.Dispatch.
+func (f *FSM) dispatchWebhooks(e WebhookOutboxEntry, done func(dropped bool)) {
+	f.webhooks.Dispatch(&e.Game, e.Events, f.webhookUserAccess(e.Game), done)
+}
+
 func (f *FSM) checkGameConflict(incoming *Game, existing *Game) error {
 	if len(incoming.ActionLog) < len(existing.ActionLog) {
 		return fmt.Errorf("incoming game state is older or forked (log length %d < %d): %w", len(incoming.ActionLog), len(existing.ActionLog), ErrConflict)
@@ -398,11 +666,11 @@ func (f *FSM) checkGameConflict(incoming *Game, existing *Game) error {
 			ID string `json:"id"`
 		}
 		if err := json.Unmarshal(existing.ActionLog[i], &exID); err != nil {
-			log.Printf("Warning: failed to unmarshal existing action ID at index %d: %v", i, err)
+			fsmLog.Warn("failed to unmarshal existing action ID", "actionIndex", i, "err", err)
 			continue
 		}
 		if err := json.Unmarshal(incoming.ActionLog[i], &inID); err != nil {
-			log.Printf("Warning: failed to unmarshal incoming action ID at index %d: %v", i, err)
+			fsmLog.Warn("failed to unmarshal incoming action ID", "actionIndex", i, "err", err)
 			continue
 		}
 		if exID.ID != inID.ID {
@@ -423,7 +691,7 @@ func (f *FSM) repairLastActionID(g *Game) {
 	}
 }
 
-func (f *FSM) applySaveGame(id string, data []byte, index uint64, force bool) error {
+func (f *FSM) applySaveGame(ctx context.Context, id string, data []byte, index uint64, force bool) error {
 	// Optimization: Load header to check index? Or just unmarshal and overwrite?
 	// If overwrite is older than current state (replayed), we should SKIP?
 	// Yes, strict linearizability.
@@ -461,11 +729,11 @@ func (f *FSM) applySaveGame(id string, data []byte, index uint64, force bool) er
 	}
 
 	f.r.UpdateGame(g)
-	f.broadcastGameUpdate(id, data, true, 0) // true = skip broadcast (overwrite)
+	f.broadcastGameUpdate(ctx, id, data, true, 0) // true = skip broadcast (overwrite)
 	return nil
 }
 
-func (f *FSM) applyDeleteGame(id string, index uint64) error {
+func (f *FSM) applyDeleteGame(id string, index uint64, now time.Time) error {
 	existing, err := f.gs.LoadGame(id)
 	if err == nil {
 		if index > 0 && index <= existing.LastRaftIndex {
@@ -473,7 +741,7 @@ func (f *FSM) applyDeleteGame(id string, index uint64) error {
 		}
 	}
 
-	if err := f.gs.DeleteGame(id); err != nil {
+	if err := f.gs.TrashGame(id, index, now); err != nil {
 		return err
 	}
 	f.r.DeleteGame(id)
@@ -481,6 +749,53 @@ func (f *FSM) applyDeleteGame(id string, index uint64) error {
 	return nil
 }
 
+// applyRestoreGame brings a game back from the trash.
+func (f *FSM) applyRestoreGame(cmd RaftCommand, index uint64) error {
+	tomb, err := f.gs.LoadGame(cmd.ID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+	if index > 0 && index <= tomb.LastRaftIndex {
+		return nil
+	}
+	g, err := untrashGame(tomb, commandTime(cmd))
+	if err != nil {
+		return err
+	}
+	g.LastRaftIndex = index
+	if err := f.gs.SaveGame(g); err != nil {
+		return err
+	}
+	f.r.UpdateGame(*g)
+	return nil
+}
+
+// applyPurgeGame discards the data retained by a game tombstone.
+// The tombstone itself is left for garbage collection.
+func (f *FSM) applyPurgeGame(id string, index uint64, now time.Time) error {
+	tomb, err := f.gs.LoadGame(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if index > 0 && index <= tomb.LastRaftIndex {
+		return nil
+	}
+	if tomb.Status != "deleted" {
+		return ErrNotInTrash
+	}
+	if err := f.gs.tombstoneGame(id, tomb, false, index, now); err != nil {
+		return err
+	}
+	f.r.updateGameTrash(id)
+	return nil
+}
+
 func (f *FSM) applySaveTeam(id string, data []byte, index uint64) error {
 	var t Team
 	if err := json.Unmarshal(data, &t); err != nil {
@@ -505,7 +820,7 @@ func (f *FSM) applySaveTeam(id string, data []byte, index uint64) error {
 	return nil
 }
 
-func (f *FSM) applyDeleteTeam(id string, index uint64) error {
+func (f *FSM) applyDeleteTeam(id string, index uint64, now time.Time) error {
 	existing, err := f.ts.LoadTeam(id)
 	if err == nil {
 		if index > 0 && index <= existing.LastRaftIndex {
@@ -513,7 +828,7 @@ func (f *FSM) applyDeleteTeam(id string, index uint64) error {
 		}
 	}
 
-	if err := f.ts.DeleteTeam(id); err != nil {
+	if err := f.ts.TrashTeam(id, index, now); err != nil {
 		return err
 	}
 	f.r.DeleteTeam(id)
@@ -521,7 +836,58 @@ func (f *FSM) applyDeleteTeam(id string, index uint64) error {
 	return nil
 }
 
-func (f *FSM) applyDeleteAllUser(userId string, index uint64) error {
+// applyRestoreTeam brings a team back from the trash. Its game list was kept
+// while it was in the trash.
+func (f *FSM) applyRestoreTeam(cmd RaftCommand, index uint64) error {
+	tomb, err := f.ts.LoadTeam(cmd.ID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+	if index > 0 && index <= tomb.LastRaftIndex {
+		return nil
+	}
+	t, err := untrashTeam(tomb, commandTime(cmd))
+	if err != nil {
+		return err
+	}
+	t.LastRaftIndex = index
+	if err := f.ts.SaveTeam(t); err != nil {
+		return err
+	}
+	f.r.UpdateTeam(*t)
+	return nil
+}
+
+// applyPurgeTeam discards the data retained by a team tombstone.
+// The tombstone itself is left for garbage collection.
+func (f *FSM) applyPurgeTeam(id string, index uint64, now time.Time) error {
+	tomb, err := f.ts.LoadTeam(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if index > 0 && index <= tomb.LastRaftIndex {
+		return nil
+	}
+	if tomb.Status != "deleted" {
+		return ErrNotInTrash
+	}
+	if err := f.ts.tombstoneTeam(id, tomb, false, index, now); err != nil {
+		return err
+	}
+	f.r.updateTeamTrash(id)
+	return nil
+}
+
+func (f *FSM) applyDeleteAllUser(cmd RaftCommand, index uint64) error {
+	userId := cmd.Action.UserID
+	entry := newAuditEntry(cmd, index)
+
 	// 1. Delete Games
 	// Registry.ListGames uses ReadLock, so it's safe to use for lookup.
 	// We iterate all games accessible to user, then check ownership.
@@ -531,7 +897,11 @@ func (f *FSM) applyDeleteAllUser(userId string, index uint64) error {
 	for _, id := range gameIds {
 		g, err := f.gs.LoadGame(id)
 		if err == nil && g.OwnerID == userId {
-			f.applyDeleteGame(id, index)
+			if f.gs.tombstoneGame(id, g, false, index, commandTime(cmd)) == nil {
+				f.r.DeleteGame(id)
+				f.hm.RemoveHub(id, false)
+				f.audit.Append(auditGameLog(id), entry)
+			}
 		}
 	}
 
@@ -540,9 +910,25 @@ func (f *FSM) applyDeleteAllUser(userId string, index uint64) error {
 	for _, id := range teamIds {
 		t, err := f.ts.LoadTeam(id)
 		if err == nil && t.OwnerID == userId {
-			f.applyDeleteTeam(id, index)
+			if f.ts.tombstoneTeam(id, t, false, index, commandTime(cmd)) == nil {
+				f.r.DeleteTeam(id)
+				f.hm.RemoveHub(id, true)
+				f.audit.Append(auditTeamLog(id), entry)
+			}
 		}
 	}
+
+	// 3. Erase the data retained in the user's trash
+	games, teams := f.r.EraseTrash(userId, index, commandTime(cmd))
+	for _, id := range games {
+		f.audit.Append(auditGameLog(id), entry)
+	}
+	for _, id := range teams {
+		f.audit.Append(auditTeamLog(id), entry)
+	}
+
+	// 4. Delete saved searches
+	f.r.DeleteSavedSearches(userId)
 	return nil
 }
 
@@ -550,6 +936,8 @@ type batchItem struct {
 	index     int // Original index in the []*raft.Log slice
 	raftIndex uint64
 	cmd       RaftCommand
+	audit     *AuditEntry
+	ctx       context.Context // Trace context and request ID of the command
 }
 
 type resourceJob struct {
@@ -565,12 +953,26 @@ type resourceJob struct {
 	dirty         bool
 	skipBroadcast bool
 	totalActions  int
+	ctx           context.Context // Context of the last traced command, for the broadcast and logs
 }
 
 // ApplyBatch implements the raft.BatchingFSM interface.
 func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
+	defer func(start time.Time) { f.applyLatency.Observe(time.Since(start)) }(time.Now())
 	results := make([]interface{}, len(logs))
+	cmds := make([]RaftCommand, len(logs))
+	audits := make([]*AuditEntry, len(logs))
 	jobs := make(map[string]*resourceJob)
+	changeTime := time.Now()
+	var spans []trace.Span
+	defer func() {
+		for i, span := range spans {
+			if span != nil {
+				err, _ := results[i].(error)
+				endSpan(span, err)
+			}
+		}
+	}()
 
 	// 1. Decode and Group
 	for i, l := range logs {
@@ -589,7 +991,7 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 		}
 
 		if err != nil {
-			log.Printf("FSM ApplyBatch Error: failed to decode command (gob=%v): %v", f.rm != nil && f.rm.UseGob, err)
+			fsmLog.Error("failed to decode command", "index", l.Index, "gob", f.rm != nil && f.rm.UseGob, "err", err)
 			results[i] = err
 			continue
 		}
@@ -599,16 +1001,16 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 		var isTeam bool
 		var isSystem bool
 		switch cmd.Type {
-		case CmdSaveGame, CmdDeleteGame:
+		case CmdSaveGame, CmdDeleteGame, CmdRestoreGame, CmdPurgeGame:
 			key = "game:" + cmd.ID
 		case CmdApplyAction:
 			if cmd.Action != nil {
 				key = "game:" + cmd.Action.GameID
 			}
-		case CmdSaveTeam, CmdDeleteTeam:
+		case CmdSaveTeam, CmdDeleteTeam, CmdRestoreTeam, CmdPurgeTeam:
 			key = "team:" + cmd.ID
 			isTeam = true
-		case CmdNodeMeta, CmdNodeLeft, CmdUpdateAccessPolicy, CmdMetricsUpdate, CmdDeleteAllUser:
+		case CmdNodeMeta, CmdNodeLeft, CmdUpdateAccessPolicy, CmdMetricsUpdate, CmdDeleteAllUser, CmdUpdateWebhook, CmdDeleteWebhook, CmdUpdateAlertRule, CmdDeleteAlertRule, CmdPutSavedSearch, CmdDeleteSavedSearch, CmdSetActiveSchemaVersion, CmdAckWebhooks:
 			key = "sys:global"
 			isSystem = true
 		default:
@@ -632,9 +1034,31 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 				isTeam:   isTeam,
 				isSystem: isSystem,
 				items:    make([]batchItem, 0),
+				ctx:      context.Background(),
+			}
+		}
+		ctx, span := f.startApplySpan(cmd, l.Index)
+		if span != nil {
+			if spans == nil {
+				spans = make([]trace.Span, len(logs))
 			}
+			spans[i] = span
 		}
-		jobs[key].items = append(jobs[key].items, batchItem{index: i, raftIndex: l.Index, cmd: cmd})
+		if span != nil || cmd.RequestID != "" {
+			jobs[key].ctx = ctx
+		}
+		entry := newAuditEntry(cmd, l.Index)
+		cmds[i] = cmd
+		changeTime = commandTime(cmd)
+		audits[i] = &entry
+		jobs[key].items = append(jobs[key].items, batchItem{index: i, raftIndex: l.Index, cmd: cmd, audit: &entry, ctx: ctx})
+	}
+
+	// The changes of the batch are numbered with its last index and dated
+	// with its last command, and become visible in the change feeds once the
+	// whole batch is applied.
+	if f.r != nil && len(logs) > 0 {
+		f.r.SetChangeIndex(logs[len(logs)-1].Index, changeTime)
 	}
 
 	// 2. Execute Parallel (I/O and reduction)
@@ -649,6 +1073,8 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 
 	wg.Wait()
 
+	f.recordAuditBatch(cmds, audits, results)
+
 	// 3. Process Side Effects Sequentially (Registry and Broadcast)
 	// This avoids deadlocks between resource locks and registry lock.
 	for _, job := range jobs {
@@ -667,11 +1093,11 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 			} else if job.game != nil {
 				newBytes, err := json.Marshal(job.game)
 				if err != nil {
-					log.Printf("FSM ApplyBatch Error: failed to marshal game %s for broadcast: %v", job.id, err)
+					fsmLog.ErrorContext(job.ctx, "failed to marshal game for broadcast", "gameId", job.id, "err", err)
 					continue
 				}
 				f.r.UpdateGame(*job.game)
-				f.broadcastGameUpdate(job.id, newBytes, job.skipBroadcast, job.totalActions)
+				f.broadcastGameUpdate(job.ctx, job.id, newBytes, job.skipBroadcast, job.totalActions)
 			}
 		}
 	}
@@ -683,26 +1109,34 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 	return results
 }
 
-func (f *FSM) applyCommand(cmd RaftCommand, index uint64) interface{} {
+func (f *FSM) applyCommand(ctx context.Context, cmd RaftCommand, index uint64) interface{} {
 	switch cmd.Type {
 	case CmdSaveGame:
-		return f.applySaveGame(cmd.ID, *cmd.GameData, index, cmd.Force)
+		return f.applySaveGame(ctx, cmd.ID, *cmd.GameData, index, cmd.Force)
 	case CmdApplyAction:
 		if len(cmd.Action.Actions) > 0 {
-			return f.applyActions(cmd.Action.GameID, cmd.Action.Actions, index)
+			return f.applyActions(ctx, cmd.Action.GameID, cmd.Action.Actions, index)
 		}
-		return f.applyAction(cmd.Action.GameID, cmd.Action.Action, index)
+		return f.applyAction(ctx, cmd.Action.GameID, cmd.Action.Action, index)
 	case CmdDeleteGame:
-		return f.applyDeleteGame(cmd.ID, index)
+		return f.applyDeleteGame(cmd.ID, index, commandTime(cmd))
+	case CmdRestoreGame:
+		return f.applyRestoreGame(cmd, index)
+	case CmdPurgeGame:
+		return f.applyPurgeGame(cmd.ID, index, commandTime(cmd))
 	case CmdSaveTeam:
 		return f.applySaveTeam(cmd.ID, *cmd.TeamData, index)
 	case CmdDeleteTeam:
-		return f.applyDeleteTeam(cmd.ID, index)
+		return f.applyDeleteTeam(cmd.ID, index, commandTime(cmd))
+	case CmdRestoreTeam:
+		return f.applyRestoreTeam(cmd, index)
+	case CmdPurgeTeam:
+		return f.applyPurgeTeam(cmd.ID, index, commandTime(cmd))
 	case CmdDeleteAllUser:
 		if cmd.Action == nil || cmd.Action.UserID == "" {
 			return fmt.Errorf("missing user id for delete all")
 		}
-		return f.applyDeleteAllUser(cmd.Action.UserID, index)
+		return f.applyDeleteAllUser(cmd, index)
 	case CmdNodeMeta:
 		if cmd.NodeMeta == nil {
 			return fmt.Errorf("missing node meta")
@@ -729,12 +1163,70 @@ func (f *FSM) applyCommand(cmd RaftCommand, index uint64) interface{} {
 		if cmd.MetricsPayload == nil {
 			return nil
 		}
-		return f.applyMetricsUpdate(cmd.MetricsPayload)
+		return f.applyMetricsCommand(cmd.MetricsPayload, index)
+	case CmdUpdateWebhook:
+		if cmd.Webhook == nil {
+			return fmt.Errorf("missing webhook")
+		}
+		return f.webhooks.Put(cmd.Webhook)
+	case CmdDeleteWebhook:
+		return f.webhooks.Delete(cmd.ID)
+	case CmdUpdateAlertRule:
+		if cmd.AlertRule == nil {
+			return fmt.Errorf("missing alert rule")
+		}
+		return f.alerts.Put(cmd.AlertRule)
+	case CmdDeleteAlertRule:
+		return f.alerts.Delete(cmd.ID)
+	case CmdPutSavedSearch:
+		if cmd.SavedSearch == nil {
+			return fmt.Errorf("missing saved search")
+		}
+		return f.r.PutSavedSearch(cmd.UserID, *cmd.SavedSearch)
+	case CmdDeleteSavedSearch:
+		return f.r.DeleteSavedSearch(cmd.UserID, cmd.ID)
+	case CmdSetActiveSchemaVersion:
+		return f.applySetActiveSchemaVersion(cmd.SchemaVersion)
+	case CmdAckWebhooks:
+		return f.webhooks.Ack(cmd.Indexes)
 	default:
 		return fmt.Errorf("unknown command type: %s", cmd.Type)
 	}
 }
 
+// metricsPersistInterval is how often, in update timestamps, metrics.json is
+// written between snapshots.
+const metricsPersistInterval = 5 * time.Minute
+
+// applyMetricsCommand applies the CmdMetricsUpdate at the given log index and
+// periodically persists the store, so that a restart only replays the updates
+// since the last write.
+func (f *FSM) applyMetricsCommand(p *MetricsPayload, index uint64) error {
+	f.metricsMu.RLock()
+	applied := f.metrics.AppliedIndex
+	f.metricsMu.RUnlock()
+	if index > 0 && index <= applied {
+		return nil // Already included in the persisted store
+	}
+	if err := f.applyMetricsUpdate(p); err != nil {
+		return err
+	}
+
+	f.metricsMu.Lock()
+	defer f.metricsMu.Unlock()
+	if index > f.metrics.AppliedIndex {
+		f.metrics.AppliedIndex = index
+	}
+	interval := int64(metricsPersistInterval.Seconds())
+	if f.storage != nil && p.Timestamp/interval != f.metricsPersistedAt/interval {
+		f.metricsPersistedAt = p.Timestamp
+		if err := f.storage.SaveDataFile("metrics.json", f.metrics); err != nil {
+			fsmLog.Warn("failed to save metrics.json", "err", err)
+		}
+	}
+	return nil
+}
+
 func (f *FSM) applyMetricsUpdate(p *MetricsPayload) error {
 	f.metricsMu.Lock()
 	defer f.metricsMu.Unlock()
@@ -743,6 +1235,18 @@ func (f *FSM) applyMetricsUpdate(p *MetricsPayload) error {
 
 	// 1. Apply Node Metrics
 	for _, nm := range p.Nodes {
+		if nm.Total > 0 {
+			if f.metrics.NodeTotals == nil {
+				f.metrics.NodeTotals = make(map[string]uint64)
+			}
+			f.metrics.NodeTotals[nm.NodeID] = nm.Total
+		}
+		if nm.DiskUsed > 0 {
+			if f.metrics.NodeDisk == nil {
+				f.metrics.NodeDisk = make(map[string]float64)
+			}
+			f.metrics.NodeDisk[nm.NodeID] = nm.DiskUsed
+		}
 		series := f.metrics.GetNodeSeries(nm.NodeID)
 		series.Ingest(p.Timestamp, nm.RPS)
 		f.metrics.GetNodeSeries(nm.NodeID+":ws").Ingest(p.Timestamp, float64(nm.ActiveWS))
@@ -778,7 +1282,8 @@ func (f *FSM) applyUpdateAccessPolicy(policy *UserAccessPolicy) error {
 func (f *FSM) processJob(j *resourceJob, results []interface{}) {
 	if j.isSystem {
 		for _, item := range j.items {
-			results[item.index] = f.applyCommand(item.cmd, item.raftIndex)
+			f.auditChanges(item.audit, item.cmd)
+			results[item.index] = f.applyCommand(item.ctx, item.cmd, item.raftIndex)
 		}
 	} else if j.isTeam {
 		f.processTeamJob(j, results)
@@ -802,6 +1307,8 @@ func (f *FSM) processGameJob(j *resourceJob, results []interface{}) {
 
 	dirty := false
 	deleted := false
+	purged := false
+	var deletedAt time.Time
 	totalActions := 0
 	forceDiskSave := false
 
@@ -813,12 +1320,22 @@ func (f *FSM) processGameJob(j *resourceJob, results []interface{}) {
 		}
 
 		if deleted {
-			if item.cmd.Type != CmdSaveGame {
+			switch {
+			case item.cmd.Type == CmdSaveGame:
+				g = &Game{ID: j.id}
+				deleted = false
+				purged = false
+			case item.cmd.Type == CmdRestoreGame && !purged:
+				// Deleted earlier in this batch; g still holds the game.
+				deleted = false
+				g.LastRaftIndex = item.raftIndex
+				results[item.index] = nil
+				continue
+			case item.cmd.Type == CmdPurgeGame:
+			default:
 				results[item.index] = fmt.Errorf("cannot apply command to deleted game %s", j.id)
 				continue
 			}
-			g = &Game{ID: j.id}
-			deleted = false
 		}
 
 		switch item.cmd.Type {
@@ -837,6 +1354,9 @@ func (f *FSM) processGameJob(j *resourceJob, results []interface{}) {
 				}
 			}
 
+			if item.audit != nil {
+				item.audit.Changes = gameChanges(g, &newG)
+			}
 			g = &newG
 			g.LastRaftIndex = item.raftIndex
 
@@ -852,42 +1372,66 @@ func (f *FSM) processGameJob(j *resourceJob, results []interface{}) {
 		case CmdApplyAction:
 			var changed bool
 			var actionErr error
+			numActions := 1
 			if len(item.cmd.Action.Actions) > 0 {
+				numActions = len(item.cmd.Action.Actions)
 				changed, actionErr = ApplyActions(g, item.cmd.Action.Actions)
-				if changed && actionErr == nil {
-					totalActions += len(item.cmd.Action.Actions)
-				}
 			} else {
 				changed, actionErr = ApplyAction(g, item.cmd.Action.Action)
-				if changed {
-					totalActions++
-				}
 			}
 			if actionErr != nil {
 				results[item.index] = actionErr
 			} else {
 				g.LastRaftIndex = item.raftIndex
 				if changed {
+					totalActions += numActions
 					dirty = true
 					j.skipBroadcast = false
+					f.queueWebhooks(item.raftIndex, g, numActions)
 				}
 				results[item.index] = nil
 			}
 
 		case CmdDeleteGame:
 			deleted = true
+			deletedAt = commandTime(item.cmd)
 			g.LastRaftIndex = item.raftIndex
 			dirty = true
 			forceDiskSave = true
 			results[item.index] = nil
+
+		case CmdRestoreGame:
+			restored, err := untrashGame(g, commandTime(item.cmd))
+			if err != nil {
+				results[item.index] = err
+				continue
+			}
+			g = restored
+			g.LastRaftIndex = item.raftIndex
+			dirty = true
+			forceDiskSave = true
+			j.skipBroadcast = true
+			results[item.index] = nil
+
+		case CmdPurgeGame:
+			if !deleted && g.Status != "deleted" {
+				results[item.index] = ErrNotInTrash
+				continue
+			}
+			deleted = true
+			deletedAt = commandTime(item.cmd)
+			purged = true
+			g.LastRaftIndex = item.raftIndex
+			dirty = true
+			results[item.index] = nil
 		}
 	}
 
 	// 3. Save Once (if dirty)
 	if dirty {
 		if deleted {
-			if err := f.gs.DeleteGame(j.id); err != nil {
-				log.Printf("Batch Error: failed to delete game %s: %v", j.id, err)
+			if err := f.gs.tombstoneGame(j.id, g, !purged, g.LastRaftIndex, deletedAt); err != nil {
+				fsmLog.ErrorContext(j.ctx, "failed to delete game", "gameId", j.id, "err", err)
 				for _, item := range j.items {
 					if results[item.index] == nil {
 						results[item.index] = err
@@ -906,7 +1450,7 @@ func (f *FSM) processGameJob(j *resourceJob, results []interface{}) {
 			}
 
 			if saveErr != nil {
-				log.Printf("Batch Error: failed to save game %s: %v", j.id, saveErr)
+				fsmLog.ErrorContext(j.ctx, "failed to save game", "gameId", j.id, "err", saveErr)
 				for _, item := range j.items {
 					if results[item.index] == nil {
 						results[item.index] = saveErr
@@ -935,6 +1479,8 @@ func (f *FSM) processTeamJob(j *resourceJob, results []interface{}) {
 
 	dirty := false
 	deleted := false
+	purged := false
+	var deletedAt time.Time
 	forceDiskSave := false
 
 	for _, item := range j.items {
@@ -944,12 +1490,22 @@ func (f *FSM) processTeamJob(j *resourceJob, results []interface{}) {
 		}
 
 		if deleted {
-			if item.cmd.Type != CmdSaveTeam {
+			switch {
+			case item.cmd.Type == CmdSaveTeam:
+				t = &Team{ID: j.id}
+				deleted = false
+				purged = false
+			case item.cmd.Type == CmdRestoreTeam && !purged:
+				// Deleted earlier in this batch; t still holds the team.
+				deleted = false
+				t.LastRaftIndex = item.raftIndex
+				results[item.index] = nil
+				continue
+			case item.cmd.Type == CmdPurgeTeam:
+			default:
 				results[item.index] = fmt.Errorf("cannot apply command to deleted team %s", j.id)
 				continue
 			}
-			t = &Team{ID: j.id}
-			deleted = false
 		}
 
 		switch item.cmd.Type {
@@ -959,6 +1515,9 @@ func (f *FSM) processTeamJob(j *resourceJob, results []interface{}) {
 				results[item.index] = err
 				continue
 			}
+			if item.audit != nil {
+				item.audit.Changes = teamChanges(t, &newT)
+			}
 			t = &newT
 			t.LastRaftIndex = item.raftIndex
 			dirty = true
@@ -966,17 +1525,40 @@ func (f *FSM) processTeamJob(j *resourceJob, results []interface{}) {
 			results[item.index] = nil
 		case CmdDeleteTeam:
 			deleted = true
+			deletedAt = commandTime(item.cmd)
 			t.LastRaftIndex = item.raftIndex
 			dirty = true
 			forceDiskSave = true
 			results[item.index] = nil
+		case CmdRestoreTeam:
+			restored, err := untrashTeam(t, commandTime(item.cmd))
+			if err != nil {
+				results[item.index] = err
+				continue
+			}
+			t = restored
+			t.LastRaftIndex = item.raftIndex
+			dirty = true
+			forceDiskSave = true
+			results[item.index] = nil
+		case CmdPurgeTeam:
+			if !deleted && t.Status != "deleted" {
+				results[item.index] = ErrNotInTrash
+				continue
+			}
+			deleted = true
+			deletedAt = commandTime(item.cmd)
+			purged = true
+			t.LastRaftIndex = item.raftIndex
+			dirty = true
+			results[item.index] = nil
 		}
 	}
 
 	if dirty {
 		if deleted {
-			if err := f.ts.DeleteTeam(j.id); err != nil {
-				log.Printf("Batch Error: failed to delete team %s: %v", j.id, err)
+			if err := f.ts.tombstoneTeam(j.id, t, !purged, t.LastRaftIndex, deletedAt); err != nil {
+				fsmLog.ErrorContext(j.ctx, "failed to delete team", "teamId", j.id, "err", err)
 				for _, item := range j.items {
 					if results[item.index] == nil {
 						results[item.index] = err
@@ -995,7 +1577,7 @@ func (f *FSM) processTeamJob(j *resourceJob, results []interface{}) {
 			}
 
 			if saveErr != nil {
-				log.Printf("Batch Error: failed to save team %s: %v", j.id, saveErr)
+				fsmLog.ErrorContext(j.ctx, "failed to save team", "teamId", j.id, "err", saveErr)
 				for _, item := range j.items {
 					if results[item.index] == nil {
 						results[item.index] = saveErr
@@ -1016,30 +1598,44 @@ type FSMSnapshot struct {
 
 // Persist saves the snapshot to the given sink.
 func (s *FSMSnapshot) Persist(sink raft.SnapshotSink) error {
+	defer func(start time.Time) { s.fsm.snapshotLatency.Observe(time.Since(start)) }(time.Now())
 	return s.fsm.persist(sink)
 }
 
 // Release releases the snapshot.
 func (s *FSMSnapshot) Release() {}
 
+// resetNodes forgets the metadata of every node.
+func (f *FSM) resetNodes() {
+	f.nodeMap.Range(func(k, _ interface{}) bool {
+		f.nodeMap.Delete(k)
+		return true
+	})
+	f.saveNodes()
+}
+
 func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
 	// 1. Flush all dirty state to disk so the snapshotter reads fresh data
 	if err := f.gs.FlushAll(); err != nil {
-		log.Printf("FSM Snapshot Error: flushing games failed: %v", err)
+		fsmLog.Error("snapshot: flushing games failed", "err", err)
 		return nil, err
 	}
 	if err := f.ts.FlushAll(); err != nil {
-		log.Printf("FSM Snapshot Error: flushing teams failed: %v", err)
+		fsmLog.Error("snapshot: flushing teams failed", "err", err)
 		return nil, err
 	}
 	if err := f.us.FlushAll(); err != nil {
-		log.Printf("FSM Snapshot Error: flushing user indices failed: %v", err)
+		fsmLog.Error("snapshot: flushing user indices failed", "err", err)
+		return nil, err
+	}
+	if err := f.audit.FlushAll(); err != nil {
+		fsmLog.Error("snapshot: flushing audit logs failed", "err", err)
 		return nil, err
 	}
 
 	if f.rm != nil {
 		if err := f.rm.RotateLogKey(); err != nil {
-			log.Printf("Warning: failed to rotate log key during snapshot: %v", err)
+			fsmLog.Warn("snapshot: failed to rotate log key", "err", err)
 		}
 	}
 
@@ -1047,7 +1643,7 @@ func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
 		// Persist Metrics
 		f.metricsMu.RLock()
 		if err := f.storage.SaveDataFile("metrics.json", f.metrics); err != nil {
-			log.Printf("Warning: failed to save metrics.json: %v", err)
+			fsmLog.Warn("snapshot: failed to save metrics.json", "err", err)
 		}
 		f.metricsMu.RUnlock()
 	}
@@ -1057,6 +1653,7 @@ func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
 
 func (f *FSM) Restore(rc io.ReadCloser) error {
 	defer rc.Close()
+	defer func(start time.Time) { f.restoreLatency.Observe(time.Since(start)) }(time.Now())
 	if err := f.restore(rc); err != nil {
 		return err
 	}
@@ -1067,9 +1664,10 @@ func (f *FSM) Restore(rc io.ReadCloser) error {
 			m.Hydrate()
 			f.metricsMu.Lock()
 			f.metrics = &m
+			f.metricsPersistedAt = m.LastUpdate
 			f.metricsMu.Unlock()
 		} else if !os.IsNotExist(err) {
-			log.Printf("Warning: failed to restore metrics.json: %v", err)
+			fsmLog.Warn("failed to restore metrics.json", "err", err)
 		}
 	}
 	return nil
@@ -1085,5 +1683,13 @@ func (f *FSM) FlushAll() error {
 	if err := f.us.FlushAll(); err != nil {
 		return err
 	}
+	if err := f.audit.FlushAll(); err != nil {
+		return err
+	}
+	if f.r != nil {
+		if err := f.r.search.FlushAll(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -56,7 +57,7 @@ func TestFSM_ApplyAction_DelayedPersistence(t *testing.T) {
 	// But wait, fsm.applyAction takes 'data []byte' which is the Action payload?
 	// Let's check fsm.go: ApplyAction(g, data)
 
-	if err := fsm.applyAction(gameId, actionBytes, 1); err != nil {
+	if err := fsm.applyAction(context.Background(), gameId, actionBytes, 1); err != nil {
 		t.Fatalf("applyAction failed: %v", err)
 	}
 
@@ -133,7 +134,7 @@ func TestFSM_Standalone_ImmediatePersistence(t *testing.T) {
 	// time.Sleep(10 * time.Millisecond) // Might not be enough on some FS, but let's try.
 
 	actionBytes := []byte(`{"type":"TEST"}`)
-	fsm.applyAction(gameId, actionBytes, 0) // Index 0 for standalone usually? Or just ignore index.
+	fsm.applyAction(context.Background(), gameId, actionBytes, 0) // Index 0 for standalone usually? Or just ignore index.
 
 	// Verify Dirty is FALSE (flushed immediately)
 	gs.dirtyMu.Lock()
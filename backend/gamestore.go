@@ -18,12 +18,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"iter"
-	"log"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/c2FmZQ/storage"
@@ -67,9 +67,17 @@ type Game struct {
 	HomeTeamID    string            `json:"homeTeamId,omitempty"`
 	ActionLog     []json.RawMessage `json:"actionLog,omitempty"`
 
+	// PendingOwner is the user the owner offered the game to. Ownership
+	// changes when that user accepts the transfer.
+	PendingOwner string `json:"pendingOwner,omitempty"`
+
 	// DeletedAt is the timestamp (Unix Nano) when the game was deleted.
 	DeletedAt int64 `json:"deletedAt,omitempty"`
 
+	// Trashed holds the original game on a tombstone so it can be restored
+	// until the tombstone is purged.
+	Trashed json.RawMessage `json:"trashed,omitempty"`
+
 	// LastRaftIndex tracks the index of the last Raft log entry applied to this game.
 	// Used for idempotency during log replay.
 	LastRaftIndex uint64 `json:"lastRaftIndex,omitempty"`
@@ -78,6 +86,10 @@ type Game struct {
 	// Used for conflict detection without loading the full log.
 	LastActionID string `json:"lastActionId,omitempty"`
 
+	// UpdatedAt is the time (Unix ms) the game was last saved, set by the
+	// GameStore. Listings use it to return the games modified since a time.
+	UpdatedAt int64 `json:"updatedAt,omitempty"`
+
 	// Roster and Subs are now strictly typed.
 	// We need custom Unmarshal to handle migration from v2 (n/u/p) to v3 (name/number/pos).
 	// For now, we'll use a map structure for intermediate loading or dual fields?
@@ -90,7 +102,7 @@ type Game struct {
 
 func (g *Game) normalize() {
 	if g.SchemaVersion == 0 {
-		g.SchemaVersion = CurrentSchemaVersion
+		g.SchemaVersion = DocumentSchemaVersion
 	}
 	if g.Permissions.Users == nil {
 		g.Permissions.Users = make(map[string]string)
@@ -133,8 +145,48 @@ func (g *Game) Metadata() *GameMetadata {
 		HomeTeamID:    g.HomeTeamID,
 		Status:        g.Status,
 		DeletedAt:     g.DeletedAt,
+		UpdatedAt:     g.UpdatedAt,
 		LastActionID:  lastActionID,
+		Restorable:    len(g.Trashed) > 0,
+		PendingOwner:  g.PendingOwner,
+		Players:       g.players(),
+		IndexVersion:  gameMetadataVersion,
+	}
+}
+
+// players returns the distinct players of both sides of the game: starters,
+// substitutes and bench, for the player search index.
+func (g *Game) players() []Player {
+	var lists [][]Player
+	for _, side := range g.Roster {
+		for _, slot := range side {
+			lists = append(lists, []Player{slot.Starter, slot.Current}, slot.History)
+		}
+	}
+	for _, subs := range g.Subs {
+		lists = append(lists, subs)
 	}
+	return indexPlayers(lists...)
+}
+
+// indexPlayers returns the distinct players with a name or number in lists,
+// in order, without their positions.
+func indexPlayers(lists ...[]Player) []Player {
+	var out []Player
+	seen := make(map[Player]bool)
+	for _, list := range lists {
+		for _, p := range list {
+			p.Pos = ""
+			p.Name = strings.TrimSpace(p.Name)
+			p.Number = strings.TrimSpace(p.Number)
+			if (p.Name == "" && p.Number == "") || seen[p] {
+				continue
+			}
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
 }
 
 // GameStore manages game persistence to disk.
@@ -147,6 +199,9 @@ type GameStore struct {
 
 	dirtyMu sync.Mutex
 	dirty   map[string]bool
+
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
 }
 
 // NewGameStore creates a new GameStore.
@@ -160,8 +215,14 @@ func NewGameStore(dataDir string, s *storage.Storage) *GameStore {
 	}
 }
 
-// SaveGame saves the game data atomically.
+// SaveGame saves the game data atomically and sets its UpdatedAt.
 func (gs *GameStore) SaveGame(game *Game) error {
+	game.UpdatedAt = time.Now().UnixMilli()
+	return gs.writeGame(game)
+}
+
+// writeGame saves the game data atomically, as is.
+func (gs *GameStore) writeGame(game *Game) error {
 	gameId := game.ID
 	// Get or create a mutex for this specific game
 	m, _ := gs.mu.LoadOrStore(gameId, &sync.RWMutex{})
@@ -175,7 +236,7 @@ func (gs *GameStore) SaveGame(game *Game) error {
 	metaFilename := filepath.Join("games", fmt.Sprintf("%s.meta.json", encodedGameId))
 
 	if len(game.ActionLog) == 0 {
-		log.Printf("SaveGame WARNING: Saving game %s with 0 actions!", gameId)
+		storeLog.Warn("saving game with no actions", "gameId", gameId)
 	}
 
 	if err := gs.storage.SaveDataFile(filename, game); err != nil {
@@ -185,10 +246,10 @@ func (gs *GameStore) SaveGame(game *Game) error {
 	// Save Metadata Sidecar
 	meta := game.Metadata()
 	if err := gs.storage.SaveDataFile(metaFilename, meta); err != nil {
-		log.Printf("Warning: Failed to save metadata sidecar for game %s: %v", gameId, err)
+		storeLog.Warn("failed to save metadata sidecar", "gameId", gameId, "err", err)
 		// Non-fatal, we can fall back to main file, but we must remove the stale meta file to force that fallback.
 		if rmErr := os.Remove(filepath.Join(gs.DataDir, metaFilename)); rmErr != nil && !os.IsNotExist(rmErr) {
-			log.Printf("Error removing stale metadata file: %v", rmErr)
+			storeLog.Error("failed to remove stale metadata file", "gameId", gameId, "err", rmErr)
 		}
 	}
 
@@ -222,7 +283,7 @@ func (gs *GameStore) RestoreGame(game *Game) error {
 	metaFilename := filepath.Join("games", fmt.Sprintf("%s.meta.json", encodedGameId))
 
 	if len(game.ActionLog) == 0 {
-		log.Printf("RestoreGame WARNING: Saving game %s with 0 actions!", gameId)
+		storeLog.Warn("restoring game with no actions", "gameId", gameId)
 	}
 
 	if err := gs.storage.SaveDataFile(filename, game); err != nil {
@@ -235,7 +296,7 @@ func (gs *GameStore) RestoreGame(game *Game) error {
 	// Save Metadata Sidecar
 	meta := game.Metadata()
 	if err := gs.storage.SaveDataFile(metaFilename, meta); err != nil {
-		log.Printf("Warning: Failed to save metadata sidecar during restore for game %s: %v", gameId, err)
+		storeLog.Warn("failed to save metadata sidecar during restore", "gameId", gameId, "err", err)
 	}
 
 	// Do NOT update cache.
@@ -244,6 +305,8 @@ func (gs *GameStore) RestoreGame(game *Game) error {
 
 // SaveGameInMemory updates the in-memory cache and marks the game as dirty.
 func (gs *GameStore) SaveGameInMemory(game *Game, forceSync bool) error {
+	game.UpdatedAt = time.Now().UnixMilli()
+
 	// 1. Update Cache (Authoritative)
 	jsonBytes, err := json.Marshal(game)
 	if err != nil {
@@ -253,7 +316,7 @@ func (gs *GameStore) SaveGameInMemory(game *Game, forceSync bool) error {
 
 	// 2. Handle Persistence
 	if forceSync {
-		return gs.SaveGame(game)
+		return gs.writeGame(game)
 	}
 
 	// 3. Mark as Dirty
@@ -293,8 +356,8 @@ func (gs *GameStore) Flush(gameId string) error {
 		return fmt.Errorf("failed to unmarshal game from cache for flush: %w", err)
 	}
 
-	// SaveGame will clear the dirty flag
-	return gs.SaveGame(&g)
+	// writeGame will clear the dirty flag
+	return gs.writeGame(&g)
 }
 
 // FlushAll persists all dirty games to disk.
@@ -322,8 +385,9 @@ func (gs *GameStore) LoadGame(gameId string) (*Game, error) {
 	if val, ok := gs.cache.Load(gameId); ok {
 		var g Game
 		if err := json.Unmarshal(val.([]byte), &g); err == nil {
+			gs.cacheHits.Add(1)
 			if gs.Debug {
-				log.Printf("[CACHE] Hit for game %s", gameId)
+				storeLog.Debug("game cache hit", "gameId", gameId)
 			}
 			g.normalize()
 			return &g, nil
@@ -331,8 +395,9 @@ func (gs *GameStore) LoadGame(gameId string) (*Game, error) {
 		// If unmarshal fails, proceed to load from disk
 		gs.cache.Delete(gameId)
 	}
+	gs.cacheMisses.Add(1)
 	if gs.Debug {
-		log.Printf("[CACHE] Miss for game %s", gameId)
+		storeLog.Debug("game cache miss", "gameId", gameId)
 	}
 
 	m, _ := gs.mu.LoadOrStore(gameId, &sync.RWMutex{})
@@ -368,7 +433,7 @@ func (gs *GameStore) LoadGame(gameId string) (*Game, error) {
 		if fi != nil {
 			size = fi.Size()
 		}
-		log.Printf("LoadGame WARNING: Loaded game %s with 0 actions! File size: %d bytes. Path: %s", gameId, size, filename)
+		storeLog.Warn("loaded game with no actions", "gameId", gameId, "size", size, "path", filename)
 	}
 
 	return &g, nil
@@ -384,7 +449,20 @@ func (gs *GameStore) LoadGameAsJSON(gameId string) ([]byte, error) {
 }
 
 // DeleteGame deletes a specific game by overwriting it with a tombstone.
+// The game data is discarded.
 func (gs *GameStore) DeleteGame(gameId string) error {
+	return gs.deleteGame(gameId, false, 0, time.Now())
+}
+
+// TrashGame deletes a game by overwriting it with a tombstone that retains
+// the original data, so it can be restored until the tombstone is purged.
+// raftIndex is recorded on the tombstone for replay idempotency, and now as
+// the deletion time.
+func (gs *GameStore) TrashGame(gameId string, raftIndex uint64, now time.Time) error {
+	return gs.deleteGame(gameId, true, raftIndex, now)
+}
+
+func (gs *GameStore) deleteGame(gameId string, keep bool, raftIndex uint64, now time.Time) error {
 	// Load first to get OwnerID
 	g, err := gs.LoadGame(gameId)
 	if err != nil {
@@ -393,6 +471,22 @@ func (gs *GameStore) DeleteGame(gameId string) error {
 		}
 		return err
 	}
+	return gs.tombstoneGame(gameId, g, keep, raftIndex, now)
+}
+
+// tombstoneGame overwrites a loaded game with its tombstone. When keep is set,
+// the tombstone retains g so it can be restored. now is the deletion time,
+// taken from the command in the FSM so that every node records the same one.
+func (gs *GameStore) tombstoneGame(gameId string, g *Game, keep bool, raftIndex uint64, now time.Time) error {
+	deletedAt := now.UnixNano()
+	if g.Status == "deleted" {
+		// Already a tombstone; deleting again only erases retained data.
+		if keep || len(g.Trashed) == 0 {
+			return nil
+		}
+		deletedAt = g.DeletedAt
+		raftIndex = max(raftIndex, g.LastRaftIndex)
+	}
 
 	m, _ := gs.mu.LoadOrStore(gameId, &sync.RWMutex{})
 	mutex := m.(*sync.RWMutex)
@@ -403,10 +497,24 @@ func (gs *GameStore) DeleteGame(gameId string) error {
 	// Create tombstone
 	tombstone := &Game{
 		ID:            gameId,
-		SchemaVersion: CurrentSchemaVersion,
+		SchemaVersion: DocumentSchemaVersion,
 		Status:        "deleted",
 		OwnerID:       g.OwnerID,
-		DeletedAt:     time.Now().UnixNano(),
+		DeletedAt:     deletedAt,
+		UpdatedAt:     now.UnixMilli(),
+		LastRaftIndex: raftIndex,
+	}
+	if keep {
+		trashed, err := json.Marshal(g)
+		if err != nil {
+			return fmt.Errorf("failed to marshal trashed game: %w", err)
+		}
+		tombstone.Trashed = trashed
+		// Keep the labels so the trash can be listed from metadata.
+		tombstone.Date = g.Date
+		tombstone.Event = g.Event
+		tombstone.Away = g.Away
+		tombstone.Home = g.Home
 	}
 
 	encodedGameId := url.PathEscape(gameId)
@@ -418,15 +526,9 @@ func (gs *GameStore) DeleteGame(gameId string) error {
 	}
 
 	// Save Metadata Tombstone
-	meta := GameMetadata{
-		ID:            gameId,
-		SchemaVersion: CurrentSchemaVersion,
-		OwnerID:       g.OwnerID,
-		Status:        "deleted",
-		DeletedAt:     tombstone.DeletedAt,
-	}
+	meta := *tombstone.Metadata()
 	if err := gs.storage.SaveDataFile(metaFilename, &meta); err != nil {
-		log.Printf("Warning: Failed to save metadata tombstone for game %s: %v", gameId, err)
+		storeLog.Warn("failed to save metadata tombstone", "gameId", gameId, "err", err)
 		// Ensure we don't leave a confusing active meta file for a deleted game
 		os.Remove(filepath.Join(gs.DataDir, metaFilename))
 	}
@@ -462,7 +564,7 @@ func (gs *GameStore) PurgeGame(gameId string) error {
 	}
 	if err := os.Remove(fullMetaPath); err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("Warning: could not purge meta file for game %s: %v", gameId, err)
+			storeLog.Warn("could not purge meta file", "gameId", gameId, "err", err)
 		}
 	}
 	return nil
@@ -514,15 +616,16 @@ func (gs *GameStore) ListAllGameIDs() ([]string, error) {
 
 // GameSummary represents a summary of a game.
 type GameSummary struct {
-	ID       string `json:"id"`
-	Date     string `json:"date"`
-	Location string `json:"location"`
-	Event    string `json:"event"`
-	Away     string `json:"away"`
-	Home     string `json:"home"`
-	Revision string `json:"revision"`
-	Status   string `json:"status"`
-	OwnerID  string `json:"ownerId"`
+	ID        string `json:"id"`
+	Date      string `json:"date"`
+	Location  string `json:"location"`
+	Event     string `json:"event"`
+	Away      string `json:"away"`
+	Home      string `json:"home"`
+	Revision  string `json:"revision"`
+	Status    string `json:"status"`
+	OwnerID   string `json:"ownerId"`
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
 }
 
 // GameMetadata contains only the fields needed for indexing.
@@ -540,9 +643,21 @@ type GameMetadata struct {
 	HomeTeamID    string      `json:"homeTeamId"`
 	Status        string      `json:"status"`
 	DeletedAt     int64       `json:"deletedAt"`
+	UpdatedAt     int64       `json:"updatedAt,omitempty"`
 	LastActionID  string      `json:"lastActionId,omitempty"`
+	Restorable    bool        `json:"restorable,omitempty"` // Tombstone retains the game data
+	PendingOwner  string      `json:"pendingOwner,omitempty"`
+	Players       []Player    `json:"players,omitempty"` // Roster players, for search
+	IndexVersion  int         `json:"indexVersion,omitempty"`
 }
 
+// gameMetadataVersion is the IndexVersion of the metadata written by this
+// version. Older sidecars are ignored in favor of the game file, see
+// ListAllGameMetadata.
+//
+//	1: Players
+const gameMetadataVersion = 1
+
 // ListAllGameMetadata returns metadata for all games without loading full action logs.
 func (gs *GameStore) ListAllGameMetadata() iter.Seq2[GameMetadata, error] {
 	return func(yield func(GameMetadata, error) bool) {
@@ -605,12 +720,18 @@ func (gs *GameStore) ListAllGameMetadata() iter.Seq2[GameMetadata, error] {
 
 			var meta GameMetadata
 			if err := gs.storage.ReadDataFile(metaFilename, &meta); err != nil {
-				log.Printf("Registry Warning: failed to load metadata for %s: %v. Falling back to main file.", id, err)
+				storeLog.Warn("failed to load metadata, falling back to main file", "gameId", id, "err", err)
 				// Fallback to main file if meta load fails
 				hasGame[id] = true // Ensure we try loading main file
 				processed[id] = false
 				continue
 			}
+			if meta.IndexVersion < gameMetadataVersion && meta.Status != "deleted" {
+				// Written before the metadata had all the indexed fields.
+				hasGame[id] = true
+				processed[id] = false
+				continue
+			}
 
 			if !yield(meta, nil) {
 				return
@@ -627,27 +748,13 @@ func (gs *GameStore) ListAllGameMetadata() iter.Seq2[GameMetadata, error] {
 			// Load Full Game
 			g, err := gs.LoadGame(id)
 			if err != nil {
-				log.Printf("Registry Warning: failed to load game %s from disk: %v", id, err)
+				storeLog.Warn("failed to load game from disk", "gameId", id, "err", err)
 				continue
 			}
 
 			// We could optionally generate the .meta.json here for self-repair,
 			// but for now we just return the data.
-			if !yield(GameMetadata{
-				ID:            g.ID,
-				SchemaVersion: g.SchemaVersion,
-				Date:          g.Date,
-				Location:      g.Location,
-				Event:         g.Event,
-				Away:          g.Away,
-				Home:          g.Home,
-				OwnerID:       g.OwnerID,
-				Permissions:   g.Permissions,
-				AwayTeamID:    g.AwayTeamID,
-				HomeTeamID:    g.HomeTeamID,
-				Status:        g.Status,
-				DeletedAt:     g.DeletedAt,
-			}, nil) {
+			if !yield(*g.Metadata(), nil) {
 				return
 			}
 		}
@@ -659,25 +766,11 @@ func (gs *GameStore) ListAllGameMetadata() iter.Seq2[GameMetadata, error] {
 			// Must verify existence (LoadGame handles cache lookup)
 			g, err := gs.LoadGame(id)
 			if err != nil {
-				log.Printf("Error: Failed to load dirty game %s: %v", id, err)
+				storeLog.Error("failed to load dirty game", "gameId", id, "err", err)
 				continue
 			}
 
-			if !yield(GameMetadata{
-				ID:            g.ID,
-				SchemaVersion: g.SchemaVersion,
-				Date:          g.Date,
-				Location:      g.Location,
-				Event:         g.Event,
-				Away:          g.Away,
-				Home:          g.Home,
-				OwnerID:       g.OwnerID,
-				Permissions:   g.Permissions,
-				AwayTeamID:    g.AwayTeamID,
-				HomeTeamID:    g.HomeTeamID,
-				Status:        g.Status,
-				DeletedAt:     g.DeletedAt,
-			}, nil) {
+			if !yield(*g.Metadata(), nil) {
 				return
 			}
 		}
@@ -722,7 +815,7 @@ func (gs *GameStore) ListAllGames() iter.Seq2[*Game, error] {
 
 				g, err := gs.LoadGame(gameId)
 				if err != nil {
-					log.Printf("Warning: could not load game '%s': %v", gameId, err)
+					storeLog.Warn("could not load game", "gameId", gameId, "err", err)
 					continue
 				}
 				g.normalize()
@@ -738,7 +831,7 @@ func (gs *GameStore) ListAllGames() iter.Seq2[*Game, error] {
 
 			g, err := gs.LoadGame(id)
 			if err != nil {
-				log.Printf("Error: Failed to load dirty game %s: %v", id, err)
+				storeLog.Error("failed to load dirty game", "gameId", id, "err", err)
 				continue
 			}
 			g.normalize()
@@ -243,14 +243,24 @@ func (s *LinkSnapshotStore) walkSnapshotEntities(store *storage.Storage, visitor
 			raftPath := relPath[5:]
 			var obj any
 
-			switch raftPath {
-			case "sys_access_policy":
+			switch {
+			case raftPath == "sys_access_policy":
 				obj = &UserAccessPolicy{}
-			case "metrics.json":
+			case raftPath == "metrics.json":
 				obj = &MetricsStore{}
-			case "nodes.json":
+			case raftPath == "nodes.json":
 				var o map[string]*NodeMeta
 				obj = &o
+			case raftPath == webhooksFile:
+				var o map[string]*Webhook
+				obj = &o
+			case raftPath == alertsFile:
+				var o map[string]*AlertRule
+				obj = &o
+			case raftPath == clusterVersionFile:
+				obj = &clusterVersion{}
+			case strings.HasPrefix(raftPath, auditDir+"/"):
+				obj = &AuditLog{}
 			default:
 				return nil
 			}
@@ -306,6 +316,7 @@ type LinkSnapshotSink struct {
 	snapDir   string
 	sourceDir string
 	stream    crypto.StreamWriter
+	closed    bool
 }
 
 func (s *LinkSnapshotSink) Write(p []byte) (n int, err error) {
@@ -313,6 +324,11 @@ func (s *LinkSnapshotSink) Write(p []byte) (n int, err error) {
 }
 
 func (s *LinkSnapshotSink) Close() error {
+	// FSM.persist closes the sink, and raft.RecoverCluster closes it again.
+	if s.closed {
+		return nil
+	}
+	s.closed = true
 	if err := s.stream.Close(); err != nil {
 		s.inner.Cancel()
 		return err
@@ -0,0 +1,291 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Subsystem loggers. Each subsystem has its own level, see LogOptions.Levels.
+var (
+	alertLog   = newSubsystemLogger("alerts")
+	authLog    = newSubsystemLogger("auth")
+	backupLog  = newSubsystemLogger("backup")
+	fsmLog     = newSubsystemLogger("fsm")
+	httpLog    = newSubsystemLogger("http")
+	hubLog     = newSubsystemLogger("hub")
+	mainLog    = newSubsystemLogger("main") // slog.Default and the log package, after SetupLogging
+	raftLog    = newSubsystemLogger("raft")
+	replicaLog = newSubsystemLogger("replica")
+	storeLog   = newSubsystemLogger("store")
+	webhookLog = newSubsystemLogger("webhooks")
+)
+
+// LogOptions configures SetupLogging.
+type LogOptions struct {
+	Format string    // "text" (default) or "json"
+	Level  string    // Default level: "debug", "info" (default), "warn" or "error"
+	Levels string    // Per-subsystem overrides, e.g. "raft=debug,auth=warn"
+	Output io.Writer // Default: os.Stderr
+}
+
+var (
+	logBase    atomic.Pointer[slog.Handler] // Handler all subsystems write to
+	logJSON    atomic.Bool
+	logLevelMu sync.Mutex
+	logLevels  = make(map[string]*slog.LevelVar) // Subsystem -> level
+)
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logBase.Store(&h)
+}
+
+// SetupLogging configures the output format and levels of all backend logs,
+// and routes the standard log package and slog.Default through them under
+// the "main" subsystem.
+func SetupLogging(o LogOptions) error {
+	out := o.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // Filtering is per subsystem
+	var h slog.Handler
+	switch o.Format {
+	case "", "text":
+		h = slog.NewTextHandler(out, opts)
+	case "json":
+		h = slog.NewJSONHandler(out, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", o.Format)
+	}
+
+	def := slog.LevelInfo
+	if o.Level != "" {
+		if err := def.UnmarshalText([]byte(o.Level)); err != nil {
+			return fmt.Errorf("invalid log level %q", o.Level)
+		}
+	}
+	overrides := make(map[string]slog.Level)
+	for _, kv := range strings.Split(o.Levels, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		name, lvl, ok := strings.Cut(kv, "=")
+		var l slog.Level
+		if !ok || l.UnmarshalText([]byte(lvl)) != nil {
+			return fmt.Errorf("invalid subsystem log level %q, want subsystem=level", kv)
+		}
+		if !slices.Contains(LogSubsystems(), name) {
+			return fmt.Errorf("unknown log subsystem %q, want one of %s", name, strings.Join(LogSubsystems(), ", "))
+		}
+		overrides[name] = l
+	}
+
+	logLevelMu.Lock()
+	for name, v := range logLevels {
+		if l, ok := overrides[name]; ok {
+			v.Set(l)
+		} else {
+			v.Set(def)
+		}
+	}
+	logLevelMu.Unlock()
+	logBase.Store(&h)
+	logJSON.Store(o.Format == "json")
+	slog.SetDefault(mainLog)
+	return nil
+}
+
+// LogSubsystems returns the names of the subsystems that can be configured.
+func LogSubsystems() []string {
+	logLevelMu.Lock()
+	defer logLevelMu.Unlock()
+	names := make([]string, 0, len(logLevels))
+	for name := range logLevels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func subsystemLevel(name string) *slog.LevelVar {
+	logLevelMu.Lock()
+	defer logLevelMu.Unlock()
+	v, ok := logLevels[name]
+	if !ok {
+		v = new(slog.LevelVar)
+		logLevels[name] = v
+	}
+	return v
+}
+
+func newSubsystemLogger(name string) *slog.Logger {
+	return slog.New(&subsystemHandler{subsystem: name, level: subsystemLevel(name)})
+}
+
+// subsystemHandler filters records by the subsystem's level, annotates them with
+// the subsystem and the request and trace IDs of the context, and writes them
+// to the current base handler.
+type subsystemHandler struct {
+	subsystem string
+	level     *slog.LevelVar
+	ops       []handlerOp // Applied to the base handler in order
+
+	cache atomic.Pointer[derivedHandler]
+}
+
+// handlerOp is a WithAttrs (group == "") or WithGroup call.
+type handlerOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+type derivedHandler struct {
+	base    *slog.Handler
+	handler slog.Handler
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.handler().Handle(ctx, r)
+}
+
+// handler returns the base handler with the subsystem attribute and ops applied.
+func (h *subsystemHandler) handler() slog.Handler {
+	base := logBase.Load()
+	if d := h.cache.Load(); d != nil && d.base == base {
+		return d.handler
+	}
+	out := (*base).WithAttrs([]slog.Attr{slog.String("subsystem", h.subsystem)})
+	for _, op := range h.ops {
+		if op.group != "" {
+			out = out.WithGroup(op.group)
+		} else {
+			out = out.WithAttrs(op.attrs)
+		}
+	}
+	h.cache.Store(&derivedHandler{base: base, handler: out})
+	return out
+}
+
+func (h *subsystemHandler) with(op handlerOp) *subsystemHandler {
+	return &subsystemHandler{subsystem: h.subsystem, level: h.level, ops: append(slices.Clip(h.ops), op)}
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(handlerOp{attrs: attrs})
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(handlerOp{group: name})
+}
+
+// newRaftLogger returns a logger for the Raft library that follows the format
+// and level of the "raft" subsystem.
+func newRaftLogger(out io.Writer) hclog.Logger {
+	level := hclog.Info
+	switch l := subsystemLevel("raft").Level(); {
+	case l < slog.LevelInfo:
+		level = hclog.Debug
+	case l >= slog.LevelError:
+		level = hclog.Error
+	case l >= slog.LevelWarn:
+		level = hclog.Warn
+	}
+	return hclog.New(&hclog.LoggerOptions{
+		Name:       "raft",
+		Level:      level,
+		Output:     out,
+		JSONFormat: logJSON.Load(),
+	})
+}
+
+// requestIDHeader carries the request ID to clients and between nodes.
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+func withRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// isValidRequestID accepts caller-supplied IDs that are safe to log.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDMiddleware assigns each request an ID, reusing the caller's
+// X-Request-ID if it has one, and returns it in the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+			// Forwarded requests copy the request headers.
+			r.Header.Set(requestIDHeader, id)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(withRequestID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs sends JSON logs to a buffer for the duration of the test.
+func captureLogs(t *testing.T, levels string) *syncBuffer {
+	var buf syncBuffer
+	if err := SetupLogging(LogOptions{Format: "json", Levels: levels, Output: &buf}); err != nil {
+		t.Fatalf("SetupLogging: %v", err)
+	}
+	t.Cleanup(func() { SetupLogging(LogOptions{}) })
+	return &buf
+}
+
+func logRecords(t *testing.T, out string) []map[string]any {
+	var recs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		recs = append(recs, m)
+	}
+	return recs
+}
+
+func TestSubsystemLogging(t *testing.T) {
+	buf := captureLogs(t, "raft=debug,auth=warn")
+
+	ctx := withRequestID(context.Background(), "req-1")
+	raftLog.DebugContext(ctx, "raft debug", "index", 7)
+	authLog.Info("auth info")
+	authLog.With("userId", "u").Warn("auth warn")
+	storeLog.Debug("store debug")
+
+	recs := logRecords(t, buf.String())
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, got %d:\n%s", len(recs), buf.String())
+	}
+	if r := recs[0]; r["subsystem"] != "raft" || r["msg"] != "raft debug" || r["request_id"] != "req-1" || r["index"] != float64(7) {
+		t.Errorf("unexpected raft record: %v", r)
+	}
+	if r := recs[1]; r["subsystem"] != "auth" || r["level"] != "WARN" || r["userId"] != "u" {
+		t.Errorf("unexpected auth record: %v", r)
+	}
+}
+
+func TestSetupLoggingErrors(t *testing.T) {
+	t.Cleanup(func() { SetupLogging(LogOptions{}) })
+	for _, o := range []LogOptions{
+		{Format: "xml"},
+		{Level: "loud"},
+		{Levels: "raft"},
+		{Levels: "nosuch=debug"},
+	} {
+		if err := SetupLogging(o); err == nil {
+			t.Errorf("SetupLogging(%+v) succeeded, want error", o)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var got string
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "client-id.1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if got != "client-id.1" || w.Header().Get(requestIDHeader) != "client-id.1" {
+		t.Errorf("caller's request ID not reused: ctx %q, header %q", got, w.Header().Get(requestIDHeader))
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if len(got) != 16 || got == "bad id\n" || w.Header().Get(requestIDHeader) != got {
+		t.Errorf("invalid request ID not replaced: ctx %q, header %q", got, w.Header().Get(requestIDHeader))
+	}
+}
+
+func TestAccessLogLevels(t *testing.T) {
+	buf := captureLogs(t, "")
+	h := loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/slow":
+			time.Sleep(slowRequestThreshold)
+		}
+	}))
+	defer func(d time.Duration) { slowRequestThreshold = d }(slowRequestThreshold)
+	slowRequestThreshold = 20 * time.Millisecond
+
+	for _, path := range []string{"/ok", "/missing", "/slow"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var paths []string
+	for _, r := range logRecords(t, buf.String()) {
+		if r["msg"] == "request" && r["level"] == "INFO" {
+			paths = append(paths, r["path"].(string))
+		}
+	}
+	if want := []string{"/missing", "/slow"}; !slices.Equal(paths, want) {
+		t.Errorf("requests logged at info: %v, want %v", paths, want)
+	}
+}
+
+func TestRequestIDReachesFSM(t *testing.T) {
+	cmd := RaftCommand{Type: CmdMetricsUpdate, RequestID: "req-2"}
+	data, _ := json.Marshal(cmd)
+	var decoded RaftCommand
+	json.Unmarshal(data, &decoded)
+	var f FSM
+	ctx, span := f.startApplySpan(decoded, 1)
+	if span != nil {
+		t.Errorf("untraced command should not start a span")
+	}
+	if id := requestIDFromContext(ctx); id != "req-2" {
+		t.Errorf("request ID = %q, want req-2", id)
+	}
+}
@@ -15,6 +15,9 @@
 package backend
 
 import (
+	"encoding/json"
+	"fmt"
+	"syscall"
 	"time"
 )
 
@@ -32,6 +35,8 @@ type NodeMetric struct {
 	RPS      float64    `json:"rps"`
 	ActiveWS int        `json:"activeWS"`
 	Latency  *Histogram `json:"latency,omitempty"`
+	Total    uint64     `json:"total,omitempty"`    // Cumulative request count reported by the node
+	DiskUsed float64    `json:"diskUsed,omitempty"` // Percentage of the node's data volume in use
 }
 
 type ClusterMetric struct {
@@ -64,6 +69,24 @@ func (h *Histogram) Add(d time.Duration) {
 	h.Sum += ms
 }
 
+// Quantile returns the q-quantile (0-1) of the histogram in milliseconds,
+// using the midpoint of the bucket like the admin dashboard.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	target := float64(h.Count) * q
+	var count uint64
+	bucketMS := float64(LatencyBucketSize.Milliseconds())
+	for i, c := range h.Buckets {
+		count += c
+		if float64(count) >= target {
+			return float64(i)*bucketMS + bucketMS/2
+		}
+	}
+	return LatencyBuckets * bucketMS
+}
+
 func (h *Histogram) Merge(other *Histogram) {
 	if other == nil {
 		return
@@ -75,6 +98,19 @@ func (h *Histogram) Merge(other *Histogram) {
 	h.Sum += other.Sum
 }
 
+// diskUsedPercent returns the percentage of the volume holding dir that is in
+// use, counting the space reserved for root as used.
+func diskUsedPercent(dir string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	if st.Blocks == 0 {
+		return 0, nil
+	}
+	return 100 * float64(st.Blocks-st.Bavail) / float64(st.Blocks), nil
+}
+
 // --- Internal Storage (RRD) ---
 
 // ResolutionConfig defines the policy for a single RRD bucket set.
@@ -233,11 +269,21 @@ func (hs *HistogramSeries) Ingest(timestamp int64, h *Histogram) {
 }
 
 // MetricsStore is the top-level container in the FSM.
+// It is persisted in the compact format of metricsFile, see MarshalJSON.
 type MetricsStore struct {
 	NodeMetrics    map[string]*MetricSeries    `json:"nodes"`
 	NodeLatencies  map[string]*HistogramSeries `json:"latencies"`
 	ClusterMetrics map[string]*MetricSeries    `json:"cluster"`
 	LastUpdate     int64                       `json:"lastUpdate"`
+
+	// NodeTotals is the last cumulative request count reported by each node,
+	// so that a new leader can compute request rates without a gap.
+	NodeTotals map[string]uint64 `json:"totals,omitempty"`
+	// NodeDisk is the last disk usage percentage reported by each node.
+	NodeDisk map[string]float64 `json:"disk,omitempty"`
+	// AppliedIndex is the Raft index of the last applied CmdMetricsUpdate.
+	// Entries at or below it are already included when replayed after a restart.
+	AppliedIndex uint64 `json:"index,omitempty"`
 }
 
 func NewMetricsStore() *MetricsStore {
@@ -333,3 +379,307 @@ func (s *MetricsStore) ToJSON() map[string]interface{} {
 		"lastUpdate": s.LastUpdate,
 	}
 }
+
+// --- Range Queries ---
+
+// MetricsRange is the result of MetricsStore.Query: the points of every series
+// within [From, To] at a single resolution, sorted by time.
+type MetricsRange struct {
+	From       int64                         `json:"from"`
+	To         int64                         `json:"to"`
+	Resolution string                        `json:"resolution"`
+	Nodes      map[string][]Point[float64]   `json:"nodes"`
+	Latencies  map[string][]Point[Histogram] `json:"latencies"`
+	Cluster    map[string][]Point[float64]   `json:"cluster"`
+}
+
+// ResolutionFor returns the finest resolution whose retention reaches back to
+// from, or the coarsest resolution if none does.
+func ResolutionFor(from int64, now time.Time) string {
+	for _, cfg := range DefaultResolutions {
+		if now.Add(-cfg.Retention).Unix() <= from {
+			return cfg.Name
+		}
+	}
+	return DefaultResolutions[len(DefaultResolutions)-1].Name
+}
+
+// Range returns the points with from <= timestamp <= to, sorted by time.
+func (rb *RingBuffer[T]) Range(from, to int64) []Point[T] {
+	var points []Point[T]
+	for _, p := range rb.GetPoints() {
+		if p.Timestamp >= from && p.Timestamp <= to {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// Query returns all series at resolution res between from and to (Unix seconds).
+func (s *MetricsStore) Query(from, to int64, res string) (*MetricsRange, error) {
+	if !isResolution(res) {
+		return nil, fmt.Errorf("unknown resolution %q", res)
+	}
+	out := &MetricsRange{
+		From:       from,
+		To:         to,
+		Resolution: res,
+		Nodes:      make(map[string][]Point[float64]),
+		Latencies:  make(map[string][]Point[Histogram]),
+		Cluster:    make(map[string][]Point[float64]),
+	}
+	for name, series := range s.NodeMetrics {
+		if buf, ok := series.Buffers[res]; ok {
+			out.Nodes[name] = buf.Range(from, to)
+		}
+	}
+	for name, series := range s.NodeLatencies {
+		if buf, ok := series.Buffers[res]; ok {
+			out.Latencies[name] = buf.Range(from, to)
+		}
+	}
+	for name, series := range s.ClusterMetrics {
+		if buf, ok := series.Buffers[res]; ok {
+			out.Cluster[name] = buf.Range(from, to)
+		}
+	}
+	return out, nil
+}
+
+func isResolution(name string) bool {
+	for _, cfg := range DefaultResolutions {
+		if cfg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Persistence ---
+
+// metricsFileVersion identifies the compact format. Files without a version
+// are the legacy format, a plain JSON dump of the ring buffers.
+const metricsFileVersion = 2
+
+// metricsFile is the persisted form of MetricsStore, in metrics.json and in
+// snapshots. Only non-empty points are stored, timestamps are delta-encoded,
+// and histograms only keep their non-empty buckets, which makes it about a
+// quarter of the size of the legacy format before compression.
+type metricsFile struct {
+	Version      int                         `json:"v"`
+	AppliedIndex uint64                      `json:"index,omitempty"`
+	LastUpdate   int64                       `json:"lastUpdate"`
+	NodeTotals   map[string]uint64           `json:"totals,omitempty"`
+	NodeDisk     map[string]float64          `json:"disk,omitempty"`
+	Nodes        map[string]compactSeries    `json:"nodes,omitempty"`
+	Latencies    map[string]compactHistories `json:"latencies,omitempty"`
+	Cluster      map[string]compactSeries    `json:"cluster,omitempty"`
+}
+
+// compactSeries is a MetricSeries; Points is keyed by resolution name.
+type compactSeries struct {
+	AggregationType string                   `json:"agg,omitempty"`
+	Points          map[string]compactPoints `json:"r"`
+}
+
+// compactPoints holds the points of one ring buffer, oldest first.
+// T[0] is a Unix timestamp and T[i] the seconds since the previous point.
+type compactPoints struct {
+	T []int64   `json:"t"`
+	V []float64 `json:"v"`
+}
+
+type compactHistories struct {
+	Points map[string]compactHistPoints `json:"r"`
+}
+
+type compactHistPoints struct {
+	T []int64            `json:"t"`
+	H []compactHistogram `json:"h"`
+}
+
+// compactHistogram is a Histogram with only its non-empty buckets, as
+// index/count pairs in B.
+type compactHistogram struct {
+	C uint64   `json:"c"`
+	S float64  `json:"s"`
+	B []uint64 `json:"b,omitempty"`
+}
+
+func deltaEncode[T any](points []Point[T]) []int64 {
+	ts := make([]int64, len(points))
+	var prev int64
+	for i, p := range points {
+		ts[i] = p.Timestamp - prev
+		prev = p.Timestamp
+	}
+	return ts
+}
+
+func deltaDecode(ts []int64) []int64 {
+	out := make([]int64, len(ts))
+	var prev int64
+	for i, d := range ts {
+		prev += d
+		out[i] = prev
+	}
+	return out
+}
+
+func compactScalars(series map[string]*MetricSeries) map[string]compactSeries {
+	out := make(map[string]compactSeries, len(series))
+	for name, ms := range series {
+		cs := compactSeries{AggregationType: ms.AggregationType, Points: make(map[string]compactPoints)}
+		for res, buf := range ms.Buffers {
+			points := buf.GetPoints()
+			if len(points) == 0 {
+				continue
+			}
+			cp := compactPoints{T: deltaEncode(points), V: make([]float64, len(points))}
+			for i, p := range points {
+				cp.V[i] = p.Value
+			}
+			cs.Points[res] = cp
+		}
+		out[name] = cs
+	}
+	return out
+}
+
+func expandScalars(series map[string]compactSeries, seriesName func(string) string) (map[string]*MetricSeries, error) {
+	out := make(map[string]*MetricSeries, len(series))
+	for name, cs := range series {
+		ms := NewMetricSeries(seriesName(name), cs.AggregationType)
+		for res, cp := range cs.Points {
+			buf, ok := ms.Buffers[res]
+			if !ok {
+				continue // Resolution no longer configured
+			}
+			if len(cp.T) != len(cp.V) {
+				return nil, fmt.Errorf("series %s/%s: %d timestamps, %d values", name, res, len(cp.T), len(cp.V))
+			}
+			for i, ts := range deltaDecode(cp.T) {
+				buf.Add(ts, cp.V[i])
+			}
+		}
+		out[name] = ms
+	}
+	return out, nil
+}
+
+func compactLatencies(series map[string]*HistogramSeries) map[string]compactHistories {
+	out := make(map[string]compactHistories, len(series))
+	for name, hs := range series {
+		ch := compactHistories{Points: make(map[string]compactHistPoints)}
+		for res, buf := range hs.Buffers {
+			points := buf.GetPoints()
+			if len(points) == 0 {
+				continue
+			}
+			cp := compactHistPoints{T: deltaEncode(points), H: make([]compactHistogram, len(points))}
+			for i, p := range points {
+				h := compactHistogram{C: p.Value.Count, S: p.Value.Sum}
+				for b, n := range p.Value.Buckets {
+					if n > 0 {
+						h.B = append(h.B, uint64(b), n)
+					}
+				}
+				cp.H[i] = h
+			}
+			ch.Points[res] = cp
+		}
+		out[name] = ch
+	}
+	return out
+}
+
+func expandLatencies(series map[string]compactHistories) (map[string]*HistogramSeries, error) {
+	out := make(map[string]*HistogramSeries, len(series))
+	for name, ch := range series {
+		hs := NewHistogramSeries("node:" + name + ":latency")
+		for res, cp := range ch.Points {
+			buf, ok := hs.Buffers[res]
+			if !ok {
+				continue
+			}
+			if len(cp.T) != len(cp.H) {
+				return nil, fmt.Errorf("latency %s/%s: %d timestamps, %d histograms", name, res, len(cp.T), len(cp.H))
+			}
+			for i, ts := range deltaDecode(cp.T) {
+				ch := cp.H[i]
+				if len(ch.B)%2 != 0 {
+					return nil, fmt.Errorf("latency %s/%s: odd bucket list", name, res)
+				}
+				h := Histogram{Count: ch.C, Sum: ch.S}
+				for j := 0; j < len(ch.B); j += 2 {
+					if ch.B[j] >= LatencyBuckets {
+						return nil, fmt.Errorf("latency %s/%s: bucket %d out of range", name, res, ch.B[j])
+					}
+					h.Buckets[ch.B[j]] = ch.B[j+1]
+				}
+				buf.Add(ts, h)
+			}
+		}
+		out[name] = hs
+	}
+	return out, nil
+}
+
+// MarshalJSON encodes the store in the compact metricsFile format.
+// The API uses ToJSON instead, which keeps the ring buffer layout.
+func (s *MetricsStore) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricsFile{
+		Version:      metricsFileVersion,
+		AppliedIndex: s.AppliedIndex,
+		LastUpdate:   s.LastUpdate,
+		NodeTotals:   s.NodeTotals,
+		NodeDisk:     s.NodeDisk,
+		Nodes:        compactScalars(s.NodeMetrics),
+		Latencies:    compactLatencies(s.NodeLatencies),
+		Cluster:      compactScalars(s.ClusterMetrics),
+	})
+}
+
+// UnmarshalJSON decodes both the compact and the legacy format.
+func (s *MetricsStore) UnmarshalJSON(data []byte) error {
+	var f metricsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if f.Version == 0 {
+		type legacy MetricsStore // Without the custom methods
+		var l legacy
+		if err := json.Unmarshal(data, &l); err != nil {
+			return err
+		}
+		*s = MetricsStore(l)
+		s.Hydrate()
+		return nil
+	}
+	if f.Version > metricsFileVersion {
+		return fmt.Errorf("unsupported metrics format version %d", f.Version)
+	}
+	nodes, err := expandScalars(f.Nodes, func(id string) string { return "node:" + id + ":rps" })
+	if err != nil {
+		return err
+	}
+	latencies, err := expandLatencies(f.Latencies)
+	if err != nil {
+		return err
+	}
+	cluster, err := expandScalars(f.Cluster, func(name string) string { return "cluster:" + name })
+	if err != nil {
+		return err
+	}
+	*s = MetricsStore{
+		NodeMetrics:    nodes,
+		NodeLatencies:  latencies,
+		ClusterMetrics: cluster,
+		LastUpdate:     f.LastUpdate,
+		NodeTotals:     f.NodeTotals,
+		NodeDisk:       f.NodeDisk,
+		AppliedIndex:   f.AppliedIndex,
+	}
+	s.Hydrate()
+	return nil
+}
@@ -535,3 +535,167 @@ func TestHistogramSeries_Ingest(t *testing.T) {
 		t.Fatalf("Expected 1 point with count 2")
 	}
 }
+
+// fillMetrics ingests one update per minute for the given duration, ending at end.
+func fillMetrics(s *MetricsStore, end time.Time, d time.Duration) {
+	for ts := end.Add(-d).Unix(); ts <= end.Unix(); ts += 60 {
+		s.GetNodeSeries("n1").Ingest(ts, float64(ts%7))
+		h := &Histogram{}
+		h.Add(time.Duration(ts%300) * time.Millisecond)
+		s.GetNodeLatencySeries("n1").Ingest(ts, h)
+		s.GetClusterSeries("leaderGapMs").Ingest(ts, 1)
+		s.LastUpdate = ts
+	}
+}
+
+func TestMetricsStore_CompactRoundTrip(t *testing.T) {
+	end := time.Unix(1_800_000_000, 0)
+	s := NewMetricsStore()
+	s.AppliedIndex = 42
+	s.NodeTotals = map[string]uint64{"n1": 1234}
+	fillMetrics(s, end, 8*24*time.Hour)
+
+	compact, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	type legacy MetricsStore
+	old, _ := json.Marshal((*legacy)(s))
+	if len(compact)*3 > len(old) {
+		t.Errorf("compact format is %d bytes, legacy %d", len(compact), len(old))
+	}
+
+	for name, data := range map[string][]byte{"compact": compact, "legacy": old} {
+		var got MetricsStore
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: Unmarshal: %v", name, err)
+		}
+		if got.AppliedIndex != 42 || got.NodeTotals["n1"] != 1234 || got.LastUpdate != s.LastUpdate {
+			t.Errorf("%s: header mismatch: index %d totals %v lastUpdate %d", name, got.AppliedIndex, got.NodeTotals, got.LastUpdate)
+		}
+		for _, cfg := range DefaultResolutions {
+			want := s.NodeMetrics["n1"].Buffers[cfg.Name].GetPoints()
+			if p := got.NodeMetrics["n1"].Buffers[cfg.Name].GetPoints(); fmt.Sprint(p) != fmt.Sprint(want) {
+				t.Errorf("%s: %s node points differ", name, cfg.Name)
+			}
+			wantH := s.NodeLatencies["n1"].Buffers[cfg.Name].GetPoints()
+			if p := got.NodeLatencies["n1"].Buffers[cfg.Name].GetPoints(); fmt.Sprint(p) != fmt.Sprint(wantH) {
+				t.Errorf("%s: %s latency points differ", name, cfg.Name)
+			}
+		}
+		if agg := got.ClusterMetrics["leaderGapMs"].AggregationType; agg != "Sum" {
+			t.Errorf("%s: aggregation type = %q", name, agg)
+		}
+	}
+}
+
+func TestFSM_MetricsPersistAndReplay(t *testing.T) {
+	tmpDir := t.TempDir()
+	s := storage.New(tmpDir, nil)
+	newFSM := func() *FSM {
+		gs := NewGameStore(tmpDir, s)
+		ts := NewTeamStore(tmpDir, s)
+		us := NewUserIndexStore(tmpDir, s, nil)
+		return NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), s, us)
+	}
+	apply := func(f *FSM, index uint64, ts int64) {
+		cmd, _ := json.Marshal(RaftCommand{Type: CmdMetricsUpdate, MetricsPayload: &MetricsPayload{
+			Timestamp: ts,
+			Nodes:     []NodeMetric{{NodeID: "n1", RPS: 1, Total: 100 * index}},
+			Cluster:   &ClusterMetric{LeaderGapMS: 10},
+		}})
+		f.ApplyBatch([]*raft.Log{{Index: index, Type: raft.LogCommand, Data: cmd}})
+	}
+
+	base := int64(1_800_000_000) // Aligned to 5 minutes
+	fsm := newFSM()
+	for i := uint64(1); i <= 7; i++ {
+		apply(fsm, i, base+int64(i-1)*60) // Crosses a 5-minute boundary at index 6
+	}
+
+	// A restart loads the history written at index 6 and replays the log.
+	fsm2 := newFSM()
+	if got := fsm2.metrics.AppliedIndex; got != 6 {
+		t.Fatalf("persisted AppliedIndex = %d, want 6", got)
+	}
+	if total, ok := fsm2.LastNodeTotal("n1"); !ok || total != 600 {
+		t.Errorf("LastNodeTotal = %d, %v", total, ok)
+	}
+	for i := uint64(1); i <= 7; i++ {
+		apply(fsm2, i, base+int64(i-1)*60)
+	}
+	sum := func(f *FSM) float64 {
+		var total float64
+		for _, p := range f.metrics.ClusterMetrics["leaderGapMs"].Buffers["1h"].GetPoints() {
+			total += p.Value
+		}
+		return total
+	}
+	if got, want := sum(fsm2), sum(fsm); got != want || want != 70 {
+		t.Errorf("replayed leaderGapMs sum = %v, want %v (70)", got, want)
+	}
+}
+
+func TestMetricsQueryRange(t *testing.T) {
+	tmpDir := t.TempDir()
+	s := storage.New(tmpDir, nil)
+	gs := NewGameStore(tmpDir, s)
+	ts := NewTeamStore(tmpDir, s)
+	us := NewUserIndexStore(tmpDir, s, nil)
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), s, us)
+	now := time.Now()
+	fillMetrics(fsm.metrics, now, 3*time.Hour)
+	rm := &RaftManager{FSM: fsm}
+
+	query := func(params string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		rm.handleMetricsQuery(w, httptest.NewRequest("GET", "/api/cluster/metrics?"+params, nil))
+		return w
+	}
+
+	from := now.Add(-30 * time.Minute).Unix()
+	w := query(fmt.Sprintf("from=%d&to=%d", from, now.Unix()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("query: %d %s", w.Code, w.Body.String())
+	}
+	var got MetricsRange
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Resolution != "1m" {
+		t.Errorf("resolution = %q, want 1m", got.Resolution)
+	}
+	points := got.Nodes["n1"]
+	if len(points) < 30 || len(points) > 31 || points[0].Timestamp < from {
+		t.Errorf("unexpected 1m points: %d from %d", len(points), points[0].Timestamp)
+	}
+	if len(got.Latencies["n1"]) != len(points) || len(got.Cluster["leaderGapMs"]) != len(points) {
+		t.Errorf("series lengths differ: %d %d %d", len(points), len(got.Latencies["n1"]), len(got.Cluster["leaderGapMs"]))
+	}
+
+	// Older ranges select a coarser resolution automatically.
+	w = query(fmt.Sprintf("from=%d", now.Add(-10*24*time.Hour).Unix()))
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Resolution != "1h" || len(got.Nodes["n1"]) < 3 {
+		t.Errorf("resolution = %q with %d points, want 1h", got.Resolution, len(got.Nodes["n1"]))
+	}
+
+	w = query("resolution=1d&to=" + now.UTC().Format(time.RFC3339))
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Resolution != "1d" || got.To-got.From != int64((183*24*time.Hour).Seconds()) {
+		t.Errorf("resolution = %q, range %d..%d", got.Resolution, got.From, got.To)
+	}
+
+	for _, bad := range []string{"from=abc", "resolution=2m", fmt.Sprintf("from=%d&to=%d", now.Unix(), from)} {
+		if w := query(bad); w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: %d, want 400", bad, w.Code)
+		}
+	}
+
+	// Without parameters, the full store is returned as before.
+	w = query("")
+	var full map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &full); err != nil || full["nodes"] == nil || full["lastUpdate"] == nil {
+		t.Errorf("unexpected full response: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// requestLatencyBounds are the /metrics bucket bounds (seconds) for HTTP latency.
+// Each is a multiple of LatencyBucketSize so they map onto Histogram buckets exactly.
+var requestLatencyBounds = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// globalLatency accumulates HTTP request latencies since process start.
+// Unlike RaftManager.latencyAccumulator it is never reset, as Prometheus expects.
+var globalLatency struct {
+	sync.Mutex
+	h Histogram
+}
+
+// durationHistogram is a cumulative histogram with exponential bounds,
+// for operations much faster than HTTP requests (FSM apply, snapshots).
+type durationHistogram struct {
+	mu     sync.Mutex
+	bounds []float64 // Upper bounds in seconds
+	counts []uint64  // counts[i] observations <= bounds[i]; last is +Inf
+	sum    float64
+	count  uint64
+}
+
+func newDurationHistogram(bounds ...float64) *durationHistogram {
+	return &durationHistogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe records one duration.
+func (h *durationHistogram) Observe(d time.Duration) {
+	s := d.Seconds()
+	i := sort.SearchFloat64s(h.bounds, s)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.sum += s
+	h.count++
+}
+
+// cumulative returns the cumulative bucket counts, ending with +Inf.
+func (h *durationHistogram) cumulative() (counts []uint64, sum float64, count uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts = make([]uint64, len(h.counts))
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		counts[i] = total
+	}
+	return counts, h.sum, h.count
+}
+
+// cumulative returns the counts of h at the given bounds (seconds), ending with +Inf.
+func (h *Histogram) cumulative(bounds []float64) []uint64 {
+	counts := make([]uint64, 0, len(bounds)+1)
+	var total uint64
+	next := 0
+	for _, b := range bounds {
+		limit := int(math.Round(b * float64(time.Second) / float64(LatencyBucketSize)))
+		for ; next < limit && next < LatencyBuckets; next++ {
+			total += h.Buckets[next]
+		}
+		counts = append(counts, total)
+	}
+	return append(counts, h.Count)
+}
+
+// openMetricsWriter renders metric families in the OpenMetrics text format.
+type openMetricsWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *openMetricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(&w.buf, "# TYPE %s %s\n# HELP %s %s\n", name, typ, name, help)
+}
+
+func (w *openMetricsWriter) sample(name string, labels []string, value float64) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, "%s=%s", labels[i], strconv.Quote(labels[i+1]))
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.buf.WriteByte('\n')
+}
+
+// gauge writes a single-sample gauge. labels are name/value pairs.
+func (w *openMetricsWriter) gauge(name, help string, value float64, labels ...string) {
+	w.header(name, "gauge", help)
+	w.sample(name, labels, value)
+}
+
+// counter writes a single-sample counter. name excludes the _total suffix.
+func (w *openMetricsWriter) counter(name, help string, value float64) {
+	w.header(name, "counter", help)
+	w.sample(name+"_total", nil, value)
+}
+
+// histogram writes a histogram from cumulative counts; counts has one more entry (+Inf) than bounds.
+func (w *openMetricsWriter) histogram(name, help string, bounds []float64, counts []uint64, sum float64) {
+	w.header(name, "histogram", help)
+	for i, b := range bounds {
+		w.sample(name+"_bucket", []string{"le", strconv.FormatFloat(b, 'g', -1, 64)}, float64(counts[i]))
+	}
+	w.sample(name+"_bucket", []string{"le", "+Inf"}, float64(counts[len(bounds)]))
+	w.sample(name+"_sum", nil, sum)
+	w.sample(name+"_count", nil, float64(counts[len(bounds)]))
+}
+
+func (w *openMetricsWriter) durationHistogram(name, help string, h *durationHistogram) {
+	counts, sum, _ := h.cumulative()
+	w.histogram(name, help, h.bounds, counts, sum)
+}
+
+// newMetricsHandler serves /metrics for this node in the OpenMetrics text format.
+// rm is nil in standalone mode. If token is set, scrapers must send it as a bearer token.
+func newMetricsHandler(rm *RaftManager, rep *Replica, hm *HubManager, gs *GameStore, ts *TeamStore, r *Registry, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" {
+			got, _ := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var m openMetricsWriter
+
+		// HTTP & WebSocket
+		m.counter("skorekeeper_http_requests", "HTTP requests received.", float64(GlobalRequestCounter.Load()))
+		globalLatency.Lock()
+		latency := globalLatency.h
+		globalLatency.Unlock()
+		m.histogram("skorekeeper_http_request_duration_seconds", "HTTP request latency, excluding WebSocket sessions.",
+			requestLatencyBounds, latency.cumulative(requestLatencyBounds), latency.Sum/1000)
+		m.gauge("skorekeeper_websocket_connections", "Open WebSocket connections.", float64(hm.GetTotalConnectionCount()))
+		games, teams := hm.HubCounts()
+		m.header("skorekeeper_hubs", "gauge", "Active hubs by resource kind.")
+		m.sample("skorekeeper_hubs", []string{"kind", "game"}, float64(games))
+		m.sample("skorekeeper_hubs", []string{"kind", "team"}, float64(teams))
+
+		// Stores
+		m.header("skorekeeper_store_cache_requests", "counter", "Game and team store reads by cache result.")
+		for _, s := range []struct {
+			store        string
+			hits, misses uint64
+		}{
+			{"games", gs.cacheHits.Load(), gs.cacheMisses.Load()},
+			{"teams", ts.cacheHits.Load(), ts.cacheMisses.Load()},
+		} {
+			m.sample("skorekeeper_store_cache_requests_total", []string{"store", s.store, "result", "hit"}, float64(s.hits))
+			m.sample("skorekeeper_store_cache_requests_total", []string{"store", s.store, "result", "miss"}, float64(s.misses))
+		}
+		m.gauge("skorekeeper_games", "Games on this node, excluding deleted games.", float64(r.CountTotalGames()))
+		m.gauge("skorekeeper_teams", "Teams on this node, excluding deleted teams.", float64(r.CountTotalTeams()))
+
+		// Raft & FSM
+		if rm != nil && rm.Raft != nil {
+			stats := rm.Raft.Stats()
+			stat := func(key string) float64 {
+				v, _ := strconv.ParseFloat(stats[key], 64)
+				return v
+			}
+			m.header("skorekeeper_raft_state", "stateset", "Raft state of this node.")
+			current := rm.Raft.State()
+			for _, s := range []raft.RaftState{raft.Follower, raft.Candidate, raft.Leader, raft.Shutdown} {
+				v := 0.0
+				if s == current {
+					v = 1
+				}
+				m.sample("skorekeeper_raft_state", []string{"skorekeeper_raft_state", s.String()}, v)
+			}
+			m.gauge("skorekeeper_raft_term", "Current Raft term.", stat("term"))
+			m.gauge("skorekeeper_raft_commit_index", "Highest log index known to be committed.", stat("commit_index"))
+			m.gauge("skorekeeper_raft_applied_index", "Highest log index applied to the FSM.", stat("applied_index"))
+			m.gauge("skorekeeper_raft_last_log_index", "Highest log index stored on this node.", stat("last_log_index"))
+			m.gauge("skorekeeper_raft_last_snapshot_index", "Log index of the latest snapshot.", stat("last_snapshot_index"))
+			m.gauge("skorekeeper_raft_fsm_pending", "Committed entries waiting to be applied.", stat("fsm_pending"))
+			if rm.FSM != nil {
+				m.gauge("skorekeeper_cluster_nodes", "Nodes in the cluster membership.", float64(rm.FSM.GetNodeCount()))
+				m.durationHistogram("skorekeeper_fsm_apply_duration_seconds", "Time to apply a Raft log entry or batch to the FSM.", rm.FSM.applyLatency)
+				m.durationHistogram("skorekeeper_fsm_snapshot_duration_seconds", "Time to persist an FSM snapshot.", rm.FSM.snapshotLatency)
+				m.durationHistogram("skorekeeper_fsm_restore_duration_seconds", "Time to restore the FSM from a snapshot.", rm.FSM.restoreLatency)
+			}
+		}
+
+		// Read replica
+		if rep != nil {
+			s := rep.Status()
+			m.gauge("skorekeeper_replica_applied_index", "Highest log index applied by this read replica.", float64(s.AppliedIndex))
+			m.gauge("skorekeeper_replica_lag_entries", "Log entries this read replica is behind the node it tails.", float64(s.LagEntries))
+			m.gauge("skorekeeper_replica_lag_seconds", "Time since this read replica was last caught up.", s.LagSeconds)
+		}
+
+		m.buf.WriteString("# EOF\n")
+		w.Header().Set("Content-Type", openMetricsContentType)
+		w.Write(m.buf.Bytes())
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+)
+
+func TestOpenMetricsEndpoint(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gStore := NewGameStore(tempDir, s)
+	tStore := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gStore, tStore, us, true)
+
+	_, _, handler := NewServerHandler(Options{
+		GameStore:      gStore,
+		TeamStore:      tStore,
+		Storage:        s,
+		Registry:       reg,
+		UserIndexStore: us,
+		UseMockAuth:    true,
+		MetricsToken:   "scrape-secret",
+	})
+
+	game := Game{ID: "88888888-0000-4000-8000-000000000008", OwnerID: "owner@example.com"}
+	if err := gStore.SaveGame(&game); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	reg.UpdateGame(game)
+	gStore.LoadGame(game.ID)
+	tStore.LoadTeam("missing-team")
+
+	scrape := func(auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := scrape(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("scrape without token: got %d, want 401", w.Code)
+	}
+	if w := scrape("Bearer wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("scrape with wrong token: got %d, want 401", w.Code)
+	}
+
+	w := scrape("Bearer scrape-secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("scrape: %d %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != openMetricsContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := w.Body.String()
+	if !strings.HasSuffix(body, "\n# EOF\n") {
+		t.Errorf("missing # EOF terminator:\n%s", body)
+	}
+	for _, want := range []string{
+		"# TYPE skorekeeper_http_requests counter\n",
+		"# TYPE skorekeeper_http_request_duration_seconds histogram\n",
+		`skorekeeper_http_request_duration_seconds_bucket{le="+Inf"} `,
+		"skorekeeper_websocket_connections 0\n",
+		`skorekeeper_hubs{kind="game"} 0` + "\n",
+		`skorekeeper_store_cache_requests_total{store="games",result="hit"} 1` + "\n",
+		`skorekeeper_store_cache_requests_total{store="teams",result="miss"} 1` + "\n",
+		"skorekeeper_games 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "skorekeeper_raft_") {
+		t.Errorf("standalone node should not export Raft metrics:\n%s", body)
+	}
+}
+
+func TestHistogramCumulative(t *testing.T) {
+	var h Histogram
+	h.Add(10 * time.Millisecond)
+	h.Add(60 * time.Millisecond)
+	h.Add(300 * time.Millisecond)
+	h.Add(10 * time.Second)
+
+	got := h.cumulative([]float64{0.05, 0.1, 0.25, 5})
+	if want := []uint64{1, 2, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cumulative = %v, want %v", got, want)
+	}
+
+	d := newDurationHistogram(.001, .01)
+	d.Observe(500 * time.Microsecond)
+	d.Observe(time.Millisecond)
+	d.Observe(time.Second)
+	counts, _, count := d.cumulative()
+	if want := []uint64{2, 2, 3}; !reflect.DeepEqual(counts, want) || count != 3 {
+		t.Errorf("durationHistogram cumulative = %v (%d), want %v", counts, count, want)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// OwnershipTransfer is a pending offer to hand a game or team to another user.
+type OwnershipTransfer struct {
+	Kind     string `json:"kind"` // "game" or "team"
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	OwnerID  string `json:"ownerId"`
+	NewOwner string `json:"newOwner"`
+}
+
+// acceptGameTransfer makes the pending owner the owner of g.
+// The previous owner stays on as an admin, which the new owner can revoke.
+func acceptGameTransfer(g *Game) {
+	prev := g.OwnerID
+	g.OwnerID = normalizeEmail(g.PendingOwner)
+	g.PendingOwner = ""
+	if g.Permissions.Users == nil {
+		g.Permissions.Users = make(map[string]string)
+	}
+	for u := range g.Permissions.Users {
+		if normalizeEmail(u) == g.OwnerID {
+			delete(g.Permissions.Users, u)
+		}
+	}
+	g.Permissions.Users[prev] = "admin"
+}
+
+// acceptTeamTransfer makes the pending owner the owner of t.
+// The previous owner stays on as an admin, which the new owner can revoke.
+func acceptTeamTransfer(t *Team) {
+	prev := t.OwnerID
+	t.OwnerID = normalizeEmail(t.PendingOwner)
+	t.PendingOwner = ""
+	isNewOwner := func(u string) bool { return normalizeEmail(u) == t.OwnerID }
+	t.Roles.Admins = slices.DeleteFunc(t.Roles.Admins, isNewOwner)
+	t.Roles.Scorekeepers = slices.DeleteFunc(t.Roles.Scorekeepers, isNewOwner)
+	t.Roles.Spectators = slices.DeleteFunc(t.Roles.Spectators, isNewOwner)
+	if !slices.ContainsFunc(t.Roles.Admins, func(u string) bool { return normalizeEmail(u) == normalizeEmail(prev) }) {
+		t.Roles.Admins = append(t.Roles.Admins, prev)
+	}
+}
+
+// gameTransfer returns the pending transfer of a game, or nil.
+func gameTransfer(g GameMetadata) *OwnershipTransfer {
+	if g.PendingOwner == "" || g.Status == "deleted" {
+		return nil
+	}
+	return &OwnershipTransfer{
+		Kind:     "game",
+		ID:       g.ID,
+		Name:     fmt.Sprintf("%s @ %s", g.Away, g.Home),
+		OwnerID:  g.OwnerID,
+		NewOwner: g.PendingOwner,
+	}
+}
+
+// teamTransfer returns the pending transfer of a team, or nil.
+func teamTransfer(t TeamMetadata) *OwnershipTransfer {
+	if t.PendingOwner == "" || t.Status == "deleted" {
+		return nil
+	}
+	return &OwnershipTransfer{
+		Kind:     "team",
+		ID:       t.ID,
+		Name:     t.Name,
+		OwnerID:  t.OwnerID,
+		NewOwner: t.PendingOwner,
+	}
+}
+
+// indexTransfer replaces the pending transfer prev of a game or team with
+// next in the indices of the users involved, so transfers can be listed
+// without scanning metadata. It reports whether anything changed.
+func (r *Registry) indexTransfer(prev, next *OwnershipTransfer) bool {
+	if prev == nil && next == nil || prev != nil && next != nil && *prev == *next {
+		return false
+	}
+	if prev != nil {
+		r.setUserTransfer(prev.NewOwner, true, prev.ID, nil)
+		r.setUserTransfer(prev.OwnerID, false, prev.ID, nil)
+	}
+	if next != nil {
+		r.setUserTransfer(next.NewOwner, true, next.ID, next)
+		r.setUserTransfer(next.OwnerID, false, next.ID, next)
+	}
+	return true
+}
+
+// setUserTransfer records or, if tr is nil, removes a transfer of the game or
+// team id in a user's incoming or outgoing offers.
+func (r *Registry) setUserTransfer(userId string, incoming bool, id string, tr *OwnershipTransfer) {
+	if userId == "" {
+		return
+	}
+	idx, err := r.userStore.GetUserIndex(normalizeEmail(userId))
+	if err != nil {
+		storeLog.Error("registry: loading user index for transfers", "err", err)
+		return
+	}
+	m := &idx.OutgoingTransfers
+	if incoming {
+		m = &idx.IncomingTransfers
+	}
+	if tr == nil {
+		if _, ok := (*m)[id]; !ok {
+			return
+		}
+		delete(*m, id)
+	} else {
+		if *m == nil {
+			*m = make(map[string]OwnershipTransfer)
+		}
+		(*m)[id] = *tr
+	}
+	r.userStore.SetUserIndex(idx)
+}
+
+// listTransfers returns the pending transfers offered to a user (incoming)
+// and by a user (outgoing), games first.
+func (r *Registry) listTransfers(userId string) (incoming, outgoing []OwnershipTransfer, err error) {
+	idx, err := r.userStore.GetUserIndex(normalizeEmail(userId))
+	if err != nil {
+		return nil, nil, err
+	}
+	sorted := func(m map[string]OwnershipTransfer) []OwnershipTransfer {
+		out := slices.AppendSeq(make([]OwnershipTransfer, 0, len(m)), maps.Values(m))
+		slices.SortFunc(out, func(a, b OwnershipTransfer) int {
+			return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.ID, b.ID))
+		})
+		return out
+	}
+	return sorted(idx.IncomingTransfers), sorted(idx.OutgoingTransfers), nil
+}
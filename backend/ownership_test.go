@@ -0,0 +1,165 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c2FmZQ/storage"
+)
+
+func TestOwnershipTransfer(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gStore := NewGameStore(tempDir, s)
+	tStore := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gStore, tStore, us, true)
+
+	_, _, handler := NewServerHandler(Options{
+		GameStore:      gStore,
+		TeamStore:      tStore,
+		Storage:        s,
+		Registry:       reg,
+		UserIndexStore: us,
+		UseMockAuth:    true,
+	})
+
+	oldCoach := "old@example.com"
+	newCoach := "new@example.com"
+	stranger := "stranger@example.com"
+	teamId := "88888888-0000-4000-8000-000000000008"
+	gameId := "99999999-0000-4000-8000-000000000009"
+
+	team := Team{ID: teamId, Name: "Sharks", OwnerID: oldCoach, Roles: TeamRoles{Scorekeepers: []string{newCoach}}}
+	if err := tStore.SaveTeam(&team); err != nil {
+		t.Fatalf("SaveTeam: %v", err)
+	}
+	reg.UpdateTeam(team)
+	game := Game{ID: gameId, Away: "Sharks", Home: "Jets", OwnerID: oldCoach}
+	if err := gStore.SaveGame(&game); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	reg.UpdateGame(game)
+
+	do := func(method, url, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	offer := `{"newOwner":"New@Example.com"}`
+
+	if w := do("POST", "/api/teams/"+teamId+"/transfer", newCoach, offer); w.Code != http.StatusForbidden {
+		t.Errorf("offer by non-owner: expected 403, got %d", w.Code)
+	}
+	if w := do("POST", "/api/teams/"+teamId+"/transfer", oldCoach, offer); w.Code != http.StatusOK {
+		t.Fatalf("offer team: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/games/"+gameId+"/transfer", oldCoach, offer); w.Code != http.StatusOK {
+		t.Fatalf("offer game: %d %s", w.Code, w.Body.String())
+	}
+
+	transfers := func(user string) (incoming, outgoing []OwnershipTransfer) {
+		t.Helper()
+		w := do("GET", "/api/transfers", user, "")
+		var lists struct {
+			Incoming []OwnershipTransfer `json:"incoming"`
+			Outgoing []OwnershipTransfer `json:"outgoing"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &lists); err != nil {
+			t.Fatalf("GET /api/transfers: %d %s", w.Code, w.Body.String())
+		}
+		return lists.Incoming, lists.Outgoing
+	}
+	if in, out := transfers(newCoach); len(in) != 2 || len(out) != 0 || in[0].Kind != "game" || in[1].Name != "Sharks" {
+		t.Fatalf("unexpected transfers for recipient: %+v %+v", in, out)
+	}
+	if in, out := transfers(oldCoach); len(in) != 0 || len(out) != 2 || out[0].NewOwner != newCoach {
+		t.Fatalf("unexpected transfers for owner: %+v %+v", in, out)
+	}
+
+	// Saving the team does not clear the pending offer.
+	body, _ := json.Marshal(Team{ID: teamId, Name: "Sharks II", OwnerID: stranger})
+	if w := do("POST", "/api/save-team", oldCoach, string(body)); w.Code != http.StatusOK {
+		t.Fatalf("save-team: %d %s", w.Code, w.Body.String())
+	}
+	if tm, _ := tStore.LoadTeam(teamId); tm.PendingOwner != newCoach || tm.OwnerID != oldCoach {
+		t.Fatalf("save-team changed ownership state: %+v", tm)
+	}
+	if in, _ := transfers(newCoach); len(in) != 2 || in[1].Name != "Sharks II" {
+		t.Errorf("transfer not renamed: %+v", in)
+	}
+
+	if w := do("POST", "/api/teams/"+teamId+"/transfer/accept", stranger, ""); w.Code != http.StatusNotFound {
+		t.Errorf("accept by stranger: expected 404, got %d", w.Code)
+	}
+
+	// The recipient's quota applies on acceptance.
+	reg.UpdateAccessPolicy(&UserAccessPolicy{DefaultPolicy: "allow", Users: map[string]UserOverride{newCoach: {Access: "allow", MaxGames: -1}}})
+	if w := do("POST", "/api/games/"+gameId+"/transfer/accept", newCoach, ""); w.Code != http.StatusForbidden {
+		t.Errorf("accept over quota: expected 403, got %d", w.Code)
+	}
+	reg.UpdateAccessPolicy(nil)
+
+	if w := do("POST", "/api/teams/"+teamId+"/transfer/accept", newCoach, ""); w.Code != http.StatusOK {
+		t.Fatalf("accept team: %d %s", w.Code, w.Body.String())
+	}
+	tm, _ := tStore.LoadTeam(teamId)
+	if tm.OwnerID != newCoach || tm.PendingOwner != "" || len(tm.Roles.Scorekeepers) != 0 || len(tm.Roles.Admins) != 1 || tm.Roles.Admins[0] != oldCoach {
+		t.Errorf("unexpected team after transfer: %+v", tm)
+	}
+	if reg.CountOwnedTeams(newCoach) != 1 || reg.CountOwnedTeams(oldCoach) != 0 {
+		t.Errorf("owned team counts not updated: new=%d old=%d", reg.CountOwnedTeams(newCoach), reg.CountOwnedTeams(oldCoach))
+	}
+
+	if w := do("POST", "/api/games/"+gameId+"/transfer/decline", newCoach, ""); w.Code != http.StatusOK {
+		t.Fatalf("decline game: %d %s", w.Code, w.Body.String())
+	}
+	if g, _ := gStore.LoadGame(gameId); g.OwnerID != oldCoach || g.PendingOwner != "" {
+		t.Errorf("unexpected game after decline: %+v", g)
+	}
+	if in, _ := transfers(newCoach); len(in) != 0 {
+		t.Errorf("answered transfers still listed: %+v", in)
+	}
+
+	// An offer can be withdrawn.
+	do("POST", "/api/games/"+gameId+"/transfer", oldCoach, offer)
+	if w := do("DELETE", "/api/games/"+gameId+"/transfer", oldCoach, ""); w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/games/"+gameId+"/transfer/accept", newCoach, ""); w.Code != http.StatusNotFound {
+		t.Errorf("accept after cancel: expected 404, got %d", w.Code)
+	}
+	if _, out := transfers(oldCoach); len(out) != 0 {
+		t.Errorf("withdrawn transfer still listed: %+v", out)
+	}
+
+	do("POST", "/api/games/"+gameId+"/transfer", oldCoach, offer)
+	if w := do("POST", "/api/games/"+gameId+"/transfer/accept", newCoach, ""); w.Code != http.StatusOK {
+		t.Fatalf("accept game: %d %s", w.Code, w.Body.String())
+	}
+	if level := reg.GetAccessLevel(oldCoach, gameId); level != AccessAdmin {
+		t.Errorf("previous owner access = %v, want admin", level)
+	}
+	if reg.CountOwnedGames(newCoach) != 1 || reg.CountOwnedGames(oldCoach) != 0 {
+		t.Errorf("owned game counts not updated")
+	}
+}
@@ -2,9 +2,12 @@ package backend
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/c2FmZQ/storage"
 )
@@ -105,6 +108,39 @@ func TestPaginationAndSorting(t *testing.T) {
 		}
 	})
 
+	t.Run("Cursor", func(t *testing.T) {
+		var events []string
+		url := "/api/list-games?limit=1"
+		for range 4 {
+			w := makeRequest(url)
+			var resp struct {
+				Data []GameSummary `json:"data"`
+				Meta struct {
+					Offset     int    `json:"offset"`
+					NextCursor string `json:"nextCursor"`
+				} `json:"meta"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			for _, g := range resp.Data {
+				events = append(events, g.Event)
+			}
+			if resp.Meta.Offset != len(events)-1 {
+				t.Errorf("Expected offset %d, got %d", len(events)-1, resp.Meta.Offset)
+			}
+			if resp.Meta.NextCursor == "" {
+				break
+			}
+			url = "/api/list-games?limit=1&cursor=" + resp.Meta.NextCursor
+		}
+		if want := []string{"Finals", "Playoff", "Opening Day"}; !slices.Equal(events, want) {
+			t.Errorf("Expected %v, got %v", want, events)
+		}
+
+		if w := makeRequest("/api/list-games?cursor=bogus"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for invalid cursor, got %d", w.Code)
+		}
+	})
+
 	// --- Sorting Games Tests ---
 	t.Run("SortGames_Date_Desc", func(t *testing.T) {
 		// Default is Date Desc
@@ -206,4 +242,67 @@ func TestPaginationAndSorting(t *testing.T) {
 			t.Errorf("Expected Red Sox, got %s", resp.Data[0].Name)
 		}
 	})
+
+	t.Run("TeamCursor", func(t *testing.T) {
+		var names []string
+		url := "/api/list-teams?limit=2&order=desc"
+		for range 3 {
+			w := makeRequest(url)
+			var resp struct {
+				Data []Team `json:"data"`
+				Meta struct {
+					NextCursor string `json:"nextCursor"`
+				} `json:"meta"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			for _, team := range resp.Data {
+				names = append(names, team.Name)
+			}
+			if resp.Meta.NextCursor == "" {
+				break
+			}
+			url = "/api/list-teams?limit=2&cursor=" + resp.Meta.NextCursor
+		}
+		if want := []string{"Yankees", "Red Sox", "Mets"}; !slices.Equal(names, want) {
+			t.Errorf("Expected %v, got %v", want, names)
+		}
+
+		if w := makeRequest("/api/list-teams?cursor=bogus"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for invalid cursor, got %d", w.Code)
+		}
+	})
+
+	t.Run("Since", func(t *testing.T) {
+		var since int64
+		for _, d := range gamesData {
+			g, err := gStore.LoadGame(d.ID)
+			if err != nil {
+				t.Fatalf("LoadGame: %v", err)
+			}
+			since = max(since, g.UpdatedAt)
+		}
+		time.Sleep(2 * time.Millisecond)
+		g, _ := gStore.LoadGame(gamesData[1].ID)
+		gStore.SaveGame(g)
+		reg.UpdateGame(*g)
+
+		w := makeRequest(fmt.Sprintf("/api/list-games?since=%d", since))
+		var resp struct {
+			Data []GameSummary `json:"data"`
+			Meta struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Meta.Total != 1 || len(resp.Data) != 1 || resp.Data[0].Event != "Playoff" {
+			t.Fatalf("Expected only Playoff since %d, got %s", since, w.Body)
+		}
+		if resp.Data[0].UpdatedAt <= since {
+			t.Errorf("Expected updatedAt after %d, got %d", since, resp.Data[0].UpdatedAt)
+		}
+
+		if w := makeRequest("/api/list-games?since=yesterday"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for invalid since, got %d", w.Code)
+		}
+	})
 }
@@ -0,0 +1,301 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const teamPlayerMaxPositions = 10
+
+// errPlayerNotFound is returned for player IDs that are not in a team's
+// player registry.
+var errPlayerNotFound = errors.New("player not found")
+
+// TeamPlayer is the persistent identity of a player on a team. The same ID is
+// used in the team roster and in the rosters of the team's games, so a
+// player's games can be found across seasons and number changes.
+type TeamPlayer struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Number    string         `json:"number"`
+	Numbers   []NumberChange `json:"numbers,omitempty"` // Jersey number history, oldest first
+	Bats      string         `json:"bats,omitempty"`    // "R", "L" or "S"
+	Throws    string         `json:"throws,omitempty"`  // "R" or "L"
+	Positions []string       `json:"positions,omitempty"`
+	// Active players are on the team roster. Inactive players keep their
+	// identity for the games they played in.
+	Active bool `json:"active"`
+	// MergedInto is set on a duplicate that was merged into another player.
+	// Its ID keeps resolving to that player.
+	MergedInto string `json:"mergedInto,omitempty"`
+	CreatedAt  int64  `json:"createdAt"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+// NumberChange records when a player started wearing a jersey number.
+type NumberChange struct {
+	Number string `json:"number"`
+	Since  int64  `json:"since"` // Unix ms
+}
+
+// PlayerUpdate is a partial update of a TeamPlayer. Nil fields are unchanged.
+type PlayerUpdate struct {
+	Name      *string   `json:"name"`
+	Number    *string   `json:"number"`
+	Bats      *string   `json:"bats"`
+	Throws    *string   `json:"throws"`
+	Positions *[]string `json:"positions"`
+	Active    *bool     `json:"active"`
+}
+
+func (u PlayerUpdate) validate() error {
+	if u.Name != nil {
+		if *u.Name == "" {
+			return errors.New("name is required")
+		}
+		if err := validateStringLen(*u.Name, 50, "name"); err != nil {
+			return err
+		}
+	}
+	if u.Number != nil {
+		if err := validateStringLen(*u.Number, 10, "number"); err != nil {
+			return err
+		}
+	}
+	if u.Bats != nil && !slices.Contains([]string{"", "R", "L", "S"}, *u.Bats) {
+		return fmt.Errorf("bats must be R, L or S")
+	}
+	if u.Throws != nil && !slices.Contains([]string{"", "R", "L"}, *u.Throws) {
+		return fmt.Errorf("throws must be R or L")
+	}
+	if u.Positions != nil {
+		if len(*u.Positions) > teamPlayerMaxPositions {
+			return fmt.Errorf("too many positions (max %d)", teamPlayerMaxPositions)
+		}
+		for _, pos := range *u.Positions {
+			if pos == "" {
+				return errors.New("empty position")
+			}
+			if err := validateStringLen(pos, 10, "position"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// playerRecord returns the registry record with the given ID, without
+// following merges.
+func (t *Team) playerRecord(id string) *TeamPlayer {
+	for i := range t.Players {
+		if t.Players[i].ID == id {
+			return &t.Players[i]
+		}
+	}
+	return nil
+}
+
+// playerIDs returns the IDs of the players of t: the registry records,
+// including merged duplicates, and the roster, which is all that teams saved
+// before the registry existed have.
+func (t *Team) playerIDs() []string {
+	var ids []string
+	for _, p := range t.Players {
+		ids = append(ids, p.ID)
+	}
+	for _, rp := range t.Roster {
+		if isValidUUID(rp.ID) && t.playerRecord(rp.ID) == nil {
+			ids = append(ids, rp.ID)
+		}
+	}
+	return ids
+}
+
+// player returns the registry record of a player, following merges.
+func (t *Team) player(id string) *TeamPlayer {
+	p := t.playerRecord(id)
+	for seen := 0; p != nil && p.MergedInto != "" && seen < len(t.Players); seen++ {
+		p = t.playerRecord(p.MergedInto)
+	}
+	if p != nil && p.MergedInto != "" {
+		return nil
+	}
+	return p
+}
+
+// setNumber changes the jersey number of p and records it in the history.
+func (p *TeamPlayer) setNumber(number string, now int64) {
+	if number == p.Number && len(p.Numbers) > 0 {
+		return
+	}
+	p.Number = number
+	if number != "" {
+		p.Numbers = append(p.Numbers, NumberChange{Number: number, Since: now})
+	}
+}
+
+// addPosition adds pos to the positions of p if it is new.
+func (p *TeamPlayer) addPosition(pos string) {
+	if pos != "" && !slices.Contains(p.Positions, pos) && len(p.Positions) < teamPlayerMaxPositions {
+		p.Positions = append(p.Positions, pos)
+	}
+}
+
+// syncPlayers brings the player registry of t in line with its roster, which
+// clients edit freely. Roster players get a valid ID and a registry record;
+// IDs of merged duplicates are replaced by the player they were merged into.
+// Name, number, and position changes on the roster are recorded, and players
+// that left the roster become inactive.
+func (t *Team) syncPlayers(now int64) {
+	onRoster := make(map[string]bool)
+	roster := t.Roster[:0]
+	for _, rp := range t.Roster {
+		if !isValidUUID(rp.ID) {
+			rp.ID = uuid.NewString()
+		}
+		if p := t.player(rp.ID); p != nil {
+			rp.ID = p.ID
+		}
+		if onRoster[rp.ID] {
+			continue
+		}
+		onRoster[rp.ID] = true
+		roster = append(roster, rp)
+
+		p := t.playerRecord(rp.ID)
+		if p == nil {
+			t.Players = append(t.Players, TeamPlayer{ID: rp.ID, CreatedAt: now})
+			p = &t.Players[len(t.Players)-1]
+		}
+		if p.Name != rp.Name || p.Number != rp.Number || !p.Active || (rp.Pos != "" && !slices.Contains(p.Positions, rp.Pos)) {
+			p.Name = rp.Name
+			p.setNumber(rp.Number, now)
+			p.addPosition(rp.Pos)
+			p.Active = true
+			p.UpdatedAt = now
+		}
+	}
+	t.Roster = roster
+
+	for i := range t.Players {
+		if p := &t.Players[i]; p.Active && !onRoster[p.ID] {
+			p.Active = false
+			p.UpdatedAt = now
+		}
+	}
+}
+
+// updatePlayer applies u to a player of t and to its roster entry. Making a
+// player inactive takes them off the roster, and making them active adds
+// them back. It returns the updated record.
+func (t *Team) updatePlayer(id string, u PlayerUpdate, now int64) (TeamPlayer, error) {
+	p := t.player(id)
+	if p == nil {
+		return TeamPlayer{}, errPlayerNotFound
+	}
+	if u.Name != nil {
+		p.Name = *u.Name
+	}
+	if u.Number != nil {
+		p.setNumber(*u.Number, now)
+	}
+	if u.Bats != nil {
+		p.Bats = *u.Bats
+	}
+	if u.Throws != nil {
+		p.Throws = *u.Throws
+	}
+	if u.Positions != nil {
+		p.Positions = slices.Clone(*u.Positions)
+	}
+	if u.Active != nil {
+		p.Active = *u.Active
+	}
+	p.UpdatedAt = now
+
+	idx := slices.IndexFunc(t.Roster, func(rp Player) bool { return rp.ID == p.ID })
+	switch {
+	case !p.Active && idx >= 0:
+		t.Roster = slices.Delete(t.Roster, idx, idx+1)
+	case p.Active && idx < 0:
+		t.Roster = append(t.Roster, Player{ID: p.ID})
+		idx = len(t.Roster) - 1
+	}
+	if p.Active {
+		rp := &t.Roster[idx]
+		rp.Name, rp.Number = p.Name, p.Number
+		if len(p.Positions) > 0 && !slices.Contains(p.Positions, rp.Pos) {
+			rp.Pos = p.Positions[0]
+		}
+	}
+	return *p, nil
+}
+
+// mergePlayers merges the duplicate player from into player into. The
+// surviving record takes the combined number history and positions, and
+// fills in handedness it is missing. The duplicate is kept, inactive, with
+// MergedInto set, so its ID in past games still resolves. It returns the
+// surviving record.
+func (t *Team) mergePlayers(from, into string, now int64) (TeamPlayer, error) {
+	dup, keep := t.player(from), t.player(into)
+	if dup == nil || keep == nil {
+		return TeamPlayer{}, errPlayerNotFound
+	}
+	if dup.ID == keep.ID {
+		return TeamPlayer{}, errors.New("cannot merge a player into itself")
+	}
+
+	keep.Numbers = append(keep.Numbers, dup.Numbers...)
+	slices.SortStableFunc(keep.Numbers, func(a, b NumberChange) int { return cmp.Compare(a.Since, b.Since) })
+	for _, pos := range dup.Positions {
+		keep.addPosition(pos)
+	}
+	keep.Bats = cmp.Or(keep.Bats, dup.Bats)
+	keep.Throws = cmp.Or(keep.Throws, dup.Throws)
+	keep.CreatedAt = min(keep.CreatedAt, dup.CreatedAt)
+	keep.Active = keep.Active || dup.Active
+	keep.UpdatedAt = now
+
+	dupId, keepId := dup.ID, keep.ID
+	for i := range t.Players {
+		if p := &t.Players[i]; p.ID == dupId || p.MergedInto == dupId {
+			p.MergedInto = keepId
+			p.Active = false
+			p.UpdatedAt = now
+		}
+	}
+
+	// The roster keeps one entry, in the position of the first one.
+	keepIdx := slices.IndexFunc(t.Roster, func(rp Player) bool { return rp.ID == keepId })
+	dupIdx := slices.IndexFunc(t.Roster, func(rp Player) bool { return rp.ID == dupId })
+	if dupIdx >= 0 {
+		if keepIdx < 0 {
+			t.Roster[dupIdx].ID = keepId
+		} else {
+			if dupIdx < keepIdx {
+				t.Roster[dupIdx] = t.Roster[keepIdx]
+				dupIdx = keepIdx
+			}
+			t.Roster = slices.Delete(t.Roster, dupIdx, dupIdx+1)
+		}
+	}
+	return *t.player(keepId), nil
+}
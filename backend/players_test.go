@@ -0,0 +1,196 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/c2FmZQ/storage"
+)
+
+const (
+	playerAnn = "a0000000-0000-4000-8000-000000000001"
+	playerBo  = "a0000000-0000-4000-8000-000000000002"
+	playerBo2 = "a0000000-0000-4000-8000-000000000003"
+)
+
+func TestTeam_SyncPlayers(t *testing.T) {
+	team := &Team{Roster: []Player{
+		{ID: playerAnn, Name: "Ann", Number: "1", Pos: "P"},
+		{ID: "not-a-uuid", Name: "Cy", Number: "7"},
+		{ID: playerAnn, Name: "Ann again"},
+	}}
+	team.syncPlayers(100)
+	if len(team.Roster) != 2 || len(team.Players) != 2 {
+		t.Fatalf("roster = %+v, players = %+v", team.Roster, team.Players)
+	}
+	if id := team.Roster[1].ID; !isValidUUID(id) || team.playerRecord(id) == nil {
+		t.Errorf("invalid roster ID was not replaced: %q", id)
+	}
+	ann := team.player(playerAnn)
+	if ann == nil || !ann.Active || ann.Number != "1" || !slices.Equal(ann.Positions, []string{"P"}) || ann.CreatedAt != 100 {
+		t.Errorf("Ann = %+v", ann)
+	}
+
+	// A number change is recorded; leaving the roster makes a player inactive.
+	team.Roster = []Player{{ID: playerAnn, Name: "Ann", Number: "12", Pos: "SS"}}
+	team.syncPlayers(200)
+	ann = team.player(playerAnn)
+	if ann.Number != "12" || !slices.Equal(ann.Numbers, []NumberChange{{"1", 100}, {"12", 200}}) || !slices.Equal(ann.Positions, []string{"P", "SS"}) {
+		t.Errorf("Ann = %+v", ann)
+	}
+	if cy := team.Players[1]; cy.Active || cy.UpdatedAt != 200 {
+		t.Errorf("Cy = %+v", cy)
+	}
+
+	// Saving the same roster again changes nothing.
+	team.syncPlayers(300)
+	if ann := team.player(playerAnn); ann.UpdatedAt != 200 || len(ann.Numbers) != 2 {
+		t.Errorf("Ann after unchanged save = %+v", ann)
+	}
+}
+
+func TestTeam_UpdateAndMergePlayers(t *testing.T) {
+	team := &Team{Roster: []Player{
+		{ID: playerAnn, Name: "Ann", Number: "1"},
+		{ID: playerBo, Name: "Bo", Number: "2", Pos: "C"},
+		{ID: playerBo2, Name: "Bo", Number: "22", Pos: "1B"},
+	}}
+	team.syncPlayers(100)
+
+	bats, active := "L", false
+	p, err := team.updatePlayer(playerAnn, PlayerUpdate{Bats: &bats, Active: &active}, 200)
+	if err != nil || p.Bats != "L" || p.Active {
+		t.Fatalf("updatePlayer = %+v, %v", p, err)
+	}
+	if slices.ContainsFunc(team.Roster, func(rp Player) bool { return rp.ID == playerAnn }) {
+		t.Error("inactive player is still on the roster")
+	}
+	active = true
+	if _, err := team.updatePlayer(playerAnn, PlayerUpdate{Active: &active}, 300); err != nil || team.Roster[len(team.Roster)-1].Name != "Ann" {
+		t.Errorf("reactivated roster = %+v, %v", team.Roster, err)
+	}
+	if _, err := team.updatePlayer("missing", PlayerUpdate{}, 300); err != errPlayerNotFound {
+		t.Errorf("updatePlayer(missing) = %v", err)
+	}
+
+	p, err = team.mergePlayers(playerBo2, playerBo, 400)
+	if err != nil {
+		t.Fatalf("mergePlayers: %v", err)
+	}
+	if p.ID != playerBo || !slices.Equal(p.Positions, []string{"C", "1B"}) || len(p.Numbers) != 2 {
+		t.Errorf("merged player = %+v", p)
+	}
+	if dup := team.playerRecord(playerBo2); dup.MergedInto != playerBo || dup.Active {
+		t.Errorf("duplicate = %+v", dup)
+	}
+	if got := team.player(playerBo2); got == nil || got.ID != playerBo {
+		t.Errorf("player(duplicate) = %+v", got)
+	}
+	if n := len(team.Roster); n != 2 {
+		t.Errorf("roster after merge = %+v", team.Roster)
+	}
+	if _, err := team.mergePlayers(playerBo, playerBo2, 500); err == nil {
+		t.Error("merging a player into its own duplicate succeeded")
+	}
+
+	// A client that still has the duplicate on its roster saves the team.
+	team.Roster = append(team.Roster, Player{ID: playerBo2, Name: "Bo", Number: "22"})
+	team.syncPlayers(600)
+	if len(team.Roster) != 2 || team.playerRecord(playerBo2).Active {
+		t.Errorf("roster after saving a merged duplicate = %+v", team.Roster)
+	}
+}
+
+func TestTeamPlayersEndpoints(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	_, _, handler := NewServerHandler(Options{
+		DataDir:     tempDir,
+		Storage:     s,
+		UseMockAuth: true,
+	})
+	doReq := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	owner := "owner@example.com"
+	teamId := "55555555-5555-4555-8555-555555555555"
+	path := "/api/teams/" + teamId + "/players"
+	saveTeam := func(players string) {
+		t.Helper()
+		if rec := doReq("POST", "/api/save-team", owner, fmt.Sprintf(`{"id":%q,"name":"Bears","roster":[%s],"players":[]}`, teamId, players)); rec.Code != http.StatusOK {
+			t.Fatalf("save-team: %d %s", rec.Code, rec.Body)
+		}
+	}
+	list := func() []TeamPlayer {
+		t.Helper()
+		rec := doReq("GET", path, owner, "")
+		var resp struct {
+			Data []TeamPlayer `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("list: %s", rec.Body)
+		}
+		return resp.Data
+	}
+
+	saveTeam(fmt.Sprintf(`{"id":%q,"name":"Bo","number":"2"},{"id":%q,"name":"Bo","number":"22"}`, playerBo, playerBo2))
+	if players := list(); len(players) != 2 || !players[0].Active {
+		t.Fatalf("players = %+v", players)
+	}
+	if rec := doReq("GET", path, "stranger@example.com", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("list by stranger: %d", rec.Code)
+	}
+
+	if rec := doReq("PATCH", path+"/"+playerBo, owner, `{"bats":"X"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid bats: %d", rec.Code)
+	}
+	rec := doReq("PATCH", path+"/"+playerBo, owner, `{"bats":"R","throws":"R","positions":["C","3B"]}`)
+	var p TeamPlayer
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Bats != "R" || !slices.Equal(p.Positions, []string{"C", "3B"}) {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body)
+	}
+	if rec := doReq("PATCH", path+"/"+playerAnn, owner, `{"bats":"R"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("update of unknown player: %d", rec.Code)
+	}
+
+	rec = doReq("POST", path+"/merge", owner, fmt.Sprintf(`{"from":%q,"into":%q}`, playerBo2, playerBo))
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.ID != playerBo || len(p.Numbers) != 2 {
+		t.Fatalf("merge = %d %s", rec.Code, rec.Body)
+	}
+
+	// The client's roster does not know about the merge; the registry survives the save.
+	saveTeam(fmt.Sprintf(`{"id":%q,"name":"Bo","number":"2"},{"id":%q,"name":"Bo","number":"22"}`, playerBo, playerBo2))
+	players := list()
+	if len(players) != 2 || players[0].Bats != "R" || players[1].MergedInto != playerBo {
+		t.Errorf("players after save = %+v", players)
+	}
+	rec = doReq("GET", "/api/load-team/"+teamId, owner, "")
+	var team Team
+	if err := json.Unmarshal(rec.Body.Bytes(), &team); err != nil || len(team.Roster) != 1 || team.Roster[0].ID != playerBo {
+		t.Errorf("roster after save = %s", rec.Body)
+	}
+}
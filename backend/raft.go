@@ -16,6 +16,7 @@ package backend
 
 import (
 	"bytes"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/tls"
@@ -29,7 +30,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"math/big"
 	"net"
 	"net/http"
@@ -46,6 +46,9 @@ import (
 	"github.com/c2FmZQ/storage/crypto"
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var ErrNotLeader = errors.New("not leader")
@@ -68,12 +71,16 @@ type RaftManager struct {
 	UseProductionTimeouts bool
 	SnapshotThreshold     uint64
 	TrailingLogs          uint64
+	Backups               *BackupManager // Optional: scheduled backups
+	RestoreBackup         string         // Optional: backup to initialize a new cluster from, or "latest"
+	Recover               bool           // Optional: recover a cluster that lost its quorum, see recoverCluster
 
 	nodeAddrMap sync.Map // map[raft.ServerID]string (ClusterAdvertise Addr)
 
 	shutdownCh     chan struct{}
 	shutdownOnce   sync.Once
 	readyCh        chan struct{}
+	ingestedCh     chan struct{} // Closed when the bootstrap ingestion of existing data is done
 	internalServer *http.Server
 	httpClient     *http.Client
 	AuthMiddleware func(http.Handler) http.Handler
@@ -99,6 +106,25 @@ type RaftManager struct {
 
 	latencyMu          sync.Mutex
 	latencyAccumulator *Histogram
+
+	// Used by the monitorAlerts goroutine only.
+	leaderLostAt time.Time // When this node last lost track of the leader
+	alertsStart  time.Time // When this node started evaluating rules as leader
+
+	webhookMu        sync.Mutex
+	webhookGen       int             // Incremented when this node stops delivering
+	webhookInFlight  map[uint64]bool // Outbox entries this leader is delivering
+	webhookDelivered []uint64        // Delivered outbox entries to acknowledge
+
+	drainMu     sync.Mutex
+	drain       DrainStatus
+	drainGen    int // Incremented when a drain starts or is cancelled
+	drainCancel context.CancelFunc
+
+	recovering atomic.Bool // Set while recoverCluster replays the log
+
+	replicaMu   sync.Mutex
+	replicaSeen map[string]ReplicaProgress // Read replicas that tailed this node's log
 }
 
 func NewRaftManager(dataDir, bind, advertise, clusterAdvertise, clusterAddr, secret string, masterKey crypto.MasterKey, fsm *FSM) *RaftManager {
@@ -115,6 +141,7 @@ func NewRaftManager(dataDir, bind, advertise, clusterAdvertise, clusterAddr, sec
 		FSM:                fsm,
 		shutdownCh:         make(chan struct{}),
 		readyCh:            make(chan struct{}),
+		ingestedCh:         make(chan struct{}),
 		LogOutput:          os.Stderr, // Default
 		nodeCounters:       make(map[string]uint64),
 		latencyAccumulator: &Histogram{},
@@ -147,7 +174,7 @@ func (rm *RaftManager) loadKeyRing() error {
 		if err := os.Rename(legacyKey, newName); err != nil {
 			return fmt.Errorf("failed to migrate log.key: %v", err)
 		}
-		log.Printf("Migrated legacy log.key to %s", newName)
+		raftLog.Info("migrated legacy log.key", "file", newName)
 	}
 
 	if _, err := os.Stat(legacyOld); err == nil {
@@ -157,7 +184,7 @@ func (rm *RaftManager) loadKeyRing() error {
 		if err := os.Rename(legacyOld, newName); err != nil {
 			return fmt.Errorf("failed to migrate log.key.old: %v", err)
 		}
-		log.Printf("Migrated legacy log.key.old to %s", newName)
+		raftLog.Info("migrated legacy log.key.old", "file", newName)
 	}
 
 	// Load all keys
@@ -209,7 +236,7 @@ func (rm *RaftManager) loadKeyRing() error {
 
 	if active == nil {
 		// Generate first key
-		log.Printf("Generating initial Raft encryption key...")
+		raftLog.Info("generating initial Raft encryption key")
 		k, err := rm.MasterKey.NewKey()
 		if err != nil {
 			return fmt.Errorf("failed to generate new key: %v", err)
@@ -278,7 +305,7 @@ func (rm *RaftManager) RotateLogKey() error {
 		rm.keyRing = NewKeyRing(newKey, filepath.Base(path))
 	}
 
-	log.Printf("Raft log key rotated successfully. New key: %s", filepath.Base(path))
+	raftLog.Info("log key rotated", "key", filepath.Base(path))
 	return rm.GarbageCollectKeys()
 }
 
@@ -302,7 +329,7 @@ func (rm *RaftManager) GarbageCollectKeys() error {
 			if err == nil && len(val) > 0 {
 				// Set will encrypt with Active key
 				if err := rm.stableStore.Set(key, val); err != nil {
-					log.Printf("Warning: Failed to re-encrypt stable key %s: %v", string(key), err)
+					raftLog.Warn("failed to re-encrypt stable key", "key", string(key), "err", err)
 				}
 			}
 		}
@@ -368,18 +395,18 @@ func (rm *RaftManager) GarbageCollectKeys() error {
 	}
 
 	keysToDelete := oldKeys[cutoff:]
-	log.Printf("GC: Found %d keys to delete (older than oldest used key index %d)", len(keysToDelete), maxUsedIndex)
+	raftLog.Info("key GC: deleting old keys", "count", len(keysToDelete), "oldestUsedIndex", maxUsedIndex)
 
 	// 3. Delete from Disk
 	keysDir := filepath.Join(rm.DataDir, "keys")
 	for _, k := range keysToDelete {
 		path := filepath.Join(keysDir, k.ID)
 		if err := os.Remove(path); err != nil {
-			log.Printf("Warning: Failed to delete key file %s: %v", path, err)
+			raftLog.Warn("failed to delete key file", "file", path, "err", err)
 			// Continue to remove from memory anyway?
 			// Yes, consistency.
 		} else {
-			log.Printf("Deleted old key: %s", k.ID)
+			raftLog.Info("deleted old key", "key", k.ID)
 		}
 	}
 
@@ -429,62 +456,79 @@ func (rm *RaftManager) saveLogKey(key crypto.EncryptionKey, path string) error {
 }
 
 func (rm *RaftManager) loadOrGenerateNodeKey() error {
-	if err := os.MkdirAll(rm.DataDir, 0755); err != nil {
+	priv, err := loadNodeKey(rm.DataDir, rm.MasterKey)
+	if err != nil {
 		return err
 	}
-	keyPath := filepath.Join(rm.DataDir, "node.key")
+	rm.NodeKey = priv
+	rm.PubKey = priv.Public().(ed25519.PublicKey)
+	return nil
+}
+
+// loadNodeKey loads the node's Ed25519 identity key from dir, or generates it.
+// The key is encrypted with mk, if set.
+func loadNodeKey(dir string, mk crypto.MasterKey) (ed25519.PrivateKey, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	keyPath := filepath.Join(dir, "node.key")
 	if data, err := os.ReadFile(keyPath); err == nil {
 		var priv ed25519.PrivateKey
 		if len(data) == ed25519.PrivateKeySize {
 			priv = ed25519.PrivateKey(data)
 			// Migration: encrypt it if we have a MasterKey
-			if rm.MasterKey != nil {
-				if encrypted, err := rm.MasterKey.Encrypt(data); err == nil {
+			if mk != nil {
+				if encrypted, err := mk.Encrypt(data); err == nil {
 					if err := os.WriteFile(keyPath, encrypted, 0600); err != nil {
-						log.Printf("Warning: failed to encrypt node.key during migration: %v", err)
+						raftLog.Warn("failed to encrypt node.key during migration", "err", err)
 					} else {
-						log.Printf("Successfully encrypted node.key during migration")
+						raftLog.Info("encrypted node.key during migration")
 					}
 				}
 			}
-		} else if rm.MasterKey != nil {
-			if decrypted, err := rm.MasterKey.Decrypt(data); err == nil && len(decrypted) == ed25519.PrivateKeySize {
+		} else if mk != nil {
+			if decrypted, err := mk.Decrypt(data); err == nil && len(decrypted) == ed25519.PrivateKeySize {
 				priv = ed25519.PrivateKey(decrypted)
 			}
 		}
 
 		if priv != nil {
-			rm.NodeKey = priv
-			rm.PubKey = priv.Public().(ed25519.PublicKey)
-			return nil
+			return priv, nil
 		}
-		return fmt.Errorf("failed to load existing node key from %s", keyPath)
+		return nil, fmt.Errorf("failed to load existing node key from %s", keyPath)
 	}
 
-	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	rm.NodeKey = priv
-	rm.PubKey = pub
 
 	saveData := []byte(priv)
-	if rm.MasterKey != nil {
-		if encrypted, err := rm.MasterKey.Encrypt(saveData); err == nil {
+	if mk != nil {
+		if encrypted, err := mk.Encrypt(saveData); err == nil {
 			saveData = encrypted
 		} else {
-			return fmt.Errorf("failed to encrypt node key: %v", err)
+			return nil, fmt.Errorf("failed to encrypt node key: %v", err)
 		}
 	}
 
-	return os.WriteFile(keyPath, saveData, 0600)
+	if err := os.WriteFile(keyPath, saveData, 0600); err != nil {
+		return nil, err
+	}
+	return priv, nil
 }
 
 func (rm *RaftManager) generateEphemeralCert() (*tls.Certificate, error) {
+	return nodeCertificate(rm.NodeID, rm.NodeKey)
+}
+
+// nodeCertificate returns a self-signed certificate for nodeID. Peers verify
+// its public key against the node map, not its issuer.
+func nodeCertificate(nodeID string, priv ed25519.PrivateKey) (*tls.Certificate, error) {
 	template := x509.Certificate{
 		SerialNumber: big.NewInt(time.Now().UnixNano()),
 		Subject: pkix.Name{
-			CommonName: rm.NodeID,
+			CommonName: nodeID,
 		},
 		NotBefore: time.Now().Add(-1 * time.Hour),
 		NotAfter:  time.Now().Add(365 * 24 * time.Hour),
@@ -496,14 +540,14 @@ func (rm *RaftManager) generateEphemeralCert() (*tls.Certificate, error) {
 		BasicConstraintsValid: true,
 	}
 
-	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, rm.PubKey, rm.NodeKey)
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, priv.Public(), priv)
 	if err != nil {
 		return nil, err
 	}
 
 	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
 
-	privBytes, err := x509.MarshalPKCS8PrivateKey(rm.NodeKey)
+	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
 	if err != nil {
 		return nil, err
 	}
@@ -529,8 +573,7 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 	// Derive NodeID from PubKey if not already set (or always?)
 	// To be safe and consistent with the plan:
 	rm.NodeID = hex.EncodeToString(rm.PubKey[:8])
-	log.Printf("NodeID: %s", rm.NodeID)
-	log.Printf("PublicKey: %s", base64.StdEncoding.EncodeToString(rm.PubKey))
+	raftLog.Info("node identity", "nodeId", rm.NodeID, "publicKey", base64.StdEncoding.EncodeToString(rm.PubKey))
 	rm.nodeAddrMap.Store(raft.ServerID(rm.NodeID), rm.ClusterAdvertise)
 
 	config := raft.DefaultConfig()
@@ -559,11 +602,9 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 	//config.ShutdownOnRemove = true
 	config.NoSnapshotRestoreOnStart = true
 	config.NoLegacyTelemetry = true
-	config.LogLevel = "INFO"
 	config.MaxAppendEntries = 200
-	if rm.LogOutput != nil {
-		config.LogOutput = rm.LogOutput
-	}
+	logger := newRaftLogger(rm.LogOutput)
+	config.Logger = logger
 
 	notifyCh := make(chan bool, 1)
 	config.NotifyCh = notifyCh
@@ -596,7 +637,7 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 	}
 	rm.listener = sl
 
-	transport := raft.NewNetworkTransport(sl, 3, 10*time.Second, rm.LogOutput)
+	transport := raft.NewNetworkTransportWithLogger(sl, 3, 10*time.Second, logger.Named("transport"))
 
 	// Setup Stores
 	if err := os.MkdirAll(rm.DataDir, 0755); err != nil {
@@ -627,7 +668,7 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 		rm.stableStore = raftStableStore
 	}
 
-	snapshotStore, err := raft.NewFileSnapshotStore(rm.DataDir, 1, rm.LogOutput)
+	snapshotStore, err := raft.NewFileSnapshotStoreWithLogger(rm.DataDir, 1, logger.Named("snapshot"))
 	if err != nil {
 		return err
 	}
@@ -655,15 +696,33 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 		// If MasterKey is nil, we don't encrypt.
 	}
 
+	var lostNodes []string
+	if rm.Recover {
+		if lostNodes, err = rm.recoverCluster(config, raftLogStore, raftStableStore, raftSnapshotStore, transport); err != nil {
+			return err
+		}
+	}
+
+	restored := false
+	if rm.RestoreBackup != "" {
+		if restored, err = rm.restoreFromBackup(raftLogStore, raftStableStore, raftSnapshotStore, transport); err != nil {
+			return err
+		}
+	}
+
 	r, err := raft.NewRaft(config, rm.FSM, raftLogStore, raftStableStore, raftSnapshotStore, transport)
 	if err != nil {
 		return err
 	}
 	rm.Raft = r
 	close(rm.readyCh)
+	if len(lostNodes) > 0 {
+		go rm.forgetNodes(lostNodes)
+	}
 
-	if bootstrap {
-		log.Printf("Bootstrapping Raft cluster with NodeID: %s", rm.NodeID)
+	// A restored backup provides the initial configuration and the data.
+	if bootstrap && !restored {
+		raftLog.Info("bootstrapping Raft cluster", "nodeId", rm.NodeID)
 		configuration := raft.Configuration{
 			Servers: []raft.Server{
 				{
@@ -674,11 +733,12 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 		}
 		f := r.BootstrapCluster(configuration)
 		if err := f.Error(); err != nil {
-			log.Printf("Bootstrap error (might be already bootstrapped): %v", err)
+			raftLog.Info("bootstrap failed, cluster may already be bootstrapped", "err", err)
 		}
 
 		// Propose own metadata once leader
 		go func() {
+			defer close(rm.ingestedCh)
 			for {
 				if r.State() == raft.Leader {
 					break
@@ -697,22 +757,22 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 				},
 			}
 			if _, err := rm.Propose(cmd); err != nil {
-				log.Printf("Failed to propose bootstrap metadata: %v", err)
+				raftLog.Error("failed to propose bootstrap metadata", "err", err)
 			}
 
 			// Ingest existing data into Raft log (Migration from standalone)
-			log.Printf("Ingesting existing data into Raft log...")
+			raftLog.Info("ingesting existing data into Raft log")
 			gs, ts := rm.FSM.GetStores()
 
 			for g, err := range gs.ListAllGames() {
 				if err != nil {
-					log.Printf("Failed to list games for ingestion: %v", err)
+					raftLog.Error("failed to list games for ingestion", "err", err)
 					break
 				}
 				// Reset LastRaftIndex on disk so the FSM accepts the new log entry
 				g.LastRaftIndex = 0
 				if err := gs.SaveGame(g); err != nil {
-					log.Printf("Failed to reset index for game %s: %v", g.ID, err)
+					raftLog.Error("failed to reset index for game", "gameId", g.ID, "err", err)
 				}
 
 				data, _ := json.Marshal(g)
@@ -724,19 +784,19 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 					Force:    true,
 				}
 				if _, err := rm.Propose(cmd); err != nil {
-					log.Printf("Failed to ingest game %s: %v", g.ID, err)
+					raftLog.Error("failed to ingest game", "gameId", g.ID, "err", err)
 				}
 			}
 
 			for t, err := range ts.ListAllTeams() {
 				if err != nil {
-					log.Printf("Failed to list teams for ingestion: %v", err)
+					raftLog.Error("failed to list teams for ingestion", "err", err)
 					break
 				}
 				// Reset LastRaftIndex on disk so the FSM accepts the new log entry
 				t.LastRaftIndex = 0
 				if err := ts.SaveTeam(t); err != nil {
-					log.Printf("Failed to reset index for team %s: %v", t.ID, err)
+					raftLog.Error("failed to reset index for team", "teamId", t.ID, "err", err)
 				}
 
 				data, _ := json.Marshal(t)
@@ -748,10 +808,10 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 					Force:    true,
 				}
 				if _, err := rm.Propose(cmd); err != nil {
-					log.Printf("Failed to ingest team %s: %v", t.ID, err)
+					raftLog.Error("failed to ingest team", "teamId", t.ID, "err", err)
 				}
 			}
-			log.Printf("Ingestion complete.")
+			raftLog.Info("ingestion complete")
 		}()
 	}
 	// Start Internal Secure Server
@@ -761,6 +821,9 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 		mux.HandleFunc("/api/cluster/join", rm.handleJoin)
 		mux.HandleFunc("/api/cluster/remove", rm.handleRemove)
 		mux.HandleFunc("/api/cluster/action", rm.handleAction)
+		mux.HandleFunc("/api/cluster/drain", rm.handleDrain)
+		mux.HandleFunc("/api/cluster/replicate", rm.handleReplicate)
+		mux.HandleFunc("/api/cluster/replicate/snapshot", rm.handleReplicateSnapshot)
 
 		if rm.AppHandler != nil {
 			mux.Handle("/", rm.AppHandler)
@@ -796,9 +859,9 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 		rm.internalServer = server
 
 		go func() {
-			log.Printf("Starting Internal Secure Cluster API on %s...", ln.Addr())
+			raftLog.Info("starting internal cluster API", "addr", ln.Addr().String())
 			if err := server.ServeTLS(ln, "", ""); err != nil && err != http.ErrServerClosed {
-				log.Printf("Internal Server Error: %v", err)
+				raftLog.Error("internal cluster API failed", "err", err)
 			}
 		}()
 	}
@@ -811,6 +874,11 @@ func (rm *RaftManager) Start(bootstrap bool) error {
 	go rm.monitorConfiguration()
 	go rm.monitorMetrics()
 	go rm.monitorLeadership(notifyCh)
+	go rm.monitorAlerts()
+	go rm.monitorWebhooks()
+	if rm.Backups != nil {
+		go rm.monitorBackups()
+	}
 
 	return nil
 }
@@ -845,27 +913,29 @@ func (rm *RaftManager) WaitForSync(timeout time.Duration) error {
 	}
 }
 
+// ProposeContext is like Propose, but records a span and embeds the trace
+// context of ctx in the log entry so that every node's FSM continues the trace.
+func (rm *RaftManager) ProposeContext(ctx context.Context, cmd RaftCommand) (uint64, error) {
+	ctx, span := tracer().Start(ctx, "RaftManager.Propose", trace.WithAttributes(
+		attribute.String("skorekeeper.command", string(cmd.Type)),
+		attribute.String("skorekeeper.node_id", rm.NodeID),
+	))
+	cmd.Trace = traceCarrier(ctx)
+	cmd.RequestID = requestIDFromContext(ctx)
+	index, err := rm.Propose(cmd)
+	span.SetAttributes(attribute.Int64("raft.index", int64(index)))
+	endSpan(span, err)
+	return index, err
+}
+
 // Propose proposes a command to the Raft cluster.
 func (rm *RaftManager) Propose(cmd RaftCommand) (uint64, error) {
 	if rm.Raft.State() != raft.Leader {
 		return 0, ErrNotLeader
 	}
-
-	var data []byte
-	var err error
-
-	if rm.UseGob {
-		var buf bytes.Buffer
-		enc := gob.NewEncoder(&buf)
-		if err := enc.Encode(cmd); err != nil {
-			return 0, err
-		}
-		data = buf.Bytes()
-	} else {
-		data, err = json.Marshal(cmd)
-		if err != nil {
-			return 0, err
-		}
+	data, err := rm.encodeCommand(cmd)
+	if err != nil {
+		return 0, err
 	}
 
 	f := rm.Raft.Apply(data, 5*time.Second)
@@ -876,20 +946,80 @@ func (rm *RaftManager) Propose(cmd RaftCommand) (uint64, error) {
 	// f.Response() returns what FSM.Apply returns.
 	// In our FSM, we return `error` or `nil`.
 	resp := f.Response()
-	if resp != nil {
-		if err, ok := resp.(error); ok {
-			return f.Index(), err
-		}
+	err, _ = resp.(error)
+	if err != nil {
+		return f.Index(), err
 	}
 	return f.Index(), nil
 }
 
+// ProposeBatch proposes several commands without waiting for each one in
+// turn. They are appended to the log back to back, so the FSM normally applies
+// them in a single ApplyBatch call. The returned slice holds the result of
+// each command; the error is only set if nothing was proposed.
+func (rm *RaftManager) ProposeBatch(ctx context.Context, cmds []RaftCommand) ([]error, error) {
+	ctx, span := tracer().Start(ctx, "RaftManager.ProposeBatch", trace.WithAttributes(
+		attribute.Int("skorekeeper.commands", len(cmds)),
+		attribute.String("skorekeeper.node_id", rm.NodeID),
+	))
+	if rm.Raft.State() != raft.Leader {
+		endSpan(span, ErrNotLeader)
+		return nil, ErrNotLeader
+	}
+
+	errs := make([]error, len(cmds))
+	futures := make([]raft.ApplyFuture, len(cmds))
+	for i, cmd := range cmds {
+		cmd.Trace = traceCarrier(ctx)
+		cmd.RequestID = requestIDFromContext(ctx)
+		data, err := rm.encodeCommand(cmd)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		futures[i] = rm.Raft.Apply(data, 5*time.Second)
+	}
+	for i, f := range futures {
+		if f == nil {
+			continue
+		}
+		if err := f.Error(); err != nil {
+			errs[i] = err
+			continue
+		}
+		if err, ok := f.Response().(error); ok {
+			errs[i] = err
+		}
+	}
+	endSpan(span, nil)
+	return errs, nil
+}
+
+// encodeCommand fills in the proposing node and time and serializes cmd for
+// the log.
+func (rm *RaftManager) encodeCommand(cmd RaftCommand) ([]byte, error) {
+	if cmd.NodeID == "" {
+		cmd.NodeID = rm.NodeID
+	}
+	if cmd.Timestamp == 0 {
+		cmd.Timestamp = time.Now().UnixMilli()
+	}
+	if rm.UseGob {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(cmd); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(cmd)
+}
+
 // Join adds a new node to the cluster.
 func (rm *RaftManager) Join(nodeID, raftAddr, httpAddr, pubKey string, nonVoter bool, appVer string, protoVer, schemaVer int) error {
 	if rm.Raft.State() != raft.Leader {
 		return ErrNotLeader
 	}
-	log.Printf("Received join request for remote node %s at Raft:%s, HTTP:%s (nonVoter: %v)", nodeID, raftAddr, httpAddr, nonVoter)
+	raftLog.Info("join request", "nodeId", nodeID, "raftAddr", raftAddr, "httpAddr", httpAddr, "nonVoter", nonVoter)
 
 	// Store public key first so the node can connect via TLS transport
 	cmd := RaftCommand{
@@ -919,7 +1049,7 @@ func (rm *RaftManager) Join(nodeID, raftAddr, httpAddr, pubKey string, nonVoter
 	}
 
 	rm.nodeAddrMap.Store(raft.ServerID(nodeID), httpAddr)
-	log.Printf("Node %s joined successfully", nodeID)
+	raftLog.Info("node joined", "nodeId", nodeID)
 	return nil
 }
 
@@ -938,7 +1068,7 @@ func (rm *RaftManager) Leave(nodeID string) error {
 	if rm.Raft.State() != raft.Leader {
 		return ErrNotLeader
 	}
-	log.Printf("Received leave request for node %s", nodeID)
+	raftLog.Info("leave request", "nodeId", nodeID)
 
 	f := rm.Raft.RemoveServer(raft.ServerID(nodeID), 0, 0)
 	if err := f.Error(); err != nil {
@@ -953,11 +1083,11 @@ func (rm *RaftManager) Leave(nodeID string) error {
 		},
 	}
 	if _, err := rm.Propose(cmd); err != nil {
-		log.Printf("Warning: Failed to broadcast node removal: %v", err)
+		raftLog.Warn("failed to broadcast node removal", "nodeId", nodeID, "err", err)
 	}
 
 	rm.nodeAddrMap.Delete(raft.ServerID(nodeID))
-	log.Printf("Node %s removed successfully", nodeID)
+	raftLog.Info("node removed", "nodeId", nodeID)
 	return nil
 }
 
@@ -978,19 +1108,27 @@ func (rm *RaftManager) handleStatus(w http.ResponseWriter, r *http.Request) {
 	_, leaderID := rm.Raft.LeaderWithID()
 
 	status := map[string]any{
-		"nodeId":          rm.NodeID,
-		"state":           rm.Raft.State().String(),
-		"leaderId":        string(leaderID),
-		"leaderAddr":      leaderAddr,
-		"raftAddr":        rm.Advertise,
-		"pubKey":          base64.StdEncoding.EncodeToString(rm.PubKey),
-		"appVersion":      CurrentAppVersion,
-		"protocolVersion": CurrentProtocolVersion,
-		"schemaVersion":   CurrentSchemaVersion,
+		"nodeId":              rm.NodeID,
+		"state":               rm.Raft.State().String(),
+		"leaderId":            string(leaderID),
+		"leaderAddr":          leaderAddr,
+		"raftAddr":            rm.Advertise,
+		"pubKey":              base64.StdEncoding.EncodeToString(rm.PubKey),
+		"appVersion":          CurrentAppVersion,
+		"protocolVersion":     CurrentProtocolVersion,
+		"schemaVersion":       CurrentSchemaVersion,
+		"activeSchemaVersion": rm.FSM.ActiveSchemaVersion(),
+		"drain":               rm.DrainStatus(),
 	}
 	if status["raftAddr"] == "" {
 		status["raftAddr"] = rm.Bind
 	}
+	if rm.Backups != nil {
+		status["backup"] = rm.Backups.Status()
+	}
+	if replicas := rm.Replicas(); len(replicas) > 0 {
+		status["replicas"] = replicas
+	}
 
 	configFuture := rm.Raft.GetConfiguration()
 	if err := configFuture.Error(); err == nil {
@@ -1054,6 +1192,7 @@ func (rm *RaftManager) handleJoin(w http.ResponseWriter, r *http.Request) {
 		AppVersion      string `json:"appVersion"`
 		ProtocolVersion int    `json:"protocolVersion"`
 		SchemaVersion   int    `json:"schemaVersion"`
+		Replica         bool   `json:"replica"`
 	}
 	// We decode into a fresh struct, so we can't reuse body if we forwarded.
 	// But forwarding happens before decode.
@@ -1062,6 +1201,18 @@ func (rm *RaftManager) handleJoin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if data.Replica {
+		rm.joinReplica(w, r, NodeMeta{
+			NodeID:          data.NodeID,
+			HttpAddr:        data.HttpAddr,
+			PubKey:          data.PubKey,
+			AppVersion:      data.AppVersion,
+			ProtocolVersion: data.ProtocolVersion,
+			SchemaVersion:   data.SchemaVersion,
+		})
+		return
+	}
+
 	if data.HttpAddr == "" {
 		http.Error(w, "Missing required field: httpAddr is required", http.StatusBadRequest)
 		return
@@ -1071,7 +1222,7 @@ func (rm *RaftManager) handleJoin(w http.ResponseWriter, r *http.Request) {
 		// Attempt Discovery
 		status, err := rm.discoverNode(data.HttpAddr, data.PubKey)
 		if err != nil {
-			log.Printf("Discovery failed for %s: %v", data.HttpAddr, err)
+			raftLog.WarnContext(r.Context(), "discovery failed", "httpAddr", data.HttpAddr, "err", err)
 			http.Error(w, fmt.Sprintf("Discovery failed: %v", err), http.StatusBadGateway)
 			return
 		}
@@ -1118,6 +1269,12 @@ func (rm *RaftManager) handleJoin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkJoinCompatibility(data.ProtocolVersion, data.SchemaVersion, rm.FSM.ActiveSchemaVersion()); err != nil {
+		raftLog.WarnContext(r.Context(), "join rejected: incompatible node", "nodeId", data.NodeID, "appVersion", data.AppVersion, "err", err)
+		http.Error(w, fmt.Sprintf("Incompatible node: %v", err), http.StatusConflict)
+		return
+	}
+
 	if err := rm.Join(data.NodeID, data.RaftAddr, data.HttpAddr, data.PubKey, data.NonVoter, data.AppVersion, data.ProtocolVersion, data.SchemaVersion); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to join: %v", err), http.StatusInternalServerError)
 		return
@@ -1276,14 +1433,18 @@ func (rm *RaftManager) forwardRequestToLeader(w http.ResponseWriter, r *http.Req
 		http.Error(w, "No leader found", http.StatusServiceUnavailable)
 		return
 	}
+	rm.forwardRequest(w, r, leaderAddr, "RaftManager.forwardRequestToLeader")
+}
 
-	if strings.HasPrefix(leaderAddr, "http://") {
-		leaderAddr = "https://" + strings.TrimPrefix(leaderAddr, "http://")
-	} else if !strings.HasPrefix(leaderAddr, "https://") {
-		leaderAddr = "https://" + leaderAddr
+// forwardRequest proxies r to the cluster API of the node at addr.
+func (rm *RaftManager) forwardRequest(w http.ResponseWriter, r *http.Request, addr, spanName string) {
+	if strings.HasPrefix(addr, "http://") {
+		addr = "https://" + strings.TrimPrefix(addr, "http://")
+	} else if !strings.HasPrefix(addr, "https://") {
+		addr = "https://" + addr
 	}
 
-	url := leaderAddr + r.URL.Path
+	url := addr + r.URL.Path
 	// We need to buffer the body to forward it
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -1317,12 +1478,18 @@ func (rm *RaftManager) forwardRequestToLeader(w http.ResponseWriter, r *http.Req
 		req.Header.Set("X-Raft-Secret", rm.Secret)
 	}
 
+	ctx, span := tracer().Start(r.Context(), spanName, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	tracePropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	resp, err := rm.httpClient.Do(req)
 	if err != nil {
+		spanError(span, err)
 		http.Error(w, fmt.Sprintf("Failed to forward request: %v", err), http.StatusBadGateway)
 		return
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	// Copy response headers
 	for k, v := range resp.Header {
@@ -1368,20 +1535,32 @@ func (rm *RaftManager) handleAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The internal cluster API is not behind tracingMiddleware; continue the follower's trace here.
+	ctx := tracePropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	if id := r.Header.Get(requestIDHeader); isValidRequestID(id) {
+		ctx = withRequestID(ctx, id)
+	}
+	ctx, span := tracer().Start(ctx, "RaftManager.handleAction", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String("skorekeeper.game_id", gameId),
+		attribute.String("skorekeeper.node_id", rm.NodeID),
+	))
+
 	// Serialize through Hub
 	hub := rm.FSM.GetHub(gameId, false)
 	reply := make(chan HubResponse)
 	hub.requests <- HubRequest{
 		Type:    ReqTypeHTTPAction,
+		Ctx:     ctx,
 		UserId:  userId,
 		Headers: r.Header,
 		Message: msg,
 		Reply:   reply,
 	}
 	resp := <-reply
+	endSpan(span, resp.Error)
 
 	if resp.Error != nil {
-		log.Printf("Error processing forwarded HTTP action: %v", resp.Error)
+		raftLog.ErrorContext(ctx, "forwarded action failed", "gameId", gameId, "err", resp.Error)
 		http.Error(w, resp.Error.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1420,7 +1599,7 @@ func (rm *RaftManager) Shutdown() error {
 
 	// Attempt graceful leadership transfer if leader
 	if rm.Raft.State() == raft.Leader {
-		log.Printf("Attempting leadership transfer before shutdown...")
+		raftLog.Info("transferring leadership before shutdown")
 		f := rm.Raft.LeadershipTransfer()
 
 		// Wait for transfer with timeout
@@ -1430,12 +1609,12 @@ func (rm *RaftManager) Shutdown() error {
 		select {
 		case err := <-done:
 			if err != nil {
-				log.Printf("Leadership transfer failed (continuing): %v", err)
+				raftLog.Warn("leadership transfer failed, continuing", "err", err)
 			} else {
-				log.Printf("Leadership transfer successful.")
+				raftLog.Info("leadership transferred")
 			}
 		case <-time.After(5 * time.Second):
-			log.Printf("Leadership transfer timed out (continuing).")
+			raftLog.Warn("leadership transfer timed out, continuing")
 		}
 	}
 
@@ -1483,9 +1662,9 @@ func (rm *RaftManager) monitorConfiguration() {
 			// 2. Identify if we are Leader
 			if leaderID == raft.ServerID(rm.NodeID) {
 				// We are Leader: Update own metadata if needed
-				currentHttpAddr := rm.FSM.GetNodeAddr(rm.NodeID)
-				if currentHttpAddr != rm.ClusterAdvertise {
-					log.Printf("[AutoConfig] Updating own HTTP address from %q to %q", currentHttpAddr, rm.ClusterAdvertise)
+				meta := rm.FSM.GetNodeMeta(rm.NodeID)
+				if meta == nil || meta.HttpAddr != rm.ClusterAdvertise || meta.AppVersion != CurrentAppVersion || meta.ProtocolVersion != CurrentProtocolVersion || meta.SchemaVersion != CurrentSchemaVersion {
+					raftLog.Info("autoconfig: updating own metadata", "httpAddr", rm.ClusterAdvertise, "appVersion", CurrentAppVersion)
 					cmd := RaftCommand{
 						Type: CmdNodeMeta,
 						NodeMeta: &NodeMeta{
@@ -1498,9 +1677,11 @@ func (rm *RaftManager) monitorConfiguration() {
 						},
 					}
 					if _, err := rm.Propose(cmd); err != nil {
-						log.Printf("[AutoConfig] Failed to update own metadata: %v", err)
+						raftLog.Warn("autoconfig: failed to update own metadata", "err", err)
 					}
 				}
+				rm.raiseActiveSchemaVersion()
+
 				// Update Raft Address if needed
 				cfg := rm.Raft.GetConfiguration()
 				if err := cfg.Error(); err == nil {
@@ -1511,9 +1692,9 @@ func (rm *RaftManager) monitorConfiguration() {
 								advertiseAddr = rm.Bind
 							}
 							if string(s.Address) != advertiseAddr {
-								log.Printf("[AutoConfig] Updating own Raft address from %q to %q", s.Address, advertiseAddr)
+								raftLog.Info("autoconfig: updating own Raft address", "from", string(s.Address), "to", advertiseAddr)
 								if f := rm.Raft.AddVoter(s.ID, raft.ServerAddress(advertiseAddr), 0, 0); f.Error() != nil {
-									log.Printf("[AutoConfig] Failed to update own Raft address: %v", f.Error())
+									raftLog.Warn("autoconfig: failed to update own Raft address", "err", f.Error())
 								}
 							}
 							break
@@ -1587,7 +1768,7 @@ func (rm *RaftManager) monitorConfiguration() {
 
 			req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/cluster/join", targetHTTP), bytes.NewBuffer(data))
 			if err != nil {
-				log.Printf("[AutoConfig] Failed to create join request: %v", err)
+				raftLog.Warn("autoconfig: failed to create join request", "err", err)
 				return
 			}
 			req.Header.Set("X-Raft-Secret", rm.Secret)
@@ -1595,17 +1776,17 @@ func (rm *RaftManager) monitorConfiguration() {
 
 			resp, err := client.Do(req)
 			if err != nil {
-				log.Printf("[AutoConfig] Failed to contact node at %s: %v", targetHTTP, err)
+				raftLog.Warn("autoconfig: failed to contact node", "addr", targetHTTP, "err", err)
 				continue
 			}
 			resp.Body.Close()
 
 			if resp.StatusCode == http.StatusOK {
-				log.Printf("[AutoConfig] Successfully registered with node at %s", targetHTTP)
+				raftLog.Info("autoconfig: registered with node", "addr", targetHTTP)
 				return
 			}
 
-			log.Printf("[AutoConfig] Registration failed: HTTP %d", resp.StatusCode)
+			raftLog.Warn("autoconfig: registration failed", "status", resp.StatusCode)
 		}
 	}
 }
@@ -1691,7 +1872,7 @@ func (rm *RaftManager) verifyPeerCertificate(rawCerts [][]byte, verifiedChains [
 		// we allow the connection to proceed. This allows the Leader to join us and replicate
 		// the cluster state (including valid public keys).
 		if !rm.Bootstrap && !rm.FSM.IsInitialized() {
-			log.Printf("Security Warning: TOFU accepted for node %s (initial join)", nodeID)
+			raftLog.Warn("trust on first use: accepted node for initial join", "nodeId", nodeID)
 			if rm.tofuCallback != nil {
 				rm.tofuCallback(nodeID)
 			}
@@ -1736,38 +1917,47 @@ func (rm *RaftManager) monitorLeadership(notifyCh <-chan bool) {
 		case <-rm.shutdownCh:
 			return
 		case isLeader := <-notifyCh:
+			if isLeader && rm.Draining() {
+				// A draining node must not keep the leadership it won back.
+				raftLog.Info("draining node became leader, transferring leadership")
+				go func() {
+					if err := rm.Raft.LeadershipTransfer().Error(); err != nil {
+						raftLog.Warn("leadership transfer failed", "err", err)
+					}
+				}()
+			}
 			if isLeader {
 				// We became leader. Calculate gap since last contact.
 				var gap time.Duration
 				last := rm.Raft.LastContact()
 				if !last.IsZero() {
 					gap = time.Since(last)
-					log.Printf("Leadership acquired. Gap since last contact: %v", gap)
+					raftLog.Info("leadership acquired", "gap", gap)
 				} else {
 					// Fallback: Check FSM for last metrics timestamp (handling restart)
 					// Wait for FSM to catch up with logs to ensure we have the latest metrics
 					// We use a longer timeout here to ensure full replay on large logs.
 					syncStart := time.Now()
 					if err := rm.WaitForSync(30 * time.Second); err != nil {
-						log.Printf("Warning: WaitForSync timed out in monitorLeadership: %v", err)
+						raftLog.Warn("FSM sync timed out after acquiring leadership", "err", err)
 					}
-					log.Printf("FSM sync completed in %v", time.Since(syncStart))
+					raftLog.Info("FSM sync completed", "duration", time.Since(syncStart))
 
 					lastTs := rm.FSM.GetLastMetricsTimestamp()
 					if lastTs > 0 {
 						gap = time.Since(time.Unix(lastTs, 0))
-						log.Printf("Leadership acquired. LastContact was zero. Calculated gap since FSM timestamp %d: %v", lastTs, gap)
+						raftLog.Info("leadership acquired, gap since FSM timestamp", "fsmTimestamp", lastTs, "gap", gap)
 					} else if !rm.startTime.IsZero() {
 						// Fallback 2: Gap since node start (Cold Start)
 						gap = time.Since(rm.startTime)
-						log.Printf("Leadership acquired. Cold start detected. Gap since node start: %v", gap)
+						raftLog.Info("leadership acquired on cold start, gap since node start", "gap", gap)
 					}
 				}
 
 				if gap > 0 {
 					atomic.AddInt64(&rm.pendingGapMS, gap.Milliseconds())
 				} else {
-					log.Printf("Leadership acquired. No gap detected (gap=%v)", gap)
+					raftLog.Info("leadership acquired, no gap detected", "gap", gap)
 				}
 			}
 		}
@@ -1818,12 +2008,17 @@ func (rm *RaftManager) reportMetrics() {
 		"activeWS":  activeWS,
 		"latency":   latency,
 	}
+	if pct, err := diskUsedPercent(rm.DataDir); err == nil {
+		payload["diskUsed"] = pct
+	} else {
+		raftLog.Debug("metrics: failed to get disk usage", "dir", rm.DataDir, "err", err)
+	}
 	data, _ := json.Marshal(payload)
 
 	// Send to Leader
 	leaderAddr := rm.GetLeaderHTTPAddr()
 	if leaderAddr == "" {
-		log.Printf("Metrics: No leader address found, skipping report (NodeID: %s)", rm.NodeID)
+		raftLog.Debug("metrics: no leader address, skipping report", "nodeId", rm.NodeID)
 		return
 	}
 
@@ -1835,7 +2030,7 @@ func (rm *RaftManager) reportMetrics() {
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
 	if err != nil {
-		log.Printf("Metrics Error: failed to create request: %v", err)
+		raftLog.Error("metrics: failed to create request", "err", err)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -1843,14 +2038,14 @@ func (rm *RaftManager) reportMetrics() {
 
 	resp, err := rm.httpClient.Do(req)
 	if err != nil {
-		log.Printf("Metrics Error: failed to send report to %s: %v", leaderAddr, err)
+		raftLog.Warn("metrics: failed to send report", "leader", leaderAddr, "err", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Metrics Error: Leader returned %d: %s", resp.StatusCode, string(body))
+		raftLog.Warn("metrics: leader rejected report", "status", resp.StatusCode, "body", string(body))
 	}
 }
 
@@ -1877,6 +2072,7 @@ func (rm *RaftManager) handleMetricsReport(w http.ResponseWriter, r *http.Reques
 		Total     uint64     `json:"total"`
 		ActiveWS  int        `json:"activeWS"`
 		Latency   *Histogram `json:"latency"`
+		DiskUsed  float64    `json:"diskUsed"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
@@ -1888,6 +2084,10 @@ func (rm *RaftManager) handleMetricsReport(w http.ResponseWriter, r *http.Reques
 	last, exists := rm.nodeCounters[req.NodeID]
 	rm.nodeCounters[req.NodeID] = req.Total
 	rm.countersMu.Unlock()
+	if !exists {
+		// New leader: continue from the count replicated by the previous one.
+		last, exists = rm.FSM.LastNodeTotal(req.NodeID)
+	}
 
 	var delta uint64
 	if !exists || req.Total < last {
@@ -1903,7 +2103,7 @@ func (rm *RaftManager) handleMetricsReport(w http.ResponseWriter, r *http.Reques
 	metricsCmd := &MetricsPayload{
 		Timestamp: req.Timestamp,
 		Nodes: []NodeMetric{
-			{NodeID: req.NodeID, RPS: rps, ActiveWS: req.ActiveWS, Latency: req.Latency},
+			{NodeID: req.NodeID, RPS: rps, ActiveWS: req.ActiveWS, Latency: req.Latency, Total: req.Total, DiskUsed: req.DiskUsed},
 		},
 	}
 
@@ -1943,15 +2143,163 @@ func (rm *RaftManager) handleMetricsReport(w http.ResponseWriter, r *http.Reques
 	}
 
 	if _, err := rm.Propose(cmd); err != nil {
-		log.Printf("Metrics Error: failed to propose update: %v", err)
+		raftLog.Error("metrics: failed to propose update", "err", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	// log.Printf("Metrics: Proposed update for node %s (RPS: %.2f)", req.NodeID, rps) // Debug log
+	raftLog.Debug("metrics: proposed update", "nodeId", req.NodeID, "rps", rps)
 	w.WriteHeader(http.StatusOK)
 }
 
+// monitorAlerts evaluates the alert rules every alertEvalInterval.
+func (rm *RaftManager) monitorAlerts() {
+	ticker := time.NewTicker(alertEvalInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rm.shutdownCh:
+			return
+		case <-ticker.C:
+			rm.evaluateAlerts(time.Now())
+		}
+	}
+}
+
+// monitorWebhooks delivers the webhook outbox every webhookDrainInterval.
+func (rm *RaftManager) monitorWebhooks() {
+	ticker := time.NewTicker(webhookDrainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rm.shutdownCh:
+			return
+		case <-ticker.C:
+			rm.drainWebhooks()
+		}
+	}
+}
+
+// drainWebhooks acknowledges the outbox entries delivered since the last call
+// with ACK_WEBHOOKS, and hands the entries that are not being delivered yet to
+// the WebhookManager. Only the leader delivers. A node that loses leadership
+// forgets its deliveries; the next leader delivers every entry that was not
+// acknowledged, so events are delivered at least once. Receivers deduplicate
+// them by event id and index.
+func (rm *RaftManager) drainWebhooks() {
+	rm.webhookMu.Lock()
+	if rm.Raft.State() != raft.Leader || !rm.FSM.deliversWebhooks() {
+		if rm.webhookInFlight != nil {
+			rm.webhookGen++
+		}
+		rm.webhookInFlight = nil
+		rm.webhookDelivered = nil
+		rm.webhookMu.Unlock()
+		return
+	}
+	if rm.webhookInFlight == nil {
+		rm.webhookInFlight = make(map[uint64]bool)
+	}
+	gen := rm.webhookGen
+	acks := rm.webhookDelivered
+	rm.webhookDelivered = nil
+	rm.webhookMu.Unlock()
+
+	if len(acks) > 0 {
+		if _, err := rm.Propose(RaftCommand{Type: CmdAckWebhooks, Indexes: acks}); err != nil {
+			raftLog.Warn("webhooks: failed to acknowledge deliveries", "entries", len(acks), "err", err)
+			rm.webhookMu.Lock()
+			if rm.webhookGen == gen {
+				rm.webhookDelivered = append(rm.webhookDelivered, acks...)
+			}
+			rm.webhookMu.Unlock()
+			return
+		}
+	}
+
+	outbox := rm.FSM.Webhooks().Outbox()
+	var todo []WebhookOutboxEntry
+	rm.webhookMu.Lock()
+	if rm.webhookGen != gen {
+		rm.webhookMu.Unlock()
+		return
+	}
+	queued := make(map[uint64]bool, len(outbox))
+	for _, e := range outbox {
+		queued[e.Index] = true
+	}
+	// Entries that left the outbox were acknowledged.
+	for i := range rm.webhookInFlight {
+		if !queued[i] {
+			delete(rm.webhookInFlight, i)
+		}
+	}
+	for _, e := range outbox {
+		if rm.webhookInFlight[e.Index] {
+			continue
+		}
+		if len(rm.webhookInFlight) >= webhookOutboxInFlight {
+			break
+		}
+		rm.webhookInFlight[e.Index] = true
+		todo = append(todo, e)
+	}
+	rm.webhookMu.Unlock()
+
+	for _, e := range todo {
+		index := e.Index
+		rm.FSM.dispatchWebhooks(e, func(dropped bool) {
+			rm.webhookMu.Lock()
+			defer rm.webhookMu.Unlock()
+			if rm.webhookGen != gen {
+				return
+			}
+			if dropped {
+				// The delivery queue was full. Try again on the next tick.
+				delete(rm.webhookInFlight, index)
+				return
+			}
+			rm.webhookDelivered = append(rm.webhookDelivered, index)
+		})
+	}
+}
+
+// evaluateAlerts evaluates the alert rules that apply to this node. The leader
+// evaluates every rule against the replicated metrics; followers only evaluate
+// no_leader rules, since a cluster without a leader has nobody else to do it.
+func (rm *RaftManager) evaluateAlerts(now time.Time) {
+	if addr, _ := rm.Raft.LeaderWithID(); addr == "" {
+		if rm.leaderLostAt.IsZero() {
+			rm.leaderLostAt = now
+		}
+	} else {
+		rm.leaderLostAt = time.Time{}
+	}
+	isLeader := rm.Raft.State() == raft.Leader
+	if !isLeader {
+		rm.alertsStart = time.Time{}
+	} else if rm.alertsStart.IsZero() {
+		rm.alertsStart = now
+	}
+
+	rm.FSM.Alerts().Evaluate(now, rm.NodeID, func(r *AlertRule) ([]AlertSample, bool) {
+		if r.Kind == AlertNoLeader {
+			if r.NodeID != "" && r.NodeID != rm.NodeID {
+				return nil, false
+			}
+			var v float64
+			if !rm.leaderLostAt.IsZero() {
+				v = now.Sub(rm.leaderLostAt).Seconds()
+			}
+			return []AlertSample{{Subject: rm.NodeID, Value: v}}, true
+		}
+		if !isLeader {
+			return nil, false
+		}
+		return rm.FSM.AlertSamples(r, now.Unix(), rm.alertsStart.Unix()), true
+	})
+}
+
 func (rm *RaftManager) handleMetricsQuery(w http.ResponseWriter, r *http.Request) {
 	// Allow any authenticated node/user to query?
 	// The doc says: "The Admin Dashboard and the GET ... endpoint are available on any node"
@@ -1964,7 +2312,68 @@ func (rm *RaftManager) handleMetricsQuery(w http.ResponseWriter, r *http.Request
 	// We also might want to check if user is Admin?
 	// For now, let's keep it open to authenticated users.
 
-	data := rm.FSM.GetMetricsJSON()
+	q := r.URL.Query()
+	if !q.Has("from") && !q.Has("to") && !q.Has("resolution") {
+		data := rm.FSM.GetMetricsJSON()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+
+	// Range query
+	now := time.Now()
+	to := now.Unix()
+	if v := q.Get("to"); v != "" {
+		t, err := parseMetricsTime(v)
+		if err != nil {
+			http.Error(w, "Bad Request: invalid to", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	res := q.Get("resolution")
+	var from int64
+	if v := q.Get("from"); v != "" {
+		t, err := parseMetricsTime(v)
+		if err != nil {
+			http.Error(w, "Bad Request: invalid from", http.StatusBadRequest)
+			return
+		}
+		from = t
+	} else {
+		// Default: the whole retention of the resolution, or the last hour.
+		from = to - int64(time.Hour.Seconds())
+		for _, cfg := range DefaultResolutions {
+			if cfg.Name == res {
+				from = to - int64(cfg.Retention.Seconds())
+			}
+		}
+	}
+	if from > to {
+		http.Error(w, "Bad Request: from is after to", http.StatusBadRequest)
+		return
+	}
+	if res == "" {
+		res = ResolutionFor(from, now)
+	}
+
+	data, err := rm.FSM.QueryMetrics(from, to, res)
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
+
+// parseMetricsTime parses a Unix timestamp in seconds or an RFC 3339 time.
+func parseMetricsTime(v string) (int64, error) {
+	if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
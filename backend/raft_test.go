@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -25,6 +26,7 @@ import (
 	"time"
 
 	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
 )
 
 func TestRaftSingleNode(t *testing.T) {
@@ -185,3 +187,28 @@ func TestRaftGetters(t *testing.T) {
 		t.Error("GetHTTPClient returned nil")
 	}
 }
+
+// startTestRaft bootstraps a single-node cluster around fsm and waits until
+// it is leader and the bootstrap has ingested the existing stores.
+func startTestRaft(t *testing.T, raftDir string, fsm *FSM) *RaftManager {
+	t.Helper()
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	raftAddr := l.Addr().String()
+	l.Close()
+	rm := NewRaftManager(raftDir, raftAddr, raftAddr, "127.0.0.1:8080", "127.0.0.1:8080", "secret", nil, fsm)
+	if err := rm.Start(true); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { rm.Shutdown() })
+	// The bootstrap ingests the stores once the node's metadata is applied;
+	// data saved before it is done could be overwritten by the ingestion.
+	select {
+	case <-rm.ingestedCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("bootstrap ingestion did not complete")
+	}
+	if rm.Raft.State() != raft.Leader || !fsm.IsInitialized() {
+		t.Fatal("no leader")
+	}
+	return rm
+}
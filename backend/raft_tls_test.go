@@ -179,6 +179,7 @@ func TestForwardRequestToLeader(t *testing.T) {
 	if err := rm1.Start(true); err != nil {
 		t.Fatalf("Leader start: %v", err)
 	}
+	defer rm1.Shutdown()
 
 	// Wait for leader
 	for {
@@ -208,6 +209,7 @@ func TestForwardRequestToLeader(t *testing.T) {
 	if err := rm2.Start(false); err != nil {
 		t.Fatalf("Follower start: %v", err)
 	}
+	defer rm2.Shutdown()
 
 	// 4. Join Follower to Cluster (bootstrap the cluster)
 	// We do this manually on Leader so Follower knows Leader exists.
@@ -32,6 +32,19 @@ const (
 	CmdUpdateAccessPolicy CommandType = "UPDATE_ACCESS_POLICY"
 	CmdMetricsUpdate      CommandType = "METRICS_UPDATE"
 	CmdDeleteAllUser      CommandType = "DELETE_ALL_USER"
+	CmdUpdateWebhook      CommandType = "UPDATE_WEBHOOK"
+	CmdDeleteWebhook      CommandType = "DELETE_WEBHOOK"
+	CmdRestoreGame        CommandType = "RESTORE_GAME"
+	CmdPurgeGame          CommandType = "PURGE_GAME"
+	CmdRestoreTeam        CommandType = "RESTORE_TEAM"
+	CmdPurgeTeam          CommandType = "PURGE_TEAM"
+	CmdUpdateAlertRule    CommandType = "UPDATE_ALERT_RULE"
+	CmdDeleteAlertRule    CommandType = "DELETE_ALERT_RULE"
+	CmdPutSavedSearch     CommandType = "PUT_SAVED_SEARCH"
+	CmdDeleteSavedSearch  CommandType = "DELETE_SAVED_SEARCH"
+	CmdAckWebhooks        CommandType = "ACK_WEBHOOKS"
+
+	CmdSetActiveSchemaVersion CommandType = "SET_ACTIVE_SCHEMA_VERSION"
 )
 
 // RaftCommand is a unified structure for all Raft log entries.
@@ -43,8 +56,23 @@ type RaftCommand struct {
 	TeamData       *json.RawMessage  `json:"teamData,omitempty"`
 	PolicyData     *UserAccessPolicy `json:"policyData,omitempty"`
 	MetricsPayload *MetricsPayload   `json:"metricsPayload,omitempty"`
+	Webhook        *Webhook          `json:"webhook,omitempty"`
+	AlertRule      *AlertRule        `json:"alertRule,omitempty"`
+	SavedSearch    *SavedSearch      `json:"savedSearch,omitempty"` // Owned by UserID
 	ID             string            `json:"id,omitempty"`
 	Force          bool              `json:"force,omitempty"`
+	SchemaVersion  int               `json:"schemaVersion,omitempty"` // CmdSetActiveSchemaVersion
+	Indexes        []uint64          `json:"indexes,omitempty"`       // CmdAckWebhooks
+
+	// Attribution, recorded in the audit log.
+	UserID    string `json:"userId,omitempty"`    // User who initiated the command
+	NodeID    string `json:"nodeId,omitempty"`    // Node that proposed the command
+	Timestamp int64  `json:"timestamp,omitempty"` // Proposal time (Unix ms)
+
+	// Trace is the W3C trace context of the proposing request, if any.
+	Trace map[string]string `json:"trace,omitempty"`
+	// RequestID is the X-Request-ID of the proposing request, for log correlation.
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // UserAccessPolicy defines global access rules and quotas.
@@ -72,6 +100,7 @@ type NodeMeta struct {
 	AppVersion      string `json:"appVersion,omitempty"`
 	ProtocolVersion int    `json:"protocolVersion,omitempty"`
 	SchemaVersion   int    `json:"schemaVersion,omitempty"`
+	Replica         bool   `json:"replica,omitempty"` // A read replica, not in the Raft configuration
 }
 
 // ActionPayload contains details for CmdApplyAction
@@ -0,0 +1,89 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// recoverCluster rebuilds the Raft configuration of a cluster that permanently
+// lost its quorum, with this node as the only voter. It replays this node's
+// snapshot and log into the FSM, writes the result as a new snapshot, and
+// returns the other nodes of the FSM's node map, which the new cluster must
+// forget before their replacements join.
+//
+// Every entry in the local log is applied, including entries the old cluster
+// may not have committed.
+func (rm *RaftManager) recoverCluster(config *raft.Config, logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore, trans raft.Transport) ([]string, error) {
+	addr := rm.Advertise
+	if addr == "" {
+		addr = rm.Bind
+	}
+	configuration := raft.Configuration{
+		Servers: []raft.Server{{ID: config.LocalID, Address: raft.ServerAddress(addr)}},
+	}
+
+	raftLog.Warn("recovering cluster with this node as the only voter", "nodeId", rm.NodeID, "raftAddr", addr)
+	// Replayed entries were applied before; their leader-only side effects,
+	// like webhooks, must not run again.
+	rm.recovering.Store(true)
+	err := raft.RecoverCluster(config, rm.FSM, logs, stable, snaps, trans, configuration)
+	rm.recovering.Store(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover cluster: %w", err)
+	}
+
+	var lost []string
+	for id, httpAddr := range rm.FSM.GetAllNodes() {
+		if id == rm.NodeID {
+			continue
+		}
+		raftLog.Warn("recovered cluster drops node", "nodeId", id, "httpAddr", httpAddr)
+		lost = append(lost, id)
+	}
+	slices.Sort(lost)
+	if rm.FSM.GetNodeMeta(rm.NodeID) == nil {
+		raftLog.Warn("this node was not in the recovered node map", "nodeId", rm.NodeID)
+	}
+	raftLog.Info("cluster recovered", "lastIndex", rm.FSM.LastAppliedIndex(), "droppedNodes", len(lost))
+	return lost, nil
+}
+
+// forgetNodes removes the given nodes from the replicated node map once this
+// node is the leader, so that their keys are no longer trusted.
+func (rm *RaftManager) forgetNodes(ids []string) {
+	for rm.Raft.State() != raft.Leader {
+		select {
+		case <-rm.shutdownCh:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	for _, id := range ids {
+		cmd := RaftCommand{
+			Type:     CmdNodeLeft,
+			NodeMeta: &NodeMeta{NodeID: id},
+		}
+		if _, err := rm.Propose(cmd); err != nil {
+			raftLog.Error("failed to remove lost node", "nodeId", id, "err", err)
+			continue
+		}
+		rm.nodeAddrMap.Delete(raft.ServerID(id))
+	}
+}
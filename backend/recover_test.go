@@ -0,0 +1,128 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/c2FmZQ/storage/crypto"
+	"github.com/hashicorp/raft"
+)
+
+func TestRaftRecoverFromSingleSurvivor(t *testing.T) {
+	baseDir := t.TempDir()
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+	raftAddrs := make(map[string]string)
+
+	startNode := func(id string, bootstrap, recover bool) *RaftManager {
+		dataDir := filepath.Join(baseDir, id)
+		raftDir := filepath.Join(dataDir, "raft")
+		s := storage.New(dataDir, mk)
+		gs := NewGameStore(dataDir, s)
+		ts := NewTeamStore(dataDir, s)
+		us := NewUserIndexStore(dataDir, s, nil)
+		fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), storage.New(raftDir, mk), us)
+
+		raftAddr, ok := raftAddrs[id]
+		if !ok {
+			l, _ := net.Listen("tcp", "127.0.0.1:0")
+			raftAddr = l.Addr().String()
+			l.Close()
+			raftAddrs[id] = raftAddr
+		}
+		rm := NewRaftManager(raftDir, raftAddr, raftAddr, "127.0.0.1:0", "127.0.0.1:0", "secret", mk, fsm)
+		rm.Recover = recover
+		if err := rm.Start(bootstrap); err != nil {
+			t.Fatalf("Start %s: %v", id, err)
+		}
+		return rm
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(15 * time.Second); !cond(); time.Sleep(50 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+	createGame := func(rm *RaftManager, id string) {
+		t.Helper()
+		raw := json.RawMessage(fmt.Sprintf(`{"id":%q,"actionLog":[],"schemaVersion":%d}`, id, CurrentSchemaVersion))
+		if _, err := rm.Propose(RaftCommand{Type: CmdSaveGame, ID: id, GameData: &raw}); err != nil {
+			t.Fatalf("propose %s: %v", id, err)
+		}
+	}
+	hasGames := func(rm *RaftManager, ids ...string) func() bool {
+		return func() bool {
+			gs, _ := rm.FSM.GetStores()
+			for _, id := range ids {
+				if _, err := gs.LoadGame(id); err != nil {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	join := func(leader, node *RaftManager) {
+		t.Helper()
+		if err := leader.Join(node.NodeID, node.Bind, node.ClusterAdvertise, node.FSM.GetNodePubKey(node.NodeID), false, CurrentAppVersion, CurrentProtocolVersion, CurrentSchemaVersion); err != nil {
+			t.Fatalf("join %s: %v", node.NodeID, err)
+		}
+	}
+
+	a := startNode("a", true, false)
+	waitFor("leader", func() bool { return a.Raft.State() == raft.Leader })
+	b := startNode("b", false, false)
+	c := startNode("c", false, false)
+	join(a, b)
+	join(a, c)
+	createGame(a, "g1")
+	waitFor("replication", hasGames(c, "g1"))
+	createGame(a, "g2")
+	waitFor("node map", func() bool { return a.FSM.GetNodeCount() == 3 })
+	lost := []string{b.NodeID, c.NodeID}
+
+	// Two of three nodes are gone for good; a alone has no quorum.
+	b.Shutdown()
+	c.Shutdown()
+	a.Shutdown()
+
+	a = startNode("a", false, true)
+	defer a.Shutdown()
+	waitFor("leader after recovery", func() bool { return a.Raft.State() == raft.Leader })
+	if !hasGames(a, "g1", "g2")() {
+		t.Fatal("games lost in recovery")
+	}
+	cfg := a.Raft.GetConfiguration()
+	if err := cfg.Error(); err != nil || len(cfg.Configuration().Servers) != 1 || cfg.Configuration().Servers[0].ID != raft.ServerID(a.NodeID) {
+		t.Fatalf("configuration = %+v, %v", cfg.Configuration(), err)
+	}
+	waitFor("lost nodes removed from node map", func() bool {
+		return a.FSM.GetNodeMeta(lost[0]) == nil && a.FSM.GetNodeMeta(lost[1]) == nil
+	})
+
+	// Replacement nodes join with fresh data.
+	createGame(a, "g3")
+	d := startNode("d", false, false)
+	defer d.Shutdown()
+	join(a, d)
+	waitFor("replication to new node", hasGames(d, "g1", "g2", "g3"))
+}
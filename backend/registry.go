@@ -15,11 +15,17 @@
 package backend
 
 import (
-	"log"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"maps"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
@@ -36,6 +42,7 @@ type Registry struct {
 	gameStore *GameStore
 	teamStore *TeamStore
 	userStore *UserIndexStore
+	search    *SearchIndex
 
 	mu sync.RWMutex
 
@@ -51,6 +58,12 @@ type Registry struct {
 	// Access Policy Cache
 	accessPolicy *UserAccessPolicy
 
+	// Change feed, see TrackChanges
+	changesMu      sync.Mutex // Guards the change logs
+	changeIndex    atomic.Uint64
+	changeTime     atomic.Int64 // Unix ms of the entries being applied
+	changesApplied atomic.Pointer[func() uint64]
+
 	// GC
 	stopChan chan struct{}
 	stopOnce sync.Once
@@ -67,6 +80,7 @@ func NewRegistry(gs *GameStore, ts *TeamStore, us *UserIndexStore, forceRebuild
 		gameStore:    gs,
 		teamStore:    ts,
 		userStore:    us,
+		search:       NewSearchIndex(us.DataDir, us.storage, us.masterKey),
 		gameMetadata: gmCache,
 		teamMetadata: tmCache,
 		stopChan:     make(chan struct{}),
@@ -77,7 +91,10 @@ func NewRegistry(gs *GameStore, ts *TeamStore, us *UserIndexStore, forceRebuild
 	} else {
 		// Fast Path: Count files (Total Objects)
 		r.RefreshCounts()
-		log.Printf("Registry: Fast startup. Found %d games, %d teams.", r.gameCount, r.teamCount)
+		storeLog.Info("registry fast startup", "games", r.gameCount, "teams", r.teamCount)
+		if !r.search.Ready() {
+			r.RebuildSearch()
+		}
 	}
 
 	r.StartGC()
@@ -111,7 +128,7 @@ func (r *Registry) StopGC() {
 
 // PurgeOldTombstones permanently deletes expired tombstones from disk.
 func (r *Registry) PurgeOldTombstones() {
-	log.Println("Registry: Garbage collection of expired tombstones started...")
+	storeLog.Debug("registry tombstone GC started")
 	now := time.Now().UnixNano()
 	cutoff := now - tombstoneTTL.Nanoseconds()
 
@@ -121,11 +138,12 @@ func (r *Registry) PurgeOldTombstones() {
 	// 1. GC Teams
 	for t, err := range r.teamStore.ListAllTeamMetadata() {
 		if err != nil {
-			log.Printf("Registry GC: error listing teams: %v", err)
+			storeLog.Error("registry GC: listing teams", "err", err)
 			break
 		}
 		if t.Status == "deleted" && t.DeletedAt > 0 && t.DeletedAt < cutoff {
 			if purgeErr := r.teamStore.PurgeTeam(t.ID); purgeErr == nil {
+				r.purgedTeam(t)
 				purgedTeams++
 			}
 		}
@@ -134,21 +152,28 @@ func (r *Registry) PurgeOldTombstones() {
 	// 2. GC Games
 	for g, err := range r.gameStore.ListAllGameMetadata() {
 		if err != nil {
-			log.Printf("Registry GC: error listing games: %v", err)
+			storeLog.Error("registry GC: listing games", "err", err)
 			break
 		}
 		if g.Status == "deleted" && g.DeletedAt > 0 && g.DeletedAt < cutoff {
 			if purgeErr := r.gameStore.PurgeGame(g.ID); purgeErr == nil {
+				r.setTrashed(g.OwnerID, g.ID, false, false)
 				purgedGames++
 			}
 		}
 	}
 
 	if purgedTeams > 0 || purgedGames > 0 {
-		log.Printf("Registry: GC complete. Purged %d games, %d teams.", purgedGames, purgedTeams)
+		storeLog.Info("registry GC complete", "purgedGames", purgedGames, "purgedTeams", purgedTeams)
 	}
 }
 
+// purgedTeam drops the indices kept for a team tombstone that was purged from disk.
+func (r *Registry) purgedTeam(t TeamMetadata) {
+	r.setTrashed(t.OwnerID, t.ID, true, false)
+	r.userStore.DeleteTeamGames(t.ID)
+}
+
 // RefreshCounts updates the global game and team counts by listing files.
 // This is a fast operation that avoids full scanning.
 func (r *Registry) RefreshCounts() {
@@ -180,12 +205,16 @@ func (r *Registry) GetAccessPolicy() *UserAccessPolicy {
 // Flush persists the registry state (indices).
 func (r *Registry) Flush() error {
 	// 1. Flush indices
-	return r.userStore.FlushAll()
+	if err := r.userStore.FlushAll(); err != nil {
+		return err
+	}
+	// 2. Flush search index
+	return r.search.FlushAll()
 }
 
 // Rebuild reconstructs the entire index by scanning the underlying stores.
 func (r *Registry) Rebuild() {
-	log.Println("Registry: Rebuild started...")
+	storeLog.Info("registry rebuild started")
 
 	var localGameCount int
 	var localTeamCount int
@@ -193,14 +222,20 @@ func (r *Registry) Rebuild() {
 	now := time.Now().UnixNano()
 	cutoff := now - tombstoneTTL.Nanoseconds()
 
+	if err := r.search.Reset(); err != nil {
+		storeLog.Error("registry rebuild: resetting search index", "err", err)
+	}
+
 	// 1. Index Teams
 	for t, err := range r.teamStore.ListAllTeamMetadata() {
 		if err != nil {
-			log.Printf("Registry: Error listing teams: %v", err)
+			storeLog.Error("registry rebuild: listing teams", "err", err)
 			break
 		}
 		if t.Status == "deleted" && t.DeletedAt > 0 && t.DeletedAt < cutoff {
-			r.teamStore.PurgeTeam(t.ID)
+			if r.teamStore.PurgeTeam(t.ID) == nil {
+				r.purgedTeam(t)
+			}
 			continue
 		}
 		if r.indexTeam(t.ID, t, true) {
@@ -211,11 +246,13 @@ func (r *Registry) Rebuild() {
 	// 2. Index Games
 	for g, err := range r.gameStore.ListAllGameMetadata() {
 		if err != nil {
-			log.Printf("Registry: Error listing games: %v", err)
+			storeLog.Error("registry rebuild: listing games", "err", err)
 			break
 		}
 		if g.Status == "deleted" && g.DeletedAt > 0 && g.DeletedAt < cutoff {
-			r.gameStore.PurgeGame(g.ID)
+			if r.gameStore.PurgeGame(g.ID) == nil {
+				r.setTrashed(g.OwnerID, g.ID, false, false)
+			}
 			continue
 		}
 		if r.indexGame(g.ID, g, true) {
@@ -231,14 +268,53 @@ func (r *Registry) Rebuild() {
 
 	// 4. Persist
 	if err := r.userStore.FlushAll(); err != nil {
-		log.Printf("Registry: Warning: failed to flush user indices: %v", err)
+		storeLog.Warn("registry rebuild: failed to flush user indices", "err", err)
+	}
+	if err := r.search.FlushAll(); err != nil {
+		storeLog.Warn("registry rebuild: failed to flush search index", "err", err)
 	}
 
 	r.mu.RLock()
-	log.Printf("Registry: Rebuild complete. Indexed %d games, %d teams.", r.gameCount, r.teamCount)
+	storeLog.Info("registry rebuild complete", "games", r.gameCount, "teams", r.teamCount)
 	r.mu.RUnlock()
 }
 
+// RebuildSearch reconstructs the full-text search index from the game and
+// team metadata, leaving the access indices untouched.
+func (r *Registry) RebuildSearch() {
+	if err := r.search.Reset(); err != nil {
+		storeLog.Error("search index rebuild: reset failed", "err", err)
+		return
+	}
+
+	var games, teams int
+	for t, err := range r.teamStore.ListAllTeamMetadata() {
+		if err != nil {
+			storeLog.Error("search index rebuild: listing teams", "err", err)
+			break
+		}
+		if t.Status != "deleted" {
+			r.search.IndexTeam(t)
+			teams++
+		}
+	}
+	for g, err := range r.gameStore.ListAllGameMetadata() {
+		if err != nil {
+			storeLog.Error("search index rebuild: listing games", "err", err)
+			break
+		}
+		if g.Status != "deleted" {
+			r.search.IndexGame(g)
+			games++
+		}
+	}
+
+	if err := r.search.FlushAll(); err != nil {
+		storeLog.Warn("search index rebuild: flush failed", "err", err)
+	}
+	storeLog.Info("search index rebuilt", "games", games, "teams", teams)
+}
+
 // indexTeam processes a team for indexing (Rebuild/Update).
 // Returns true if the team was indexed (i.e. not deleted).
 func (r *Registry) indexTeam(teamId string, t TeamMetadata, isRebuild bool) bool {
@@ -246,16 +322,26 @@ func (r *Registry) indexTeam(teamId string, t TeamMetadata, isRebuild bool) bool
 	r.teamMetadata.Add(teamId, t)
 
 	if t.Status == "deleted" {
+		r.search.RemoveTeam(teamId)
 		// Ensure user indices are cleaned up
 		oldIdx, _ := r.userStore.GetTeamUsers(teamId)
 		for u := range oldIdx.UserIDs {
 			r.updateUserTeamAccess(u, teamId, AccessNone)
 		}
+		if !isRebuild {
+			r.recordTeamChange(teamId, ChangeDeleted, oldIdx.UserIDs, oldIdx.UserIDs)
+		}
+		r.indexTransfer(oldIdx.Transfer, nil)
 		r.userStore.DeleteTeamUsers(teamId)
-		r.userStore.DeleteTeamGames(teamId)
+		r.setTrashed(t.OwnerID, teamId, true, t.Restorable)
 		return false
 	}
 
+	r.setTrashed(t.OwnerID, teamId, true, false)
+
+	r.search.IndexTeam(t)
+	r.indexTeamPlayers(teamId, t.PlayerIDs)
+
 	// Update TeamUsersIndex
 	newMembers := make(map[string]bool)
 	newMembers[t.OwnerID] = true
@@ -271,11 +357,16 @@ func (r *Registry) indexTeam(teamId string, t TeamMetadata, isRebuild bool) bool
 
 	oldIdx, _ := r.userStore.GetTeamUsers(teamId)
 	isNew := len(oldIdx.UserIDs) == 0
+	members := maps.Clone(newMembers) // Before and after the change
+	accessChanged := make(map[string]bool)
 
 	// Identify Removed
 	for u := range oldIdx.UserIDs {
 		if !newMembers[u] {
-			r.updateUserTeamAccess(u, teamId, AccessNone)
+			members[u] = true
+			if r.updateUserTeamAccess(u, teamId, AccessNone) {
+				accessChanged[u] = true
+			}
 		}
 	}
 
@@ -304,11 +395,15 @@ func (r *Registry) indexTeam(teamId string, t TeamMetadata, isRebuild bool) bool
 
 	for u := range newMembers {
 		level := getLevel(u)
-		r.updateUserTeamAccess(u, teamId, level)
+		if r.updateUserTeamAccess(u, teamId, level) {
+			accessChanged[u] = true
+		}
 	}
 
-	if !maps.Equal(oldIdx.UserIDs, newMembers) {
+	transfer := teamTransfer(t)
+	if r.indexTransfer(oldIdx.Transfer, transfer) || !maps.Equal(oldIdx.UserIDs, newMembers) {
 		oldIdx.UserIDs = newMembers
+		oldIdx.Transfer = transfer
 		r.userStore.SetTeamUsers(oldIdx)
 	}
 
@@ -317,6 +412,13 @@ func (r *Registry) indexTeam(teamId string, t TeamMetadata, isRebuild bool) bool
 		r.teamCount++
 		r.mu.Unlock()
 	}
+	if !isRebuild {
+		op := ChangeUpdated
+		if isNew {
+			op = ChangeCreated
+		}
+		r.recordTeamChange(teamId, op, members, accessChanged)
+	}
 	return true
 }
 
@@ -327,15 +429,24 @@ func (r *Registry) indexGame(gameId string, g GameMetadata, isRebuild bool) bool
 	r.gameMetadata.Add(gameId, g)
 
 	if g.Status == "deleted" {
+		r.search.RemoveGame(gameId)
 		// Ensure user indices are cleaned up
 		oldIdx, _ := r.userStore.GetGameUsers(gameId)
 		for u := range oldIdx.UserIDs {
 			r.updateUserGameAccess(u, gameId, AccessNone)
 		}
+		if !isRebuild && len(oldIdx.UserIDs) > 0 {
+			r.recordGameChange(g, ChangeDeleted, oldIdx.UserIDs, nil)
+		}
+		r.indexTransfer(oldIdx.Transfer, nil)
 		r.userStore.DeleteGameUsers(gameId)
+		r.setTrashed(g.OwnerID, gameId, false, g.Restorable)
 		return false
 	}
 
+	r.setTrashed(g.OwnerID, gameId, false, false)
+	r.search.IndexGame(g)
+
 	// Update GameUsersIndex (Direct Access Only)
 	newUsers := make(map[string]bool)
 	newUsers[g.OwnerID] = true
@@ -348,11 +459,16 @@ func (r *Registry) indexGame(gameId string, g GameMetadata, isRebuild bool) bool
 
 	oldIdx, _ := r.userStore.GetGameUsers(gameId)
 	isNew := len(oldIdx.UserIDs) == 0
+	users := maps.Clone(newUsers) // Direct users before and after the change
+	accessChanged := make(map[string]bool)
 
 	// Removed (Direct)
 	for u := range oldIdx.UserIDs {
 		if !newUsers[u] {
-			r.updateUserGameAccess(u, gameId, AccessNone)
+			users[u] = true
+			if r.updateUserGameAccess(u, gameId, AccessNone) {
+				accessChanged[u] = true
+			}
 		}
 	}
 
@@ -385,11 +501,15 @@ func (r *Registry) indexGame(gameId string, g GameMetadata, isRebuild bool) bool
 
 	for u := range newUsers {
 		level := getLevel(u)
-		r.updateUserGameAccess(u, gameId, level)
+		if r.updateUserGameAccess(u, gameId, level) {
+			accessChanged[u] = true
+		}
 	}
 
-	if !maps.Equal(oldIdx.UserIDs, newUsers) {
+	transfer := gameTransfer(g)
+	if r.indexTransfer(oldIdx.Transfer, transfer) || !maps.Equal(oldIdx.UserIDs, newUsers) {
 		oldIdx.UserIDs = newUsers
+		oldIdx.Transfer = transfer
 		r.userStore.SetGameUsers(oldIdx)
 	}
 
@@ -402,10 +522,19 @@ func (r *Registry) indexGame(gameId string, g GameMetadata, isRebuild bool) bool
 		r.gameCount++
 		r.mu.Unlock()
 	}
+	if !isRebuild {
+		op := ChangeUpdated
+		if isNew {
+			op = ChangeCreated
+		}
+		r.recordGameChange(g, op, users, accessChanged)
+	}
 	return true
 }
 
-func (r *Registry) updateUserTeamAccess(userId, teamId string, level AccessLevel) {
+// updateUserTeamAccess sets the access level of a user to a team and reports
+// whether it changed.
+func (r *Registry) updateUserTeamAccess(userId, teamId string, level AccessLevel) bool {
 	idx, _ := r.userStore.GetUserIndex(userId)
 	changed := false
 	if level == AccessNone {
@@ -422,9 +551,12 @@ func (r *Registry) updateUserTeamAccess(userId, teamId string, level AccessLevel
 	if changed {
 		r.userStore.SetUserIndex(idx)
 	}
+	return changed
 }
 
-func (r *Registry) updateUserGameAccess(userId, gameId string, level AccessLevel) {
+// updateUserGameAccess sets the direct access level of a user to a game and
+// reports whether it changed.
+func (r *Registry) updateUserGameAccess(userId, gameId string, level AccessLevel) bool {
 	idx, _ := r.userStore.GetUserIndex(userId)
 	changed := false
 	if level == AccessNone {
@@ -441,6 +573,7 @@ func (r *Registry) updateUserGameAccess(userId, gameId string, level AccessLevel
 	if changed {
 		r.userStore.SetUserIndex(idx)
 	}
+	return changed
 }
 
 func (r *Registry) addTeamGame(teamId, gameId string) {
@@ -457,10 +590,7 @@ func (r *Registry) addTeamGame(teamId, gameId string) {
 }
 
 func (r *Registry) UpdateTeam(t Team) {
-	r.indexTeam(t.ID, TeamMetadata{
-		ID: t.ID, Name: t.Name, OwnerID: t.OwnerID, Roles: t.Roles,
-		UpdatedAt: t.UpdatedAt, Status: t.Status, DeletedAt: t.DeletedAt,
-	}, false)
+	r.indexTeam(t.ID, *t.Metadata(), false)
 }
 
 func (r *Registry) UpdateGame(g Game) {
@@ -468,22 +598,34 @@ func (r *Registry) UpdateGame(g Game) {
 }
 
 func (r *Registry) DeleteGame(gameId string) {
+	m := r.deletedGameMetadata(gameId)
 	r.markGameDeleted(gameId, time.Now().UnixNano())
+	r.search.RemoveGame(gameId)
 	guIdx, _ := r.userStore.GetGameUsers(gameId)
 	for u := range guIdx.UserIDs {
 		r.updateUserGameAccess(u, gameId, AccessNone)
 	}
+	if len(guIdx.UserIDs) > 0 {
+		r.recordGameChange(m, ChangeDeleted, guIdx.UserIDs, nil)
+	}
 	r.userStore.DeleteGameUsers(gameId)
+	r.updateGameTrash(gameId)
 }
 
+// DeleteTeam removes a deleted team from the indices. Its game list is kept
+// until the tombstone is purged, so the team can be restored from the trash.
 func (r *Registry) DeleteTeam(teamId string) {
 	r.markTeamDeleted(teamId, time.Now().UnixNano())
+	r.search.RemoveTeam(teamId)
 	tuIdx, _ := r.userStore.GetTeamUsers(teamId)
 	for u := range tuIdx.UserIDs {
 		r.updateUserTeamAccess(u, teamId, AccessNone)
 	}
+	if len(tuIdx.UserIDs) > 0 {
+		r.recordTeamChange(teamId, ChangeDeleted, tuIdx.UserIDs, tuIdx.UserIDs)
+	}
 	r.userStore.DeleteTeamUsers(teamId)
-	r.userStore.DeleteTeamGames(teamId)
+	r.updateTeamTrash(teamId)
 }
 
 func (r *Registry) markGameDeleted(id string, ts int64) {
@@ -535,11 +677,7 @@ func (r *Registry) IsTeamDeleted(id string) bool {
 	}
 	t, err := r.teamStore.LoadTeam(id)
 	if err == nil {
-		m := TeamMetadata{
-			ID: t.ID, Name: t.Name, OwnerID: t.OwnerID, Roles: t.Roles,
-			UpdatedAt: t.UpdatedAt, Status: t.Status, DeletedAt: t.DeletedAt,
-		}
-		r.teamMetadata.Add(id, m)
+		r.teamMetadata.Add(id, *t.Metadata())
 		return t.Status == "deleted"
 	}
 	return false
@@ -684,8 +822,7 @@ func (r *Registry) CountOwnedTeams(userId string) int {
 			}
 		} else {
 			if t, err := r.teamStore.LoadTeam(tId); err == nil && t.Status != "deleted" {
-				m := TeamMetadata{ID: t.ID, Name: t.Name, OwnerID: t.OwnerID, Status: t.Status}
-				r.teamMetadata.Add(tId, m)
+				r.teamMetadata.Add(tId, *t.Metadata())
 				if t.OwnerID == userId {
 					count++
 				}
@@ -707,35 +844,225 @@ func (r *Registry) CountTotalTeams() int {
 	return r.teamCount
 }
 
+// gameHit is a listed game and its search relevance.
+type gameHit struct {
+	meta  GameMetadata
+	score int
+}
+
+// gameHitLess reports whether a sorts before b in ascending sortBy order.
+// Ties are broken by ID.
+func gameHitLess(sortBy string, a, b gameHit) bool {
+	switch sortBy {
+	case "relevance":
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		if a.meta.Date != b.meta.Date {
+			return a.meta.Date < b.meta.Date
+		}
+	case "date":
+		if a.meta.Date != b.meta.Date {
+			return a.meta.Date < b.meta.Date
+		}
+	case "event":
+		if a.meta.Event != b.meta.Event {
+			return a.meta.Event < b.meta.Event
+		}
+	case "location":
+		if a.meta.Location != b.meta.Location {
+			return a.meta.Location < b.meta.Location
+		}
+	case "updated":
+		if a.meta.UpdatedAt != b.meta.UpdatedAt {
+			return a.meta.UpdatedAt < b.meta.UpdatedAt
+		}
+	}
+	return a.meta.ID < b.meta.ID
+}
+
+// teamHit is a listed team and its search relevance.
+type teamHit struct {
+	meta  TeamMetadata
+	score int
+}
+
+// teamHitLess reports whether a sorts before b in ascending sortBy order.
+// Ties are broken by ID.
+func teamHitLess(sortBy string, a, b teamHit) bool {
+	switch sortBy {
+	case "relevance":
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		if a.meta.UpdatedAt != b.meta.UpdatedAt {
+			return a.meta.UpdatedAt < b.meta.UpdatedAt
+		}
+	case "name":
+		if a.meta.Name != b.meta.Name {
+			return a.meta.Name < b.meta.Name
+		}
+	case "updated":
+		if a.meta.UpdatedAt != b.meta.UpdatedAt {
+			return a.meta.UpdatedAt < b.meta.UpdatedAt
+		}
+	}
+	return a.meta.ID < b.meta.ID
+}
+
+// listCursor is an opaque continuation cursor of a game or team listing: the
+// listing's parameters and the sort key of the last item of a page.
+type listCursor struct {
+	Sort     string `json:"s"`
+	Order    string `json:"o"`
+	Query    string `json:"q,omitempty"`
+	Since    int64  `json:"t,omitempty"`
+	ID       string `json:"id"`
+	Score    int    `json:"r,omitempty"`
+	Date     string `json:"d,omitempty"`
+	Event    string `json:"e,omitempty"`
+	Location string `json:"l,omitempty"`
+	Name     string `json:"n,omitempty"`
+	Updated  int64  `json:"u,omitempty"`
+}
+
+// errInvalidCursor is returned for malformed continuation cursors.
+var errInvalidCursor = errors.New("invalid cursor")
+
+func encodeCursor(c listCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &c) != nil || c.ID == "" {
+		return c, errInvalidCursor
+	}
+	return c, nil
+}
+
+// ListPage is one page of a game or team listing.
+type ListPage struct {
+	IDs        []string
+	Offset     int
+	Total      int
+	NextCursor string // Empty on the last page
+}
+
+// pageBounds returns the bounds of the page of up to limit hits that follow
+// after, or that start at offset when after is nil. hits are sorted by less.
+func pageBounds[H any](hits []H, less func(a, b H) bool, after *H, offset, limit int) (int, int) {
+	start := min(offset, len(hits))
+	if after != nil {
+		start = sort.Search(len(hits), func(i int) bool { return less(*after, hits[i]) })
+	}
+	return start, min(start+limit, len(hits))
+}
+
+// ListGames returns the IDs of the games accessible to userId that match
+// query, sorted by sortBy ("date", "event", "location", "updated" or
+// "relevance") in order ("asc" or "desc").
 func (r *Registry) ListGames(userId, sortBy, order, query string) []string {
+	hits, _, _ := r.listGameHits(userId, sortBy, order, query, 0)
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.meta.ID
+	}
+	return ids
+}
+
+// PageGames returns a page of up to limit games of the listing of ListGames,
+// restricted to the games updated after since (Unix ms) when since is set.
+// The page starts after cursor, which carries the parameters of the listing,
+// or at offset when cursor is empty.
+func (r *Registry) PageGames(userId, sortBy, order, query, cursor string, since int64, offset, limit int) (ListPage, error) {
+	var after *gameHit
+	if cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return ListPage{}, err
+		}
+		after = &gameHit{
+			meta:  GameMetadata{ID: c.ID, Date: c.Date, Event: c.Event, Location: c.Location, UpdatedAt: c.Updated},
+			score: c.Score,
+		}
+		sortBy, order, query, since = c.Sort, c.Order, c.Query, c.Since
+	}
+
+	hits, sortBy, order := r.listGameHits(userId, sortBy, order, query, since)
+	less := func(a, b gameHit) bool {
+		if order == "desc" {
+			return gameHitLess(sortBy, b, a)
+		}
+		return gameHitLess(sortBy, a, b)
+	}
+	start, end := pageBounds(hits, less, after, offset, limit)
+
+	page := ListPage{IDs: make([]string, 0, end-start), Offset: start, Total: len(hits)}
+	for _, h := range hits[start:end] {
+		page.IDs = append(page.IDs, h.meta.ID)
+	}
+	if end > start && end < len(hits) {
+		last := hits[end-1]
+		page.NextCursor = encodeCursor(listCursor{
+			Sort:     sortBy,
+			Order:    order,
+			Query:    query,
+			Since:    since,
+			ID:       last.meta.ID,
+			Score:    last.score,
+			Date:     last.meta.Date,
+			Event:    last.meta.Event,
+			Location: last.meta.Location,
+			Updated:  last.meta.UpdatedAt,
+		})
+	}
+	return page, nil
+}
+
+// listGameHits returns the sorted games of a listing, with the effective sort
+// field and order. When since is set, only the games updated after since are
+// listed, by default in the order of their updates.
+func (r *Registry) listGameHits(userId, sortBy, order, query string, since int64) ([]gameHit, string, string) {
+	expr := parseLowerExpr(query, "date")
+
+	// Candidates for the free text that all results contain come from the
+	// search index, which also scores relevance.
+	var scores map[string]int
+	if q := expr.Query(); len(q.FreeText) > 0 {
+		scores = r.search.SearchGames(q.FreeText)
+	}
+
 	// Defaults
 	if sortBy == "" {
-		sortBy = "date"
+		switch {
+		case since > 0:
+			sortBy = "updated"
+		case scores != nil:
+			sortBy = "relevance"
+		default:
+			sortBy = "date"
+		}
 	}
 	if order == "" {
-		if sortBy == "date" {
+		if sortBy == "date" || sortBy == "relevance" {
 			order = "desc"
 		} else {
 			order = "asc"
 		}
 	}
 
-	q := search.Parse(query)
-	for i, t := range q.FreeText {
-		q.FreeText[i] = strings.ToLower(t)
-	}
-	for i, f := range q.Filters {
-		if f.Key != "date" {
-			q.Filters[i].Value = strings.ToLower(f.Value)
-		}
-	}
-
 	idx, err := r.userStore.GetUserIndex(userId)
 	if err != nil {
-		return []string{}
+		return nil, sortBy, order
+	}
+	var pIdx *UserIndex
+	if userId != "" {
+		pIdx, _ = r.userStore.GetUserIndex("")
 	}
 
-	var ids []string
 	getMeta := func(id string) (GameMetadata, bool) {
 		if m, ok := r.gameMetadata.Get(id); ok {
 			return m, true
@@ -749,131 +1076,163 @@ func (r *Registry) ListGames(userId, sortBy, order, query string) []string {
 		return m, true
 	}
 
+	var hits []gameHit
 	seen := make(map[string]bool)
-	for id := range idx.GameAccess {
-		meta, ok := getMeta(id)
-		if !ok || meta.Status == "deleted" || !matchesGame(meta, q) {
-			continue
+	add := func(id string) {
+		if seen[id] {
+			return
 		}
-		ids = append(ids, id)
 		seen[id] = true
+		meta, ok := getMeta(id)
+		if !ok || meta.Status == "deleted" || (since > 0 && meta.UpdatedAt <= since) || !r.matchesGame(userId, meta, expr) {
+			return
+		}
+		hits = append(hits, gameHit{meta: meta, score: scores[id]})
 	}
 
-	// Add team games
-	for teamId := range idx.TeamAccess {
-		tg, _ := r.userStore.GetTeamGames(teamId)
-		for id := range tg.GameIDs {
-			if seen[id] {
-				continue
-			}
+	if scores != nil {
+		// Only look at the matching games.
+		for id := range scores {
 			meta, ok := getMeta(id)
-			if !ok || meta.Status == "deleted" || !matchesGame(meta, q) {
-				continue
+			if ok && canListGame(meta, idx, pIdx) {
+				add(id)
 			}
-			ids = append(ids, id)
-			seen[id] = true
 		}
-	}
-
-	if userId != "" {
-		pIdx, err := r.userStore.GetUserIndex("")
-		if err == nil {
+	} else {
+		for id := range idx.GameAccess {
+			add(id)
+		}
+		// Add team games
+		for teamId := range idx.TeamAccess {
+			tg, _ := r.userStore.GetTeamGames(teamId)
+			for id := range tg.GameIDs {
+				add(id)
+			}
+		}
+		if pIdx != nil {
 			for id := range pIdx.GameAccess {
-				if seen[id] {
-					continue
-				}
-				meta, ok := getMeta(id)
-				if !ok || meta.Status == "deleted" || !matchesGame(meta, q) {
-					continue
-				}
-				ids = append(ids, id)
-				seen[id] = true
+				add(id)
 			}
 		}
 	}
 
-	sort.Slice(ids, func(i, j int) bool {
-		id1, id2 := ids[i], ids[j]
-		m1, ok1 := getMeta(id1)
-		m2, ok2 := getMeta(id2)
-		if !ok1 || !ok2 {
-			if order == "desc" {
-				return id1 > id2
-			}
-			return id1 < id2
-		}
-		var less bool
-		switch sortBy {
-		case "date":
-			if m1.Date != m2.Date {
-				less = m1.Date < m2.Date
-			} else {
-				less = id1 < id2
-			}
-		case "event":
-			if m1.Event != m2.Event {
-				less = m1.Event < m2.Event
-			} else {
-				less = id1 < id2
-			}
-		case "location":
-			if m1.Location != m2.Location {
-				less = m1.Location < m2.Location
-			} else {
-				less = id1 < id2
-			}
-		default:
-			less = id1 < id2
-		}
+	sort.Slice(hits, func(i, j int) bool {
 		if order == "desc" {
-			switch sortBy {
-			case "date":
-				if m1.Date != m2.Date {
-					return m1.Date > m2.Date
-				}
-				return id1 > id2
-			case "event":
-				if m1.Event != m2.Event {
-					return m1.Event > m2.Event
-				}
-				return id1 > id2
-			case "location":
-				if m1.Location != m2.Location {
-					return m1.Location > m2.Location
-				}
-				return id1 > id2
-			default:
-				return id1 > id2
-			}
+			return gameHitLess(sortBy, hits[j], hits[i])
 		}
-		return less
+		return gameHitLess(sortBy, hits[i], hits[j])
 	})
-	return ids
+	return hits, sortBy, order
 }
 
+// canListGame reports whether a game is in the listing of the user with
+// index idx: directly, through one of its teams, or publicly (pIdx, the index
+// of anonymous access, when the user isn't anonymous).
+func canListGame(m GameMetadata, idx, pIdx *UserIndex) bool {
+	if _, ok := idx.GameAccess[m.ID]; ok {
+		return true
+	}
+	for _, teamId := range []string{m.AwayTeamID, m.HomeTeamID} {
+		if _, ok := idx.TeamAccess[teamId]; ok && teamId != "" {
+			return true
+		}
+	}
+	if pIdx != nil {
+		if _, ok := pIdx.GameAccess[m.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTeams returns the IDs of the teams accessible to userId that match
+// query, sorted by sortBy ("name", "updated" or "relevance") in order ("asc"
+// or "desc").
 func (r *Registry) ListTeams(userId, sortBy, order, query string) []string {
+	hits, _, _ := r.listTeamHits(userId, sortBy, order, query)
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.meta.ID
+	}
+	return ids
+}
+
+// PageTeams returns a page of up to limit teams of the listing of ListTeams.
+// The page starts after cursor, which carries the parameters of the listing,
+// or at offset when cursor is empty.
+func (r *Registry) PageTeams(userId, sortBy, order, query, cursor string, offset, limit int) (ListPage, error) {
+	var after *teamHit
+	if cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return ListPage{}, err
+		}
+		after = &teamHit{
+			meta:  TeamMetadata{ID: c.ID, Name: c.Name, UpdatedAt: c.Updated},
+			score: c.Score,
+		}
+		sortBy, order, query = c.Sort, c.Order, c.Query
+	}
+
+	hits, sortBy, order := r.listTeamHits(userId, sortBy, order, query)
+	less := func(a, b teamHit) bool {
+		if order == "desc" {
+			return teamHitLess(sortBy, b, a)
+		}
+		return teamHitLess(sortBy, a, b)
+	}
+	start, end := pageBounds(hits, less, after, offset, limit)
+
+	page := ListPage{IDs: make([]string, 0, end-start), Offset: start, Total: len(hits)}
+	for _, h := range hits[start:end] {
+		page.IDs = append(page.IDs, h.meta.ID)
+	}
+	if end > start && end < len(hits) {
+		last := hits[end-1]
+		page.NextCursor = encodeCursor(listCursor{
+			Sort:    sortBy,
+			Order:   order,
+			Query:   query,
+			ID:      last.meta.ID,
+			Score:   last.score,
+			Name:    last.meta.Name,
+			Updated: last.meta.UpdatedAt,
+		})
+	}
+	return page, nil
+}
+
+// listTeamHits returns the sorted teams of a listing, with the effective sort
+// field and order.
+func (r *Registry) listTeamHits(userId, sortBy, order, query string) ([]teamHit, string, string) {
+	expr := parseLowerExpr(query)
+
+	// Candidates for the free text that all results contain come from the
+	// search index, which also scores relevance.
+	var scores map[string]int
+	if q := expr.Query(); len(q.FreeText) > 0 {
+		scores = r.search.SearchTeams(q.FreeText)
+	}
+
 	// Defaults
 	if sortBy == "" {
 		sortBy = "name"
+		if scores != nil {
+			sortBy = "relevance"
+		}
 	}
 	if order == "" {
 		order = "asc"
-	}
-
-	q := search.Parse(query)
-	for i, t := range q.FreeText {
-		q.FreeText[i] = strings.ToLower(t)
-	}
-	for i, f := range q.Filters {
-		q.Filters[i].Value = strings.ToLower(f.Value)
+		if sortBy == "relevance" {
+			order = "desc"
+		}
 	}
 
 	idx, err := r.userStore.GetUserIndex(userId)
 	if err != nil {
-		return []string{}
+		return nil, sortBy, order
 	}
 
-	var ids []string
 	getMeta := func(id string) (TeamMetadata, bool) {
 		if m, ok := r.teamMetadata.Get(id); ok {
 			return m, true
@@ -882,65 +1241,30 @@ func (r *Registry) ListTeams(userId, sortBy, order, query string) []string {
 		if err != nil {
 			return TeamMetadata{}, false
 		}
-		m := TeamMetadata{ID: t.ID, Name: t.Name, OwnerID: t.OwnerID, Roles: t.Roles, UpdatedAt: t.UpdatedAt, Status: t.Status, DeletedAt: t.DeletedAt}
+		m := *t.Metadata()
 		r.teamMetadata.Add(id, m)
 		return m, true
 	}
 
-	for id := range idx.TeamAccess {
+	var hits []teamHit
+	for id, level := range idx.TeamAccess {
+		if _, ok := scores[id]; scores != nil && !ok {
+			continue
+		}
 		meta, ok := getMeta(id)
-		if !ok || meta.Status == "deleted" || !matchesTeam(meta, q) {
+		if !ok || meta.Status == "deleted" || !matchesTeam(userId, meta, level, expr) {
 			continue
 		}
-		ids = append(ids, id)
+		hits = append(hits, teamHit{meta: meta, score: scores[id]})
 	}
 
-	sort.Slice(ids, func(i, j int) bool {
-		id1, id2 := ids[i], ids[j]
-		m1, ok1 := getMeta(id1)
-		m2, ok2 := getMeta(id2)
-		if !ok1 || !ok2 {
-			if order == "desc" {
-				return id1 > id2
-			}
-			return id1 < id2
-		}
-		var less bool
-		switch sortBy {
-		case "name":
-			if m1.Name != m2.Name {
-				less = m1.Name < m2.Name
-			} else {
-				less = id1 < id2
-			}
-		case "updated":
-			if m1.UpdatedAt != m2.UpdatedAt {
-				less = m1.UpdatedAt < m2.UpdatedAt
-			} else {
-				less = id1 < id2
-			}
-		default:
-			less = id1 < id2
-		}
+	sort.Slice(hits, func(i, j int) bool {
 		if order == "desc" {
-			switch sortBy {
-			case "name":
-				if m1.Name != m2.Name {
-					return m1.Name > m2.Name
-				}
-				return id1 > id2
-			case "updated":
-				if m1.UpdatedAt != m2.UpdatedAt {
-					return m1.UpdatedAt > m2.UpdatedAt
-				}
-				return id1 > id2
-			default:
-				return id1 > id2
-			}
+			return teamHitLess(sortBy, hits[j], hits[i])
 		}
-		return less
+		return teamHitLess(sortBy, hits[i], hits[j])
 	})
-	return ids
+	return hits, sortBy, order
 }
 
 // --- Search Helpers ---
@@ -949,58 +1273,169 @@ func containsLower(s, substrLower string) bool {
 	return strings.Contains(strings.ToLower(s), substrLower)
 }
 
-func matchesGame(m GameMetadata, q search.Query) bool {
-	for _, token := range q.FreeText {
-		match := containsLower(m.Event, token) ||
-			containsLower(m.Location, token) ||
-			containsLower(m.Away, token) ||
-			containsLower(m.Home, token)
-		if !match {
+// parseLowerExpr parses a query with lowercase free text and filter values,
+// except for the filters with keepCase keys.
+func parseLowerExpr(query string, keepCase ...string) *search.Expr {
+	expr := search.ParseExpr(query)
+	expr.Terms(func(t *search.Expr) {
+		t.Text = strings.ToLower(t.Text)
+		if !slices.Contains(keepCase, t.Filter.Key) {
+			t.Filter.Value = strings.ToLower(t.Filter.Value)
+		}
+	})
+	return expr
+}
+
+// matchesText reports whether lowercase free text matches fields the way the
+// search index does: each of its words begins a word of the fields (or is
+// one, when shorter than searchMinPrefix). A phrase of several words must
+// also appear as is in one of the fields.
+func matchesText(text string, fields ...string) bool {
+	words := searchTokens(text)
+	if len(words) == 0 {
+		return slices.ContainsFunc(fields, func(f string) bool { return containsLower(f, text) })
+	}
+	var fieldWords []string
+	for _, f := range fields {
+		fieldWords = append(fieldWords, searchTokens(f)...)
+	}
+	for _, w := range words {
+		if !slices.ContainsFunc(fieldWords, func(fw string) bool {
+			return fw == w || (len(w) >= searchMinPrefix && strings.HasPrefix(fw, w))
+		}) {
 			return false
 		}
 	}
-	for _, f := range q.Filters {
-		switch f.Key {
-		case "event":
-			if !containsLower(m.Event, f.Value) {
-				return false
-			}
-		case "location":
-			if !containsLower(m.Location, f.Value) {
-				return false
-			}
-		case "away":
-			if !containsLower(m.Away, f.Value) {
-				return false
-			}
-		case "home":
-			if !containsLower(m.Home, f.Value) {
-				return false
-			}
-		case "date":
-			if !checkDateFilter(m.Date, f) {
-				return false
-			}
+	return len(words) == 1 || slices.ContainsFunc(fields, func(f string) bool { return containsLower(f, text) })
+}
+
+// playerNames returns the names of players.
+func playerNames(players []Player) []string {
+	names := make([]string, len(players))
+	for i, p := range players {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// matchesGame reports whether the game matches the query expression e for
+// userId. Free text and filter values are lowercase, except dates.
+func (r *Registry) matchesGame(userId string, m GameMetadata, e *search.Expr) bool {
+	return e.Eval(func(t *search.Expr) bool {
+		if t.Op == search.ExprText {
+			return matchesText(t.Text, append([]string{m.Event, m.Location, m.Away, m.Home}, playerNames(m.Players)...)...)
+		}
+		return r.matchesGameFilter(userId, m, t.Filter)
+	})
+}
+
+// matchesGameFilter reports whether the game matches a filter for userId.
+// Unknown keys match.
+func (r *Registry) matchesGameFilter(userId string, m GameMetadata, f search.Filter) bool {
+	switch f.Key {
+	case "event":
+		return containsLower(m.Event, f.Value)
+	case "location":
+		return containsLower(m.Location, f.Value)
+	case "away":
+		return containsLower(m.Away, f.Value)
+	case "home":
+		return containsLower(m.Home, f.Value)
+	case "date":
+		return checkDateFilter(m.Date, f)
+	case "team":
+		return m.AwayTeamID == f.Value || m.HomeTeamID == f.Value ||
+			containsLower(m.Away, f.Value) || containsLower(m.Home, f.Value)
+	case "status":
+		// Games without a status are ongoing.
+		return cmp.Or(strings.ToLower(m.Status), "ongoing") == f.Value
+	case "player", "number":
+		return matchesPlayer(m.Players, f)
+	case "owner":
+		return matchesOwner(userId, m.OwnerID, f.Value)
+	case "role":
+		level := AccessNone
+		if userId != "" {
+			level = r.GetAccessLevel(userId, m.ID)
 		}
+		return matchesRole(userId, m.OwnerID, level, f.Value)
 	}
 	return true
 }
 
-func matchesTeam(m TeamMetadata, q search.Query) bool {
-	for _, token := range q.FreeText {
-		if !containsLower(m.Name, token) {
-			return false
+// matchesTeam reports whether the team matches the query expression e for
+// userId, whose access level to the team is level. Free text and filter
+// values are lowercase.
+func matchesTeam(userId string, m TeamMetadata, level AccessLevel, e *search.Expr) bool {
+	return e.Eval(func(t *search.Expr) bool {
+		if t.Op == search.ExprText {
+			return matchesText(t.Text, append([]string{m.Name}, playerNames(m.Players)...)...)
 		}
-	}
-	for _, f := range q.Filters {
+		f := t.Filter
 		switch f.Key {
 		case "name":
-			if !containsLower(m.Name, f.Value) {
-				return false
+			return containsLower(m.Name, f.Value)
+		case "team":
+			return m.ID == f.Value || containsLower(m.Name, f.Value)
+		case "player", "number":
+			return matchesPlayer(m.Players, f)
+		case "owner":
+			return matchesOwner(userId, m.OwnerID, f.Value)
+		case "role":
+			return matchesRole(userId, m.OwnerID, level, f.Value)
+		}
+		return true
+	})
+}
+
+// matchesPlayer reports whether one of players matches a player (name) or
+// number filter. Numbers match exactly, with or without a leading "#", or
+// compare numerically with a comparison operator (number:>=10).
+func matchesPlayer(players []Player, f search.Filter) bool {
+	number := strings.TrimPrefix(f.Value, "#")
+	for _, p := range players {
+		if f.Key == "number" && f.Operator == search.OpEqual && strings.TrimPrefix(p.Number, "#") == number {
+			return true
+		}
+		if f.Key == "number" && f.Operator != search.OpEqual {
+			f.Value = number
+			f.MaxValue = strings.TrimPrefix(f.MaxValue, "#")
+			n, err := strconv.ParseFloat(strings.TrimPrefix(p.Number, "#"), 64)
+			if err == nil && f.CompareNumber(n) {
+				return true
 			}
 		}
+		if f.Key == "player" && containsLower(p.Name, f.Value) {
+			return true
+		}
 	}
-	return true
+	return false
+}
+
+// matchesOwner reports whether ownerId matches an owner filter: "me" or an
+// email address.
+func matchesOwner(userId, ownerId, value string) bool {
+	if value == "me" {
+		return userId != "" && ownerId == userId
+	}
+	return strings.EqualFold(ownerId, value)
+}
+
+// matchesRole reports whether userId has the role in a role filter, given its
+// effective access level: owner, admin (including the owner), scorekeeper or
+// spectator.
+func matchesRole(userId, ownerId string, level AccessLevel, role string) bool {
+	switch role {
+	case "owner":
+		return userId != "" && ownerId == userId
+	case "admin":
+		return level == AccessAdmin
+	case "scorekeeper":
+		return level == AccessWrite
+	case "spectator":
+		return level == AccessRead
+	}
+	return false
 }
 
 func checkDateFilter(dateVal string, f search.Filter) bool {
@@ -1,6 +1,8 @@
 package backend
 
 import (
+	"slices"
+	"sort"
 	"testing"
 
 	"github.com/c2FmZQ/storage"
@@ -135,3 +137,222 @@ func TestRegistry_TeamAccess(t *testing.T) {
 		t.Errorf("Removed team member should NOT have access to game")
 	}
 }
+
+func TestRegistry_SearchFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+	s := storage.New(tmpDir, mk)
+	gs := NewGameStore(tmpDir, s)
+	ts := NewTeamStore(tmpDir, s)
+	us := NewUserIndexStore(tmpDir, s, mk)
+
+	coach := "coach@example.com"
+	scorer := "scorer@example.com"
+	other := "other@example.com"
+
+	team := &Team{
+		ID:            "team-hawks",
+		SchemaVersion: CurrentSchemaVersion,
+		Name:          "Hawks",
+		OwnerID:       coach,
+		Roles:         TeamRoles{Scorekeepers: []string{scorer}},
+		Roster:        []Player{{ID: "p1", Name: "Alex Smith", Number: "12"}, {ID: "p2", Name: "Sam Lee", Number: "7"}},
+	}
+	ts.SaveTeam(team)
+
+	games := []*Game{
+		{
+			ID: "g1", SchemaVersion: CurrentSchemaVersion, OwnerID: coach, Status: "final",
+			Home: "Hawks", Away: "Owls", HomeTeamID: team.ID,
+			Roster: map[string][]RosterSlot{"home": {
+				{Slot: 0, Starter: Player{Name: "Alex Smith", Number: "12", Pos: "SS"}, Current: Player{Name: "Alex Smith", Number: "12", Pos: "SS"}},
+			}},
+		},
+		{
+			ID: "g2", SchemaVersion: CurrentSchemaVersion, OwnerID: coach,
+			Home: "Hawks", Away: "Bears", HomeTeamID: team.ID,
+			Roster: map[string][]RosterSlot{"home": {
+				{Slot: 0, Starter: Player{Name: "Sam Lee", Number: "7"}, Current: Player{Name: "Sam Lee", Number: "7"}},
+			}},
+			Subs: map[string][]Player{"home": {{Name: "Alex Smith", Number: "12"}}},
+		},
+		{
+			ID: "g3", SchemaVersion: CurrentSchemaVersion, OwnerID: other, Status: "final",
+			Home: "Owls", Away: "Bears",
+			Permissions: Permissions{Users: map[string]string{coach: "write"}},
+			Roster: map[string][]RosterSlot{"away": {
+				{Slot: 0, Starter: Player{Name: "Pat Alexander", Number: "#21"}, Current: Player{Name: "Pat Alexander", Number: "#21"}},
+			}},
+		},
+	}
+	for _, g := range games {
+		gs.SaveGame(g)
+	}
+	// g1's metadata was written by a version without the player index.
+	stale := *games[0].Metadata()
+	stale.Players = nil
+	stale.IndexVersion = 0
+	if err := s.SaveDataFile("games/g1.meta.json", &stale); err != nil {
+		t.Fatalf("SaveDataFile: %v", err)
+	}
+
+	r := NewRegistry(gs, ts, us, true)
+	for _, tc := range []struct {
+		user, query string
+		want        []string
+	}{
+		{coach, `player:"alex smith"`, []string{"g1", "g2"}},
+		{coach, `player:alex`, []string{"g1", "g2", "g3"}},
+		{coach, `number:12`, []string{"g1", "g2"}},
+		{coach, `number:21`, []string{"g3"}},
+		{coach, `number:#21`, []string{"g3"}},
+		{coach, `status:final`, []string{"g1", "g3"}},
+		{coach, `status:ongoing`, []string{"g2"}},
+		{coach, `owner:me`, []string{"g1", "g2"}},
+		{coach, `owner:other@example.com`, []string{"g3"}},
+		{coach, `team:team-hawks`, []string{"g1", "g2"}},
+		{coach, `team:owls`, []string{"g1", "g3"}},
+		{coach, `role:scorekeeper`, []string{"g3"}},
+		{coach, `role:admin`, []string{"g1", "g2"}},
+		{scorer, `role:scorekeeper number:12`, []string{"g1", "g2"}},
+		{coach, `player:alex status:final owner:me`, []string{"g1"}},
+		// Boolean queries and numeric comparisons.
+		{coach, `team:owls OR player:"sam lee"`, []string{"g1", "g2", "g3"}},
+		{coach, `player:alex -status:final`, []string{"g2"}},
+		{coach, `-(owner:me)`, []string{"g3"}},
+		{coach, `(home:hawks OR away:hawks) -number:7`, []string{"g1"}},
+		{coach, `owls -bears`, []string{"g1"}},
+		{coach, `number:>=12`, []string{"g1", "g2", "g3"}},
+		{coach, `number:<10`, []string{"g2"}},
+		{coach, `number:13..#30`, []string{"g3"}},
+	} {
+		got := r.ListGames(tc.user, "event", "asc", tc.query)
+		sort.Strings(got)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("ListGames(%s, %q) = %v, want %v", tc.user, tc.query, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		user, query string
+		want        int
+	}{
+		{coach, `player:"sam lee"`, 1},
+		{coach, `number:99`, 0},
+		{coach, `role:admin`, 1},
+		{scorer, `role:scorekeeper`, 1},
+		{scorer, `owner:me`, 0},
+		{scorer, `team:hawks`, 1},
+		{coach, `-player:sam`, 0},
+		{coach, `hawks OR eagles`, 1},
+	} {
+		if got := r.ListTeams(tc.user, "", "", tc.query); len(got) != tc.want {
+			t.Errorf("ListTeams(%s, %q) = %v, want %d teams", tc.user, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestRegistry_FullTextSearch(t *testing.T) {
+	tmpDir := t.TempDir()
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+	s := storage.New(tmpDir, mk)
+	gs := NewGameStore(tmpDir, s)
+	ts := NewTeamStore(tmpDir, s)
+	us := NewUserIndexStore(tmpDir, s, mk)
+
+	admin := "admin@example.com"
+	other := "other@example.com"
+
+	team := &Team{
+		ID:            "team-hawks",
+		SchemaVersion: CurrentSchemaVersion,
+		Name:          "Riverside Hawks",
+		OwnerID:       admin,
+		Roster:        []Player{{ID: "p1", Name: "Alex Smith", Number: "12"}},
+	}
+	ts.SaveTeam(team)
+
+	games := []*Game{
+		{ID: "g1", SchemaVersion: CurrentSchemaVersion, OwnerID: admin, Date: "2025-05-01", Event: "Spring League", Location: "Hawk Park", Away: "Owls", Home: "Bears"},
+		{ID: "g2", SchemaVersion: CurrentSchemaVersion, OwnerID: admin, Date: "2025-05-02", Event: "Hawks Cup", Away: "Owls", Home: "Hawks"},
+		{ID: "g3", SchemaVersion: CurrentSchemaVersion, OwnerID: admin, Date: "2025-05-03", Event: "Scrimmage", Away: "Bears", Home: "Owls",
+			Subs: map[string][]Player{"home": {{Name: "Hawkins Jones", Number: "3"}}}},
+		{ID: "g4", SchemaVersion: CurrentSchemaVersion, OwnerID: other, Date: "2025-05-04", Event: "Hawks Cup", Away: "Owls", Home: "Hawks"},
+		{ID: "g5", SchemaVersion: CurrentSchemaVersion, OwnerID: other, Date: "2025-05-05", Event: "Final", Home: "Bears", HomeTeamID: team.ID},
+	}
+	for _, g := range games {
+		gs.SaveGame(g)
+	}
+
+	r := NewRegistry(gs, ts, us, true)
+
+	check := func(r *Registry, query string, want []string) {
+		t.Helper()
+		if got := r.ListGames(admin, "", "", query); !slices.Equal(got, want) {
+			t.Errorf("ListGames(%q) = %v, want %v", query, got, want)
+		}
+	}
+	// Ranked by relevance, then date. g4 isn't accessible, g5 is through the
+	// team.
+	check(r, "hawks", []string{"g2"})
+	check(r, "hawk", []string{"g2", "g1", "g3"})
+	check(r, "HAWK owls", []string{"g2", "g1", "g3"})
+	check(r, "hawk scrim", []string{"g3"})
+	check(r, "h", nil)
+	check(r, "bears final", []string{"g5"})
+	check(r, "hawk event:cup", []string{"g2"})
+	check(r, "nothing", nil)
+
+	if got := r.ListTeams(admin, "", "", "alex"); !slices.Equal(got, []string{team.ID}) {
+		t.Errorf("ListTeams(alex) = %v", got)
+	}
+	if got := r.ListTeams(admin, "", "", "owls"); len(got) != 0 {
+		t.Errorf("ListTeams(owls) = %v", got)
+	}
+
+	// Updates and deletions are reflected.
+	games[0].Location = "Memorial Field"
+	gs.SaveGame(games[0])
+	r.UpdateGame(*games[0])
+	r.DeleteGame("g3")
+	check(r, "hawk", []string{"g2"})
+	check(r, "memorial", []string{"g1"})
+
+	// The index persists.
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	r.StopGC()
+	us = NewUserIndexStore(tmpDir, s, mk)
+	r = NewRegistry(gs, ts, us, false)
+	defer r.StopGC()
+	check(r, "memorial", []string{"g1"})
+	check(r, "hawk", []string{"g2"})
+
+	// Cursor pagination.
+	var got []string
+	cursor := ""
+	for {
+		page, err := r.PageGames(admin, "", "", "owls", cursor, 0, 0, 1)
+		if err != nil {
+			t.Fatalf("PageGames: %v", err)
+		}
+		got = append(got, page.IDs...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+		if len(got) == 1 {
+			// Games added before the cursor don't shift the next pages.
+			g := &Game{ID: "g0", SchemaVersion: CurrentSchemaVersion, OwnerID: admin, Date: "2025-06-01", Away: "Owls"}
+			gs.SaveGame(g)
+			r.UpdateGame(*g)
+		}
+	}
+	if want := []string{"g2", "g1"}; !slices.Equal(got, want) {
+		t.Errorf("PageGames(owls) = %v, want %v", got, want)
+	}
+	if _, err := r.PageGames(admin, "", "", "", "bogus", 0, 0, 1); err == nil {
+		t.Error("PageGames with an invalid cursor succeeded")
+	}
+}
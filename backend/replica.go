@@ -0,0 +1,513 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c2FmZQ/storage/crypto"
+	"github.com/hashicorp/raft"
+)
+
+const (
+	replicaStateFile = "replica.json"
+	replicaPollWait  = 20 * time.Second
+)
+
+var (
+	replicaRetryInterval = 2 * time.Second
+
+	errReplicaCompacted = errors.New("log compacted")
+)
+
+// Replica is a read replica. It is not part of the Raft configuration: it
+// registers with the cluster, then tails the committed log from the cluster
+// API of any node and applies it to a local FSM.
+type Replica struct {
+	Primary   string // Public URL of the cluster, where scorers are redirected
+	Advertise string // Optional: public URL of this replica, shown in the cluster status
+	Secret    string // Cluster secret
+	DataDir   string
+	MasterKey crypto.MasterKey
+	FSM       *FSM
+	NodeID    string
+
+	nodeKey ed25519.PrivateKey
+	client  *http.Client // mTLS client for the cluster API
+
+	mu     sync.Mutex
+	peers  map[string]replicaPeer // Cluster nodes, from the primary's status
+	source string                 // Node currently tailed
+	status ReplicaStatus
+	synced time.Time // When the replica last had every entry of its source
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type replicaPeer struct {
+	HttpAddr string
+	PubKey   string
+}
+
+// ReplicaStatus reports the replication progress of a read replica.
+type ReplicaStatus struct {
+	NodeID       string  `json:"nodeId"`
+	Primary      string  `json:"primary"`
+	Registered   bool    `json:"registered"`
+	Source       string  `json:"source,omitempty"`      // Node ID of the node being tailed
+	AppliedIndex uint64  `json:"appliedIndex"`          // Last log index applied locally
+	SourceIndex  uint64  `json:"sourceIndex"`           // Last log index applied by the source
+	LagEntries   uint64  `json:"lagEntries"`            // SourceIndex - AppliedIndex
+	LagSeconds   float64 `json:"lagSeconds"`            // Time since the replica was last caught up
+	LastContact  int64   `json:"lastContact,omitempty"` // Unix ms of the last response from the source
+	LastError    string  `json:"lastError,omitempty"`
+}
+
+// replicaState is persisted in the FSM storage after each applied batch.
+type replicaState struct {
+	Index uint64 `json:"index"`
+}
+
+// NewReplica returns a read replica of the cluster at primary. Its FSM is set
+// by NewServerHandler, unless set by the caller.
+func NewReplica(primary, advertise, secret, dataDir string, mk crypto.MasterKey) *Replica {
+	return &Replica{
+		Primary:   strings.TrimSuffix(primary, "/"),
+		Advertise: advertise,
+		Secret:    secret,
+		DataDir:   dataDir,
+		MasterKey: mk,
+	}
+}
+
+// Start loads the replica's identity and starts tailing the cluster log.
+func (r *Replica) Start() error {
+	if r.FSM == nil {
+		return fmt.Errorf("replica has no FSM")
+	}
+	priv, err := loadNodeKey(r.DataDir, r.MasterKey)
+	if err != nil {
+		return fmt.Errorf("failed to load node key: %v", err)
+	}
+	r.nodeKey = priv
+	r.NodeID = hex.EncodeToString(priv.Public().(ed25519.PublicKey)[:8])
+	cert, err := nodeCertificate(r.NodeID, priv)
+	if err != nil {
+		return fmt.Errorf("failed to generate ephemeral cert: %v", err)
+	}
+	r.client = &http.Client{
+		Timeout: replicaPollWait + 30*time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates:          []tls.Certificate{*cert},
+				InsecureSkipVerify:    true, // Verification is done by VerifyPeerCertificate against the node map
+				VerifyPeerCertificate: r.verifyPeerCertificate,
+			},
+		},
+	}
+
+	r.FSM.SetReadOnly()
+	if r.FSM.storage != nil {
+		var state replicaState
+		if err := r.FSM.storage.ReadDataFile(replicaStateFile, &state); err == nil {
+			r.FSM.lastAppliedIndex.Store(state.Index)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %v", replicaStateFile, err)
+		}
+	}
+	replicaLog.Info("replica identity", "nodeId", r.NodeID, "primary", r.Primary, "index", r.FSM.LastAppliedIndex())
+
+	r.status = ReplicaStatus{NodeID: r.NodeID, Primary: r.Primary}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.done = make(chan struct{})
+	go r.run()
+	return nil
+}
+
+// Shutdown stops tailing the cluster log.
+func (r *Replica) Shutdown() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// Status returns the replication progress.
+func (r *Replica) Status() ReplicaStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.status
+	s.AppliedIndex = r.FSM.LastAppliedIndex()
+	if s.SourceIndex > s.AppliedIndex {
+		s.LagEntries = s.SourceIndex - s.AppliedIndex
+	}
+	if !r.synced.IsZero() && (s.LagEntries > 0 || s.LastError != "") {
+		s.LagSeconds = time.Since(r.synced).Seconds()
+	}
+	return s
+}
+
+func (r *Replica) run() {
+	defer close(r.done)
+	for r.ctx.Err() == nil {
+		if err := r.register(); err != nil {
+			r.fail("registration failed", err)
+			continue
+		}
+		break
+	}
+	for r.ctx.Err() == nil {
+		if err := r.poll(); err != nil {
+			if errors.Is(err, errReplicaCompacted) {
+				if err = r.loadSnapshot(); err == nil {
+					continue
+				}
+			}
+			r.fail("replication failed", err)
+			r.nextSource()
+		}
+	}
+}
+
+// fail records err and waits before the next attempt.
+func (r *Replica) fail(msg string, err error) {
+	if r.ctx.Err() != nil {
+		return
+	}
+	replicaLog.Warn(msg, "source", r.currentSource(), "err", err)
+	r.mu.Lock()
+	r.status.LastError = err.Error()
+	r.mu.Unlock()
+	select {
+	case <-r.ctx.Done():
+	case <-time.After(replicaRetryInterval):
+	}
+}
+
+// register records the replica's identity in the cluster, and fetches the
+// cluster nodes from the primary.
+func (r *Replica) register() error {
+	payload, _ := json.Marshal(map[string]any{
+		"nodeId":          r.NodeID,
+		"httpAddr":        r.Advertise,
+		"pubKey":          base64.StdEncoding.EncodeToString(r.nodeKey.Public().(ed25519.PublicKey)),
+		"appVersion":      CurrentAppVersion,
+		"protocolVersion": CurrentProtocolVersion,
+		"schemaVersion":   CurrentSchemaVersion,
+		"replica":         true,
+	})
+	if _, err := r.primaryRequest(http.MethodPost, "/api/cluster/join", payload); err != nil {
+		return err
+	}
+	if err := r.refreshPeers(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.status.Registered = true
+	r.mu.Unlock()
+	replicaLog.Info("registered with cluster", "primary", r.Primary)
+	return nil
+}
+
+// primaryRequest sends a request to the public API of the primary cluster.
+func (r *Replica) primaryRequest(method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(r.ctx, method, r.Primary+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Raft-Secret", r.Secret)
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// refreshPeers fetches the cluster nodes and their public keys from the
+// primary's status.
+func (r *Replica) refreshPeers() error {
+	data, err := r.primaryRequest(http.MethodGet, "/api/cluster/status", nil)
+	if err != nil {
+		return err
+	}
+	var status struct {
+		Nodes []struct {
+			ID       string `json:"id"`
+			HttpAddr string `json:"httpAddr"`
+			PubKey   string `json:"pubKey"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return err
+	}
+	peers := make(map[string]replicaPeer)
+	for _, n := range status.Nodes {
+		if n.HttpAddr != "" && n.PubKey != "" {
+			peers[n.ID] = replicaPeer{HttpAddr: n.HttpAddr, PubKey: n.PubKey}
+		}
+	}
+	if len(peers) == 0 {
+		return fmt.Errorf("the cluster status has no nodes")
+	}
+	r.mu.Lock()
+	r.peers = peers
+	r.mu.Unlock()
+	return nil
+}
+
+// verifyPeerCertificate pins the public keys of the cluster nodes: from the
+// replicated node map, or from the primary's status before the node map is
+// replicated.
+func (r *Replica) verifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("no peer certificate")
+	}
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return err
+	}
+	nodeID := cert.Subject.CommonName
+	pubKey, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf("peer public key is not ed25519, got %T", cert.PublicKey)
+	}
+	expected := r.FSM.GetNodePubKey(nodeID)
+	if expected == "" {
+		r.mu.Lock()
+		expected = r.peers[nodeID].PubKey
+		r.mu.Unlock()
+	}
+	if expected == "" {
+		return fmt.Errorf("unknown node %s", nodeID)
+	}
+	expectedPubKey, err := base64.StdEncoding.DecodeString(expected)
+	if err != nil {
+		return err
+	}
+	if !ed25519.PublicKey(expectedPubKey).Equal(pubKey) {
+		return fmt.Errorf("public key mismatch for node %s", nodeID)
+	}
+	return nil
+}
+
+// candidates returns the cluster nodes the replica can tail, sorted by ID.
+func (r *Replica) candidates() map[string]string {
+	nodes := r.FSM.GetAllNodes()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, p := range r.peers {
+		if _, ok := nodes[id]; !ok {
+			nodes[id] = p.HttpAddr
+		}
+	}
+	return nodes
+}
+
+func (r *Replica) currentSource() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.source
+}
+
+// sourceAddr returns the cluster API URL of the node to tail.
+func (r *Replica) sourceAddr() (string, error) {
+	nodes := r.candidates()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addr, ok := nodes[r.source]
+	if !ok || addr == "" {
+		ids := make([]string, 0, len(nodes))
+		for id, addr := range nodes {
+			if addr != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return "", fmt.Errorf("no cluster node to replicate from")
+		}
+		sort.Strings(ids)
+		r.source = ids[0]
+		r.status.Source = r.source
+		addr = nodes[r.source]
+	}
+	if !strings.HasPrefix(addr, "https://") {
+		addr = "https://" + strings.TrimPrefix(addr, "http://")
+	}
+	return addr, nil
+}
+
+// nextSource switches to the next cluster node, and refreshes the nodes from
+// the primary, in case the cluster changed.
+func (r *Replica) nextSource() {
+	if err := r.refreshPeers(); err != nil {
+		replicaLog.Debug("failed to refresh cluster nodes", "err", err)
+	}
+	nodes := r.candidates()
+	ids := make([]string, 0, len(nodes))
+	for id, addr := range nodes {
+		if addr != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	sort.Strings(ids)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next := ids[0]
+	for i, id := range ids {
+		if id == r.source {
+			next = ids[(i+1)%len(ids)]
+			break
+		}
+	}
+	r.source = next
+	r.status.Source = next
+}
+
+// clusterRequest sends a GET request to the cluster API of the source node.
+func (r *Replica) clusterRequest(path string) (*http.Response, error) {
+	addr, err := r.sourceAddr()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, addr+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Raft-Secret", r.Secret)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusGone {
+		resp.Body.Close()
+		return nil, errReplicaCompacted
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// poll fetches and applies the next batch of committed entries.
+func (r *Replica) poll() error {
+	after := r.FSM.LastAppliedIndex()
+	resp, err := r.clusterRequest(fmt.Sprintf("/api/cluster/replicate?after=%d&wait=%d", after, int(replicaPollWait/time.Second)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var batch replicationBatch
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return err
+	}
+	for _, e := range batch.Entries {
+		if e.Index <= r.FSM.LastAppliedIndex() {
+			continue
+		}
+		if res := r.FSM.Apply(&raft.Log{Index: e.Index, Term: e.Term, Type: raft.LogCommand, Data: e.Data}); res != nil {
+			if err, ok := res.(error); ok {
+				replicaLog.Warn("failed to apply entry", "index", e.Index, "err", err)
+			}
+		}
+	}
+	// Entries without commands, such as configuration changes, are skipped.
+	if batch.LastIndex > r.FSM.LastAppliedIndex() {
+		r.FSM.lastAppliedIndex.Store(batch.LastIndex)
+	}
+	if err := r.saveState(); err != nil {
+		return err
+	}
+	r.progress(batch.AppliedIndex)
+	return nil
+}
+
+// loadSnapshot replaces the local state with the source's latest snapshot.
+func (r *Replica) loadSnapshot() error {
+	resp, err := r.clusterRequest("/api/cluster/replicate/snapshot")
+	if err != nil {
+		return err
+	}
+	index, err := strconv.ParseUint(resp.Header.Get("X-Snapshot-Index"), 10, 64)
+	if err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("invalid snapshot index: %v", err)
+	}
+	replicaLog.Info("restoring snapshot", "source", r.currentSource(), "index", index)
+	if err := r.FSM.Restore(resp.Body); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	r.FSM.lastAppliedIndex.Store(index)
+	if err := r.saveState(); err != nil {
+		return err
+	}
+	r.progress(index)
+	return nil
+}
+
+func (r *Replica) saveState() error {
+	if r.FSM.storage == nil {
+		return nil
+	}
+	return r.FSM.storage.SaveDataFile(replicaStateFile, replicaState{Index: r.FSM.LastAppliedIndex()})
+}
+
+// progress records a successful exchange with the source, which has applied
+// the log up to sourceIndex.
+func (r *Replica) progress(sourceIndex uint64) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.SourceIndex = sourceIndex
+	r.status.LastContact = now.UnixMilli()
+	r.status.LastError = ""
+	if r.FSM.LastAppliedIndex() >= sourceIndex {
+		r.synced = now
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/c2FmZQ/storage/crypto"
+	"github.com/hashicorp/raft"
+)
+
+func TestReadReplica(t *testing.T) {
+	defer func(d time.Duration) { replicaRetryInterval = d }(replicaRetryInterval)
+	replicaRetryInterval = 100 * time.Millisecond
+
+	baseDir := t.TempDir()
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+	newFSM := func(id, dir string) *FSM {
+		dataDir := filepath.Join(baseDir, id)
+		s := storage.New(dataDir, mk)
+		gs := NewGameStore(dataDir, s)
+		ts := NewTeamStore(dataDir, s)
+		us := NewUserIndexStore(dataDir, s, nil)
+		return NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), storage.New(filepath.Join(dataDir, dir), mk), us)
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(15 * time.Second); !cond(); time.Sleep(50 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	raftAddr := l.Addr().String()
+	l.Close()
+	rm := NewRaftManager(filepath.Join(baseDir, "a", "raft"), raftAddr, raftAddr, "127.0.0.1:0", "127.0.0.1:0", "secret", mk, newFSM("a", "raft"))
+	rm.TrailingLogs = 1
+	if err := rm.Start(true); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer rm.Shutdown()
+	waitFor("leader", func() bool { return rm.Raft.State() == raft.Leader })
+
+	// The public API of the cluster.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/cluster/join", rm.handleJoin)
+	mux.HandleFunc("/api/cluster/status", rm.handleStatus)
+	primary := httptest.NewServer(mux)
+	defer primary.Close()
+
+	createGame := func(id string) {
+		t.Helper()
+		raw := json.RawMessage(fmt.Sprintf(`{"id":%q,"actionLog":[],"schemaVersion":%d}`, id, CurrentSchemaVersion))
+		if _, err := rm.Propose(RaftCommand{Type: CmdSaveGame, ID: id, GameData: &raw}); err != nil {
+			t.Fatalf("propose %s: %v", id, err)
+		}
+	}
+	hasGames := func(f *FSM, ids ...string) func() bool {
+		return func() bool {
+			gs, _ := f.GetStores()
+			for _, id := range ids {
+				if _, err := gs.LoadGame(id); err != nil {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	startReplica := func(id string) *Replica {
+		t.Helper()
+		rep := NewReplica(primary.URL+"/", "https://"+id+".example.com", "secret", filepath.Join(baseDir, id, "replica"), mk)
+		rep.FSM = newFSM(id, "replica")
+		if err := rep.Start(); err != nil {
+			t.Fatalf("Start replica: %v", err)
+		}
+		return rep
+	}
+
+	createGame("g1")
+	r1 := startReplica("r1")
+	defer r1.Shutdown()
+	waitFor("replication of g1", hasGames(r1.FSM, "g1"))
+	createGame("g2")
+	waitFor("replication of g2", hasGames(r1.FSM, "g2"))
+	waitFor("caught up", func() bool {
+		s := r1.Status()
+		return s.Registered && s.LagEntries == 0 && s.AppliedIndex == rm.Raft.AppliedIndex()
+	})
+
+	// The replica is registered, but not a cluster member.
+	if meta := rm.FSM.GetNodeMeta(r1.NodeID); meta == nil || !meta.Replica || meta.HttpAddr != "https://r1.example.com" {
+		t.Fatalf("replica meta = %+v", meta)
+	}
+	if n := rm.FSM.GetNodeCount(); n != 1 {
+		t.Errorf("GetNodeCount() = %d, want 1", n)
+	}
+	if cfg := rm.Raft.GetConfiguration(); len(cfg.Configuration().Servers) != 1 {
+		t.Errorf("configuration = %+v", cfg.Configuration())
+	}
+	waitFor("replica progress", func() bool {
+		replicas := rm.Replicas()
+		return len(replicas) == 1 && replicas[0].NodeID == r1.NodeID && replicas[0].LastSeen > 0
+	})
+
+	// A new replica of a compacted log starts from a snapshot.
+	if err := rm.Raft.Snapshot().Error(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	createGame("g3")
+	if first, _ := rm.logStore.FirstIndex(); first <= 1 {
+		t.Fatalf("log not compacted, first index %d", first)
+	}
+	r2 := startReplica("r2")
+	defer r2.Shutdown()
+	waitFor("replication to new replica", hasGames(r2.FSM, "g1", "g2", "g3"))
+	waitFor("replication of g3", hasGames(r1.FSM, "g3"))
+
+	// Replicas do not deliver webhooks.
+	if r1.FSM.deliversWebhooks() {
+		t.Error("replica FSM acts as leader")
+	}
+
+	if err := rm.Leave(r1.NodeID); err != nil {
+		t.Fatalf("Leave: %v", err)
+	}
+	waitFor("replica removed", func() bool { return len(rm.Replicas()) == 1 })
+}
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	h := readOnlyMiddleware("https://primary.example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/api/list-games", http.StatusNoContent},
+		{"GET", "/api/load/x", http.StatusNoContent},
+		{"POST", "/api/save?x=1", http.StatusTemporaryRedirect},
+		{"DELETE", "/api/webhooks/1", http.StatusTemporaryRedirect},
+		{"POST", "/api/check-deletions", http.StatusNoContent},
+		{"POST", "/api/list-games", http.StatusNoContent},
+		{"POST", "/api/list-teams?q=x", http.StatusNoContent},
+		{"POST", "/api/cluster/join", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}")))
+		if w.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+		if tc.want == http.StatusTemporaryRedirect {
+			if got, want := w.Header().Get("Location"), "https://primary.example.com"+tc.path; got != want {
+				t.Errorf("%s %s: Location = %q, want %q", tc.method, tc.path, got, want)
+			}
+			if got := w.Header().Get("X-Skorekeeper-Primary"); got != "https://primary.example.com" {
+				t.Errorf("%s %s: X-Skorekeeper-Primary = %q", tc.method, tc.path, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// Read replicas are not part of the Raft configuration. They register their
+// identity with the cluster (NodeMeta.Replica), then tail the committed log
+// from any node's cluster API over mTLS, see Replica.
+const (
+	replicationMaxEntries = 512
+	replicationMaxBytes   = 8 << 20
+	replicationMaxWait    = 30 * time.Second
+)
+
+// replicationBatch is the response of /api/cluster/replicate.
+type replicationBatch struct {
+	Entries      []replicatedEntry `json:"entries"`
+	LastIndex    uint64            `json:"lastIndex"`    // Index of the last entry covered by the batch
+	AppliedIndex uint64            `json:"appliedIndex"` // Last index applied by the serving node
+}
+
+// replicatedEntry is a committed command log entry.
+type replicatedEntry struct {
+	Index uint64 `json:"index"`
+	Term  uint64 `json:"term"`
+	Data  []byte `json:"data"`
+}
+
+// ReplicaProgress is what a cluster node knows about a read replica.
+type ReplicaProgress struct {
+	NodeID   string `json:"nodeId"`
+	HttpAddr string `json:"httpAddr,omitempty"`
+	Index    uint64 `json:"index,omitempty"`    // Last index the replica reported applying
+	LastSeen int64  `json:"lastSeen,omitempty"` // Unix ms of its last request to this node
+	Lag      uint64 `json:"lag"`                // Entries behind this node
+}
+
+// Replicas returns the registered read replicas, with the progress they last
+// reported to this node.
+func (rm *RaftManager) Replicas() []ReplicaProgress {
+	applied := rm.Raft.AppliedIndex()
+	rm.replicaMu.Lock()
+	defer rm.replicaMu.Unlock()
+	var out []ReplicaProgress
+	for id, meta := range rm.FSM.GetReplicas() {
+		p := rm.replicaSeen[id]
+		p.NodeID = id
+		p.HttpAddr = meta.HttpAddr
+		if applied > p.Index {
+			p.Lag = applied - p.Index
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// joinReplica registers a read replica. Replicas are not added to the Raft
+// configuration; their node metadata lets them authenticate to the cluster API.
+func (rm *RaftManager) joinReplica(w http.ResponseWriter, r *http.Request, meta NodeMeta) {
+	pub, err := base64.StdEncoding.DecodeString(meta.PubKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		http.Error(w, "Invalid PubKey: must be a base64 Ed25519 public key", http.StatusBadRequest)
+		return
+	}
+	if meta.NodeID != hex.EncodeToString(pub[:8]) {
+		http.Error(w, "NodeID does not match PubKey", http.StatusBadRequest)
+		return
+	}
+	if existing := rm.FSM.GetNodeMeta(meta.NodeID); existing != nil && !existing.Replica {
+		http.Error(w, "Node is a cluster member", http.StatusConflict)
+		return
+	}
+	// Replicas apply every command, so they must support the active schema.
+	if err := checkJoinCompatibility(meta.ProtocolVersion, meta.SchemaVersion, rm.FSM.ActiveSchemaVersion()); err != nil {
+		raftLog.WarnContext(r.Context(), "replica rejected: incompatible node", "nodeId", meta.NodeID, "appVersion", meta.AppVersion, "err", err)
+		http.Error(w, fmt.Sprintf("Incompatible node: %v", err), http.StatusConflict)
+		return
+	}
+
+	meta.Replica = true
+	if _, err := rm.ProposeContext(r.Context(), RaftCommand{Type: CmdNodeMeta, NodeMeta: &meta}); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to register replica: %v", err), http.StatusInternalServerError)
+		return
+	}
+	raftLog.InfoContext(r.Context(), "replica registered", "nodeId", meta.NodeID, "httpAddr", meta.HttpAddr)
+	fmt.Fprintf(w, "Replica %s registered", meta.NodeID)
+}
+
+// peerNodeID returns the node ID of the mTLS client of r.
+func peerNodeID(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+	return r.TLS.PeerCertificates[0].Subject.CommonName
+}
+
+// handleReplicate serves the committed command entries after the index in the
+// "after" parameter. When there are none, it waits up to "wait" seconds for
+// new ones. It responds 410 Gone when the entries were compacted, in which
+// case the replica must load the snapshot from /api/cluster/replicate/snapshot.
+func (rm *RaftManager) handleReplicate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	if secret := r.Header.Get("X-Raft-Secret"); rm.Secret == "" || secret != rm.Secret {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	after, err := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid after", http.StatusBadRequest)
+		return
+	}
+	wait, _ := strconv.Atoi(r.URL.Query().Get("wait"))
+	rm.recordReplica(peerNodeID(r), after)
+
+	deadline := time.Now().Add(min(time.Duration(wait)*time.Second, replicationMaxWait))
+	for rm.Raft.AppliedIndex() <= after && time.Now().Before(deadline) {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-rm.shutdownCh:
+			http.Error(w, "Shutting down", http.StatusServiceUnavailable)
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	applied := rm.Raft.AppliedIndex()
+	batch := replicationBatch{Entries: []replicatedEntry{}, LastIndex: after, AppliedIndex: applied}
+	if after < applied {
+		first, err := rm.logStore.FirstIndex()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if first == 0 || after+1 < first {
+			http.Error(w, "Log compacted, load the snapshot", http.StatusGone)
+			return
+		}
+		size := 0
+		for i := after + 1; i <= applied && len(batch.Entries) < replicationMaxEntries && size < replicationMaxBytes; i++ {
+			var l raft.Log
+			if err := rm.logStore.GetLog(i, &l); err != nil {
+				if err == raft.ErrLogNotFound {
+					http.Error(w, "Log compacted, load the snapshot", http.StatusGone)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if l.Type == raft.LogCommand {
+				batch.Entries = append(batch.Entries, replicatedEntry{Index: l.Index, Term: l.Term, Data: l.Data})
+				size += len(l.Data)
+			}
+			batch.LastIndex = i
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
+// handleReplicateSnapshot streams this node's latest snapshot, as produced by
+// LinkSnapshotStore.Open. The X-Snapshot-Index header has its index.
+func (rm *RaftManager) handleReplicateSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	if secret := r.Header.Get("X-Raft-Secret"); rm.Secret == "" || secret != rm.Secret {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if rm.snapStoreEnc == nil {
+		http.Error(w, "Snapshots require an encrypted data directory", http.StatusNotImplemented)
+		return
+	}
+	snaps, err := rm.snapStoreEnc.List()
+	if err == nil && len(snaps) == 0 {
+		err = fmt.Errorf("no snapshot")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	meta, rc, err := rm.snapStoreEnc.Open(snaps[0].ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+	raftLog.InfoContext(r.Context(), "sending snapshot to replica", "nodeId", peerNodeID(r), "index", meta.Index)
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("X-Snapshot-Index", strconv.FormatUint(meta.Index, 10))
+	io.Copy(w, rc)
+}
+
+// recordReplica records the progress reported by a replica.
+func (rm *RaftManager) recordReplica(nodeID string, index uint64) {
+	if meta := rm.FSM.GetNodeMeta(nodeID); meta == nil || !meta.Replica {
+		return
+	}
+	rm.replicaMu.Lock()
+	defer rm.replicaMu.Unlock()
+	if rm.replicaSeen == nil {
+		rm.replicaSeen = make(map[string]ReplicaProgress)
+	}
+	rm.replicaSeen[nodeID] = ReplicaProgress{Index: index, LastSeen: time.Now().UnixMilli()}
+}
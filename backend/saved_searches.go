@@ -0,0 +1,155 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ttbt-io/skorekeeper/backend/search"
+)
+
+const (
+	savedSearchMaxPerUser = 50
+	savedSearchMaxName    = 100
+	savedSearchMaxQuery   = 1000
+)
+
+// errSavedSearchNotFound is returned for unknown saved search IDs.
+var errSavedSearchNotFound = errors.New("saved search not found")
+
+// SavedSearch is a named search query of a user, shown as a smart folder.
+type SavedSearch struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Query     string `json:"query"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// Validate checks that the saved search is well formed.
+func (s *SavedSearch) Validate() error {
+	if s.ID == "" {
+		return fmt.Errorf("missing saved search id")
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("missing name")
+	}
+	if utf8.RuneCountInString(s.Name) > savedSearchMaxName {
+		return fmt.Errorf("name is longer than %d characters", savedSearchMaxName)
+	}
+	if len(s.Query) > savedSearchMaxQuery {
+		return fmt.Errorf("query is longer than %d bytes", savedSearchMaxQuery)
+	}
+	if search.ParseExpr(s.Query) == nil {
+		return fmt.Errorf("empty query")
+	}
+	return nil
+}
+
+// SavedSearches returns the saved searches of a user, in creation order.
+func (r *Registry) SavedSearches(userId string) []SavedSearch {
+	idx, err := r.userStore.GetUserIndex(userId)
+	if err != nil {
+		return nil
+	}
+	return slices.Clone(idx.SavedSearches)
+}
+
+// SavedSearch returns a saved search of a user.
+func (r *Registry) SavedSearch(userId, id string) (SavedSearch, bool) {
+	for _, s := range r.SavedSearches(userId) {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return SavedSearch{}, false
+}
+
+// PutSavedSearch adds or replaces a saved search of a user. A replaced search
+// keeps its creation time.
+func (r *Registry) PutSavedSearch(userId string, s SavedSearch) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	idx, err := r.userStore.GetUserIndex(userId)
+	if err != nil {
+		return err
+	}
+	searches := slices.Clone(idx.SavedSearches)
+	if i := slices.IndexFunc(searches, func(e SavedSearch) bool { return e.ID == s.ID }); i >= 0 {
+		s.CreatedAt = searches[i].CreatedAt
+		searches[i] = s
+	} else {
+		if len(searches) >= savedSearchMaxPerUser {
+			return fmt.Errorf("too many saved searches (max %d)", savedSearchMaxPerUser)
+		}
+		searches = append(searches, s)
+	}
+	idx.SavedSearches = searches
+	r.userStore.SetUserIndex(idx)
+	return nil
+}
+
+// DeleteSavedSearch removes a saved search of a user.
+func (r *Registry) DeleteSavedSearch(userId, id string) error {
+	idx, err := r.userStore.GetUserIndex(userId)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(idx.SavedSearches, func(e SavedSearch) bool { return e.ID == id })
+	if i < 0 {
+		return errSavedSearchNotFound
+	}
+	idx.SavedSearches = slices.Delete(slices.Clone(idx.SavedSearches), i, i+1)
+	r.userStore.SetUserIndex(idx)
+	return nil
+}
+
+// DeleteSavedSearches removes all the saved searches of a user.
+func (r *Registry) DeleteSavedSearches(userId string) {
+	idx, err := r.userStore.GetUserIndex(userId)
+	if err != nil || len(idx.SavedSearches) == 0 {
+		return
+	}
+	idx.SavedSearches = nil
+	r.userStore.SetUserIndex(idx)
+}
+
+// withSavedSearch combines the query of a user's saved search with query:
+// results must match both. The queries are parsed separately, so that query
+// can't change the grouping of the saved one, e.g. with a leading OR or an
+// unbalanced parenthesis.
+func (r *Registry) withSavedSearch(userId, savedSearchId, query string) (string, error) {
+	if savedSearchId == "" {
+		return query, nil
+	}
+	s, ok := r.SavedSearch(userId, savedSearchId)
+	if !ok {
+		return "", errSavedSearchNotFound
+	}
+	saved, extra := search.ParseExpr(s.Query), search.ParseExpr(query)
+	if extra == nil {
+		return s.Query, nil
+	}
+	if saved == nil {
+		return extra.String(), nil
+	}
+	and := &search.Expr{Op: search.ExprAnd, Children: []*search.Expr{saved, extra}}
+	return and.String(), nil
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/c2FmZQ/storage/crypto"
+)
+
+func TestRegistry_SavedSearches(t *testing.T) {
+	tmpDir := t.TempDir()
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+	s := storage.New(tmpDir, mk)
+	gs := NewGameStore(tmpDir, s)
+	ts := NewTeamStore(tmpDir, s)
+	us := NewUserIndexStore(tmpDir, s, mk)
+
+	user := "user@example.com"
+	for _, g := range []*Game{
+		{ID: "g1", SchemaVersion: CurrentSchemaVersion, OwnerID: user, Date: "2025-05-01", Event: "Spring League", Away: "Owls", Home: "Bears"},
+		{ID: "g2", SchemaVersion: CurrentSchemaVersion, OwnerID: user, Date: "2025-05-02", Event: "Hawks Cup", Away: "Owls", Home: "Hawks"},
+		{ID: "g3", SchemaVersion: CurrentSchemaVersion, OwnerID: user, Date: "2025-06-03", Event: "Scrimmage", Away: "Bears", Home: "Owls"},
+	} {
+		gs.SaveGame(g)
+	}
+	r := NewRegistry(gs, ts, us, true)
+
+	if err := r.PutSavedSearch(user, SavedSearch{ID: "s1", Name: "Owls", Query: ""}); err == nil {
+		t.Error("PutSavedSearch accepted an empty query")
+	}
+	if err := r.PutSavedSearch(user, SavedSearch{ID: "s1", Name: "Owls", Query: "away:owls", CreatedAt: 10, UpdatedAt: 10}); err != nil {
+		t.Fatalf("PutSavedSearch: %v", err)
+	}
+	if err := r.PutSavedSearch(user, SavedSearch{ID: "s1", Name: "Owls away", Query: "away:owls", CreatedAt: 20, UpdatedAt: 20}); err != nil {
+		t.Fatalf("PutSavedSearch (replace): %v", err)
+	}
+	got, ok := r.SavedSearch(user, "s1")
+	if !ok || got.Name != "Owls away" || got.CreatedAt != 10 || got.UpdatedAt != 20 {
+		t.Errorf("SavedSearch = %+v, %v", got, ok)
+	}
+	if n := len(r.SavedSearches("other@example.com")); n != 0 {
+		t.Errorf("other user has %d saved searches", n)
+	}
+
+	query, err := r.withSavedSearch(user, "s1", "date:>=2025-05-02")
+	if err != nil {
+		t.Fatalf("withSavedSearch: %v", err)
+	}
+	if ids := r.ListGames(user, "date", "asc", query); !slices.Equal(ids, []string{"g2"}) {
+		t.Errorf("ListGames(%q) = %v, want [g2]", query, ids)
+	}
+	// The query can't widen the saved search.
+	for _, q := range []string{"OR home:owls", ") OR home:owls", "home:owls) OR (event:scrimmage"} {
+		query, err := r.withSavedSearch(user, "s1", q)
+		if err != nil {
+			t.Fatalf("withSavedSearch: %v", err)
+		}
+		if ids := r.ListGames(user, "date", "asc", query); len(ids) != 0 {
+			t.Errorf("ListGames(%q) = %v, want none", query, ids)
+		}
+	}
+	if _, err := r.withSavedSearch(user, "missing", ""); err != errSavedSearchNotFound {
+		t.Errorf("withSavedSearch(missing) = %v", err)
+	}
+
+	for i := 1; i < savedSearchMaxPerUser; i++ {
+		if err := r.PutSavedSearch(user, SavedSearch{ID: fmt.Sprintf("x%d", i), Name: "x", Query: "x"}); err != nil {
+			t.Fatalf("PutSavedSearch #%d: %v", i, err)
+		}
+	}
+	if err := r.PutSavedSearch(user, SavedSearch{ID: "over", Name: "x", Query: "x"}); err == nil {
+		t.Error("PutSavedSearch accepted more than the maximum")
+	}
+
+	if err := r.DeleteSavedSearch(user, "s1"); err != nil {
+		t.Fatalf("DeleteSavedSearch: %v", err)
+	}
+	if err := r.DeleteSavedSearch(user, "s1"); err != errSavedSearchNotFound {
+		t.Errorf("DeleteSavedSearch (again) = %v", err)
+	}
+	r.DeleteSavedSearches(user)
+	if n := len(r.SavedSearches(user)); n != 0 {
+		t.Errorf("%d saved searches left after DeleteSavedSearches", n)
+	}
+}
+
+func TestSavedSearchesEndpoint(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	_, _, handler := NewServerHandler(Options{
+		DataDir:     tempDir,
+		Storage:     s,
+		UseMockAuth: true,
+	})
+
+	user := "user1@example.com"
+	doReq := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if body != "" {
+			req.Body = io.NopCloser(strings.NewReader(body))
+		}
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, g := range []string{
+		`{"id":"11111111-1111-4111-8111-111111111111","away":"Owls","home":"Bears","date":"2025-01-01"}`,
+		`{"id":"22222222-2222-4222-8222-222222222222","away":"Hawks","home":"Bears","date":"2025-01-02"}`,
+	} {
+		if rec := doReq("POST", "/api/save", user, g); rec.Code != http.StatusOK {
+			t.Fatalf("save game %d: %d %s", i, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := doReq("POST", "/api/saved-searches", user, `{"name":"","query":"away:owls"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("create without name: %d", rec.Code)
+	}
+	rec := doReq("POST", "/api/saved-searches", user, `{"name":"Owls","query":"away:owls"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	var created SavedSearch
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" || created.CreatedAt == 0 {
+		t.Fatalf("create response = %+v, %v", created, err)
+	}
+
+	rec = doReq("GET", "/api/saved-searches", user, "")
+	var list struct {
+		Data []SavedSearch `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Data) != 1 || list.Data[0].ID != created.ID {
+		t.Fatalf("list = %s, %v", rec.Body, err)
+	}
+	rec = doReq("GET", "/api/saved-searches", "user2@example.com", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Data) != 0 {
+		t.Errorf("other user's list = %s, %v", rec.Body, err)
+	}
+
+	rec = doReq("GET", "/api/list-games?savedSearch="+created.ID, user, "")
+	var games struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &games); err != nil || len(games.Data) != 1 || games.Data[0].ID != "11111111-1111-4111-8111-111111111111" {
+		t.Errorf("list-games?savedSearch = %s, %v", rec.Body, err)
+	}
+	if rec := doReq("GET", "/api/list-games?savedSearch="+created.ID, "user2@example.com", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("other user's saved search: %d", rec.Code)
+	}
+
+	rec = doReq("POST", "/api/saved-searches", user, fmt.Sprintf(`{"id":%q,"name":"Hawks","query":"away:hawks"}`, created.ID))
+	var updated SavedSearch
+	if err := json.Unmarshal(rec.Body.Bytes(), &updated); err != nil || updated.Name != "Hawks" || updated.CreatedAt != created.CreatedAt {
+		t.Errorf("update = %s, %v", rec.Body, err)
+	}
+
+	if rec := doReq("DELETE", "/api/saved-searches/"+created.ID, "user2@example.com", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("delete by other user: %d", rec.Code)
+	}
+	if rec := doReq("DELETE", "/api/saved-searches/"+created.ID, user, ""); rec.Code != http.StatusOK {
+		t.Errorf("delete: %d", rec.Code)
+	}
+	if rec := doReq("GET", "/api/list-games?savedSearch="+created.ID, user, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted saved search: %d", rec.Code)
+	}
+}
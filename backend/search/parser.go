@@ -1,6 +1,7 @@
 package search
 
 import (
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -25,105 +26,334 @@ type Filter struct {
 	Operator Operator // e.g., "=", ">="
 }
 
-// Query represents the parsed search query.
+// Query represents the parsed search query: the filters and free text that all
+// results must match.
 type Query struct {
 	Filters  []Filter
 	FreeText []string
 }
 
-// Parse parses a search query string into a structured Query object.
-// It handles:
-// - quoted strings (key:"value with spaces")
-// - key:value pairs
-// - simple operators for specific keys (mainly date)
-// - flags (is:local)
-func Parse(input string) Query {
+// ExprOp is the type of a query expression node.
+type ExprOp int
+
+const (
+	ExprAnd    ExprOp = iota // All Children match
+	ExprOr                   // At least one of Children matches
+	ExprNot                  // Children[0] doesn't match
+	ExprFilter               // Filter matches
+	ExprText                 // Text (free text) matches
+)
+
+// Expr is a node of the expression tree of a search query.
+type Expr struct {
+	Op       ExprOp
+	Children []*Expr
+	Filter   Filter
+	Text     string
+}
+
+// Eval reports whether the expression matches, using term to match its
+// filters and free text. A nil expression matches everything.
+func (e *Expr) Eval(term func(*Expr) bool) bool {
+	if e == nil {
+		return true
+	}
+	switch e.Op {
+	case ExprAnd:
+		for _, c := range e.Children {
+			if !c.Eval(term) {
+				return false
+			}
+		}
+		return true
+	case ExprOr:
+		for _, c := range e.Children {
+			if c.Eval(term) {
+				return true
+			}
+		}
+		return false
+	case ExprNot:
+		return !e.Children[0].Eval(term)
+	}
+	return term(e)
+}
+
+// String returns the expression in query syntax, with groups in parentheses.
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+	quote := func(s string) string {
+		if strings.ContainsFunc(s, unicode.IsSpace) {
+			return `"` + s + `"`
+		}
+		return s
+	}
+	switch e.Op {
+	case ExprAnd, ExprOr:
+		sep := " "
+		if e.Op == ExprOr {
+			sep = " OR "
+		}
+		parts := make([]string, len(e.Children))
+		for i, c := range e.Children {
+			parts[i] = c.String()
+		}
+		return "(" + strings.Join(parts, sep) + ")"
+	case ExprNot:
+		return "-" + e.Children[0].String()
+	case ExprFilter:
+		f := e.Filter
+		switch f.Operator {
+		case OpEqual:
+			return f.Key + ":" + quote(f.Value)
+		case OpRange:
+			return f.Key + ":" + quote(f.Value) + ".." + quote(f.MaxValue)
+		}
+		return f.Key + ":" + string(f.Operator) + quote(f.Value)
+	}
+	return quote(e.Text)
+}
+
+// Terms calls fn for every filter and free text node of the expression,
+// including negated ones.
+func (e *Expr) Terms(fn func(*Expr)) {
+	if e == nil {
+		return
+	}
+	if e.Op == ExprFilter || e.Op == ExprText {
+		fn(e)
+		return
+	}
+	for _, c := range e.Children {
+		c.Terms(fn)
+	}
+}
+
+// Query returns the filters and free text that every match of the
+// expression must satisfy, i.e. those that aren't negated or alternatives.
+func (e *Expr) Query() Query {
 	q := Query{
 		Filters:  make([]Filter, 0),
 		FreeText: make([]string, 0),
 	}
+	var collect func(*Expr)
+	collect = func(e *Expr) {
+		if e == nil {
+			return
+		}
+		switch e.Op {
+		case ExprAnd:
+			for _, c := range e.Children {
+				collect(c)
+			}
+		case ExprFilter:
+			q.Filters = append(q.Filters, e.Filter)
+		case ExprText:
+			q.FreeText = append(q.FreeText, e.Text)
+		}
+	}
+	collect(e)
+	return q
+}
 
-	tokens := tokenize(input)
+// Parse parses a search query string into a structured Query object: the
+// filters and free text that all results must match. See ParseExpr for the
+// full expression.
+func Parse(input string) Query {
+	return ParseExpr(input).Query()
+}
 
-	for _, token := range tokens {
-		// Check for key:value pattern
-		// We split by first colon.
-		parts := strings.SplitN(token, ":", 2)
-		if len(parts) == 2 {
-			key := strings.ToLower(strings.TrimSpace(parts[0]))
-			val := strings.TrimSpace(parts[1])
+// ParseExpr parses a search query string into an expression tree. Nil means
+// the query is empty. It handles:
+// - quoted strings (key:"value with spaces")
+// - key:value pairs
+// - comparison operators (date:>=2025-01-01, number:<10) and ranges
+// - flags (is:local)
+// - implicit AND between terms, and OR between terms, which binds tighter:
+// "a OR b c" is "(a OR b) AND c"
+// - parenthesized groups
+// - negation with a leading "-" (-event:scrimmage, -word, -(a OR b))
+//
+// Unbalanced parentheses and dangling operators are ignored.
+func ParseExpr(input string) *Expr {
+	p := &parser{tokens: tokenize(input)}
+	return p.parseAnd(false)
+}
 
-			// If value contains unquoted colon, treat as free text to avoid ambiguity (e.g. broken:range:..)
-			// Exception: if starts with quote
-			if strings.Contains(val, ":") && !strings.HasPrefix(val, "\"") && !strings.HasPrefix(val, "'") {
-				q.FreeText = append(q.FreeText, token)
-				continue
-			}
+type parser struct {
+	tokens []string
+	pos    int
+}
 
-			if key == "" || val == "" {
-				// Treat as free text if key or value is empty (e.g. "foo:")
-				q.FreeText = append(q.FreeText, token)
-				continue
+// parseAnd parses a sequence of terms, up to the end of the input or, in a
+// group, up to the closing parenthesis, which is left to the caller.
+func (p *parser) parseAnd(inGroup bool) *Expr {
+	and := &Expr{Op: ExprAnd}
+	for p.pos < len(p.tokens) {
+		switch p.tokens[p.pos] {
+		case ")":
+			if inGroup {
+				return simplify(and)
 			}
+			p.pos++
+			continue
+		case "AND", "OR":
+			p.pos++
+			continue
+		}
+		if e := p.parseOr(); e != nil {
+			and.Children = append(and.Children, e)
+		}
+	}
+	return simplify(and)
+}
 
-			// Parse Value for Operators (mainly for date)
-			// Check for range ".."
-			if strings.Contains(val, "..") {
-				rangeParts := strings.SplitN(val, "..", 2)
-				if len(rangeParts) == 2 {
-					q.Filters = append(q.Filters, Filter{
-						Key:      key,
-						Value:    rangeParts[0],
-						MaxValue: rangeParts[1],
-						Operator: OpRange,
-					})
-					continue
-				}
-			}
+// parseOr parses terms separated by OR.
+func (p *parser) parseOr() *Expr {
+	or := &Expr{Op: ExprOr}
+	for {
+		if e := p.parseUnary(); e != nil {
+			or.Children = append(or.Children, e)
+		}
+		if p.pos < len(p.tokens) && p.tokens[p.pos] == "OR" {
+			p.pos++
+			continue
+		}
+		return simplify(or)
+	}
+}
 
-			// Check for >=, <=, >, <
-			// Note: tokenization keeps value intact.
-			// "date:>=2025" -> key="date", val=">=2025"
-			if strings.HasPrefix(val, ">=") {
-				q.Filters = append(q.Filters, Filter{
-					Key:      key,
-					Value:    removeQuotes(strings.TrimPrefix(val, ">=")),
-					Operator: OpGreaterOrEqual,
-				})
-			} else if strings.HasPrefix(val, "<=") {
-				q.Filters = append(q.Filters, Filter{
-					Key:      key,
-					Value:    removeQuotes(strings.TrimPrefix(val, "<=")),
-					Operator: OpLessOrEqual,
-				})
-			} else if strings.HasPrefix(val, ">") {
-				q.Filters = append(q.Filters, Filter{
-					Key:      key,
-					Value:    removeQuotes(strings.TrimPrefix(val, ">")),
-					Operator: OpGreater,
-				})
-			} else if strings.HasPrefix(val, "<") {
-				q.Filters = append(q.Filters, Filter{
-					Key:      key,
-					Value:    removeQuotes(strings.TrimPrefix(val, "<")),
-					Operator: OpLess,
-				})
-			} else {
-				// Default Equal
-				// Also removes quotes from value if present
-				cleanedVal := removeQuotes(val)
-				q.Filters = append(q.Filters, Filter{
-					Key:      key,
-					Value:    cleanedVal,
-					Operator: OpEqual,
-				})
-			}
-		} else {
-			q.FreeText = append(q.FreeText, removeQuotes(token))
+// parseUnary parses a term, a group, or a negation.
+func (p *parser) parseUnary() *Expr {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	token := p.tokens[p.pos]
+	switch {
+	case token == ")" || token == "AND" || token == "OR":
+		return nil
+	case token == "(":
+		p.pos++
+		e := p.parseAnd(true)
+		p.pos++ // ")"
+		return e
+	case token == "-":
+		p.pos++
+		return negate(p.parseUnary())
+	case strings.HasPrefix(token, "-"):
+		p.pos++
+		return negate(parseTerm(token[1:]))
+	}
+	p.pos++
+	return parseTerm(token)
+}
+
+func negate(e *Expr) *Expr {
+	if e == nil {
+		return nil
+	}
+	return &Expr{Op: ExprNot, Children: []*Expr{e}}
+}
+
+// simplify removes empty and single-child AND and OR nodes.
+func simplify(e *Expr) *Expr {
+	switch len(e.Children) {
+	case 0:
+		return nil
+	case 1:
+		return e.Children[0]
+	}
+	return e
+}
+
+// parseTerm parses a key:value filter or free text.
+func parseTerm(token string) *Expr {
+	text := &Expr{Op: ExprText, Text: removeQuotes(token)}
+	filter := func(f Filter) *Expr {
+		return &Expr{Op: ExprFilter, Filter: f}
+	}
+
+	// Check for key:value pattern
+	// We split by first colon.
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 {
+		return text
+	}
+	key := strings.ToLower(strings.TrimSpace(parts[0]))
+	val := strings.TrimSpace(parts[1])
+
+	// If value contains unquoted colon, treat as free text to avoid ambiguity (e.g. broken:range:..)
+	// Exception: if starts with quote
+	if strings.Contains(val, ":") && !strings.HasPrefix(val, "\"") && !strings.HasPrefix(val, "'") {
+		return &Expr{Op: ExprText, Text: token}
+	}
+
+	if key == "" || val == "" {
+		// Treat as free text if key or value is empty (e.g. "foo:")
+		return &Expr{Op: ExprText, Text: token}
+	}
+
+	// Parse Value for Operators
+	// Check for range ".."
+	if strings.Contains(val, "..") {
+		rangeParts := strings.SplitN(val, "..", 2)
+		if len(rangeParts) == 2 {
+			return filter(Filter{
+				Key:      key,
+				Value:    rangeParts[0],
+				MaxValue: rangeParts[1],
+				Operator: OpRange,
+			})
 		}
 	}
 
-	return q
+	// Check for >=, <=, >, <
+	// Note: tokenization keeps value intact.
+	// "date:>=2025" -> key="date", val=">=2025"
+	for _, op := range []Operator{OpGreaterOrEqual, OpLessOrEqual, OpGreater, OpLess} {
+		if strings.HasPrefix(val, string(op)) {
+			return filter(Filter{
+				Key:      key,
+				Value:    removeQuotes(strings.TrimPrefix(val, string(op))),
+				Operator: op,
+			})
+		}
+	}
+
+	// Default Equal
+	// Also removes quotes from value if present
+	return filter(Filter{
+		Key:      key,
+		Value:    removeQuotes(val),
+		Operator: OpEqual,
+	})
+}
+
+// CompareNumber reports whether v satisfies the filter as a numeric
+// comparison. It reports false when the filter's values aren't numbers.
+func (f Filter) CompareNumber(v float64) bool {
+	n, err := strconv.ParseFloat(f.Value, 64)
+	if err != nil {
+		return false
+	}
+	switch f.Operator {
+	case OpEqual:
+		return v == n
+	case OpGreater:
+		return v > n
+	case OpGreaterOrEqual:
+		return v >= n
+	case OpLess:
+		return v < n
+	case OpLessOrEqual:
+		return v <= n
+	case OpRange:
+		m, err := strconv.ParseFloat(f.MaxValue, 64)
+		return err == nil && v >= n && v <= m
+	}
+	return false
 }
 
 // tokenize splits the string by spaces, respecting quotes.
@@ -147,6 +377,13 @@ func tokenize(input string) []string {
 				tokens = append(tokens, currentToken.String())
 				currentToken.Reset()
 			}
+		case r == '(' || r == ')':
+			// Parentheses are tokens of their own: "-(a" is "-", "(", "a".
+			if currentToken.Len() > 0 {
+				tokens = append(tokens, currentToken.String())
+				currentToken.Reset()
+			}
+			tokens = append(tokens, string(r))
 		case r == '"' || r == '\'':
 			inQuote = true
 			quoteChar = r
@@ -81,6 +81,19 @@ func TestParse(t *testing.T) {
 				FreeText: []string{"broken:range:.."},
 			},
 		},
+		{
+			input: "player:\"Alex Smith\" number:12 status:final owner:me role:scorekeeper",
+			expected: Query{
+				Filters: []Filter{
+					{Key: "player", Value: "Alex Smith", Operator: OpEqual},
+					{Key: "number", Value: "12", Operator: OpEqual},
+					{Key: "status", Value: "final", Operator: OpEqual},
+					{Key: "owner", Value: "me", Operator: OpEqual},
+					{Key: "role", Value: "scorekeeper", Operator: OpEqual},
+				},
+				FreeText: []string{},
+			},
+		},
 		{
 			input: "time:12:00", // Unquoted colon -> FreeText
 			expected: Query{
@@ -116,3 +129,92 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"", ""},
+		{"tigers", "tigers"},
+		{"home:Tigers OR away:Tigers -event:scrimmage", "((home:Tigers OR away:Tigers) -event:scrimmage)"},
+		{"a b OR c d", "(a (b OR c) d)"},
+		{"a AND b", "(a b)"},
+		{"(a b) OR c", "((a b) OR c)"},
+		{"-(a OR b) c", "(-(a OR b) c)"},
+		{"- (a OR b)", "-(a OR b)"},
+		{`-"red sox" -player:"Alex Smith"`, `(-"red sox" -player:"Alex Smith")`},
+		{"runs:>10 innings:>=9", "(runs:>10 innings:>=9)"},
+		{"number:1..9", "number:1..9"},
+		{`event:"Game (1)"`, `event:"Game (1)"`},
+		{"or and", "(or and)"},
+		// Unbalanced parentheses and dangling operators.
+		{"(a OR b", "(a OR b)"},
+		{"a) b", "(a b)"},
+		{"OR a OR", "a"},
+		{"()", ""},
+		{"-", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseExpr(tt.input).String(); got != tt.expected {
+			t.Errorf("ParseExpr(%q) = %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseRequiredTerms(t *testing.T) {
+	got := Parse("home:Tigers OR away:Tigers -event:scrimmage (finals date:2025) -x")
+	expected := Query{
+		Filters:  []Filter{{Key: "date", Value: "2025", Operator: OpEqual}},
+		FreeText: []string{"finals"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Parse() = %#v, want %#v", got, expected)
+	}
+}
+
+func TestEval(t *testing.T) {
+	words := map[string]bool{"a": true, "b": true}
+	term := func(e *Expr) bool { return words[e.Text] }
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"", true},
+		{"a b", true},
+		{"a c", false},
+		{"c OR b", true},
+		{"-c", true},
+		{"-(a OR c)", false},
+		{"(c OR -a) OR (b -c)", true},
+	}
+	for _, tt := range tests {
+		if got := ParseExpr(tt.input).Eval(term); got != tt.expected {
+			t.Errorf("ParseExpr(%q).Eval() = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCompareNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		value    float64
+		expected bool
+	}{
+		{"runs:>10", 11, true},
+		{"runs:>10", 10, false},
+		{"innings:>=9", 9, true},
+		{"runs:<3", 2.5, true},
+		{"runs:<=3", 4, false},
+		{"runs:7", 7, true},
+		{"runs:1..5", 5, true},
+		{"runs:1..5", 6, false},
+		{"runs:many", 1, false},
+	}
+	for _, tt := range tests {
+		f := Parse(tt.input).Filters[0]
+		if got := f.CompareNumber(tt.value); got != tt.expected {
+			t.Errorf("%q.CompareNumber(%v) = %v, want %v", tt.input, tt.value, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/c2FmZQ/storage/crypto"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// searchIndexVersion is the version of the search index format. An index
+// with a different version is rebuilt at startup.
+const searchIndexVersion = 1
+
+// searchMinPrefix is the length from which query tokens also match the tokens
+// they are a prefix of. Shorter query tokens only match exactly.
+const searchMinPrefix = 2
+
+// Relevance weights of the fields a token appears in.
+const (
+	searchWeightPlayer   = 1
+	searchWeightLocation = 2
+	searchWeightName     = 4 // Event, team names
+)
+
+const (
+	searchDir       = "search"
+	searchDictFile  = "search/dictionary.json"
+	searchTokensDir = "search/tokens"
+	searchGamesDir  = "search/games"
+	searchTeamsDir  = "search/teams"
+)
+
+// SearchPostings lists the games and teams containing a token, with the
+// token's relevance weight in each.
+type SearchPostings struct {
+	Token string         `json:"token"`
+	Games map[string]int `json:"games,omitempty"`
+	Teams map[string]int `json:"teams,omitempty"`
+}
+
+// SearchDocument records the tokens of an indexed game or team, so that its
+// postings can be updated when it changes.
+type SearchDocument struct {
+	ID     string         `json:"id"`
+	Tokens map[string]int `json:"tokens"`
+}
+
+// searchDictionary is the sorted list of indexed tokens, used for prefix
+// matching.
+type searchDictionary struct {
+	Version int      `json:"version"`
+	Tokens  []string `json:"tokens"`
+}
+
+// SearchIndex is a persistent, encrypted inverted index of the text of games
+// (event, location, team and player names) and teams (name and player names).
+// It is derived from the game and team metadata: it is not part of snapshots
+// and is rebuilt when missing.
+type SearchIndex struct {
+	DataDir   string
+	storage   *storage.Storage
+	masterKey crypto.MasterKey
+
+	// mu serializes updates. Searches hold a read lock.
+	mu        sync.RWMutex
+	version   int
+	tokens    []string // Sorted
+	dictDirty bool
+
+	postings *lru.Cache[string, *SearchPostings] // Key: Token
+	docs     *lru.Cache[string, *SearchDocument] // Key: Dir + "|" + ID
+
+	dirtyMu sync.Mutex
+	dirtyP  map[string]bool // Token
+	dirtyD  map[string]bool // Dir + "|" + ID
+}
+
+// NewSearchIndex opens the search index stored in dataDir.
+func NewSearchIndex(dataDir string, s *storage.Storage, mk crypto.MasterKey) *SearchIndex {
+	idx := &SearchIndex{
+		DataDir:   dataDir,
+		storage:   s,
+		masterKey: mk,
+		dirtyP:    make(map[string]bool),
+		dirtyD:    make(map[string]bool),
+	}
+
+	onPostingsEvict := func(key string, value *SearchPostings) {
+		idx.dirtyMu.Lock()
+		isDirty := idx.dirtyP[key]
+		delete(idx.dirtyP, key)
+		idx.dirtyMu.Unlock()
+
+		if isDirty {
+			if err := idx.storage.SaveDataFile(idx.getHashPath(key, searchTokensDir), value); err != nil {
+				storeLog.Error("search index: saving postings", "err", err)
+			}
+		}
+	}
+	onDocEvict := func(key string, value *SearchDocument) {
+		idx.dirtyMu.Lock()
+		isDirty := idx.dirtyD[key]
+		delete(idx.dirtyD, key)
+		idx.dirtyMu.Unlock()
+
+		if isDirty {
+			dir, id, _ := strings.Cut(key, "|")
+			if err := idx.storage.SaveDataFile(idx.getHashPath(id, dir), value); err != nil {
+				storeLog.Error("search index: saving document", "err", err)
+			}
+		}
+	}
+	idx.postings, _ = lru.NewWithEvict[string, *SearchPostings](5000, onPostingsEvict)
+	idx.docs, _ = lru.NewWithEvict[string, *SearchDocument](2000, onDocEvict)
+
+	var dict searchDictionary
+	if err := s.ReadDataFile(searchDictFile, &dict); err == nil {
+		idx.version = dict.Version
+		idx.tokens = dict.Tokens
+	} else if !os.IsNotExist(err) {
+		storeLog.Warn("search index: reading dictionary", "err", err)
+	}
+	return idx
+}
+
+// Ready reports whether the index is current. When it isn't, it must be
+// rebuilt with Reset and a full re-indexing.
+func (s *SearchIndex) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version == searchIndexVersion
+}
+
+// Reset empties the index, in preparation for a rebuild.
+func (s *SearchIndex) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dirtyMu.Lock()
+	clear(s.dirtyP)
+	clear(s.dirtyD)
+	s.dirtyMu.Unlock()
+	s.postings.Purge()
+	s.docs.Purge()
+
+	if err := os.RemoveAll(filepath.Join(s.DataDir, searchDir)); err != nil {
+		return err
+	}
+	s.version = searchIndexVersion
+	s.tokens = nil
+	s.dictDirty = true
+	return nil
+}
+
+// getHashPath calculates the storage path of a token or document.
+func (s *SearchIndex) getHashPath(key, dir string) string {
+	var hash string
+	if s.masterKey != nil {
+		hash = hex.EncodeToString(s.masterKey.Hash([]byte(key)))
+	} else {
+		h := sha256.Sum256([]byte(key))
+		hash = hex.EncodeToString(h[:])
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.json", hash))
+}
+
+// IndexGame adds or updates a game in the index.
+func (s *SearchIndex) IndexGame(m GameMetadata) {
+	tokens := make(map[string]int)
+	addSearchTokens(tokens, m.Event, searchWeightName)
+	addSearchTokens(tokens, m.Away, searchWeightName)
+	addSearchTokens(tokens, m.Home, searchWeightName)
+	addSearchTokens(tokens, m.Location, searchWeightLocation)
+	for _, p := range m.Players {
+		addSearchTokens(tokens, p.Name, searchWeightPlayer)
+	}
+	s.update(searchGamesDir, m.ID, tokens)
+}
+
+// IndexTeam adds or updates a team in the index.
+func (s *SearchIndex) IndexTeam(m TeamMetadata) {
+	tokens := make(map[string]int)
+	addSearchTokens(tokens, m.Name, searchWeightName)
+	for _, p := range m.Players {
+		addSearchTokens(tokens, p.Name, searchWeightPlayer)
+	}
+	s.update(searchTeamsDir, m.ID, tokens)
+}
+
+// RemoveGame removes a game from the index.
+func (s *SearchIndex) RemoveGame(id string) {
+	s.update(searchGamesDir, id, nil)
+}
+
+// RemoveTeam removes a team from the index.
+func (s *SearchIndex) RemoveTeam(id string) {
+	s.update(searchTeamsDir, id, nil)
+}
+
+// SearchGames returns the games matching every term, by prefix, with their
+// relevance scores.
+func (s *SearchIndex) SearchGames(terms []string) map[string]int {
+	return s.search(searchGamesDir, terms)
+}
+
+// SearchTeams returns the teams matching every term, by prefix, with their
+// relevance scores.
+func (s *SearchIndex) SearchTeams(terms []string) map[string]int {
+	return s.search(searchTeamsDir, terms)
+}
+
+// update replaces the tokens of a document. nil tokens remove it.
+func (s *SearchIndex) update(dir, id string, tokens map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := s.getDoc(dir, id)
+	if err != nil {
+		storeLog.Error("search index: loading document", "err", err)
+		return
+	}
+	if maps.Equal(doc.Tokens, tokens) {
+		return
+	}
+
+	for token := range doc.Tokens {
+		if _, ok := tokens[token]; !ok {
+			s.setPosting(dir, token, id, 0)
+		}
+	}
+	for token, weight := range tokens {
+		if doc.Tokens[token] != weight {
+			s.setPosting(dir, token, id, weight)
+		}
+	}
+
+	key := dir + "|" + id
+	if len(tokens) == 0 {
+		s.dirtyMu.Lock()
+		delete(s.dirtyD, key)
+		s.dirtyMu.Unlock()
+		s.docs.Remove(key)
+		if err := os.Remove(filepath.Join(s.DataDir, s.getHashPath(id, dir))); err != nil && !os.IsNotExist(err) {
+			storeLog.Error("search index: removing document", "err", err)
+		}
+		return
+	}
+	s.docs.Add(key, &SearchDocument{ID: id, Tokens: tokens})
+	s.dirtyMu.Lock()
+	s.dirtyD[key] = true
+	s.dirtyMu.Unlock()
+}
+
+// setPosting sets the weight of a token in a document. A zero weight removes
+// the document from the token's postings.
+func (s *SearchIndex) setPosting(dir, token, id string, weight int) {
+	p, err := s.getPostings(token)
+	if err != nil {
+		storeLog.Error("search index: loading postings", "err", err)
+		return
+	}
+	docs := &p.Games
+	if dir == searchTeamsDir {
+		docs = &p.Teams
+	}
+	if weight == 0 {
+		delete(*docs, id)
+	} else {
+		if *docs == nil {
+			*docs = make(map[string]int)
+		}
+		(*docs)[id] = weight
+	}
+
+	i, found := slices.BinarySearch(s.tokens, token)
+	if len(p.Games) == 0 && len(p.Teams) == 0 {
+		s.dirtyMu.Lock()
+		delete(s.dirtyP, token)
+		s.dirtyMu.Unlock()
+		s.postings.Remove(token)
+		if err := os.Remove(filepath.Join(s.DataDir, s.getHashPath(token, searchTokensDir))); err != nil && !os.IsNotExist(err) {
+			storeLog.Error("search index: removing postings", "err", err)
+		}
+		if found {
+			s.tokens = slices.Delete(s.tokens, i, i+1)
+			s.dictDirty = true
+		}
+		return
+	}
+	if !found {
+		s.tokens = slices.Insert(s.tokens, i, token)
+		s.dictDirty = true
+	}
+	s.postings.Add(token, p)
+	s.dirtyMu.Lock()
+	s.dirtyP[token] = true
+	s.dirtyMu.Unlock()
+}
+
+func (s *SearchIndex) getDoc(dir, id string) (*SearchDocument, error) {
+	if doc, ok := s.docs.Get(dir + "|" + id); ok {
+		return doc, nil
+	}
+	var doc SearchDocument
+	if err := s.storage.ReadDataFile(s.getHashPath(id, dir), &doc); err != nil {
+		if os.IsNotExist(err) {
+			return &SearchDocument{ID: id}, nil
+		}
+		return nil, err
+	}
+	s.docs.Add(dir+"|"+id, &doc)
+	return &doc, nil
+}
+
+func (s *SearchIndex) getPostings(token string) (*SearchPostings, error) {
+	if p, ok := s.postings.Get(token); ok {
+		return p, nil
+	}
+	var p SearchPostings
+	if err := s.storage.ReadDataFile(s.getHashPath(token, searchTokensDir), &p); err != nil {
+		if os.IsNotExist(err) {
+			return &SearchPostings{Token: token}, nil
+		}
+		return nil, err
+	}
+	s.postings.Add(token, &p)
+	return &p, nil
+}
+
+// search returns the documents of dir containing, for every query token, an
+// indexed token it equals or is a prefix of. A document's score is the sum,
+// over the query tokens, of the best weight of its matching tokens, doubled
+// for exact matches.
+func (s *SearchIndex) search(dir string, terms []string) map[string]int {
+	var queryTokens []string
+	for _, t := range terms {
+		queryTokens = append(queryTokens, searchTokens(t)...)
+	}
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result map[string]int
+	for _, qt := range queryTokens {
+		best := make(map[string]int)
+		for i := sort.SearchStrings(s.tokens, qt); i < len(s.tokens); i++ {
+			token := s.tokens[i]
+			if token != qt && (len(qt) < searchMinPrefix || !strings.HasPrefix(token, qt)) {
+				break
+			}
+			p, err := s.getPostings(token)
+			if err != nil {
+				storeLog.Error("search index: loading postings", "err", err)
+				continue
+			}
+			docs := p.Games
+			if dir == searchTeamsDir {
+				docs = p.Teams
+			}
+			for id, weight := range docs {
+				if token == qt {
+					weight *= 2
+				}
+				if weight > best[id] {
+					best[id] = weight
+				}
+			}
+		}
+		if result == nil {
+			result = best
+			continue
+		}
+		for id, score := range result {
+			if weight, ok := best[id]; ok {
+				result[id] = score + weight
+			} else {
+				delete(result, id)
+			}
+		}
+	}
+	return result
+}
+
+// FlushAll persists the dirty postings, documents and dictionary.
+func (s *SearchIndex) FlushAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dirtyMu.Lock()
+	tokens := slices.Collect(maps.Keys(s.dirtyP))
+	docs := slices.Collect(maps.Keys(s.dirtyD))
+	clear(s.dirtyP)
+	clear(s.dirtyD)
+	s.dirtyMu.Unlock()
+
+	for _, token := range tokens {
+		if p, ok := s.postings.Peek(token); ok {
+			if err := s.storage.SaveDataFile(s.getHashPath(token, searchTokensDir), p); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range docs {
+		if doc, ok := s.docs.Peek(key); ok {
+			dir, id, _ := strings.Cut(key, "|")
+			if err := s.storage.SaveDataFile(s.getHashPath(id, dir), doc); err != nil {
+				return err
+			}
+		}
+	}
+	if s.dictDirty {
+		dict := searchDictionary{Version: s.version, Tokens: s.tokens}
+		if err := s.storage.SaveDataFile(searchDictFile, dict); err != nil {
+			return err
+		}
+		s.dictDirty = false
+	}
+	return nil
+}
+
+// searchTokens splits text into lowercase words.
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// addSearchTokens adds the words of text to tokens with the given weight,
+// keeping the highest weight of each word.
+func addSearchTokens(tokens map[string]int, text string, weight int) {
+	for _, t := range searchTokens(text) {
+		tokens[t] = max(tokens[t], weight)
+	}
+}
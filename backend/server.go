@@ -26,6 +26,7 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -37,6 +38,7 @@ import (
 
 	"github.com/c2FmZQ/storage"
 	"github.com/c2FmZQ/storage/crypto"
+	"github.com/google/uuid"
 	"github.com/hashicorp/raft"
 	"github.com/ttbt-io/skorekeeper/frontend"
 )
@@ -91,7 +93,7 @@ type Options struct {
 	Cert             *tls.Certificate
 	DataDir          string
 	UseMockAuth      bool
-	Debug            bool
+	Debug            bool // Unused; debug logging is enabled with SetupLogging
 	GameStore        *GameStore
 	TeamStore        *TeamStore
 	Storage          *storage.Storage
@@ -112,6 +114,14 @@ type Options struct {
 	UseProductionTimeouts bool              // Set to true to use longer timeouts (e.g. for production)
 	SnapshotThreshold     uint64            // For testing: override Raft snapshot threshold
 	TrailingLogs          uint64            // For testing: override Raft trailing logs
+	Backups               *BackupManager    // Scheduled backups, made by the leader
+	RestoreBackup         string            // Backup to initialize a new cluster from, or "latest"
+	RaftRecover           bool              // Recover a cluster that lost its quorum with this node as the only voter
+
+	// Read Replica Options
+	ReplicaOf        string   // Public URL of the cluster this server is a read replica of
+	ReplicaAdvertise string   // Public URL of this replica, shown in the cluster status
+	Replica          *Replica // Allow injecting a pre-configured Replica
 
 	// Auth Options
 	AuthCookieName string
@@ -123,6 +133,18 @@ type Options struct {
 	MinifyMode bool
 
 	ForceRebuild bool
+
+	// MetricsToken, if set, is the bearer token required to scrape /metrics.
+	MetricsToken string
+
+	// Alert email notifications are sent through this SMTP relay, "host:port",
+	// from AlertFrom.
+	SMTPRelay string
+	AlertFrom string
+
+	// WebhookAllowLoopback allows webhooks and alert webhooks to be
+	// delivered to loopback addresses. For testing purposes only.
+	WebhookAllowLoopback bool
 }
 
 //go:embed cluster_dashboard.html
@@ -147,6 +169,7 @@ const (
 type Server struct {
 	httpServer *http.Server
 	raftMgr    *RaftManager
+	replica    *Replica
 	registry   *Registry
 }
 
@@ -158,7 +181,16 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		s.registry.StopGC()
 	}
 
+	if s.replica != nil {
+		s.replica.Shutdown()
+	}
+
 	flush := func() {
+		if s.replica != nil {
+			if err := s.replica.FSM.FlushAll(); err != nil {
+				errs = append(errs, fmt.Sprintf("fsm flush: %v", err))
+			}
+		}
 		if s.raftMgr != nil {
 			if err := s.raftMgr.Shutdown(); err != nil {
 				errs = append(errs, fmt.Sprintf("raft: %v", err))
@@ -186,13 +218,19 @@ func (s *Server) Shutdown(ctx context.Context) error {
 
 // StartServer starts the web server and registers the API handlers.
 func StartServer(opts Options) (*Server, error) {
+	if opts.ReplicaOf != "" && opts.Replica == nil {
+		if opts.DataDir == "" {
+			opts.DataDir = "data"
+		}
+		opts.Replica = NewReplica(opts.ReplicaOf, opts.ReplicaAdvertise, opts.RaftSecret, filepath.Join(opts.DataDir, "replica"), opts.MasterKey)
+	}
 	raftMgr, registry, handler := NewServerHandler(opts)
 
 	if raftMgr != nil {
 		// Wait for Raft to replay log and catch up to ensure data consistency
 		// before starting the public HTTP server.
 		if err := raftMgr.WaitForSync(30 * time.Second); err != nil {
-			log.Printf("Warning: Raft sync timed out: %v", err)
+			httpLog.Warn("raft sync timed out", "err", err)
 		}
 	}
 
@@ -218,34 +256,35 @@ func StartServer(opts Options) (*Server, error) {
 		var err error
 		if opts.Listener != nil {
 			if httpServer.TLSConfig != nil {
-				log.Printf("Starting HTTPS server on provided listener %s...", opts.Listener.Addr())
+				httpLog.Info("starting HTTPS server", "addr", opts.Listener.Addr().String())
 				err = httpServer.ServeTLS(opts.Listener, "", "")
 			} else {
-				log.Printf("Starting HTTP server on provided listener %s...", opts.Listener.Addr())
+				httpLog.Info("starting HTTP server", "addr", opts.Listener.Addr().String())
 				err = httpServer.Serve(opts.Listener)
 			}
 		} else {
 			// Legacy/Default path
-			log.Printf("Server starting on port %s...\n", opts.Addr)
+			httpLog.Info("server starting", "addr", opts.Addr)
 			if opts.Cert != nil {
 				err = httpServer.ListenAndServeTLS("", "")
 			} else if _, statErr := os.Stat("certs/cert.pem"); statErr == nil {
-				log.Println("Starting HTTPS server using certs/cert.pem...")
+				httpLog.Info("starting HTTPS server using certs/cert.pem")
 				err = httpServer.ListenAndServeTLS("certs/cert.pem", "certs/key.pem")
 			} else {
-				log.Println("Starting HTTP server...")
+				httpLog.Info("starting HTTP server")
 				err = httpServer.ListenAndServe()
 			}
 		}
 
 		if err != nil && !errors.Is(err, net.ErrClosed) && err != http.ErrServerClosed {
-			log.Printf("Server error: %v", err)
+			httpLog.Error("server failed", "err", err)
 		}
 	}()
 
 	return &Server{
 			httpServer: httpServer,
 			raftMgr:    raftMgr,
+			replica:    opts.Replica,
 			registry:   registry,
 		},
 		nil
@@ -300,6 +339,9 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			raftMgr.UseProductionTimeouts = opts.UseProductionTimeouts
 			raftMgr.SnapshotThreshold = opts.SnapshotThreshold
 			raftMgr.TrailingLogs = opts.TrailingLogs
+			raftMgr.Backups = opts.Backups
+			raftMgr.RestoreBackup = opts.RestoreBackup
+			raftMgr.Recover = opts.RaftRecover
 
 			if opts.UseMockAuth {
 				raftMgr.AuthMiddleware = func(next http.Handler) http.Handler {
@@ -316,14 +358,22 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			go func() { opts.RaftManagerChan <- raftMgr }()
 		}
 		hm.SetRaftManager(raftMgr)
+		raftMgr.FSM.Alerts().ConfigureSMTP(opts.SMTPRelay, opts.AlertFrom)
+		raftMgr.FSM.Webhooks().AllowLoopback(opts.WebhookAllowLoopback)
+		raftMgr.FSM.Alerts().AllowLoopback(opts.WebhookAllowLoopback)
 	}
 
-	debugf := func(string, ...any) {}
-	if opts.Debug {
-		debugf = func(f string, a ...any) {
-			log.Printf("[DEBUG BACKEND] "+f, a...)
+	replica := opts.Replica
+	if replica != nil {
+		if replica.FSM == nil {
+			if err := os.MkdirAll(replica.DataDir, 0755); err != nil {
+				log.Fatalf("Failed to create replica data directory: %v", err)
+			}
+			replica.FSM = NewFSM(store, tStore, registry, hm, storage.New(replica.DataDir, opts.MasterKey), userStore)
 		}
+		hm.SetReadOnly(replica.Primary)
 	}
+
 	mux := http.NewServeMux()
 
 	// Cluster Dashboard
@@ -382,6 +432,14 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		}
 		raftMgr.handleRemove(w, r)
 	})
+	// Cluster Drain Handler (Public API - Secured by Secret)
+	mux.HandleFunc("/api/cluster/drain", func(w http.ResponseWriter, r *http.Request) {
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusBadRequest)
+			return
+		}
+		raftMgr.handleDrain(w, r)
+	})
 	// Cluster Status Handler (Public/Protected)
 	mux.HandleFunc("/api/cluster/status", func(w http.ResponseWriter, r *http.Request) {
 		if raftMgr == nil || !opts.RaftEnabled {
@@ -406,6 +464,27 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		}
 	})
 
+	// Read Replica Status Handler (Protected by Secret)
+	mux.HandleFunc("/api/replica/status", func(w http.ResponseWriter, r *http.Request) {
+		if replica == nil {
+			http.Error(w, "This server is not a read replica", http.StatusNotImplemented)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if secret := r.Header.Get("X-Raft-Secret"); replica.Secret == "" || secret != replica.Secret {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(replica.Status())
+	})
+
+	// Prometheus/OpenMetrics scrape endpoint (per node)
+	mux.HandleFunc("/metrics", newMetricsHandler(raftMgr, replica, hm, store, tStore, registry, opts.MetricsToken))
+
 	// Admin API - Get/Update Policy
 	mux.HandleFunc("/api/admin/policy", func(w http.ResponseWriter, r *http.Request) {
 		userId := getUserID(r)
@@ -453,8 +532,9 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				cmd := RaftCommand{
 					Type:       CmdUpdateAccessPolicy,
 					PolicyData: &newPolicy,
+					UserID:     getUserID(r),
 				}
-				if _, err := raftMgr.Propose(cmd); err != nil {
+				if _, err := raftMgr.ProposeContext(r.Context(), cmd); err != nil {
 					if errors.Is(err, ErrNotLeader) {
 						// Re-marshal body to forward
 						body, _ := json.Marshal(newPolicy)
@@ -462,7 +542,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 						raftMgr.forwardRequestToLeader(w, r)
 						return
 					}
-					log.Printf("Raft Propose Error: %v", err)
+					httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					return
 				}
@@ -483,6 +563,159 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 	})
 
+	// Admin API - Alert rules
+	mux.HandleFunc("/api/admin/alerts", func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserID(r)
+		if !accessControl.IsAdmin(userId) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusNotImplemented)
+			return
+		}
+		alerts := raftMgr.FSM.Alerts()
+
+		switch r.Method {
+		case http.MethodGet:
+			// Alert state is kept by the leader, which evaluates the rules.
+			if raftMgr.Raft.State() != raft.Leader && raftMgr.GetLeaderHTTPAddr() != "" {
+				raftMgr.forwardRequestToLeader(w, r)
+				return
+			}
+			rules := alerts.List()
+			for i := range rules {
+				rules[i] = rules[i].Redacted()
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"rules":   rules,
+				"active":  alerts.Active(),
+				"history": alerts.History(),
+				"kinds":   alertKinds,
+				"nodes":   raftMgr.FSM.GetAllNodes(),
+			})
+
+		case http.MethodPost:
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			var req struct {
+				ID           string   `json:"id"`
+				Name         string   `json:"name"`
+				Kind         string   `json:"kind"`
+				Threshold    float64  `json:"threshold"`
+				Window       int64    `json:"window"`
+				For          int64    `json:"for"`
+				NodeID       string   `json:"nodeId"`
+				URL          string   `json:"url"`
+				Email        []string `json:"email"`
+				Disabled     bool     `json:"disabled"`
+				RotateSecret bool     `json:"rotateSecret"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+				return
+			}
+
+			var rule AlertRule
+			if req.ID != "" {
+				existing, ok := alerts.Get(req.ID)
+				if !ok {
+					http.Error(w, "Not Found", http.StatusNotFound)
+					return
+				}
+				rule = existing
+			} else {
+				rule = AlertRule{
+					ID:        uuid.NewString(),
+					CreatedBy: userId,
+					CreatedAt: time.Now().UnixMilli(),
+				}
+				req.RotateSecret = true
+			}
+			rule.Name = strings.TrimSpace(req.Name)
+			rule.Kind = req.Kind
+			rule.Threshold = req.Threshold
+			rule.Window = req.Window
+			rule.For = req.For
+			rule.NodeID = req.NodeID
+			rule.URL = req.URL
+			rule.Email = req.Email
+			rule.Disabled = req.Disabled
+			returnSecret := false
+			if req.RotateSecret && rule.URL != "" {
+				secret, err := newSecretToken()
+				if err != nil {
+					httpLog.ErrorContext(r.Context(), "failed to generate alert secret", "err", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				rule.Secret = secret
+				returnSecret = true
+			}
+			if err := rule.Validate(); err != nil {
+				http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdUpdateAlertRule, AlertRule: &rule, UserID: userId}); err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+
+			// The signing secret is only revealed when it is created or rotated.
+			resp := rule.Redacted()
+			if returnSecret {
+				resp.Secret = rule.Secret
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/alerts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserID(r)
+		if !accessControl.IsAdmin(userId) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusNotImplemented)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rule, ok := raftMgr.FSM.Alerts().Get(r.PathValue("id"))
+		if !ok {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdDeleteAlertRule, ID: rule.ID, UserID: userId}); err != nil {
+			if errors.Is(err, ErrNotLeader) {
+				raftMgr.forwardRequestToLeader(w, r)
+				return
+			}
+			httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
 	// User Status & Quota Endpoint
 	mux.HandleFunc("/api/me", func(w http.ResponseWriter, r *http.Request) {
 		userId := getUserID(r)
@@ -551,6 +784,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		select {
 		case hub.requests <- HubRequest{
 			Type:    ReqTypeHTTPAction,
+			Ctx:     r.Context(),
 			UserId:  userId,
 			Headers: r.Header,
 			Host:    r.Host,
@@ -560,7 +794,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			select {
 			case resp := <-reply:
 				if resp.Error != nil {
-					log.Printf("Error processing HTTP action: %v", resp.Error)
+					httpLog.ErrorContext(r.Context(), "action failed", "err", resp.Error)
 					// Map specific errors to HTTP codes if possible, otherwise 500
 					// Currently hub returns generic errors, maybe improve later.
 					// If error string contains "Forbidden", return 403
@@ -628,9 +862,11 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			}
 			// Enforce existing ownership
 			g.OwnerID = existingGame.OwnerID
+			g.PendingOwner = existingGame.PendingOwner
 		} else if errors.Is(err, os.ErrNotExist) {
 			// New game: Set owner to current user
 			g.OwnerID = userId
+			g.PendingOwner = ""
 
 			// Quota Check
 			ownedCount := registry.CountOwnedGames(userId)
@@ -639,7 +875,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				return
 			}
 		} else {
-			log.Printf("Error checking existing game %s: %v", gameId, err)
+			httpLog.ErrorContext(r.Context(), "failed to check existing game", "gameId", gameId, "err", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
@@ -656,7 +892,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 
 		// Validate the entire game data structure
 		if err := ValidateGameData(body); err != nil {
-			log.Printf("Validation error for game %s: %v", gameId, err)
+			httpLog.InfoContext(r.Context(), "game validation failed", "gameId", gameId, "err", err)
 			http.Error(w, fmt.Sprintf("Bad Request: Data validation failed: %v", err), http.StatusBadRequest)
 			return
 		}
@@ -668,6 +904,8 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		select {
 		case hub.requests <- HubRequest{
 			Type:    ReqTypeHTTPSave,
+			Ctx:     r.Context(),
+			UserId:  userId,
 			Payload: body,
 			Reply:   reply,
 			Force:   force, // Add Force field to HubRequest
@@ -681,11 +919,11 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 						return
 					}
 					if errors.Is(resp.Error, ErrConflict) {
-						log.Printf("Conflict during Hub Save: %v", resp.Error)
+						httpLog.InfoContext(r.Context(), "conflict saving game", "gameId", gameId, "err", resp.Error)
 						http.Error(w, "Conflict: "+resp.Error.Error(), http.StatusConflict)
 						return
 					}
-					log.Printf("Internal Server Error during Hub Save: %v", resp.Error)
+					httpLog.ErrorContext(r.Context(), "failed to save game", "gameId", gameId, "err", resp.Error)
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					return
 				}
@@ -734,7 +972,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 					if os.IsNotExist(resp.Error) {
 						http.Error(w, "Not Found: Game not found", http.StatusNotFound)
 					} else {
-						log.Printf("Internal Server Error during Hub Load: %v", resp.Error)
+						httpLog.ErrorContext(r.Context(), "failed to load game", "gameId", gameId, "err", resp.Error)
 						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					}
 					return
@@ -744,7 +982,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				// Authorization Check
 				var g Game
 				if err := json.Unmarshal(data, &g); err != nil {
-					log.Printf("Error unmarshaling game data for auth check: %v", err)
+					httpLog.ErrorContext(r.Context(), "failed to unmarshal game for auth check", "gameId", gameId, "err", err)
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					return
 				}
@@ -797,22 +1035,27 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		}
 
 		limit, offset, sortBy, order, query := parsePagination(r)
-		accessibleIds := registry.ListGames(userId, sortBy, order, query)
-		total := len(accessibleIds)
-
-		// Pagination Logic
-		var pageIds []string
-		if offset < total {
-			end := offset + limit
-			if end > total {
-				end = total
+		query, err := registry.withSavedSearch(userId, r.URL.Query().Get("savedSearch"), query)
+		if err != nil {
+			http.Error(w, "Saved search not found", http.StatusNotFound)
+			return
+		}
+		var since int64
+		if s := r.URL.Query().Get("since"); s != "" {
+			if since, err = strconv.ParseInt(s, 10, 64); err != nil || since < 0 {
+				http.Error(w, "Invalid since", http.StatusBadRequest)
+				return
 			}
-			pageIds = accessibleIds[offset:end]
+		}
+		page, err := registry.PageGames(userId, sortBy, order, query, r.URL.Query().Get("cursor"), since, offset, limit)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
 		}
 
 		games := make([]GameSummary, 0)
 
-		for _, gid := range pageIds {
+		for _, gid := range page.IDs {
 			gf, err := store.LoadGame(gid)
 			if err != nil {
 				continue
@@ -829,15 +1072,16 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			}
 
 			games = append(games, GameSummary{
-				ID:       gf.ID,
-				Date:     gf.Date,
-				Location: gf.Location,
-				Event:    gf.Event,
-				Away:     gf.Away,
-				Home:     gf.Home,
-				Revision: revision,
-				Status:   gf.Status,
-				OwnerID:  gf.OwnerID,
+				ID:        gf.ID,
+				Date:      gf.Date,
+				Location:  gf.Location,
+				Event:     gf.Event,
+				Away:      gf.Away,
+				Home:      gf.Home,
+				Revision:  revision,
+				Status:    gf.Status,
+				OwnerID:   gf.OwnerID,
+				UpdatedAt: gf.UpdatedAt,
 			})
 		}
 
@@ -855,20 +1099,22 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		respData := struct {
 			Data []GameSummary `json:"data"`
 			Meta struct {
-				Total  int `json:"total"`
-				Offset int `json:"offset"`
-				Limit  int `json:"limit"`
+				Total      int    `json:"total"`
+				Offset     int    `json:"offset"`
+				Limit      int    `json:"limit"`
+				NextCursor string `json:"nextCursor,omitempty"`
 			} `json:"meta"`
 		}{
 			Data: games,
 		}
-		respData.Meta.Total = total
-		respData.Meta.Offset = offset
+		respData.Meta.Total = page.Total
+		respData.Meta.Offset = page.Offset
 		respData.Meta.Limit = limit
+		respData.Meta.NextCursor = page.NextCursor
 
 		response, err := json.Marshal(respData)
 		if err != nil {
-			log.Printf("Internal Server Error during JSON Marshal: %v", err)
+			httpLog.ErrorContext(r.Context(), "failed to marshal response", "err", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
@@ -924,9 +1170,17 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			}
 			// Enforce existing ownership
 			t.OwnerID = existingTeam.OwnerID
+			t.CalendarToken = existingTeam.CalendarToken
+			t.Templates = existingTeam.Templates
+			t.Players = existingTeam.Players
+			t.PendingOwner = existingTeam.PendingOwner
 		} else if errors.Is(err, os.ErrNotExist) {
 			// New team: set owner to current user
 			t.OwnerID = userId
+			t.CalendarToken = ""
+			t.Templates = nil
+			t.Players = nil
+			t.PendingOwner = ""
 
 			// Quota Check
 			ownedCount := registry.CountOwnedTeams(userId)
@@ -938,6 +1192,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 
 		// Enforce Schema Version
 		t.SchemaVersion = SchemaVersionV3
+		t.syncPlayers(time.Now().UnixMilli())
 
 		// Re-marshal to enforce server-side fields
 		body, err := json.Marshal(t)
@@ -952,6 +1207,8 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		select {
 		case hub.requests <- HubRequest{
 			Type:    ReqTypeHTTPSave,
+			Ctx:     r.Context(),
+			UserId:  userId,
 			Payload: body,
 			Reply:   reply,
 		}:
@@ -963,7 +1220,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 						raftMgr.forwardRequestToLeader(w, r)
 						return
 					}
-					log.Printf("Internal Server Error during Hub SaveTeam: %v", resp.Error)
+					httpLog.ErrorContext(r.Context(), "failed to save team", "err", resp.Error)
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					return
 				}
@@ -1004,26 +1261,27 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		}
 
 		limit, offset, sortBy, order, query := parsePagination(r)
-		accessibleIds := registry.ListTeams(userId, sortBy, order, query)
-		total := len(accessibleIds)
-
-		// Pagination Logic
-		var pageIds []string
-		if offset < total {
-			end := offset + limit
-			if end > total {
-				end = total
-			}
-			pageIds = accessibleIds[offset:end]
+		query, err := registry.withSavedSearch(userId, r.URL.Query().Get("savedSearch"), query)
+		if err != nil {
+			http.Error(w, "Saved search not found", http.StatusNotFound)
+			return
+		}
+		page, err := registry.PageTeams(userId, sortBy, order, query, r.URL.Query().Get("cursor"), offset, limit)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
 		}
 
 		teams := make([]json.RawMessage, 0)
 
-		for _, tid := range pageIds {
+		for _, tid := range page.IDs {
 			t, err := tStore.LoadTeam(tid)
 			if err != nil {
 				continue
 			}
+			if GetTeamAccess(userId, *t) < AccessAdmin {
+				t.CalendarToken = ""
+			}
 			// Marshalling struct to JSON for list response
 			data, _ := json.Marshal(t)
 			teams = append(teams, json.RawMessage(data))
@@ -1045,20 +1303,22 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		respData := struct {
 			Data []json.RawMessage `json:"data"`
 			Meta struct {
-				Total  int `json:"total"`
-				Offset int `json:"offset"`
-				Limit  int `json:"limit"`
+				Total      int    `json:"total"`
+				Offset     int    `json:"offset"`
+				Limit      int    `json:"limit"`
+				NextCursor string `json:"nextCursor,omitempty"`
 			} `json:"meta"`
 		}{
 			Data: teams,
 		}
-		respData.Meta.Total = total
-		respData.Meta.Offset = offset
+		respData.Meta.Total = page.Total
+		respData.Meta.Offset = page.Offset
 		respData.Meta.Limit = limit
+		respData.Meta.NextCursor = page.NextCursor
 
 		response, err := json.Marshal(respData)
 		if err != nil {
-			log.Printf("Internal Server Error during JSON Marshal: %v", err)
+			httpLog.ErrorContext(r.Context(), "failed to marshal response", "err", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
@@ -1105,7 +1365,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 					if os.IsNotExist(resp.Error) {
 						http.Error(w, "Not Found: Team not found", http.StatusNotFound)
 					} else {
-						log.Printf("Internal Server Error during Hub LoadTeam: %v", resp.Error)
+						httpLog.ErrorContext(r.Context(), "failed to load team", "teamId", teamId, "err", resp.Error)
 						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					}
 					return
@@ -1115,7 +1375,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				// Authorization Check
 				var t Team
 				if err := json.Unmarshal(data, &t); err != nil {
-					log.Printf("Error unmarshaling team data for auth check: %v", err)
+					httpLog.ErrorContext(r.Context(), "failed to unmarshal team for auth check", "teamId", teamId, "err", err)
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 					return
 				}
@@ -1123,6 +1383,11 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 					http.Error(w, "Forbidden: You do not have access to this team", http.StatusForbidden)
 					return
 				}
+				// The calendar feed secret is only visible to team admins.
+				if t.CalendarToken != "" && GetTeamAccess(userId, t) < AccessAdmin {
+					t.CalendarToken = ""
+					data, _ = json.Marshal(t)
+				}
 
 				etag := generateETag(data)
 				if r.Header.Get("If-None-Match") == etag {
@@ -1176,8 +1441,25 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			}
 		}
 
-		if err := tStore.DeleteTeam(teamId); err != nil {
-			log.Printf("Internal Server Error during DeleteTeam: %v", err)
+		if raftMgr != nil {
+			if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdDeleteTeam, ID: teamId, UserID: userId}); err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					body, _ := json.Marshal(data)
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "teamId", teamId, "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "Team %s deleted successfully", teamId)
+			return
+		}
+
+		if err := tStore.TrashTeam(teamId, 0, time.Now()); err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to delete team", "teamId", teamId, "err", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
@@ -1248,6 +1530,8 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				select {
 				case hub.requests <- HubRequest{
 					Type:    ReqTypeHTTPSave,
+					Ctx:     r.Context(),
+					UserId:  userId,
 					Payload: updatedBytes,
 					Reply:   replySave,
 				}:
@@ -1273,56 +1557,62 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		}
 	})
 
-	mux.HandleFunc("/api/delete-game", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	// Team Calendar Feed (Authenticated by the per-team secret token)
+	mux.HandleFunc("/api/teams/{id}/calendar.ics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		userId := getUserID(r)
-		if userId == "" || !isValidEmail(userId) {
-			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+		teamId := r.PathValue("id")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: teamId is missing or invalid", http.StatusBadRequest)
 			return
 		}
 
-		var data struct {
-			ID string `json:"id"`
-		}
-		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576)).Decode(&data); err != nil {
-			http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+		t, err := tStore.LoadTeam(teamId)
+		if err != nil || t.Status == "deleted" || !checkCalendarToken(t, r.URL.Query().Get("token")) {
+			// Do not reveal whether the team exists.
+			http.Error(w, "Not Found", http.StatusNotFound)
 			return
 		}
 
-		gameId := data.ID
-		if gameId == "" || !isValidUUID(gameId) {
-			http.Error(w, "Bad Request: gameId is missing or invalid", http.StatusBadRequest)
+		tg, err := userStore.GetTeamGames(teamId)
+		if err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to list team games", "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
-		// Authorization Check
-		g, err := store.LoadGame(gameId)
-		if err == nil {
-			if GetGameAccess(userId, *g, tStore) < AccessAdmin {
-				http.Error(w, "Forbidden: Only the owner can delete this game", http.StatusForbidden)
-				return
+		games := make([]*Game, 0, len(tg.GameIDs))
+		for gid := range tg.GameIDs {
+			g, err := store.LoadGame(gid)
+			if err != nil || g.Status == "deleted" {
+				continue
+			}
+			// Games may be unlinked after indexing.
+			if g.AwayTeamID != teamId && g.HomeTeamID != teamId {
+				continue
 			}
+			games = append(games, g)
 		}
 
-		if err := store.DeleteGame(gameId); err != nil {
-			log.Printf("Internal Server Error during DeleteGame: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		data := buildTeamCalendar(t, games, time.Now())
+		etag := generateETag(data)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
-		registry.DeleteGame(gameId)
-
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, "Game %s deleted successfully", gameId)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write(data)
 	})
 
-	mux.HandleFunc("/api/check-deletions", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	// Team Calendar Token Management (Team Admins)
+	mux.HandleFunc("/api/teams/{id}/calendar-token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
@@ -1332,57 +1622,1425 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			return
 		}
 
-		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
-			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
-			return
-		}
-
-		var req struct {
-			GameIDs []string `json:"gameIds"`
-			TeamIDs []string `json:"teamIds"`
-		}
-		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576)).Decode(&req); err != nil {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+		teamId := r.PathValue("id")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: teamId is missing or invalid", http.StatusBadRequest)
 			return
 		}
 
-		var resp struct {
-			DeletedGameIDs []string `json:"deletedGameIds"`
-			DeletedTeamIDs []string `json:"deletedTeamIds"`
-		}
-		resp.DeletedGameIDs = make([]string, 0)
-		resp.DeletedTeamIDs = make([]string, 0)
-
-		for _, gid := range req.GameIDs {
-			// Report as deleted if explicitly tombstoned OR if it exists but is no longer accessible
-			if registry.IsGameDeleted(gid) || (registry.GameExists(gid) && !registry.HasGameAccess(userId, gid)) {
-				resp.DeletedGameIDs = append(resp.DeletedGameIDs, gid)
-			}
-		}
-		for _, tid := range req.TeamIDs {
-			if registry.IsTeamDeleted(tid) || (registry.TeamExists(tid) && !registry.HasTeamAccess(userId, tid)) {
-				resp.DeletedTeamIDs = append(resp.DeletedTeamIDs, tid)
-			}
-		}
+		// Serialize through Hub
+		hub := hm.GetHub(teamId, true, store, tStore, registry)
+		reply := make(chan HubResponse, 1)
+		select {
+		case hub.requests <- HubRequest{
+			Type:  ReqTypeHTTPLoad,
+			Reply: reply,
+		}:
+			select {
+			case resp := <-reply:
+				if resp.Error != nil {
+					if os.IsNotExist(resp.Error) {
+						http.Error(w, "Not Found", http.StatusNotFound)
+					} else {
+						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					}
+					return
+				}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-	})
+				var t Team
+				if err := json.Unmarshal(resp.Data, &t); err != nil {
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
 
-	mux.HandleFunc("/api/delete-all", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
-		}
+				if GetTeamAccess(userId, t) < AccessAdmin {
+					http.Error(w, "Forbidden: Only team admins can manage the calendar feed", http.StatusForbidden)
+					return
+				}
 
-		userId := getUserID(r)
-		if userId == "" || !isValidEmail(userId) {
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
-		}
+				// POST rotates the token, DELETE revokes the feed.
+				t.CalendarToken = ""
+				if r.Method == http.MethodPost {
+					token, err := newSecretToken()
+					if err != nil {
+						httpLog.ErrorContext(r.Context(), "failed to generate calendar token", "err", err)
+						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+						return
+					}
+					t.CalendarToken = token
+				}
+				updatedBytes, _ := json.Marshal(t)
 
-		if raftMgr != nil && raftMgr.Raft.State() != raft.Leader {
-			raftMgr.forwardRequestToLeader(w, r)
+				replySave := make(chan HubResponse, 1)
+				select {
+				case hub.requests <- HubRequest{
+					Type:    ReqTypeHTTPSave,
+					Ctx:     r.Context(),
+					UserId:  userId,
+					Payload: updatedBytes,
+					Reply:   replySave,
+				}:
+					select {
+					case respSave := <-replySave:
+						if respSave.Error != nil {
+							if errors.Is(respSave.Error, ErrNotLeader) && raftMgr != nil {
+								raftMgr.forwardRequestToLeader(w, r)
+								return
+							}
+							httpLog.ErrorContext(r.Context(), "failed to save team", "err", respSave.Error)
+							http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+							return
+						}
+
+						resp := map[string]string{"token": t.CalendarToken}
+						if t.CalendarToken != "" {
+							resp["url"] = "/api/teams/" + teamId + "/calendar.ics?token=" + t.CalendarToken
+						}
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(resp)
+					case <-r.Context().Done():
+						return
+					}
+				default:
+					hubBusyResponse(w, retryAfterSave)
+				}
+			case <-r.Context().Done():
+				return
+			}
+		default:
+			hubBusyResponse(w, retryAfterLoad)
+		}
+	})
+
+	// Saved searches (smart folders), stored in the user's index
+	mux.HandleFunc("/api/saved-searches", func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			searches := registry.SavedSearches(userId)
+			if searches == nil {
+				searches = []SavedSearch{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"data": searches})
+
+		case http.MethodPost:
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			var req struct {
+				ID    string `json:"id"`
+				Name  string `json:"name"`
+				Query string `json:"query"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+				return
+			}
+
+			now := time.Now().UnixMilli()
+			s := SavedSearch{
+				ID:        req.ID,
+				Name:      strings.TrimSpace(req.Name),
+				Query:     strings.TrimSpace(req.Query),
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if req.ID != "" {
+				existing, ok := registry.SavedSearch(userId, req.ID)
+				if !ok {
+					http.Error(w, "Not Found", http.StatusNotFound)
+					return
+				}
+				s.CreatedAt = existing.CreatedAt
+			} else {
+				if len(registry.SavedSearches(userId)) >= savedSearchMaxPerUser {
+					http.Error(w, fmt.Sprintf("Bad Request: too many saved searches (max %d)", savedSearchMaxPerUser), http.StatusBadRequest)
+					return
+				}
+				s.ID = uuid.NewString()
+			}
+			if err := s.Validate(); err != nil {
+				http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if raftMgr != nil {
+				if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdPutSavedSearch, SavedSearch: &s, UserID: userId}); err != nil {
+					if errors.Is(err, ErrNotLeader) {
+						r.Body = io.NopCloser(bytes.NewReader(body))
+						raftMgr.forwardRequestToLeader(w, r)
+						return
+					}
+					httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+			} else if err := registry.PutSavedSearch(userId, s); err != nil {
+				http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/saved-searches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		id := r.PathValue("id")
+		if _, ok := registry.SavedSearch(userId, id); !ok {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+
+		if raftMgr != nil {
+			if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdDeleteSavedSearch, ID: id, UserID: userId}); err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+		} else if err := registry.DeleteSavedSearch(userId, id); err != nil {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Webhooks (Raft-replicated configuration, delivered by the leader)
+	mux.HandleFunc("/api/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusNotImplemented)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		webhooks := raftMgr.FSM.Webhooks()
+
+		switch r.Method {
+		case http.MethodGet:
+			scope := Webhook{Scope: WebhookScopeUser, ScopeID: userId}
+			if teamId := r.URL.Query().Get("teamId"); teamId != "" {
+				scope = Webhook{Scope: WebhookScopeTeam, ScopeID: teamId}
+			}
+			if !canManageWebhook(userId, &scope, tStore) {
+				http.Error(w, "Forbidden: You do not have permission to manage these webhooks", http.StatusForbidden)
+				return
+			}
+			hooks := webhooks.List(scope.Scope, scope.ScopeID)
+			for i := range hooks {
+				hooks[i] = hooks[i].Redacted()
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"data": hooks})
+
+		case http.MethodPost:
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			var req struct {
+				ID           string   `json:"id"`
+				TeamID       string   `json:"teamId"`
+				URL          string   `json:"url"`
+				Events       []string `json:"events"`
+				Disabled     bool     `json:"disabled"`
+				RotateSecret bool     `json:"rotateSecret"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+				return
+			}
+
+			var hook Webhook
+			returnSecret := false
+			if req.ID != "" {
+				existing, ok := webhooks.Get(req.ID)
+				if !ok {
+					http.Error(w, "Not Found", http.StatusNotFound)
+					return
+				}
+				hook = existing
+			} else {
+				hook = Webhook{
+					ID:        uuid.NewString(),
+					Scope:     WebhookScopeUser,
+					ScopeID:   userId,
+					CreatedBy: userId,
+					CreatedAt: time.Now().UnixMilli(),
+				}
+				if req.TeamID != "" {
+					if !isValidUUID(req.TeamID) {
+						http.Error(w, "Bad Request: teamId is invalid", http.StatusBadRequest)
+						return
+					}
+					hook.Scope = WebhookScopeTeam
+					hook.ScopeID = req.TeamID
+				}
+				req.RotateSecret = true
+			}
+			if !canManageWebhook(userId, &hook, tStore) {
+				http.Error(w, "Forbidden: You do not have permission to manage this webhook", http.StatusForbidden)
+				return
+			}
+
+			hook.URL = req.URL
+			hook.Events = req.Events
+			hook.Disabled = req.Disabled
+			if req.RotateSecret {
+				secret, err := newSecretToken()
+				if err != nil {
+					httpLog.ErrorContext(r.Context(), "failed to generate webhook secret", "err", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				hook.Secret = secret
+				returnSecret = true
+			}
+			if err := hook.Validate(); err != nil {
+				http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdUpdateWebhook, Webhook: &hook, UserID: userId}); err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+
+			// The signing secret is only revealed when it is created or rotated.
+			resp := hook.Redacted()
+			if returnSecret {
+				resp.Secret = hook.Secret
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusNotImplemented)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		hook, ok := raftMgr.FSM.Webhooks().Get(r.PathValue("id"))
+		if !ok {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if !canManageWebhook(userId, &hook, tStore) {
+			http.Error(w, "Forbidden: You do not have permission to manage this webhook", http.StatusForbidden)
+			return
+		}
+
+		if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdDeleteWebhook, ID: hook.ID, UserID: userId}); err != nil {
+			if errors.Is(err, ErrNotLeader) {
+				raftMgr.forwardRequestToLeader(w, r)
+				return
+			}
+			httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/api/webhooks/{id}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusNotImplemented)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		hook, ok := raftMgr.FSM.Webhooks().Get(r.PathValue("id"))
+		if !ok {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if !canManageWebhook(userId, &hook, tStore) {
+			http.Error(w, "Forbidden: You do not have permission to manage this webhook", http.StatusForbidden)
+			return
+		}
+
+		// Deliveries are made (and logged) by the leader.
+		if raftMgr.Raft.State() != raft.Leader {
+			raftMgr.forwardRequestToLeader(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"data": raftMgr.FSM.Webhooks().Deliveries(hook.ID)})
+	})
+
+	// updateViaHub loads a game or team through its Hub, applies update, and
+	// saves the result through the Hub so that in-memory state and Raft
+	// replication stay consistent. update returns the new document, or a
+	// non-zero HTTP status and message to reject the request. It reports
+	// whether the document was saved; otherwise a response has been written.
+	updateViaHub := func(w http.ResponseWriter, r *http.Request, userId, id string, isTeam bool, update func(data []byte) ([]byte, int, string)) bool {
+		hub := hm.GetHub(id, isTeam, store, tStore, registry)
+		reply := make(chan HubResponse, 1)
+		select {
+		case hub.requests <- HubRequest{Type: ReqTypeHTTPLoad, Reply: reply}:
+		default:
+			hubBusyResponse(w, retryAfterLoad)
+			return false
+		}
+		var data []byte
+		select {
+		case resp := <-reply:
+			if resp.Error != nil {
+				if os.IsNotExist(resp.Error) {
+					http.Error(w, "Not Found", http.StatusNotFound)
+				} else {
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				}
+				return false
+			}
+			data = resp.Data
+		case <-r.Context().Done():
+			return false
+		}
+
+		updated, status, msg := update(data)
+		if status != 0 {
+			http.Error(w, msg, status)
+			return false
+		}
+
+		replySave := make(chan HubResponse, 1)
+		select {
+		case hub.requests <- HubRequest{Type: ReqTypeHTTPSave, Ctx: r.Context(), UserId: userId, Payload: updated, Reply: replySave}:
+		default:
+			hubBusyResponse(w, retryAfterSave)
+			return false
+		}
+		select {
+		case respSave := <-replySave:
+			if respSave.Error != nil {
+				if errors.Is(respSave.Error, ErrNotLeader) && raftMgr != nil {
+					raftMgr.forwardRequestToLeader(w, r)
+					return false
+				}
+				httpLog.ErrorContext(r.Context(), "failed to save resource", "id", id, "err", respSave.Error)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return false
+			}
+			return true
+		case <-r.Context().Done():
+			return false
+		}
+	}
+
+	// handleTransfer implements the ownership transfer flow for games and teams:
+	// the owner (or a system admin) offers the resource to another user with
+	// POST .../transfer and withdraws the offer with DELETE .../transfer; the
+	// recipient answers with POST .../transfer/accept or .../transfer/decline.
+	handleTransfer := func(w http.ResponseWriter, r *http.Request, isTeam bool, op string) {
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		id := r.PathValue("id")
+		if !isValidUUID(id) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		var newOwner string
+		if op == "offer" {
+			var req struct {
+				NewOwner string `json:"newOwner"`
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576)).Decode(&req); err != nil {
+				http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+				return
+			}
+			newOwner = normalizeEmail(req.NewOwner)
+			if !isValidEmail(newOwner) {
+				http.Error(w, "Bad Request: newOwner must be a valid email", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var result OwnershipTransfer
+		saved := updateViaHub(w, r, userId, id, isTeam, func(data []byte) ([]byte, int, string) {
+			var g Game
+			var t Team
+			var owner, pending *string
+			var status string
+			if isTeam {
+				if err := json.Unmarshal(data, &t); err != nil {
+					return nil, http.StatusInternalServerError, "Internal Server Error"
+				}
+				owner, pending, status = &t.OwnerID, &t.PendingOwner, t.Status
+				result = OwnershipTransfer{Kind: "team", ID: id, Name: t.Name}
+			} else {
+				if err := json.Unmarshal(data, &g); err != nil {
+					return nil, http.StatusInternalServerError, "Internal Server Error"
+				}
+				owner, pending, status = &g.OwnerID, &g.PendingOwner, g.Status
+				result = OwnershipTransfer{Kind: "game", ID: id, Name: fmt.Sprintf("%s @ %s", g.Away, g.Home)}
+			}
+			if status == "deleted" || *owner == "" {
+				return nil, http.StatusNotFound, "Not Found"
+			}
+			isOwner := normalizeEmail(*owner) == userId || accessControl.IsAdmin(userId)
+			isRecipient := *pending != "" && normalizeEmail(*pending) == userId
+
+			switch op {
+			case "offer":
+				if !isOwner {
+					return nil, http.StatusForbidden, "Forbidden: Only the owner can transfer ownership"
+				}
+				if newOwner == normalizeEmail(*owner) {
+					return nil, http.StatusBadRequest, "Bad Request: newOwner is already the owner"
+				}
+				*pending = newOwner
+			case "cancel":
+				if !isOwner {
+					return nil, http.StatusForbidden, "Forbidden: Only the owner can cancel a transfer"
+				}
+				if *pending == "" {
+					return nil, http.StatusNotFound, "Not Found: no pending transfer"
+				}
+				*pending = ""
+			case "accept", "decline":
+				if !isRecipient {
+					return nil, http.StatusNotFound, "Not Found: no pending transfer to you"
+				}
+				if op == "decline" {
+					*pending = ""
+					break
+				}
+				var err error
+				if isTeam {
+					if err = accessControl.CheckTeamQuota(userId, registry.CountOwnedTeams(userId)); err == nil {
+						acceptTeamTransfer(&t)
+					}
+				} else {
+					if err = accessControl.CheckGameQuota(userId, registry.CountOwnedGames(userId)); err == nil {
+						acceptGameTransfer(&g)
+					}
+				}
+				if err != nil {
+					return nil, http.StatusForbidden, "Forbidden: " + err.Error()
+				}
+			}
+
+			result.OwnerID, result.NewOwner = *owner, *pending
+			var out []byte
+			if isTeam {
+				out, _ = json.Marshal(t)
+			} else {
+				out, _ = json.Marshal(g)
+			}
+			return out, 0, ""
+		})
+		if !saved {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+
+	transferRoute := func(isTeam bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				handleTransfer(w, r, isTeam, "offer")
+			case http.MethodDelete:
+				handleTransfer(w, r, isTeam, "cancel")
+			default:
+				http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			}
+		}
+	}
+	transferReplyRoute := func(isTeam bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			op := r.PathValue("op")
+			if op != "accept" && op != "decline" {
+				http.NotFound(w, r)
+				return
+			}
+			handleTransfer(w, r, isTeam, op)
+		}
+	}
+	mux.HandleFunc("/api/games/{id}/transfer", transferRoute(false))
+	mux.HandleFunc("/api/games/{id}/transfer/{op}", transferReplyRoute(false))
+	mux.HandleFunc("/api/teams/{id}/transfer", transferRoute(true))
+	mux.HandleFunc("/api/teams/{id}/transfer/{op}", transferReplyRoute(true))
+
+	mux.HandleFunc("/api/transfers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		incoming, outgoing, err := registry.listTransfers(userId)
+		if err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to list transfers", "userId", maskEmail(userId), "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"incoming": incoming, "outgoing": outgoing})
+	})
+
+	// createGameFromSetup starts a new game owned by the caller from setup,
+	// with the date and overrides of the request body, and writes its ID.
+	createGameFromSetup := func(w http.ResponseWriter, r *http.Request, userId string, setup GameSetup) {
+		var req GameFromSetup
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576)).Decode(&req); err != nil {
+			http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+			return
+		}
+		setup, err := req.apply(setup)
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := accessControl.CheckGameQuota(userId, registry.CountOwnedGames(userId)); err != nil {
+			http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		gameId := uuid.NewString()
+		actions, err := setup.actions(gameId, userId, req.Date, time.Now().UnixMilli())
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		hub := hm.GetHub(gameId, false, store, tStore, registry)
+		if status, msg := hub.submitAction(r.Context(), HubRequest{
+			UserId:  userId,
+			Headers: r.Header,
+			Host:    r.Host,
+			Message: Message{Type: MsgTypeAction, GameId: gameId, Actions: actions},
+		}); status != 0 {
+			httpLog.ErrorContext(r.Context(), "failed to create game", "gameId", gameId, "err", msg)
+			http.Error(w, msg, status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": gameId})
+	}
+
+	mux.HandleFunc("/api/games/{id}/clone", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		gameId := r.PathValue("id")
+		if !isValidUUID(gameId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+		g, err := store.LoadGame(gameId)
+		if err != nil || g.Status == "deleted" || GetGameAccess(userId, *g, tStore) < AccessRead {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		createGameFromSetup(w, r, userId, setupFor(userId, g, tStore))
+	})
+
+	// Game templates saved on a team
+	mux.HandleFunc("/api/teams/{id}/templates", func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+		teamId := r.PathValue("id")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			t, err := tStore.LoadTeam(teamId)
+			if err != nil || t.Status == "deleted" {
+				http.Error(w, "Not Found", http.StatusNotFound)
+				return
+			}
+			if GetTeamAccess(userId, *t) < AccessRead {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			templates := t.Templates
+			if templates == nil {
+				templates = []GameTemplate{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"data": templates})
+
+		case http.MethodPost:
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+			if err != nil {
+				http.Error(w, "Bad Request: Body too large", http.StatusBadRequest)
+				return
+			}
+			var req struct {
+				Name   string `json:"name"`
+				GameID string `json:"gameId"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+				return
+			}
+			if !isValidUUID(req.GameID) {
+				http.Error(w, "Bad Request: gameId is missing or invalid", http.StatusBadRequest)
+				return
+			}
+			g, err := store.LoadGame(req.GameID)
+			if err != nil || g.Status == "deleted" || GetGameAccess(userId, *g, tStore) < AccessRead {
+				http.Error(w, "Game Not Found", http.StatusNotFound)
+				return
+			}
+
+			tmpl := GameTemplate{
+				ID:        uuid.NewString(),
+				Name:      req.Name,
+				CreatedBy: userId,
+				CreatedAt: time.Now().UnixMilli(),
+				Setup:     setupFor(userId, g, tStore),
+			}
+			// The body is read again if the save is forwarded to the leader.
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			saved := updateViaHub(w, r, userId, teamId, true, func(data []byte) ([]byte, int, string) {
+				var t Team
+				if err := json.Unmarshal(data, &t); err != nil {
+					return nil, http.StatusInternalServerError, "Internal Server Error"
+				}
+				if t.Status == "deleted" {
+					return nil, http.StatusNotFound, "Not Found"
+				}
+				if GetTeamAccess(userId, t) < AccessWrite {
+					return nil, http.StatusForbidden, "Forbidden: You do not have permission to manage this team"
+				}
+				var err error
+				if tmpl, err = t.addTemplate(tmpl); err != nil {
+					return nil, http.StatusBadRequest, "Bad Request: " + err.Error()
+				}
+				updated, _ := json.Marshal(t)
+				return updated, 0, ""
+			})
+			if !saved {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(tmpl)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/teams/{id}/templates/{templateId}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+		teamId, templateId := r.PathValue("id"), r.PathValue("templateId")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		saved := updateViaHub(w, r, userId, teamId, true, func(data []byte) ([]byte, int, string) {
+			var t Team
+			if err := json.Unmarshal(data, &t); err != nil {
+				return nil, http.StatusInternalServerError, "Internal Server Error"
+			}
+			if t.Status == "deleted" {
+				return nil, http.StatusNotFound, "Not Found"
+			}
+			if GetTeamAccess(userId, t) < AccessWrite {
+				return nil, http.StatusForbidden, "Forbidden: You do not have permission to manage this team"
+			}
+			if err := t.removeTemplate(templateId); err != nil {
+				return nil, http.StatusNotFound, "Template Not Found"
+			}
+			updated, _ := json.Marshal(t)
+			return updated, 0, ""
+		})
+		if saved {
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	mux.HandleFunc("/api/teams/{id}/templates/{templateId}/games", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+		teamId := r.PathValue("id")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		t, err := tStore.LoadTeam(teamId)
+		if err != nil || t.Status == "deleted" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if GetTeamAccess(userId, *t) < AccessWrite {
+			http.Error(w, "Forbidden: Only team scorekeepers can create games from templates", http.StatusForbidden)
+			return
+		}
+		tmpl, err := t.template(r.PathValue("templateId"))
+		if err != nil {
+			http.Error(w, "Template Not Found", http.StatusNotFound)
+			return
+		}
+		createGameFromSetup(w, r, userId, tmpl.Setup)
+	})
+
+	// Team player registry
+	playerRequest := func(w http.ResponseWriter, r *http.Request) (userId, teamId string, ok bool) {
+		userId = getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return "", "", false
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return "", "", false
+		}
+		teamId = r.PathValue("id")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return "", "", false
+		}
+		return userId, teamId, true
+	}
+
+	// updatePlayers applies update to the player registry of a team through
+	// its Hub and writes the player it returns. body is the request body,
+	// restored in case the request is forwarded to the leader.
+	updatePlayers := func(w http.ResponseWriter, r *http.Request, userId, teamId string, body []byte, update func(t *Team, now int64) (TeamPlayer, error)) {
+		var result TeamPlayer
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		saved := updateViaHub(w, r, userId, teamId, true, func(data []byte) ([]byte, int, string) {
+			var t Team
+			if err := json.Unmarshal(data, &t); err != nil {
+				return nil, http.StatusInternalServerError, "Internal Server Error"
+			}
+			if t.Status == "deleted" {
+				return nil, http.StatusNotFound, "Not Found"
+			}
+			if GetTeamAccess(userId, t) < AccessWrite {
+				return nil, http.StatusForbidden, "Forbidden: You do not have permission to manage this team"
+			}
+			now := time.Now().UnixMilli()
+			t.syncPlayers(now)
+			var err error
+			if result, err = update(&t, now); err != nil {
+				if errors.Is(err, errPlayerNotFound) {
+					return nil, http.StatusNotFound, "Player Not Found"
+				}
+				return nil, http.StatusBadRequest, "Bad Request: " + err.Error()
+			}
+			updated, _ := json.Marshal(t)
+			return updated, 0, ""
+		})
+		if saved {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+		}
+	}
+
+	mux.HandleFunc("/api/teams/{id}/players", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId, teamId, ok := playerRequest(w, r)
+		if !ok {
+			return
+		}
+		t, err := tStore.LoadTeam(teamId)
+		if err != nil || t.Status == "deleted" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if GetTeamAccess(userId, *t) < AccessRead {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		// Teams saved before the registry existed get their records here;
+		// they are stored with the next change to the team.
+		t.syncPlayers(t.UpdatedAt)
+		players := t.Players
+		if players == nil {
+			players = []TeamPlayer{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"data": players})
+	})
+
+	mux.HandleFunc("/api/teams/{id}/players/{playerId}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId, teamId, ok := playerRequest(w, r)
+		if !ok {
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+		if err != nil {
+			http.Error(w, "Bad Request: Body too large", http.StatusBadRequest)
+			return
+		}
+		var u PlayerUpdate
+		if err := json.Unmarshal(body, &u); err != nil {
+			http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+			return
+		}
+		if err := u.validate(); err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		playerId := r.PathValue("playerId")
+		updatePlayers(w, r, userId, teamId, body, func(t *Team, now int64) (TeamPlayer, error) {
+			return t.updatePlayer(playerId, u, now)
+		})
+	})
+
+	mux.HandleFunc("/api/teams/{id}/players/merge", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId, teamId, ok := playerRequest(w, r)
+		if !ok {
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+		if err != nil {
+			http.Error(w, "Bad Request: Body too large", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			From string `json:"from"`
+			Into string `json:"into"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+			return
+		}
+		updatePlayers(w, r, userId, teamId, body, func(t *Team, now int64) (TeamPlayer, error) {
+			return t.mergePlayers(req.From, req.Into, now)
+		})
+	})
+
+	// Player profiles: teams, game log and career totals
+	mux.HandleFunc("/api/players/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+		playerId := r.PathValue("id")
+		if !isValidUUID(playerId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+		career, err := registry.PlayerCareer(userId, playerId)
+		if errors.Is(err, errPlayerNotFound) {
+			http.Error(w, "Player Not Found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to build player career", "playerId", playerId, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"data": career})
+	})
+
+	// serveAuditLog writes one page of an audit log, newest entries first.
+	serveAuditLog := func(w http.ResponseWriter, r *http.Request, name string) {
+		limit, offset, _, _, _ := parsePagination(r)
+		page, total, err := raftMgr.FSM.Audit().ReadPage(name, offset, limit)
+		if err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to read audit log", "log", name, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		respData := struct {
+			Data []AuditEntry `json:"data"`
+			Meta struct {
+				Total  int `json:"total"`
+				Offset int `json:"offset"`
+				Limit  int `json:"limit"`
+			} `json:"meta"`
+		}{
+			Data: page,
+		}
+		respData.Meta.Total = total
+		respData.Meta.Offset = offset
+		respData.Meta.Limit = limit
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(respData)
+	}
+
+	// checkAuditRequest validates an audit log request and returns the caller's ID.
+	checkAuditRequest := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		if raftMgr == nil {
+			http.Error(w, "Raft is not enabled on this node", http.StatusNotImplemented)
+			return "", false
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return "", false
+		}
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return "", false
+		}
+		return userId, true
+	}
+
+	mux.HandleFunc("/api/games/{id}/audit", func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := checkAuditRequest(w, r)
+		if !ok {
+			return
+		}
+		gameId := r.PathValue("id")
+		if !isValidUUID(gameId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		// Logs of deleted games remain readable by system admins.
+		if !accessControl.IsAdmin(userId) {
+			g, err := store.LoadGame(gameId)
+			if err != nil || GetGameAccess(userId, *g, tStore) < AccessAdmin {
+				http.Error(w, "Forbidden: You do not have permission to view this audit log", http.StatusForbidden)
+				return
+			}
+		}
+		serveAuditLog(w, r, auditGameLog(gameId))
+	})
+
+	mux.HandleFunc("/api/teams/{id}/audit", func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := checkAuditRequest(w, r)
+		if !ok {
+			return
+		}
+		teamId := r.PathValue("id")
+		if !isValidUUID(teamId) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		if !accessControl.IsAdmin(userId) {
+			t, err := tStore.LoadTeam(teamId)
+			if err != nil || GetTeamAccess(userId, *t) < AccessAdmin {
+				http.Error(w, "Forbidden: You do not have permission to view this audit log", http.StatusForbidden)
+				return
+			}
+		}
+		serveAuditLog(w, r, auditTeamLog(teamId))
+	})
+
+	mux.HandleFunc("/api/admin/audit", func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := checkAuditRequest(w, r)
+		if !ok {
+			return
+		}
+		if !accessControl.IsAdmin(userId) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		serveAuditLog(w, r, auditSystemLog)
+	})
+
+	mux.HandleFunc("/api/delete-game", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		var data struct {
+			ID string `json:"id"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576)).Decode(&data); err != nil {
+			http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+			return
+		}
+
+		gameId := data.ID
+		if gameId == "" || !isValidUUID(gameId) {
+			http.Error(w, "Bad Request: gameId is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		// Authorization Check
+		g, err := store.LoadGame(gameId)
+		if err == nil {
+			if GetGameAccess(userId, *g, tStore) < AccessAdmin {
+				http.Error(w, "Forbidden: Only the owner can delete this game", http.StatusForbidden)
+				return
+			}
+		}
+
+		if raftMgr != nil {
+			if _, err := raftMgr.ProposeContext(r.Context(), RaftCommand{Type: CmdDeleteGame, ID: gameId, UserID: userId}); err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					body, _ := json.Marshal(data)
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "gameId", gameId, "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "Game %s deleted successfully", gameId)
+			return
+		}
+
+		if err := store.TrashGame(gameId, 0, time.Now()); err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to delete game", "gameId", gameId, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		registry.DeleteGame(gameId)
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Game %s deleted successfully", gameId)
+	})
+
+	mux.HandleFunc("/api/games/bulk", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Unauthenticated", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+		if err != nil {
+			http.Error(w, "Bad Request: Body too large", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Operations []BulkOperation `json:"operations"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Bad Request: Malformed JSON", http.StatusBadRequest)
+			return
+		}
+		if err := validateBulk(req.Operations); err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Authorize against the leader's state, which is what the commands apply to.
+		if raftMgr != nil && raftMgr.Raft.State() != raft.Leader {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			raftMgr.forwardRequestToLeader(w, r)
+			return
+		}
+
+		activeVersion := CurrentSchemaVersion
+		if raftMgr != nil {
+			activeVersion = raftMgr.FSM.ActiveSchemaVersion()
+		}
+		results, items := planBulk(userId, req.Operations, store, tStore, time.Now().UnixMilli(), activeVersion)
+		if raftMgr != nil && len(items) > 0 {
+			errs, err := raftMgr.ProposeBatch(r.Context(), bulkCommands(userId, items))
+			if err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			for i, err := range errs {
+				if err != nil {
+					httpLog.ErrorContext(r.Context(), "bulk item failed", "gameId", items[i].gameId, "err", err)
+					results[items[i].result].Status = http.StatusInternalServerError
+					results[items[i].result].Error = err.Error()
+				}
+			}
+		} else if raftMgr == nil {
+			applyBulkStandalone(r.Context(), userId, items, results, hm, store, tStore, registry)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"data": results})
+	})
+
+	mux.HandleFunc("/api/check-deletions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			GameIDs []string `json:"gameIds"`
+			TeamIDs []string `json:"teamIds"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576)).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		var resp struct {
+			DeletedGameIDs []string `json:"deletedGameIds"`
+			DeletedTeamIDs []string `json:"deletedTeamIds"`
+		}
+		resp.DeletedGameIDs = make([]string, 0)
+		resp.DeletedTeamIDs = make([]string, 0)
+
+		for _, gid := range req.GameIDs {
+			// Report as deleted if explicitly tombstoned OR if it exists but is no longer accessible
+			if registry.IsGameDeleted(gid) || (registry.GameExists(gid) && !registry.HasGameAccess(userId, gid)) {
+				resp.DeletedGameIDs = append(resp.DeletedGameIDs, gid)
+			}
+		}
+		for _, tid := range req.TeamIDs {
+			if registry.IsTeamDeleted(tid) || (registry.TeamExists(tid) && !registry.HasTeamAccess(userId, tid)) {
+				resp.DeletedTeamIDs = append(resp.DeletedTeamIDs, tid)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	// Change feed: the games and teams of the user that changed since an index
+	mux.HandleFunc("/api/changes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		var since uint64
+		if s := r.URL.Query().Get("since"); s != "" {
+			var err error
+			if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+				http.Error(w, "Invalid since", http.StatusBadRequest)
+				return
+			}
+		}
+		limit := 500
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = min(l, 1000)
+		}
+
+		feed, err := registry.Changes(userId, since, limit)
+		if err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to read change feed", "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := struct {
+			Data []Change `json:"data"`
+			Meta struct {
+				Index uint64 `json:"index"`
+				More  bool   `json:"more,omitempty"`
+				Reset bool   `json:"reset,omitempty"`
+			} `json:"meta"`
+		}{
+			Data: feed.Changes,
+		}
+		resp.Meta.Index = feed.Index
+		resp.Meta.More = feed.More
+		resp.Meta.Reset = feed.Reset
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	mux.HandleFunc("/api/delete-all", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if raftMgr != nil && raftMgr.Raft.State() != raft.Leader {
+			raftMgr.forwardRequestToLeader(w, r)
 			return
 		}
 
@@ -1393,9 +3051,10 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				Action: &ActionPayload{
 					UserID: userId,
 				},
+				UserID: userId,
 			}
-			if _, err := raftMgr.Propose(cmd); err != nil {
-				log.Printf("Raft Propose Error delete all for user %s: %v", userId, err)
+			if _, err := raftMgr.ProposeContext(r.Context(), cmd); err != nil {
+				httpLog.ErrorContext(r.Context(), "raft propose failed", "userId", maskEmail(userId), "err", err)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 				return
 			}
@@ -1407,16 +3066,16 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 			return
 		}
 
-		log.Printf("DeleteAll: User %s requested deletion", userId)
+		httpLog.InfoContext(r.Context(), "delete all requested", "userId", maskEmail(userId))
 
 		// 1. Delete Games
 		accessibleGames := registry.ListGames(userId, "", "", "")
-		log.Printf("DeleteAll: Found %d accessible games", len(accessibleGames))
+		httpLog.InfoContext(r.Context(), "delete all: found games", "count", len(accessibleGames))
 		deletedGames := 0
 		for _, id := range accessibleGames {
 			g, err := store.LoadGame(id)
 			if err != nil {
-				log.Printf("DeleteAll: Error loading game %s: %v", id, err)
+				httpLog.ErrorContext(r.Context(), "delete all: failed to load game", "gameId", id, "err", err)
 				continue
 			}
 			if g.OwnerID != userId {
@@ -1427,18 +3086,18 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				hm.RemoveHub(id, false) // Clear from memory
 				deletedGames++
 			} else {
-				log.Printf("Error deleting game %s: %v", id, err)
+				httpLog.ErrorContext(r.Context(), "delete all: failed to delete game", "gameId", id, "err", err)
 			}
 		}
 
 		// 2. Delete Teams
 		accessibleTeams := registry.ListTeams(userId, "", "", "")
-		log.Printf("DeleteAll: Found %d accessible teams", len(accessibleTeams))
+		httpLog.InfoContext(r.Context(), "delete all: found teams", "count", len(accessibleTeams))
 		deletedTeams := 0
 		for _, id := range accessibleTeams {
 			t, err := tStore.LoadTeam(id)
 			if err != nil {
-				log.Printf("DeleteAll: Error loading team %s: %v", id, err)
+				httpLog.ErrorContext(r.Context(), "delete all: failed to load team", "teamId", id, "err", err)
 				continue
 			}
 			if t.OwnerID != userId {
@@ -1449,14 +3108,210 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				hm.RemoveHub(id, true) // Clear from memory
 				deletedTeams++
 			} else {
-				log.Printf("Error deleting team %s: %v", id, err)
+				httpLog.ErrorContext(r.Context(), "delete all: failed to delete team", "teamId", id, "err", err)
 			}
 		}
 
+		// 3. Erase the trash
+		erasedGames, erasedTeams := registry.EraseTrash(userId, 0, time.Now())
+		httpLog.InfoContext(r.Context(), "delete all: erased trash", "games", len(erasedGames), "teams", len(erasedTeams))
+
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "Deleted %d games and %d teams", deletedGames, deletedTeams)
 	})
 
+	mux.HandleFunc("/api/trash", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+
+		games, teams, err := registry.ListTrash(userId, time.Now())
+		if err != nil {
+			httpLog.ErrorContext(r.Context(), "failed to list trash", "userId", maskEmail(userId), "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"games": games, "teams": teams})
+	})
+
+	// handleTrashItem restores (POST .../restore) or permanently deletes (DELETE)
+	// a game or team in the caller's trash.
+	handleTrashItem := func(w http.ResponseWriter, r *http.Request, restore bool) {
+		userId := getUserID(r)
+		if userId == "" || !isValidEmail(userId) {
+			http.Error(w, "Forbidden: Invalid User ID", http.StatusForbidden)
+			return
+		}
+		if allowed, msg := accessControl.IsAllowed(userId); !allowed {
+			http.Error(w, "Forbidden: "+msg, http.StatusForbidden)
+			return
+		}
+
+		id := r.PathValue("id")
+		var isTeam bool
+		switch r.PathValue("kind") {
+		case "games":
+		case "teams":
+			isTeam = true
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if !isValidUUID(id) {
+			http.Error(w, "Bad Request: id is missing or invalid", http.StatusBadRequest)
+			return
+		}
+
+		// Load the tombstone and check that it is in the caller's trash.
+		var ownerId string
+		var err error
+		if isTeam {
+			var t *Team
+			if t, err = tStore.LoadTeam(id); err == nil {
+				ownerId = t.OwnerID
+				if restore {
+					_, err = untrashTeam(t, time.Now())
+				} else if t.Status != "deleted" || len(t.Trashed) == 0 {
+					err = ErrNotInTrash
+				}
+			}
+		} else {
+			var g *Game
+			if g, err = store.LoadGame(id); err == nil {
+				ownerId = g.OwnerID
+				if restore {
+					_, err = untrashGame(g, time.Now())
+				} else if g.Status != "deleted" || len(g.Trashed) == 0 {
+					err = ErrNotInTrash
+				}
+			}
+		}
+		if err == nil && normalizeEmail(ownerId) != normalizeEmail(userId) {
+			http.Error(w, "Forbidden: Only the owner can manage this item", http.StatusForbidden)
+			return
+		}
+		writeTrashError := func(err error) {
+			switch {
+			case errors.Is(err, os.ErrNotExist), errors.Is(err, ErrNotInTrash), errors.Is(err, ErrNotRestorable):
+				http.Error(w, "Not Found: not in trash", http.StatusNotFound)
+			case errors.Is(err, ErrTrashExpired):
+				http.Error(w, "Gone: "+err.Error(), http.StatusGone)
+			default:
+				httpLog.ErrorContext(r.Context(), "trash operation failed", "method", r.Method, "path", r.URL.Path, "err", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+		}
+		if err != nil {
+			writeTrashError(err)
+			return
+		}
+
+		if restore {
+			if isTeam {
+				err = accessControl.CheckTeamQuota(userId, registry.CountOwnedTeams(userId))
+			} else {
+				err = accessControl.CheckGameQuota(userId, registry.CountOwnedGames(userId))
+			}
+			if err != nil {
+				http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		if raftMgr != nil {
+			cmd := RaftCommand{ID: id, UserID: userId}
+			switch {
+			case restore && isTeam:
+				cmd.Type = CmdRestoreTeam
+			case restore:
+				cmd.Type = CmdRestoreGame
+			case isTeam:
+				cmd.Type = CmdPurgeTeam
+			default:
+				cmd.Type = CmdPurgeGame
+			}
+			if _, err := raftMgr.ProposeContext(r.Context(), cmd); err != nil {
+				if errors.Is(err, ErrNotLeader) {
+					raftMgr.forwardRequestToLeader(w, r)
+					return
+				}
+				writeTrashError(err)
+				return
+			}
+		} else {
+			switch {
+			case restore && isTeam:
+				var t *Team
+				if t, err = tStore.LoadTeam(id); err == nil {
+					if t, err = untrashTeam(t, time.Now()); err == nil {
+						if err = tStore.SaveTeam(t); err == nil {
+							registry.UpdateTeam(*t)
+						}
+					}
+				}
+			case restore:
+				var g *Game
+				if g, err = store.LoadGame(id); err == nil {
+					if g, err = untrashGame(g, time.Now()); err == nil {
+						if err = store.SaveGame(g); err == nil {
+							registry.UpdateGame(*g)
+						}
+					}
+				}
+			case isTeam:
+				var t *Team
+				if t, err = tStore.LoadTeam(id); err == nil {
+					if err = tStore.tombstoneTeam(id, t, false, 0, time.Now()); err == nil {
+						registry.updateTeamTrash(id)
+					}
+				}
+			default:
+				var g *Game
+				if g, err = store.LoadGame(id); err == nil {
+					if err = store.tombstoneGame(id, g, false, 0, time.Now()); err == nil {
+						registry.updateGameTrash(id)
+					}
+				}
+			}
+			if err != nil {
+				writeTrashError(err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if restore {
+			fmt.Fprintf(w, "%s restored successfully", id)
+		} else {
+			fmt.Fprintf(w, "%s permanently deleted", id)
+		}
+	}
+
+	mux.HandleFunc("/api/trash/{kind}/{id}/restore", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleTrashItem(w, r, true)
+	})
+
+	mux.HandleFunc("/api/trash/{kind}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleTrashItem(w, r, false)
+	})
+
 	mux.HandleFunc("/api/ws", func(w http.ResponseWriter, r *http.Request) {
 		userId := getUserID(r)
 		if userId != "" {
@@ -1465,7 +3320,7 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 				return
 			}
 		}
-		ServeWS(store, tStore, registry, hm, w, r, debugf)
+		ServeWS(store, tStore, registry, hm, w, r)
 	})
 
 	mux.HandleFunc("/api/login", func(w http.ResponseWriter, r *http.Request) {
@@ -1519,7 +3374,12 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 	} else {
 		handler = jwtAuthMiddleware(opts, handler)
 	}
+	if replica != nil {
+		handler = readOnlyMiddleware(replica.Primary, handler)
+	}
 	handler = loggingMiddleware(handler)
+	handler = tracingMiddleware(mux, handler)
+	handler = requestIDMiddleware(handler)
 	handler = monitoringMiddleware(raftMgr, handler)
 	handler = securityMiddleware(handler)
 	handler = cacheControlMiddleware(handler)
@@ -1531,9 +3391,40 @@ func NewServerHandler(opts Options) (*RaftManager, *Registry, http.Handler) {
 		}
 	}
 
+	if replica != nil {
+		if err := replica.Start(); err != nil {
+			log.Fatalf("Failed to start read replica: %v", err)
+		}
+	}
+
 	return raftMgr, registry, handler
 }
 
+// replicaPostReads are the API endpoints that read with a POST body, so that
+// sync clients can send the IDs they know. Read replicas serve them.
+var replicaPostReads = map[string]bool{
+	"/api/check-deletions": true,
+	"/api/list-games":      true,
+	"/api/list-teams":      true,
+}
+
+// readOnlyMiddleware redirects the API writes of a read replica to the
+// primary cluster, with 307 so that the method and body are kept.
+func readOnlyMiddleware(primary string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if strings.HasPrefix(r.URL.Path, "/api/") && !replicaPostReads[r.URL.Path] && !strings.HasPrefix(r.URL.Path, "/api/cluster/") {
+				w.Header().Set("X-Skorekeeper-Primary", primary)
+				http.Redirect(w, r, primary+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // cacheControlMiddleware adds Cache-Control headers optimized for PWA reliability behind a proxy.
 func cacheControlMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1632,11 +3523,38 @@ func contentTypeMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// loggingMiddleware logs the method and URL path of every incoming HTTP request.
+// slowRequestThreshold is the duration above which a successful request is
+// logged at info level.
+var slowRequestThreshold = time.Second
+
+// accessLogLevel returns the level of an access log entry. Successful requests
+// are only logged at debug level, unless they were slow.
+func accessLogLevel(status int, d time.Duration) slog.Level {
+	if status >= http.StatusBadRequest || d >= slowRequestThreshold {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// loggingMiddleware writes an access log entry for every HTTP request.
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("Received request: %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
+		start := time.Now()
+		if r.URL.Path == "/api/ws" {
+			// The upgrader needs the original writer to hijack the connection.
+			next.ServeHTTP(w, r)
+			httpLog.DebugContext(r.Context(), "websocket session", "path", r.URL.Path, "duration", time.Since(start))
+			return
+		}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		d := time.Since(start)
+		httpLog.Log(r.Context(), accessLogLevel(sw.status, d), "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.status,
+			"duration", d,
+		)
 	})
 }
 
@@ -1645,7 +3563,7 @@ func monitoringMiddleware(rm *RaftManager, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		GlobalRequestCounter.Add(1)
 
-		if rm == nil || r.URL.Path == "/api/ws" {
+		if r.URL.Path == "/api/ws" {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -1654,6 +3572,13 @@ func monitoringMiddleware(rm *RaftManager, next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 		duration := time.Since(start)
 
+		globalLatency.Lock()
+		globalLatency.h.Add(duration)
+		globalLatency.Unlock()
+
+		if rm == nil {
+			return
+		}
 		rm.latencyMu.Lock()
 		rm.latencyAccumulator.Add(duration)
 		rm.latencyMu.Unlock()
@@ -553,7 +553,7 @@ func TestHTTPHandlers(t *testing.T) {
 
 	// Test SSO Logout Handler
 	t.Run("SSOLogoutHandler", func(t *testing.T) {
-		_, _, mockAuthHandler := NewServerHandler(Options{UseMockAuth: true})
+		_, _, mockAuthHandler := NewServerHandler(Options{DataDir: t.TempDir(), UseMockAuth: true})
 		req := httptest.NewRequest("POST", "/.sso/logout", nil)
 		w := httptest.NewRecorder()
 		mockAuthHandler.ServeHTTP(w, req)
@@ -663,6 +663,17 @@ func TestHTTPHandlers(t *testing.T) {
 		if status["state"].(string) == "" {
 			t.Error("State is empty")
 		}
+
+		// 3. Audit logs are only served for valid IDs.
+		for _, path := range []string{"/api/games/..%2F..%2Fsystem/audit", "/api/teams/not-a-uuid/audit"} {
+			req = httptest.NewRequest("GET", path, nil)
+			req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: "user@example.com"})
+			w = httptest.NewRecorder()
+			raftHandler.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("GET %s: expected 400, got %d", path, w.Code)
+			}
+		}
 	})
 }
 
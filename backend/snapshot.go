@@ -21,7 +21,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -61,6 +60,9 @@ func (f *FSM) persist(sink io.WriteCloser) (err error) {
 			return fmt.Errorf("failed to flush user indices: %w", err)
 		}
 	}
+	if err := f.audit.FlushAll(); err != nil {
+		return fmt.Errorf("failed to flush audit logs: %w", err)
+	}
 
 	// Check if sink supports linking
 	linker, ok := sink.(SnapshotLinker)
@@ -144,10 +146,16 @@ func (f *FSM) persist(sink io.WriteCloser) (err error) {
 		if err := linkGroup(f.us.ListTeamUsersFiles); err != nil {
 			return err
 		}
+		if err := linkGroup(f.us.ListChangeLogFiles); err != nil {
+			return err
+		}
+		if err := linkGroup(f.us.ListPlayerTeamsFiles); err != nil {
+			return err
+		}
 	}
 
 	// 5. Write System Files
-	sysFiles := []string{"sys_access_policy", "metrics.json", "nodes.json"}
+	sysFiles := []string{"sys_access_policy", "metrics.json", "nodes.json", webhooksFile, webhookOutboxFile, alertsFile, clusterVersionFile}
 	for _, fname := range sysFiles {
 		// Only link if exists in source directory
 		// We can't check existence easily without full path, but LinkFile checks it?
@@ -164,6 +172,17 @@ func (f *FSM) persist(sink io.WriteCloser) (err error) {
 		}
 	}
 
+	// 6. Write Audit Logs
+	auditFiles, err := f.audit.ListFiles()
+	if err != nil {
+		return fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	for _, fname := range auditFiles {
+		if err := link(filepath.Join("raft", fname)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -185,6 +204,10 @@ func (f *FSM) restore(rc io.Reader) error {
 
 	processedGames := make(map[string]bool)
 	processedTeams := make(map[string]bool)
+	restoredWebhooks := false
+	restoredOutbox := false
+	restoredAlerts := false
+	restoredVersion := false
 
 	// Worker Pool Setup (for heavy Game/Team restore)
 	numWorkers := runtime.NumCPU()
@@ -224,6 +247,10 @@ func (f *FSM) restore(rc io.Reader) error {
 
 	teardown := func() { close(jobs); wg.Wait() }
 
+	if err := f.audit.Reset(); err != nil {
+		fsmLog.Warn("restore: failed to clear audit logs", "err", err)
+	}
+
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
@@ -246,6 +273,7 @@ func (f *FSM) restore(rc io.Reader) error {
 			if manifest.Initialized {
 				f.setInitialized()
 			}
+			f.lastAppliedIndex.Store(manifest.RaftIndex)
 			continue
 		}
 
@@ -257,7 +285,7 @@ func (f *FSM) restore(rc io.Reader) error {
 				}
 				f.r.UpdateAccessPolicy(&policy)
 			} else {
-				log.Printf("Restore Warning: failed to decode sys_access_policy: %v", err)
+				fsmLog.Warn("restore: failed to decode sys_access_policy", "err", err)
 			}
 			continue
 		}
@@ -269,7 +297,7 @@ func (f *FSM) restore(rc io.Reader) error {
 					f.storage.SaveDataFile("metrics.json", &m)
 				}
 			} else {
-				log.Printf("Restore Warning: failed to decode metrics.json: %v", err)
+				fsmLog.Warn("restore: failed to decode metrics.json", "err", err)
 			}
 			continue
 		}
@@ -284,7 +312,72 @@ func (f *FSM) restore(rc io.Reader) error {
 					f.storage.SaveDataFile("nodes.json", nodes)
 				}
 			} else {
-				log.Printf("Restore Warning: failed to decode nodes.json: %v", err)
+				fsmLog.Warn("restore: failed to decode nodes.json", "err", err)
+			}
+			continue
+		}
+
+		if header.Name == "raft/"+webhooksFile {
+			var hooks map[string]*Webhook
+			if err := json.NewDecoder(tr).Decode(&hooks); err == nil {
+				if err := f.webhooks.Replace(hooks); err != nil {
+					fsmLog.Warn("restore: failed to save webhooks", "err", err)
+				}
+				restoredWebhooks = true
+			} else {
+				fsmLog.Warn("restore: failed to decode", "file", webhooksFile, "err", err)
+			}
+			continue
+		}
+
+		if header.Name == "raft/"+webhookOutboxFile {
+			var outbox map[uint64]*WebhookOutboxEntry
+			if err := json.NewDecoder(tr).Decode(&outbox); err == nil {
+				if err := f.webhooks.ReplaceOutbox(outbox); err != nil {
+					fsmLog.Warn("restore: failed to save webhook outbox", "err", err)
+				}
+				restoredOutbox = true
+			} else {
+				fsmLog.Warn("restore: failed to decode", "file", webhookOutboxFile, "err", err)
+			}
+			continue
+		}
+
+		if header.Name == "raft/"+alertsFile {
+			var rules map[string]*AlertRule
+			if err := json.NewDecoder(tr).Decode(&rules); err == nil {
+				if err := f.alerts.Replace(rules); err != nil {
+					fsmLog.Warn("restore: failed to save alert rules", "err", err)
+				}
+				restoredAlerts = true
+			} else {
+				fsmLog.Warn("restore: failed to decode", "file", alertsFile, "err", err)
+			}
+			continue
+		}
+
+		if header.Name == "raft/"+clusterVersionFile {
+			var cv clusterVersion
+			if err := json.NewDecoder(tr).Decode(&cv); err == nil {
+				f.activeSchemaVersion.Store(int64(cv.ActiveSchemaVersion))
+				if err := f.saveClusterVersion(); err != nil {
+					fsmLog.Warn("restore: failed to save cluster version", "err", err)
+				}
+				restoredVersion = true
+			} else {
+				fsmLog.Warn("restore: failed to decode", "file", clusterVersionFile, "err", err)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(header.Name, "raft/"+auditDir+"/") {
+			var l AuditLog
+			if err := json.NewDecoder(tr).Decode(&l); err == nil {
+				if err := f.audit.Restore(strings.TrimPrefix(header.Name, "raft/"), &l); err != nil {
+					fsmLog.Warn("restore: failed to save", "file", header.Name, "err", err)
+				}
+			} else {
+				fsmLog.Warn("restore: failed to decode", "file", header.Name, "err", err)
 			}
 			continue
 		}
@@ -317,31 +410,45 @@ func (f *FSM) restore(rc io.Reader) error {
 			// Restore User Index directly
 			var idx UserIndex
 			if err := json.NewDecoder(tr).Decode(&idx); err != nil {
-				log.Printf("Restore Warning: failed to unmarshal user index %s: %v", header.Name, err)
+				fsmLog.Warn("restore: failed to unmarshal user index", "file", header.Name, "err", err)
 				continue
 			}
 			f.us.RestoreUserIndex(&idx)
 		} else if strings.HasPrefix(header.Name, "team_games/") {
 			var idx TeamGamesIndex
 			if err := json.NewDecoder(tr).Decode(&idx); err != nil {
-				log.Printf("Restore Warning: failed to unmarshal team_games index %s: %v", header.Name, err)
+				fsmLog.Warn("restore: failed to unmarshal team_games index", "file", header.Name, "err", err)
 				continue
 			}
 			f.us.RestoreTeamGames(&idx)
 		} else if strings.HasPrefix(header.Name, "game_users/") {
 			var idx GameUsersIndex
 			if err := json.NewDecoder(tr).Decode(&idx); err != nil {
-				log.Printf("Restore Warning: failed to unmarshal game_users index %s: %v", header.Name, err)
+				fsmLog.Warn("restore: failed to unmarshal game_users index", "file", header.Name, "err", err)
 				continue
 			}
 			f.us.RestoreGameUsers(&idx)
 		} else if strings.HasPrefix(header.Name, "team_users/") {
 			var idx TeamUsersIndex
 			if err := json.NewDecoder(tr).Decode(&idx); err != nil {
-				log.Printf("Restore Warning: failed to unmarshal team_users index %s: %v", header.Name, err)
+				fsmLog.Warn("restore: failed to unmarshal team_users index", "file", header.Name, "err", err)
 				continue
 			}
 			f.us.RestoreTeamUsers(&idx)
+		} else if strings.HasPrefix(header.Name, "changes/") {
+			var l ChangeLog
+			if err := json.NewDecoder(tr).Decode(&l); err != nil {
+				fsmLog.Warn("restore: failed to unmarshal change log", "file", header.Name, "err", err)
+				continue
+			}
+			f.us.RestoreChangeLog(&l)
+		} else if strings.HasPrefix(header.Name, "player_teams/") {
+			var idx PlayerTeamsIndex
+			if err := json.NewDecoder(tr).Decode(&idx); err != nil {
+				fsmLog.Warn("restore: failed to unmarshal player_teams index", "file", header.Name, "err", err)
+				continue
+			}
+			f.us.RestorePlayerTeams(&idx)
 		}
 	}
 
@@ -354,6 +461,30 @@ func (f *FSM) restore(rc io.Reader) error {
 
 	f.saveNodes()
 
+	// Webhooks absent from the snapshot were deleted before it was taken.
+	if !restoredWebhooks {
+		if err := f.webhooks.Replace(nil); err != nil {
+			fsmLog.Warn("restore: failed to clear webhooks", "err", err)
+		}
+	}
+	if !restoredOutbox {
+		if err := f.webhooks.ReplaceOutbox(nil); err != nil {
+			fsmLog.Warn("restore: failed to clear webhook outbox", "err", err)
+		}
+	}
+	if !restoredAlerts {
+		if err := f.alerts.Replace(nil); err != nil {
+			fsmLog.Warn("restore: failed to clear alert rules", "err", err)
+		}
+	}
+	// Snapshots taken before the active schema version was first raised.
+	if !restoredVersion {
+		f.activeSchemaVersion.Store(0)
+		if f.storage != nil {
+			os.Remove(filepath.Join(f.storage.Dir(), clusterVersionFile))
+		}
+	}
+
 	// Cleanup Zombies (Games and Teams only).
 	// We delete any local entities that were not present in the snapshot to maintain consistency.
 	// User index cleanup is currently skipped as listing all users is prohibitively expensive
@@ -366,7 +497,7 @@ func (f *FSM) restore(rc io.Reader) error {
 			}
 		}
 	} else {
-		log.Printf("Restore Cleanup Warning: failed to list games for zombie cleanup: %v", err)
+		fsmLog.Warn("restore: failed to list games for zombie cleanup", "err", err)
 	}
 	teamIDs, err := f.ts.ListAllTeamIDs()
 	if err == nil {
@@ -376,7 +507,7 @@ func (f *FSM) restore(rc io.Reader) error {
 			}
 		}
 	} else {
-		log.Printf("Restore Cleanup Warning: failed to list teams for zombie cleanup: %v", err)
+		fsmLog.Warn("restore: failed to list teams for zombie cleanup", "err", err)
 	}
 
 	// Re-initialize the registry to use the restored on-disk indices
@@ -384,6 +515,9 @@ func (f *FSM) restore(rc io.Reader) error {
 	// We just refresh the file counts so stats are correct.
 	// The existing Registry instance is preserved, keeping external references valid.
 	f.r.RefreshCounts()
+	// The search index isn't part of snapshots: it is derived from the
+	// restored games and teams.
+	f.r.RebuildSearch()
 
 	return nil
 }
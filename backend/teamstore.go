@@ -18,12 +18,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"iter"
-	"log"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/c2FmZQ/storage"
@@ -60,11 +60,32 @@ type Team struct {
 	Roles         TeamRoles `json:"roles,omitempty"`
 	UpdatedAt     int64     `json:"updatedAt,omitempty"`
 
+	// CalendarToken is the secret embedded in the team's iCalendar feed URL.
+	// It is managed by the server and cannot be set through save-team.
+	CalendarToken string `json:"calendarToken,omitempty"`
+
+	// Templates are game setups that new games can be created from. They
+	// are managed by the server and cannot be set through save-team.
+	Templates []GameTemplate `json:"templates,omitempty"`
+
+	// Players is the team's player registry: every player who has been on
+	// the roster. It is kept in line with Roster by the server and cannot
+	// be set through save-team.
+	Players []TeamPlayer `json:"players,omitempty"`
+
+	// PendingOwner is the user the owner offered the team to. Ownership
+	// changes when that user accepts the transfer.
+	PendingOwner string `json:"pendingOwner,omitempty"`
+
 	// Status can be "active" (default/empty) or "deleted"
 	Status string `json:"status,omitempty"`
 	// DeletedAt is the timestamp (Unix Nano) when the team was deleted.
 	DeletedAt int64 `json:"deletedAt,omitempty"`
 
+	// Trashed holds the original team on a tombstone so it can be restored
+	// until the tombstone is purged.
+	Trashed json.RawMessage `json:"trashed,omitempty"`
+
 	// LastRaftIndex tracks the index of the last Raft log entry applied to this team.
 	// Used for idempotency during log replay.
 	LastRaftIndex uint64 `json:"lastRaftIndex,omitempty"`
@@ -72,7 +93,7 @@ type Team struct {
 
 func (t *Team) normalize() {
 	if t.SchemaVersion == 0 {
-		t.SchemaVersion = CurrentSchemaVersion
+		t.SchemaVersion = DocumentSchemaVersion
 	}
 	if t.Roster == nil {
 		t.Roster = make([]Player, 0)
@@ -89,6 +110,9 @@ type TeamStore struct {
 	cache   sync.Map // Stores latest []byte (JSON) for each teamId
 	dirtyMu sync.Mutex
 	dirty   map[string]bool
+
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
 }
 
 // NewTeamStore creates a new TeamStore.
@@ -142,11 +166,13 @@ func (ts *TeamStore) LoadTeam(teamId string) (*Team, error) {
 	if val, ok := ts.cache.Load(teamId); ok {
 		var t Team
 		if err := json.Unmarshal(val.([]byte), &t); err == nil {
+			ts.cacheHits.Add(1)
 			t.normalize()
 			return &t, nil
 		}
 		ts.cache.Delete(teamId)
 	}
+	ts.cacheMisses.Add(1)
 
 	encodedTeamId := url.PathEscape(teamId)
 	filename := filepath.Join("teams", fmt.Sprintf("%s.json", encodedTeamId))
@@ -183,13 +209,34 @@ func (ts *TeamStore) LoadTeamAsJSON(teamId string) ([]byte, error) {
 
 // TeamMetadata contains only the fields needed for indexing.
 type TeamMetadata struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"` // Added for sorting
-	OwnerID   string    `json:"ownerId"`
-	Roles     TeamRoles `json:"roles"`
-	UpdatedAt int64     `json:"updatedAt"`
-	Status    string    `json:"status"`
-	DeletedAt int64     `json:"deletedAt"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"` // Added for sorting
+	OwnerID      string    `json:"ownerId"`
+	Roles        TeamRoles `json:"roles"`
+	UpdatedAt    int64     `json:"updatedAt"`
+	Status       string    `json:"status"`
+	DeletedAt    int64     `json:"deletedAt"`
+	Restorable   bool      `json:"restorable,omitempty"` // Tombstone retains the team data
+	PendingOwner string    `json:"pendingOwner,omitempty"`
+	Players      []Player  `json:"players,omitempty"`   // Roster players, for search
+	PlayerIDs    []string  `json:"playerIds,omitempty"` // Registry and roster player IDs, for careers
+}
+
+// Metadata returns the indexed fields of the team.
+func (t *Team) Metadata() *TeamMetadata {
+	return &TeamMetadata{
+		ID:           t.ID,
+		Name:         t.Name,
+		OwnerID:      t.OwnerID,
+		Roles:        t.Roles,
+		UpdatedAt:    t.UpdatedAt,
+		Status:       t.Status,
+		DeletedAt:    t.DeletedAt,
+		Restorable:   len(t.Trashed) > 0,
+		PendingOwner: t.PendingOwner,
+		Players:      indexPlayers(t.Roster),
+		PlayerIDs:    t.playerIDs(),
+	}
 }
 
 // ListAllTeamMetadata returns an iterator over metadata for all teams.
@@ -233,15 +280,7 @@ func (ts *TeamStore) ListAllTeamMetadata() iter.Seq2[TeamMetadata, error] {
 					continue
 				}
 
-				if !yield(TeamMetadata{
-					ID:        t.ID,
-					Name:      t.Name, // Populated
-					OwnerID:   t.OwnerID,
-					Roles:     t.Roles,
-					UpdatedAt: t.UpdatedAt,
-					Status:    t.Status,
-					DeletedAt: t.DeletedAt,
-				}, nil) {
+				if !yield(*t.Metadata(), nil) {
 					return
 				}
 			}
@@ -253,19 +292,11 @@ func (ts *TeamStore) ListAllTeamMetadata() iter.Seq2[TeamMetadata, error] {
 
 			t, err := ts.LoadTeam(id)
 			if err != nil {
-				log.Printf("Error: Failed to load dirty team %s: %v", id, err)
+				storeLog.Error("failed to load dirty team", "teamId", id, "err", err)
 				continue
 			}
 
-			if !yield(TeamMetadata{
-				ID:        t.ID,
-				Name:      t.Name, // Populated
-				OwnerID:   t.OwnerID,
-				Roles:     t.Roles,
-				UpdatedAt: t.UpdatedAt,
-				Status:    t.Status,
-				DeletedAt: t.DeletedAt,
-			}, nil) {
+			if !yield(*t.Metadata(), nil) {
 				return
 			}
 		}
@@ -310,7 +341,7 @@ func (ts *TeamStore) ListAllTeams() iter.Seq2[*Team, error] {
 
 				t, err := ts.LoadTeam(teamId)
 				if err != nil {
-					log.Printf("Warning: could not load team '%s': %v", teamId, err)
+					storeLog.Warn("could not load team", "teamId", teamId, "err", err)
 					continue
 				}
 				t.normalize()
@@ -326,7 +357,7 @@ func (ts *TeamStore) ListAllTeams() iter.Seq2[*Team, error] {
 
 			t, err := ts.LoadTeam(id)
 			if err != nil {
-				log.Printf("Error: Failed to load dirty team %s: %v", id, err)
+				storeLog.Error("failed to load dirty team", "teamId", id, "err", err)
 				continue
 			}
 			t.normalize()
@@ -421,7 +452,20 @@ func (ts *TeamStore) FlushAll() error {
 }
 
 // DeleteTeam deletes a specific team by overwriting it with a tombstone.
+// The team data is discarded.
 func (ts *TeamStore) DeleteTeam(teamId string) error {
+	return ts.deleteTeam(teamId, false, 0, time.Now())
+}
+
+// TrashTeam deletes a team by overwriting it with a tombstone that retains
+// the original data, so it can be restored until the tombstone is purged.
+// raftIndex is recorded on the tombstone for replay idempotency, and now as
+// the deletion time.
+func (ts *TeamStore) TrashTeam(teamId string, raftIndex uint64, now time.Time) error {
+	return ts.deleteTeam(teamId, true, raftIndex, now)
+}
+
+func (ts *TeamStore) deleteTeam(teamId string, keep bool, raftIndex uint64, now time.Time) error {
 	// Load first to get OwnerID
 	t, err := ts.LoadTeam(teamId)
 	if err != nil {
@@ -430,6 +474,22 @@ func (ts *TeamStore) DeleteTeam(teamId string) error {
 		}
 		return err
 	}
+	return ts.tombstoneTeam(teamId, t, keep, raftIndex, now)
+}
+
+// tombstoneTeam overwrites a loaded team with its tombstone. When keep is set,
+// the tombstone retains t so it can be restored. now is the deletion time,
+// taken from the command in the FSM so that every node records the same one.
+func (ts *TeamStore) tombstoneTeam(teamId string, t *Team, keep bool, raftIndex uint64, now time.Time) error {
+	deletedAt := now.UnixNano()
+	if t.Status == "deleted" {
+		// Already a tombstone; deleting again only erases retained data.
+		if keep || len(t.Trashed) == 0 {
+			return nil
+		}
+		deletedAt = t.DeletedAt
+		raftIndex = max(raftIndex, t.LastRaftIndex)
+	}
 
 	// Get or create a mutex for this specific team
 	m, _ := ts.mu.LoadOrStore(teamId, &sync.RWMutex{})
@@ -441,10 +501,20 @@ func (ts *TeamStore) DeleteTeam(teamId string) error {
 	// Create Tombstone
 	tombstone := &Team{
 		ID:            teamId,
-		SchemaVersion: CurrentSchemaVersion,
+		SchemaVersion: DocumentSchemaVersion,
 		OwnerID:       t.OwnerID,
 		Status:        "deleted",
-		DeletedAt:     time.Now().UnixNano(),
+		DeletedAt:     deletedAt,
+		LastRaftIndex: raftIndex,
+	}
+	if keep {
+		trashed, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Errorf("failed to marshal trashed team: %w", err)
+		}
+		tombstone.Trashed = trashed
+		// Keep the name so the trash can be listed from metadata.
+		tombstone.Name = t.Name
 	}
 
 	encodedTeamId := url.PathEscape(teamId)
@@ -0,0 +1,267 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	teamTemplateMax     = 20
+	teamTemplateMaxName = 100
+)
+
+// errTemplateNotFound is returned for unknown template IDs.
+var errTemplateNotFound = errors.New("template not found")
+
+// GameSetup is the part of a game that carries over to the next one: the
+// teams and their lineups, sharing, and the event details.
+type GameSetup struct {
+	Away        string              `json:"away"`
+	Home        string              `json:"home"`
+	AwayTeamID  string              `json:"awayTeamId,omitempty"`
+	HomeTeamID  string              `json:"homeTeamId,omitempty"`
+	Event       string              `json:"event,omitempty"`
+	Location    string              `json:"location,omitempty"`
+	Permissions Permissions         `json:"permissions"`
+	Roster      map[string][]Player `json:"roster,omitempty"` // Starters in batting order, by side
+	Subs        map[string][]Player `json:"subs,omitempty"`   // Bench, by side
+}
+
+// GameTemplate is a named GameSetup saved on a team.
+type GameTemplate struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt int64     `json:"createdAt"`
+	Setup     GameSetup `json:"setup"`
+}
+
+// gameSetup returns the setup of g. Metadata comes from the game itself and
+// the lineups from its action log: the initial rosters of GAME_START, replaced
+// by each LINEUP_UPDATE that was not undone. Substitutions made during the
+// game are not part of the setup.
+func gameSetup(g *Game) GameSetup {
+	s := GameSetup{
+		Away:        g.Away,
+		Home:        g.Home,
+		AwayTeamID:  g.AwayTeamID,
+		HomeTeamID:  g.HomeTeamID,
+		Event:       g.Event,
+		Location:    g.Location,
+		Permissions: Permissions{Public: g.Permissions.Public, Users: maps.Clone(g.Permissions.Users)},
+		Roster:      make(map[string][]Player),
+		Subs:        make(map[string][]Player),
+	}
+	for _, a := range effectiveActions(g.ActionLog) {
+		switch a.Type {
+		case ActionGameStart:
+			var p struct {
+				InitialRosters map[string][]Player `json:"initialRosters"`
+				InitialSubs    map[string][]Player `json:"initialSubs"`
+			}
+			if json.Unmarshal(a.Payload, &p) != nil {
+				continue
+			}
+			for _, side := range []string{"away", "home"} {
+				if len(p.InitialRosters[side]) > 0 {
+					s.Roster[side] = p.InitialRosters[side]
+				}
+				if len(p.InitialSubs[side]) > 0 {
+					s.Subs[side] = p.InitialSubs[side]
+				}
+			}
+		case ActionLineupUpdate:
+			var p struct {
+				Team   string       `json:"team"`
+				Roster []RosterSlot `json:"roster"`
+				Subs   []Player     `json:"subs"`
+			}
+			if json.Unmarshal(a.Payload, &p) != nil || (p.Team != "away" && p.Team != "home") {
+				continue
+			}
+			starters := make([]Player, 0, len(p.Roster))
+			for _, slot := range p.Roster {
+				starters = append(starters, slot.Starter)
+			}
+			s.Roster[p.Team] = starters
+			s.Subs[p.Team] = p.Subs
+		}
+	}
+	return s
+}
+
+// setupFor returns the setup of g as userId may copy it: the collaborators
+// of the game are only copied by its admins.
+func setupFor(userId string, g *Game, ts *TeamStore) GameSetup {
+	s := gameSetup(g)
+	if GetGameAccess(userId, *g, ts) < AccessAdmin {
+		s.Permissions.Users = nil
+	}
+	return s
+}
+
+// actions returns the action sequence that starts a new game with this
+// setup: a GAME_START with the metadata, followed by a LINEUP_UPDATE for
+// each side with a lineup. Players keep their IDs, so their statistics carry
+// over; players without a valid ID get a new one.
+func (s GameSetup) actions(gameId, ownerId, date string, now int64) ([]json.RawMessage, error) {
+	teamId := func(id string) any {
+		if id == "" {
+			return nil
+		}
+		return id
+	}
+	perms := Permissions{Public: s.Permissions.Public, Users: maps.Clone(s.Permissions.Users)}
+	if perms.Public == "" {
+		perms.Public = "none"
+	}
+	if perms.Users == nil {
+		perms.Users = make(map[string]string)
+	}
+	delete(perms.Users, ownerId)
+
+	var out []json.RawMessage
+	add := func(typ string, p any) error {
+		payload, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		raw, err := json.Marshal(BaseAction{ID: uuid.NewString(), Type: typ, Payload: payload, Timestamp: now})
+		out = append(out, raw)
+		return err
+	}
+
+	if err := add(ActionGameStart, map[string]any{
+		"id":          gameId,
+		"date":        date,
+		"location":    s.Location,
+		"event":       s.Event,
+		"away":        s.Away,
+		"home":        s.Home,
+		"awayTeamId":  teamId(s.AwayTeamID),
+		"homeTeamId":  teamId(s.HomeTeamID),
+		"ownerId":     ownerId,
+		"permissions": perms,
+	}); err != nil {
+		return nil, err
+	}
+	for _, side := range []string{"away", "home"} {
+		if len(s.Roster[side]) == 0 && len(s.Subs[side]) == 0 {
+			continue
+		}
+		name := s.Away
+		if side == "home" {
+			name = s.Home
+		}
+		roster := make([]RosterSlot, 0, len(s.Roster[side]))
+		for i, p := range s.Roster[side] {
+			p = templatePlayer(p)
+			roster = append(roster, RosterSlot{Slot: i, Starter: p, Current: p, History: []Player{}})
+		}
+		subs := make([]Player, 0, len(s.Subs[side]))
+		for _, p := range s.Subs[side] {
+			subs = append(subs, templatePlayer(p))
+		}
+		if err := add(ActionLineupUpdate, map[string]any{"team": side, "teamName": name, "roster": roster, "subs": subs}); err != nil {
+			return nil, err
+		}
+	}
+	return out, ValidateActions(out)
+}
+
+// templatePlayer returns p with a valid ID.
+func templatePlayer(p Player) Player {
+	if !isValidUUID(p.ID) {
+		p.ID = uuid.NewString()
+	}
+	return p
+}
+
+// GameFromSetup is the body of the requests that create a game from another
+// game or a template. Event and Location override the setup when set.
+type GameFromSetup struct {
+	Date     string `json:"date"`
+	Event    string `json:"event,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// apply returns s with the overrides of req, or an error if req is invalid.
+func (req GameFromSetup) apply(s GameSetup) (GameSetup, error) {
+	if _, err := time.Parse(time.RFC3339, req.Date); err != nil {
+		return s, fmt.Errorf("invalid date: must be RFC 3339")
+	}
+	if req.Event != "" {
+		s.Event = req.Event
+	}
+	if req.Location != "" {
+		s.Location = req.Location
+	}
+	return s, nil
+}
+
+// addTemplate adds tmpl to t and returns it as stored. A template with the
+// same name is replaced and keeps its ID. Templates are visible to every
+// reader of the team, so they don't keep the collaborators of the game.
+func (t *Team) addTemplate(tmpl GameTemplate) (GameTemplate, error) {
+	tmpl.Setup.Permissions.Users = nil
+	tmpl.Name = strings.TrimSpace(tmpl.Name)
+	if tmpl.Name == "" {
+		return tmpl, errors.New("template name is required")
+	}
+	if err := validateStringLen(tmpl.Name, teamTemplateMaxName, "name"); err != nil {
+		return tmpl, err
+	}
+	for i, existing := range t.Templates {
+		if strings.EqualFold(existing.Name, tmpl.Name) {
+			tmpl.ID = existing.ID
+			t.Templates[i] = tmpl
+			return tmpl, nil
+		}
+	}
+	if len(t.Templates) >= teamTemplateMax {
+		return tmpl, fmt.Errorf("too many templates (max %d)", teamTemplateMax)
+	}
+	t.Templates = append(t.Templates, tmpl)
+	return tmpl, nil
+}
+
+// template returns the template of t with the given ID.
+func (t *Team) template(id string) (GameTemplate, error) {
+	for _, tmpl := range t.Templates {
+		if tmpl.ID == id {
+			return tmpl, nil
+		}
+	}
+	return GameTemplate{}, errTemplateNotFound
+}
+
+// removeTemplate removes the template with the given ID from t.
+func (t *Team) removeTemplate(id string) error {
+	for i, tmpl := range t.Templates {
+		if tmpl.ID == id {
+			t.Templates = append(t.Templates[:i], t.Templates[i+1:]...)
+			return nil
+		}
+	}
+	return errTemplateNotFound
+}
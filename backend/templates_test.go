@@ -0,0 +1,218 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c2FmZQ/storage"
+)
+
+const (
+	tmplPlayerA = "a0000000-0000-4000-8000-000000000001"
+	tmplPlayerB = "a0000000-0000-4000-8000-000000000002"
+	tmplPlayerC = "a0000000-0000-4000-8000-000000000003"
+	tmplPlayerH = "b0000000-0000-4000-8000-000000000001"
+	tmplUndone  = "c0000000-0000-4000-8000-000000000003"
+)
+
+// templateSourceGame returns a game whose home lineup change was undone and
+// whose away lineup changed during the game.
+func templateSourceGame(id, owner, teamId string) *Game {
+	return &Game{
+		ID:            id,
+		SchemaVersion: CurrentSchemaVersion,
+		OwnerID:       owner,
+		Date:          "2025-06-01T18:00:00Z",
+		Event:         "Spring Tournament",
+		Location:      "Field 2",
+		Away:          "Owls",
+		Home:          "Bears",
+		HomeTeamID:    teamId,
+		Permissions:   Permissions{Public: "read", Users: map[string]string{"coach@example.com": "write"}},
+		ActionLog: []json.RawMessage{
+			json.RawMessage(fmt.Sprintf(`{"id":"c0000000-0000-4000-8000-000000000001","type":"GAME_START","payload":{"id":%q,"date":"2025-06-01T18:00:00Z","away":"Owls","home":"Bears","initialRosters":{"home":[{"id":%q,"name":"Hana","number":"3"}]}}}`, id, tmplPlayerH)),
+			json.RawMessage(fmt.Sprintf(`{"id":"c0000000-0000-4000-8000-000000000002","type":"LINEUP_UPDATE","payload":{"team":"away","roster":[{"slot":0,"starter":{"id":%q,"name":"Ann","number":"1","pos":"P"},"current":{"id":%q,"name":"Ann","number":"1","pos":"P"}}],"subs":[{"id":%q,"name":"Bo","number":"2"}]}}`, tmplPlayerA, tmplPlayerA, tmplPlayerB)),
+			json.RawMessage(fmt.Sprintf(`{"id":%q,"type":"LINEUP_UPDATE","payload":{"team":"home","roster":[],"subs":[]}}`, tmplUndone)),
+			json.RawMessage(fmt.Sprintf(`{"id":"c0000000-0000-4000-8000-000000000004","type":"UNDO","payload":{"refId":%q}}`, tmplUndone)),
+			json.RawMessage(fmt.Sprintf(`{"id":"c0000000-0000-4000-8000-000000000005","type":"SUBSTITUTION","payload":{"team":"away","rosterIndex":0,"subParams":{"id":%q,"name":"Cy","number":"9"}}}`, tmplPlayerC)),
+		},
+	}
+}
+
+func TestGameSetup(t *testing.T) {
+	g := templateSourceGame("11111111-1111-4111-8111-111111111111", "owner@example.com", "")
+	s := gameSetup(g)
+	if len(s.Roster["away"]) != 1 || s.Roster["away"][0].ID != tmplPlayerA || s.Roster["away"][0].Pos != "P" {
+		t.Errorf("away roster = %+v", s.Roster["away"])
+	}
+	if len(s.Subs["away"]) != 1 || s.Subs["away"][0].ID != tmplPlayerB {
+		t.Errorf("away subs = %+v", s.Subs["away"])
+	}
+	// The undone LINEUP_UPDATE must not clear the home lineup.
+	if len(s.Roster["home"]) != 1 || s.Roster["home"][0].ID != tmplPlayerH {
+		t.Errorf("home roster = %+v", s.Roster["home"])
+	}
+	if s.Event != "Spring Tournament" || s.Permissions.Users["coach@example.com"] != "write" {
+		t.Errorf("setup = %+v", s)
+	}
+
+	newId := "22222222-2222-4222-8222-222222222222"
+	actions, err := s.actions(newId, "coach@example.com", "2025-06-08T18:00:00Z", 1)
+	if err != nil {
+		t.Fatalf("actions: %v", err)
+	}
+	if len(actions) != 3 {
+		t.Fatalf("got %d actions, want GAME_START and two LINEUP_UPDATEs", len(actions))
+	}
+	var clone Game
+	if _, err := ApplyActions(&clone, actions); err != nil {
+		t.Fatalf("ApplyActions: %v", err)
+	}
+	if clone.ID != newId || clone.OwnerID != "coach@example.com" || clone.Date != "2025-06-08T18:00:00Z" || clone.Location != "Field 2" {
+		t.Errorf("clone = %+v", clone)
+	}
+	// The new owner is not listed as a collaborator.
+	if _, ok := clone.Permissions.Users["coach@example.com"]; ok || clone.Permissions.Public != "read" {
+		t.Errorf("clone permissions = %+v", clone.Permissions)
+	}
+	if got := gameSetup(&clone); len(got.Roster["away"]) != 1 || got.Roster["away"][0].ID != tmplPlayerA || len(got.Subs["away"]) != 1 {
+		t.Errorf("setup of the clone = %+v", got)
+	}
+}
+
+func TestGameTemplatesEndpoints(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+
+	owner := "owner@example.com"
+	teamId := "55555555-5555-4555-8555-555555555555"
+	sourceId := "11111111-1111-4111-8111-111111111111"
+	if err := gs.SaveGame(templateSourceGame(sourceId, owner, teamId)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.SaveTeam(&Team{ID: teamId, SchemaVersion: CurrentSchemaVersion, Name: "Bears", OwnerID: owner}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, handler := NewServerHandler(Options{
+		DataDir:     tempDir,
+		Storage:     s,
+		GameStore:   gs,
+		TeamStore:   ts,
+		UseMockAuth: true,
+	})
+	doReq := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	newGame := func(rec *httptest.ResponseRecorder) *Game {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("create game: %d %s", rec.Code, rec.Body)
+		}
+		var resp struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		g, err := gs.LoadGame(resp.ID)
+		if err != nil {
+			t.Fatalf("LoadGame(%s): %v", resp.ID, err)
+		}
+		return g
+	}
+
+	t.Run("Clone", func(t *testing.T) {
+		if rec := doReq("POST", "/api/games/"+sourceId+"/clone", owner, `{"date":"next week"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("invalid date: %d", rec.Code)
+		}
+		if rec := doReq("POST", "/api/games/22222222-2222-4222-8222-222222222222/clone", owner, `{"date":"2025-06-08T18:00:00Z"}`); rec.Code != http.StatusNotFound {
+			t.Errorf("clone of unknown game: %d", rec.Code)
+		}
+		g := newGame(doReq("POST", "/api/games/"+sourceId+"/clone", owner, `{"date":"2025-06-08T18:00:00Z","location":"Field 3"}`))
+		if g.ID == sourceId || g.OwnerID != owner || g.HomeTeamID != teamId || g.Event != "Spring Tournament" || g.Location != "Field 3" || len(g.ActionLog) != 3 {
+			t.Errorf("clone = %+v", g)
+		}
+		// Anyone who can read the game can clone it, and owns the new game.
+		g = newGame(doReq("POST", "/api/games/"+sourceId+"/clone", "stranger@example.com", `{"date":"2025-06-08T18:00:00Z"}`))
+		if g.OwnerID != "stranger@example.com" || len(g.Permissions.Users) != 0 {
+			t.Errorf("clone by reader = %+v", g)
+		}
+	})
+
+	t.Run("Templates", func(t *testing.T) {
+		path := "/api/teams/" + teamId + "/templates"
+		if rec := doReq("POST", path, owner, fmt.Sprintf(`{"name":"","gameId":%q}`, sourceId)); rec.Code != http.StatusBadRequest {
+			t.Errorf("template without name: %d", rec.Code)
+		}
+		if rec := doReq("POST", path, "stranger@example.com", fmt.Sprintf(`{"name":"Weekend","gameId":%q}`, sourceId)); rec.Code != http.StatusForbidden {
+			t.Errorf("template by stranger: %d", rec.Code)
+		}
+		rec := doReq("POST", path, owner, fmt.Sprintf(`{"name":"Weekend","gameId":%q}`, sourceId))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("create template: %d %s", rec.Code, rec.Body)
+		}
+		var tmpl GameTemplate
+		if err := json.Unmarshal(rec.Body.Bytes(), &tmpl); err != nil || tmpl.ID == "" || tmpl.Setup.Away != "Owls" {
+			t.Fatalf("template = %s, %v", rec.Body, err)
+		}
+		// Team readers see the templates, so they don't list the game's collaborators.
+		if tmpl.Setup.Permissions.Users != nil || tmpl.Setup.Permissions.Public != "read" {
+			t.Errorf("template permissions = %+v", tmpl.Setup.Permissions)
+		}
+
+		// save-team cannot change templates.
+		if rec := doReq("POST", "/api/save-team", owner, fmt.Sprintf(`{"id":%q,"name":"Bears","templates":[]}`, teamId)); rec.Code != http.StatusOK {
+			t.Fatalf("save-team: %d %s", rec.Code, rec.Body)
+		}
+		rec = doReq("GET", path, owner, "")
+		var list struct {
+			Data []GameTemplate `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Data) != 1 || list.Data[0].ID != tmpl.ID {
+			t.Fatalf("list = %s, %v", rec.Body, err)
+		}
+
+		g := newGame(doReq("POST", path+"/"+tmpl.ID+"/games", owner, `{"date":"2025-06-15T18:00:00Z","event":"Summer Cup"}`))
+		if g.Event != "Summer Cup" || g.Away != "Owls" || g.HomeTeamID != teamId || len(g.Permissions.Users) != 0 {
+			t.Errorf("game from template = %+v", g)
+		}
+		if s := gameSetup(g); len(s.Roster["away"]) != 1 || s.Roster["away"][0].ID != tmplPlayerA {
+			t.Errorf("lineup of game from template = %+v", s.Roster)
+		}
+		if rec := doReq("POST", path+"/missing/games", owner, `{"date":"2025-06-15T18:00:00Z"}`); rec.Code != http.StatusNotFound {
+			t.Errorf("unknown template: %d", rec.Code)
+		}
+
+		if rec := doReq("DELETE", path+"/"+tmpl.ID, owner, ""); rec.Code != http.StatusOK {
+			t.Errorf("delete template: %d %s", rec.Code, rec.Body)
+		}
+		if rec := doReq("DELETE", path+"/"+tmpl.ID, owner, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("delete template again: %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ttbt-io/skorekeeper/backend"
+
+// tracePropagator carries W3C trace context across HTTP hops and Raft log entries.
+var tracePropagator = propagation.TraceContext{}
+
+// tracer returns the tracer of the global provider, which is a no-op unless
+// SetupTracing was called.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// SetupTracing installs a global tracer provider that exports spans to the
+// OTLP/HTTP collector at endpoint, e.g. "http://localhost:4318". Root spans are
+// sampled with probability sampleRatio; child spans follow their parent.
+// The returned function flushes pending spans and should be called on shutdown.
+func SetupTracing(endpoint string, sampleRatio float64) (func(context.Context) error, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	if !strings.HasSuffix(u.Path, "/v1/traces") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/traces"
+	}
+	exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(u.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	host, _ := os.Hostname()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "skorekeeper"),
+			attribute.String("service.version", CurrentAppVersion),
+			attribute.String("host.name", host),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// traceCarrier returns the trace context of ctx for embedding in a RaftCommand,
+// or nil if ctx carries no span.
+func traceCarrier(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	c := propagation.MapCarrier{}
+	tracePropagator.Inject(ctx, c)
+	return c
+}
+
+// traceContext returns a context carrying the trace context and request ID
+// recorded in cmd.
+func (cmd RaftCommand) traceContext() context.Context {
+	ctx := withRequestID(context.Background(), cmd.RequestID)
+	if len(cmd.Trace) == 0 {
+		return ctx
+	}
+	return tracePropagator.Extract(ctx, propagation.MapCarrier(cmd.Trace))
+}
+
+// spanError records err, if any, on span.
+func spanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	spanError(span, err)
+	span.End()
+}
+
+// tracingMiddleware starts a server span for each request, continuing the
+// caller's trace if the request has a traceparent header. mux is only used
+// to name spans after the matched route.
+func tracingMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// WebSocket sessions are long-lived; individual actions are traced by the Hub.
+		if r.URL.Path == "/api/ws" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		_, route := mux.Handler(r)
+		ctx := tracePropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, strings.TrimSpace(r.Method+" "+route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+	})
+}
+
+// statusWriter records the response status code.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
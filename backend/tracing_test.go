@@ -0,0 +1,187 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordSpans installs an in-memory tracer provider for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return exp
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestTracingFSMPropagation(t *testing.T) {
+	exp := recordSpans(t)
+
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gs, ts, us, true)
+	hm := NewHubManager()
+	fsm := NewFSM(gs, ts, reg, hm, s, us)
+
+	gameId := "game-trace"
+	hm.GetHub(gameId, false, gs, ts, reg)
+
+	ctx, parent := tracer().Start(context.Background(), "client")
+	start := json.RawMessage(`{"id":"a1","type":"GAME_START","payload":{"id":"` + gameId + `","ownerId":"owner@example.com"}}`)
+	traced, _ := json.Marshal(RaftCommand{Type: CmdApplyAction, Action: &ActionPayload{GameID: gameId, Action: start}, Trace: traceCarrier(ctx)})
+	untraced, _ := json.Marshal(RaftCommand{Type: CmdMetricsUpdate, MetricsPayload: &MetricsPayload{Timestamp: 1}})
+	fsm.ApplyBatch([]*raft.Log{
+		{Index: 1, Type: raft.LogCommand, Data: traced},
+		{Index: 2, Type: raft.LogCommand, Data: untraced},
+	})
+	parent.End()
+
+	var broadcast *tracetest.SpanStub
+	deadline := time.Now().Add(5 * time.Second)
+	for broadcast == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		broadcast = findSpan(exp.GetSpans(), "Hub.broadcast")
+	}
+	spans := exp.GetSpans()
+	apply := findSpan(spans, "FSM.Apply")
+	if apply == nil || broadcast == nil {
+		t.Fatalf("missing spans: %+v", spans)
+	}
+	if apply.Parent.SpanID() != parent.SpanContext().SpanID() || apply.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("FSM.Apply does not continue the proposer's trace")
+	}
+	if broadcast.Parent.SpanID() != apply.SpanContext.SpanID() {
+		t.Errorf("Hub.broadcast is not a child of FSM.Apply")
+	}
+	var applies int
+	for _, s := range spans {
+		if s.Name == "FSM.Apply" {
+			applies++
+		}
+	}
+	if applies != 1 {
+		t.Errorf("expected 1 FSM.Apply span for the traced command, got %d", applies)
+	}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exp := recordSpans(t)
+
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gStore := NewGameStore(tempDir, s)
+	tStore := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	_, _, handler := NewServerHandler(Options{
+		GameStore:      gStore,
+		TeamStore:      tStore,
+		Storage:        s,
+		Registry:       NewRegistry(gStore, tStore, us, true),
+		UserIndexStore: us,
+		UseMockAuth:    true,
+	})
+
+	req := httptest.NewRequest("GET", "/api/trash", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: "owner@example.com"})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/trash: %d %s", w.Code, w.Body.String())
+	}
+
+	span := findSpan(exp.GetSpans(), "GET /api/trash")
+	if span == nil {
+		t.Fatalf("missing server span: %+v", exp.GetSpans())
+	}
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s, want the caller's", got)
+	}
+	if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span id = %s, want the caller's", got)
+	}
+}
+
+func TestSetupTracing(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	shutdown, err := SetupTracing(srv.URL, 1)
+	if err != nil {
+		t.Fatalf("SetupTracing: %v", err)
+	}
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	_, span := tracer().Start(context.Background(), "RaftManager.Propose")
+	span.SetAttributes(attribute.Int64("raft.index", 42))
+	endSpan(span, errors.New("not leader"))
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		t.Fatalf("bad OTLP body: %v", err)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("unexpected OTLP request: %v", &req)
+	}
+	if scope := req.ResourceSpans[0].ScopeSpans[0].Scope.GetName(); scope != tracerName {
+		t.Errorf("scope = %q", scope)
+	}
+	s := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if s.Name != "RaftManager.Propose" || s.Status.GetMessage() != "not leader" || len(s.Attributes) != 1 {
+		t.Errorf("unexpected span: %v", s)
+	}
+
+	if _, err := SetupTracing("localhost:4318", 1); err == nil {
+		t.Error("expected an error for an endpoint without a scheme")
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNotInTrash    = errors.New("not in trash")
+	ErrNotRestorable = errors.New("deleted data was not retained")
+	ErrTrashExpired  = errors.New("retention period has expired")
+)
+
+// TrashItem is a deleted game or team that can still be restored.
+type TrashItem struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Date      string `json:"date,omitempty"`
+	DeletedAt int64  `json:"deletedAt"` // Unix ms
+	ExpiresAt int64  `json:"expiresAt"` // Unix ms; the tombstone may be purged after this time
+}
+
+// trashExpiresAt returns when a tombstone becomes eligible for garbage collection.
+func trashExpiresAt(deletedAt int64) time.Time {
+	return time.Unix(0, deletedAt).Add(tombstoneTTL)
+}
+
+// commandTime returns the proposal time of a command. The FSM uses it instead
+// of the local clock so that every node makes the same expiry decision.
+func commandTime(cmd RaftCommand) time.Time {
+	if cmd.Timestamp > 0 {
+		return time.UnixMilli(cmd.Timestamp)
+	}
+	return time.Now()
+}
+
+// untrashGame returns the game retained by a tombstone.
+func untrashGame(tomb *Game, now time.Time) (*Game, error) {
+	if tomb.Status != "deleted" {
+		return nil, ErrNotInTrash
+	}
+	if len(tomb.Trashed) == 0 {
+		return nil, ErrNotRestorable
+	}
+	if !now.Before(trashExpiresAt(tomb.DeletedAt)) {
+		return nil, ErrTrashExpired
+	}
+	var g Game
+	if err := json.Unmarshal(tomb.Trashed, &g); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trashed game: %w", err)
+	}
+	return &g, nil
+}
+
+// untrashTeam returns the team retained by a tombstone.
+func untrashTeam(tomb *Team, now time.Time) (*Team, error) {
+	if tomb.Status != "deleted" {
+		return nil, ErrNotInTrash
+	}
+	if len(tomb.Trashed) == 0 {
+		return nil, ErrNotRestorable
+	}
+	if !now.Before(trashExpiresAt(tomb.DeletedAt)) {
+		return nil, ErrTrashExpired
+	}
+	var t Team
+	if err := json.Unmarshal(tomb.Trashed, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trashed team: %w", err)
+	}
+	return &t, nil
+}
+
+// setTrashed records whether a game or team tombstone retains restorable data
+// in its owner's index, so the trash can be listed without scanning metadata.
+func (r *Registry) setTrashed(ownerId, id string, isTeam, trashed bool) {
+	if ownerId == "" {
+		return
+	}
+	idx, err := r.userStore.GetUserIndex(normalizeEmail(ownerId))
+	if err != nil {
+		storeLog.Error("registry: loading user index for trash", "err", err)
+		return
+	}
+	m := &idx.TrashedGames
+	if isTeam {
+		m = &idx.TrashedTeams
+	}
+	if (*m)[id] == trashed {
+		return
+	}
+	if trashed {
+		if *m == nil {
+			*m = make(map[string]bool)
+		}
+		(*m)[id] = true
+	} else {
+		delete(*m, id)
+	}
+	r.userStore.SetUserIndex(idx)
+}
+
+// trashedIDs returns the IDs of the tombstones indexed in a user's trash.
+func (r *Registry) trashedIDs(userId string) (games, teams []string, err error) {
+	idx, err := r.userStore.GetUserIndex(normalizeEmail(userId))
+	if err != nil {
+		return nil, nil, err
+	}
+	return slices.Sorted(maps.Keys(idx.TrashedGames)), slices.Sorted(maps.Keys(idx.TrashedTeams)), nil
+}
+
+// updateGameTrash indexes the tombstone of a game that was just deleted or
+// purged in its owner's trash.
+func (r *Registry) updateGameTrash(gameId string) {
+	if g, err := r.gameStore.LoadGame(gameId); err == nil && g.Status == "deleted" {
+		r.setTrashed(g.OwnerID, gameId, false, len(g.Trashed) > 0)
+	}
+}
+
+// updateTeamTrash indexes the tombstone of a team that was just deleted or
+// purged in its owner's trash.
+func (r *Registry) updateTeamTrash(teamId string) {
+	if t, err := r.teamStore.LoadTeam(teamId); err == nil && t.Status == "deleted" {
+		r.setTrashed(t.OwnerID, teamId, true, len(t.Trashed) > 0)
+	}
+}
+
+// ListTrash returns the restorable games and teams owned by a user, most recently deleted first.
+func (r *Registry) ListTrash(userId string, now time.Time) (games, teams []TrashItem, err error) {
+	games = make([]TrashItem, 0)
+	teams = make([]TrashItem, 0)
+	gameIds, teamIds, err := r.trashedIDs(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	userId = normalizeEmail(userId)
+
+	for _, id := range gameIds {
+		g, err := r.gameStore.LoadGame(id)
+		if err != nil {
+			continue
+		}
+		m := g.Metadata()
+		if m.Status != "deleted" || !m.Restorable || normalizeEmail(m.OwnerID) != userId {
+			continue
+		}
+		expires := trashExpiresAt(m.DeletedAt)
+		if !now.Before(expires) {
+			continue
+		}
+		games = append(games, TrashItem{
+			ID:        m.ID,
+			Name:      fmt.Sprintf("%s @ %s", m.Away, m.Home),
+			Date:      m.Date,
+			DeletedAt: time.Unix(0, m.DeletedAt).UnixMilli(),
+			ExpiresAt: expires.UnixMilli(),
+		})
+	}
+
+	for _, id := range teamIds {
+		t, err := r.teamStore.LoadTeam(id)
+		if err != nil {
+			continue
+		}
+		m := t.Metadata()
+		if m.Status != "deleted" || !m.Restorable || normalizeEmail(m.OwnerID) != userId {
+			continue
+		}
+		expires := trashExpiresAt(m.DeletedAt)
+		if !now.Before(expires) {
+			continue
+		}
+		teams = append(teams, TrashItem{
+			ID:        m.ID,
+			Name:      m.Name,
+			DeletedAt: time.Unix(0, m.DeletedAt).UnixMilli(),
+			ExpiresAt: expires.UnixMilli(),
+		})
+	}
+
+	byDeletedAt := func(items []TrashItem) {
+		sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt > items[j].DeletedAt })
+	}
+	byDeletedAt(games)
+	byDeletedAt(teams)
+	return games, teams, nil
+}
+
+// EraseTrash discards the data retained by all of a user's tombstones,
+// including expired ones awaiting garbage collection. It returns the IDs of
+// the erased games and teams.
+func (r *Registry) EraseTrash(userId string, raftIndex uint64, now time.Time) (games, teams []string) {
+	gameIds, teamIds, err := r.trashedIDs(userId)
+	if err != nil {
+		storeLog.Error("erase trash: loading user index", "err", err)
+		return nil, nil
+	}
+	userId = normalizeEmail(userId)
+
+	for _, id := range gameIds {
+		g, err := r.gameStore.LoadGame(id)
+		if err != nil || g.Status != "deleted" || len(g.Trashed) == 0 || normalizeEmail(g.OwnerID) != userId {
+			r.setTrashed(userId, id, false, false)
+			continue
+		}
+		if err := r.gameStore.tombstoneGame(id, g, false, raftIndex, now); err != nil {
+			storeLog.Error("erase trash: game", "gameId", id, "err", err)
+			continue
+		}
+		r.setTrashed(userId, id, false, false)
+		games = append(games, id)
+	}
+	for _, id := range teamIds {
+		t, err := r.teamStore.LoadTeam(id)
+		if err != nil || t.Status != "deleted" || len(t.Trashed) == 0 || normalizeEmail(t.OwnerID) != userId {
+			r.setTrashed(userId, id, true, false)
+			continue
+		}
+		if err := r.teamStore.tombstoneTeam(id, t, false, raftIndex, now); err != nil {
+			storeLog.Error("erase trash: team", "teamId", id, "err", err)
+			continue
+		}
+		r.setTrashed(userId, id, true, false)
+		teams = append(teams, id)
+	}
+	return games, teams
+}
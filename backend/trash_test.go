@@ -0,0 +1,245 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
+)
+
+func TestTrashHandlers(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gStore := NewGameStore(tempDir, s)
+	tStore := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gStore, tStore, us, true)
+
+	_, _, handler := NewServerHandler(Options{
+		GameStore:      gStore,
+		TeamStore:      tStore,
+		Storage:        s,
+		Registry:       reg,
+		UserIndexStore: us,
+		UseMockAuth:    true,
+	})
+
+	owner := "owner@example.com"
+	other := "other@example.com"
+	teamId := "66666666-0000-4000-8000-000000000006"
+	gameId := "77777777-0000-4000-8000-000000000007"
+
+	team := Team{ID: teamId, Name: "Sharks", OwnerID: owner}
+	if err := tStore.SaveTeam(&team); err != nil {
+		t.Fatalf("SaveTeam: %v", err)
+	}
+	reg.UpdateTeam(team)
+	game := Game{ID: gameId, Away: "Sharks", Home: "Jets", AwayTeamID: teamId, OwnerID: owner, Status: "ongoing"}
+	if err := gStore.SaveGame(&game); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	reg.UpdateGame(game)
+
+	do := func(method, url, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "mock_auth_user", Value: user})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	listTrash := func(user string) (games, teams []TrashItem) {
+		t.Helper()
+		w := do("GET", "/api/trash", user, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/trash: %d %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Games []TrashItem `json:"games"`
+			Teams []TrashItem `json:"teams"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Games, resp.Teams
+	}
+
+	if w := do("POST", "/api/delete-game", owner, `{"id":"`+gameId+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("delete-game: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/delete-team", owner, `{"id":"`+teamId+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("delete-team: %d %s", w.Code, w.Body.String())
+	}
+	if !reg.IsGameDeleted(gameId) || !reg.IsTeamDeleted(teamId) {
+		t.Fatalf("expected game and team to be deleted")
+	}
+
+	games, teams := listTrash(owner)
+	if len(games) != 1 || games[0].ID != gameId || games[0].Name != "Sharks @ Jets" {
+		t.Fatalf("unexpected trashed games: %+v", games)
+	}
+	if len(teams) != 1 || teams[0].Name != "Sharks" {
+		t.Fatalf("unexpected trashed teams: %+v", teams)
+	}
+	if want := time.UnixMilli(games[0].DeletedAt).Add(tombstoneTTL).UnixMilli(); games[0].ExpiresAt != want {
+		t.Errorf("expiresAt = %d, want %d", games[0].ExpiresAt, want)
+	}
+	if games, teams := listTrash(other); len(games) != 0 || len(teams) != 0 {
+		t.Errorf("other user sees trash: %+v %+v", games, teams)
+	}
+
+	if w := do("POST", "/api/trash/games/"+gameId+"/restore", other, ""); w.Code != http.StatusForbidden {
+		t.Errorf("restore by other user: expected 403, got %d", w.Code)
+	}
+	if w := do("POST", "/api/trash/widgets/"+gameId+"/restore", owner, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown kind: expected 404, got %d", w.Code)
+	}
+	if w := do("DELETE", "/api/trash/teams/not-a-uuid", owner, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid id: expected 400, got %d", w.Code)
+	}
+
+	// Restore the team, then the game; the team's game list is rebuilt.
+	if w := do("POST", "/api/trash/teams/"+teamId+"/restore", owner, ""); w.Code != http.StatusOK {
+		t.Fatalf("restore team: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/trash/games/"+gameId+"/restore", owner, ""); w.Code != http.StatusOK {
+		t.Fatalf("restore game: %d %s", w.Code, w.Body.String())
+	}
+	g, err := gStore.LoadGame(gameId)
+	if err != nil || g.Status != "ongoing" || g.Home != "Jets" || len(g.Trashed) != 0 {
+		t.Fatalf("game not restored: %+v, %v", g, err)
+	}
+	if reg.IsGameDeleted(gameId) || !reg.HasGameAccess(owner, gameId) {
+		t.Errorf("registry not updated after restore")
+	}
+	if idx, _ := us.GetTeamGames(teamId); !idx.GameIDs[gameId] {
+		t.Errorf("team games not relinked: %v", idx.GameIDs)
+	}
+	if w := do("POST", "/api/trash/games/"+gameId+"/restore", owner, ""); w.Code != http.StatusNotFound {
+		t.Errorf("restoring a live game: expected 404, got %d", w.Code)
+	}
+
+	// Permanent delete erases the retained data.
+	do("POST", "/api/delete-game", owner, `{"id":"`+gameId+`"}`)
+	if w := do("DELETE", "/api/trash/games/"+gameId, owner, ""); w.Code != http.StatusOK {
+		t.Fatalf("permanent delete: %d %s", w.Code, w.Body.String())
+	}
+	if g, _ := gStore.LoadGame(gameId); g.Status != "deleted" || len(g.Trashed) != 0 || g.Home != "" {
+		t.Errorf("tombstone still holds data: %+v", g)
+	}
+	if w := do("POST", "/api/trash/games/"+gameId+"/restore", owner, ""); w.Code != http.StatusNotFound {
+		t.Errorf("restore after permanent delete: expected 404, got %d", w.Code)
+	}
+
+	// Delete-all also empties the trash.
+	do("POST", "/api/delete-team", owner, `{"id":"`+teamId+`"}`)
+	if w := do("POST", "/api/delete-all", owner, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete-all: %d", w.Code)
+	}
+	if games, teams := listTrash(owner); len(games) != 0 || len(teams) != 0 {
+		t.Errorf("trash not empty after delete-all: %+v %+v", games, teams)
+	}
+}
+
+func TestTrashFSM(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gs, ts, us, true)
+	fsm := NewFSM(gs, ts, reg, NewHubManager(), s, us)
+
+	owner := "owner@example.com"
+	gameId := "game-trash"
+	now := time.Now()
+
+	var index uint64
+	logFor := func(cmd RaftCommand) *raft.Log {
+		index++
+		if cmd.Timestamp == 0 {
+			cmd.Timestamp = now.UnixMilli()
+		}
+		data, _ := json.Marshal(cmd)
+		return &raft.Log{Index: index, Type: raft.LogCommand, Data: data}
+	}
+	gameData := func(g Game) *json.RawMessage {
+		b, _ := json.Marshal(g)
+		m := json.RawMessage(b)
+		return &m
+	}
+
+	fsm.Apply(logFor(RaftCommand{Type: CmdSaveGame, ID: gameId, GameData: gameData(Game{ID: gameId, OwnerID: owner, Away: "A", Home: "B"})}))
+	deletedAt := now.Add(-time.Hour).UnixMilli()
+	fsm.Apply(logFor(RaftCommand{Type: CmdDeleteGame, ID: gameId, Timestamp: deletedAt}))
+
+	// The deletion time comes from the command, and the owner's trash lists it.
+	if g, _ := gs.LoadGame(gameId); g.DeletedAt != time.UnixMilli(deletedAt).UnixNano() {
+		t.Errorf("DeletedAt = %d, want the command time %d", g.DeletedAt, time.UnixMilli(deletedAt).UnixNano())
+	}
+	if games, _, err := reg.ListTrash(owner, now); err != nil || len(games) != 1 || games[0].DeletedAt != deletedAt {
+		t.Fatalf("ListTrash = %+v, %v", games, err)
+	}
+
+	// Expiry is decided from the proposal time, not the local clock.
+	expired := now.Add(tombstoneTTL + time.Hour).UnixMilli()
+	if res := fsm.Apply(logFor(RaftCommand{Type: CmdRestoreGame, ID: gameId, Timestamp: expired})); !errors.Is(res.(error), ErrTrashExpired) {
+		t.Fatalf("expected ErrTrashExpired, got %v", res)
+	}
+
+	restore := logFor(RaftCommand{Type: CmdRestoreGame, ID: gameId})
+	if res := fsm.Apply(restore); res != nil {
+		t.Fatalf("restore: %v", res)
+	}
+	if g, _ := gs.LoadGame(gameId); g.Status == "deleted" || g.Away != "A" || g.LastRaftIndex != restore.Index {
+		t.Fatalf("game not restored: %+v", g)
+	}
+
+	// Delete, restore and delete again in one batch; then purge in a second batch.
+	results := fsm.ApplyBatch([]*raft.Log{
+		logFor(RaftCommand{Type: CmdDeleteGame, ID: gameId}),
+		logFor(RaftCommand{Type: CmdRestoreGame, ID: gameId}),
+		logFor(RaftCommand{Type: CmdDeleteGame, ID: gameId}),
+	})
+	for i, res := range results {
+		if res != nil {
+			t.Fatalf("batch result %d: %v", i, res)
+		}
+	}
+	g, _ := gs.LoadGame(gameId)
+	if g.Status != "deleted" || len(g.Trashed) == 0 {
+		t.Fatalf("expected restorable tombstone: %+v", g)
+	}
+
+	purge := logFor(RaftCommand{Type: CmdPurgeGame, ID: gameId})
+	if res := fsm.ApplyBatch([]*raft.Log{purge})[0]; res != nil {
+		t.Fatalf("purge: %v", res)
+	}
+	g, _ = gs.LoadGame(gameId)
+	if len(g.Trashed) != 0 || g.LastRaftIndex != purge.Index {
+		t.Fatalf("purge did not erase data: %+v", g)
+	}
+	if res := fsm.Apply(logFor(RaftCommand{Type: CmdRestoreGame, ID: gameId})); !errors.Is(res.(error), ErrNotRestorable) {
+		t.Errorf("expected ErrNotRestorable, got %v", res)
+	}
+	if idx, _ := us.GetUserIndex(owner); len(idx.TrashedGames) != 0 {
+		t.Errorf("purged game still indexed in the trash: %v", idx.TrashedGames)
+	}
+}
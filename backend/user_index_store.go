@@ -28,12 +28,18 @@ import (
 	lru "github.com/hashicorp/golang-lru/v2"
 )
 
-// UserIndex represents the set of entities accessible by a user.
+// UserIndex represents the set of entities accessible by a user and the
+// user's saved searches.
 type UserIndex struct {
-	UserID      string                 `json:"userId"`
-	GameAccess  map[string]AccessLevel `json:"gameAccess"` // GameID -> AccessLevel
-	TeamAccess  map[string]AccessLevel `json:"teamAccess"` // TeamID -> AccessLevel
-	LastUpdated int64                  `json:"lastUpdated"`
+	UserID            string                       `json:"userId"`
+	GameAccess        map[string]AccessLevel       `json:"gameAccess"` // GameID -> AccessLevel
+	TeamAccess        map[string]AccessLevel       `json:"teamAccess"` // TeamID -> AccessLevel
+	SavedSearches     []SavedSearch                `json:"savedSearches,omitempty"`
+	TrashedGames      map[string]bool              `json:"trashedGames,omitempty"`      // Restorable game tombstones owned by the user
+	TrashedTeams      map[string]bool              `json:"trashedTeams,omitempty"`      // Restorable team tombstones owned by the user
+	IncomingTransfers map[string]OwnershipTransfer `json:"incomingTransfers,omitempty"` // Game or team ID -> pending offer to the user
+	OutgoingTransfers map[string]OwnershipTransfer `json:"outgoingTransfers,omitempty"` // Game or team ID -> pending offer by the user
+	LastUpdated       int64                        `json:"lastUpdated"`
 }
 
 // TeamGamesIndex represents the set of games associated with a team.
@@ -45,15 +51,25 @@ type TeamGamesIndex struct {
 
 // GameUsersIndex represents the set of users with direct access to a game.
 type GameUsersIndex struct {
-	GameID      string          `json:"gameId"`
-	UserIDs     map[string]bool `json:"userIds"`
-	LastUpdated int64           `json:"lastUpdated"`
+	GameID      string             `json:"gameId"`
+	UserIDs     map[string]bool    `json:"userIds"`
+	Transfer    *OwnershipTransfer `json:"transfer,omitempty"` // Pending transfer indexed in the users' indices
+	LastUpdated int64              `json:"lastUpdated"`
 }
 
 // TeamUsersIndex represents the set of users who are members of a team.
 type TeamUsersIndex struct {
-	TeamID      string          `json:"teamId"`
-	UserIDs     map[string]bool `json:"userIds"`
+	TeamID      string             `json:"teamId"`
+	UserIDs     map[string]bool    `json:"userIds"`
+	Transfer    *OwnershipTransfer `json:"transfer,omitempty"` // Pending transfer indexed in the users' indices
+	LastUpdated int64              `json:"lastUpdated"`
+}
+
+// PlayerTeamsIndex represents the set of teams whose player registry or
+// roster holds a player ID.
+type PlayerTeamsIndex struct {
+	PlayerID    string          `json:"playerId"`
+	TeamIDs     map[string]bool `json:"teamIds"`
 	LastUpdated int64           `json:"lastUpdated"`
 }
 
@@ -63,21 +79,27 @@ type UserIndexStore struct {
 	storage   *storage.Storage
 	masterKey crypto.MasterKey
 
-	userCache     *lru.Cache[string, *UserIndex]      // Key: UserID
-	teamGameCache *lru.Cache[string, *TeamGamesIndex] // Key: TeamID
-	gameUserCache *lru.Cache[string, *GameUsersIndex] // Key: GameID
-	teamUserCache *lru.Cache[string, *TeamUsersIndex] // Key: TeamID
+	userCache     *lru.Cache[string, *UserIndex]        // Key: UserID
+	teamGameCache *lru.Cache[string, *TeamGamesIndex]   // Key: TeamID
+	gameUserCache *lru.Cache[string, *GameUsersIndex]   // Key: GameID
+	teamUserCache *lru.Cache[string, *TeamUsersIndex]   // Key: TeamID
+	changeCache   *lru.Cache[string, *ChangeLog]        // Key: UserID
+	playerCache   *lru.Cache[string, *PlayerTeamsIndex] // Key: PlayerID
 
 	dirtyMu sync.Mutex
 	dirtyU  map[string]bool // UserID
 	dirtyTG map[string]bool // TeamID (Games)
 	dirtyGU map[string]bool // GameID (Users)
 	dirtyTU map[string]bool // TeamID (Users)
+	dirtyCL map[string]bool // UserID (Changes)
+	dirtyPT map[string]bool // PlayerID (Teams)
 
 	muU  sync.Map
 	muTG sync.Map
 	muGU sync.Map
 	muTU sync.Map
+	muCL sync.Map
+	muPT sync.Map
 }
 
 // NewUserIndexStore creates a new store for registry indices.
@@ -90,6 +112,8 @@ func NewUserIndexStore(dataDir string, s *storage.Storage, mk crypto.MasterKey)
 		dirtyTG:   make(map[string]bool),
 		dirtyGU:   make(map[string]bool),
 		dirtyTU:   make(map[string]bool),
+		dirtyCL:   make(map[string]bool),
+		dirtyPT:   make(map[string]bool),
 	}
 
 	// Define Eviction Callbacks
@@ -145,6 +169,32 @@ func NewUserIndexStore(dataDir string, s *storage.Storage, mk crypto.MasterKey)
 		}
 	}
 
+	onChangeLogEvict := func(key string, value *ChangeLog) {
+		store.dirtyMu.Lock()
+		isDirty := store.dirtyCL[key]
+		if isDirty {
+			delete(store.dirtyCL, key)
+		}
+		store.dirtyMu.Unlock()
+
+		if isDirty {
+			store.persistChangeLog(value)
+		}
+	}
+
+	onPlayerTeamsEvict := func(key string, value *PlayerTeamsIndex) {
+		store.dirtyMu.Lock()
+		isDirty := store.dirtyPT[key]
+		if isDirty {
+			delete(store.dirtyPT, key)
+		}
+		store.dirtyMu.Unlock()
+
+		if isDirty {
+			store.persistPlayerTeamsIndex(value)
+		}
+	}
+
 	uCache, _ := lru.NewWithEvict[string, *UserIndex](1000, onUserEvict)
 	tgCache, _ := lru.NewWithEvict[string, *TeamGamesIndex](500, onTeamGameEvict)
 	guCache, _ := lru.NewWithEvict[string, *GameUsersIndex](1000, onGameUserEvict)
@@ -154,6 +204,8 @@ func NewUserIndexStore(dataDir string, s *storage.Storage, mk crypto.MasterKey)
 	store.teamGameCache = tgCache
 	store.gameUserCache = guCache
 	store.teamUserCache = tuCache
+	store.changeCache, _ = lru.NewWithEvict[string, *ChangeLog](1000, onChangeLogEvict)
+	store.playerCache, _ = lru.NewWithEvict[string, *PlayerTeamsIndex](1000, onPlayerTeamsEvict)
 
 	return store
 }
@@ -346,6 +398,69 @@ func (s *UserIndexStore) DeleteTeamUsers(teamId string) error {
 	return err
 }
 
+// --- Player Teams Index Methods ---
+
+func (s *UserIndexStore) GetPlayerTeams(playerId string) (*PlayerTeamsIndex, error) {
+	if idx, ok := s.playerCache.Get(playerId); ok {
+		return idx, nil
+	}
+	path := s.getHashPath(playerId, "player_teams")
+	m, _ := s.muPT.LoadOrStore(path, &sync.Mutex{})
+	mutex := m.(*sync.Mutex)
+	mutex.Lock()
+	var idx PlayerTeamsIndex
+	err := s.storage.ReadDataFile(path, &idx)
+	mutex.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &PlayerTeamsIndex{PlayerID: playerId, TeamIDs: make(map[string]bool)}, nil
+		}
+		return nil, err
+	}
+	if idx.TeamIDs == nil {
+		idx.TeamIDs = make(map[string]bool)
+	}
+	s.playerCache.Add(playerId, &idx)
+	return &idx, nil
+}
+
+func (s *UserIndexStore) SetPlayerTeams(idx *PlayerTeamsIndex) {
+	s.playerCache.Add(idx.PlayerID, idx)
+	s.dirtyMu.Lock()
+	s.dirtyPT[idx.PlayerID] = true
+	s.dirtyMu.Unlock()
+}
+
+// --- Change Log Methods ---
+
+func (s *UserIndexStore) GetChangeLog(userId string) (*ChangeLog, error) {
+	if l, ok := s.changeCache.Get(userId); ok {
+		return l, nil
+	}
+	path := s.getHashPath(userId, "changes")
+	m, _ := s.muCL.LoadOrStore(path, &sync.Mutex{})
+	mutex := m.(*sync.Mutex)
+	mutex.Lock()
+	var l ChangeLog
+	err := s.storage.ReadDataFile(path, &l)
+	mutex.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ChangeLog{UserID: userId}, nil
+		}
+		return nil, err
+	}
+	s.changeCache.Add(userId, &l)
+	return &l, nil
+}
+
+func (s *UserIndexStore) SetChangeLog(l *ChangeLog) {
+	s.changeCache.Add(l.UserID, l)
+	s.dirtyMu.Lock()
+	s.dirtyCL[l.UserID] = true
+	s.dirtyMu.Unlock()
+}
+
 // --- Persistence Methods ---
 
 func (s *UserIndexStore) FlushAll() error {
@@ -366,6 +481,14 @@ func (s *UserIndexStore) FlushAll() error {
 	for k := range s.dirtyTU {
 		teamUsers = append(teamUsers, k)
 	}
+	changeLogs := make([]string, 0, len(s.dirtyCL))
+	for k := range s.dirtyCL {
+		changeLogs = append(changeLogs, k)
+	}
+	playerTeams := make([]string, 0, len(s.dirtyPT))
+	for k := range s.dirtyPT {
+		playerTeams = append(playerTeams, k)
+	}
 	s.dirtyMu.Unlock()
 
 	for _, id := range users {
@@ -380,6 +503,12 @@ func (s *UserIndexStore) FlushAll() error {
 	for _, id := range teamUsers {
 		s.saveTeamUsersToDisk(id)
 	}
+	for _, id := range changeLogs {
+		s.saveChangeLogToDisk(id)
+	}
+	for _, id := range playerTeams {
+		s.savePlayerTeamsToDisk(id)
+	}
 	return nil
 }
 
@@ -422,6 +551,24 @@ func (s *UserIndexStore) persistTeamUsersIndex(idx *TeamUsersIndex) error {
 	return s.storage.SaveDataFile(path, idx)
 }
 
+func (s *UserIndexStore) persistChangeLog(l *ChangeLog) error {
+	path := s.getHashPath(l.UserID, "changes")
+	m, _ := s.muCL.LoadOrStore(path, &sync.Mutex{})
+	mutex := m.(*sync.Mutex)
+	mutex.Lock()
+	defer mutex.Unlock()
+	return s.storage.SaveDataFile(path, l)
+}
+
+func (s *UserIndexStore) persistPlayerTeamsIndex(idx *PlayerTeamsIndex) error {
+	path := s.getHashPath(idx.PlayerID, "player_teams")
+	m, _ := s.muPT.LoadOrStore(path, &sync.Mutex{})
+	mutex := m.(*sync.Mutex)
+	mutex.Lock()
+	defer mutex.Unlock()
+	return s.storage.SaveDataFile(path, idx)
+}
+
 // Public Load/Save (handles cache/dirty logic)
 
 func (s *UserIndexStore) loadUserFromDisk(id string) (*UserIndex, error) {
@@ -567,6 +714,42 @@ func (s *UserIndexStore) saveTeamUsersToDisk(id string) error {
 	return s.persistTeamUsersIndex(idx)
 }
 
+func (s *UserIndexStore) saveChangeLogToDisk(id string) error {
+	s.dirtyMu.Lock()
+	if !s.dirtyCL[id] {
+		s.dirtyMu.Unlock()
+		return nil
+	}
+	l, ok := s.changeCache.Get(id)
+	if !ok {
+		s.dirtyMu.Unlock()
+		return nil
+	}
+
+	delete(s.dirtyCL, id)
+	s.dirtyMu.Unlock()
+
+	return s.persistChangeLog(l)
+}
+
+func (s *UserIndexStore) savePlayerTeamsToDisk(id string) error {
+	s.dirtyMu.Lock()
+	if !s.dirtyPT[id] {
+		s.dirtyMu.Unlock()
+		return nil
+	}
+	idx, ok := s.playerCache.Get(id)
+	if !ok {
+		s.dirtyMu.Unlock()
+		return nil
+	}
+
+	delete(s.dirtyPT, id)
+	s.dirtyMu.Unlock()
+
+	return s.persistPlayerTeamsIndex(idx)
+}
+
 // Invalidation
 func (s *UserIndexStore) InvalidateUser(id string)      { s.userCache.Remove(id) }
 func (s *UserIndexStore) InvalidateTeamGames(id string) { s.teamGameCache.Remove(id) }
@@ -610,6 +793,14 @@ func (s *UserIndexStore) ListTeamUsersFiles() ([]string, error) {
 	return s.listIndexFiles("team_users")
 }
 
+func (s *UserIndexStore) ListChangeLogFiles() ([]string, error) {
+	return s.listIndexFiles("changes")
+}
+
+func (s *UserIndexStore) ListPlayerTeamsFiles() ([]string, error) {
+	return s.listIndexFiles("player_teams")
+}
+
 // --- Iterators ---
 
 func (s *UserIndexStore) iterateIndices(subDir string, load func(string) (any, error)) iter.Seq2[any, error] {
@@ -895,6 +1086,14 @@ func (s *UserIndexStore) RestoreTeamUsers(idx *TeamUsersIndex) error {
 	s.teamUserCache.Remove(idx.TeamID)
 	return s.persistTeamUsersIndex(idx)
 }
+func (s *UserIndexStore) RestoreChangeLog(l *ChangeLog) error {
+	s.changeCache.Remove(l.UserID)
+	return s.persistChangeLog(l)
+}
+func (s *UserIndexStore) RestorePlayerTeams(idx *PlayerTeamsIndex) error {
+	s.playerCache.Remove(idx.PlayerID)
+	return s.persistPlayerTeamsIndex(idx)
+}
 
 // Legacy shims
 func (s *UserIndexStore) Get(userId string) (*UserIndex, error) { return s.GetUserIndex(userId) }
@@ -37,11 +37,16 @@ func isValidEmail(email string) bool {
 }
 
 const (
-	CurrentSchemaVersion   = 3
+	CurrentSchemaVersion   = SchemaVersionV4
 	CurrentProtocolVersion = 1
 	CurrentAppVersion      = "0.2.28"
 )
 
+// DocumentSchemaVersion is the schema version of Game and Team documents.
+// Their format has not changed since v3; CurrentSchemaVersion only governs
+// which actions may be used, see ActiveSchemaVersion.
+const DocumentSchemaVersion = SchemaVersionV3
+
 // ActionTypes constants
 const (
 	ActionGameStart          = "GAME_START"
@@ -624,6 +629,40 @@ func ValidateActions(actions []json.RawMessage) error {
 	return nil
 }
 
+// effectiveActions returns the actions of a log that are in effect, in order:
+// UNDO actions and the actions they undo are left out. Like the client
+// reducer, it walks the log backwards so that an undone UNDO has no effect.
+// Malformed entries are skipped.
+func effectiveActions(log []json.RawMessage) []BaseAction {
+	actions := make([]BaseAction, 0, len(log))
+	for _, raw := range log {
+		var a BaseAction
+		if err := json.Unmarshal(raw, &a); err == nil {
+			actions = append(actions, a)
+		}
+	}
+	undone := make(map[string]bool)
+	for i := len(actions) - 1; i >= 0; i-- {
+		a := actions[i]
+		if undone[a.ID] || a.Type != ActionUndo {
+			continue
+		}
+		var p struct {
+			RefId string `json:"refId"`
+		}
+		if json.Unmarshal(a.Payload, &p) == nil && p.RefId != "" {
+			undone[p.RefId] = true
+		}
+	}
+	out := actions[:0]
+	for _, a := range actions {
+		if a.Type != ActionUndo && !undone[a.ID] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
 // ApplyActions appends multiple actions to the game state.
 func ApplyActions(g *Game, actions []json.RawMessage) (bool, error) {
 	anyChanged := false
@@ -667,7 +706,7 @@ func ApplyAction(g *Game, raw json.RawMessage) (bool, error) {
 	if action.Type == ActionGameStart {
 		g.SchemaVersion = action.SchemaVersion
 		if g.SchemaVersion == 0 {
-			g.SchemaVersion = CurrentSchemaVersion
+			g.SchemaVersion = DocumentSchemaVersion
 		}
 		var p struct {
 			ID          string      `json:"id"`
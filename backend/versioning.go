@@ -0,0 +1,163 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/raft"
+)
+
+// Oldest versions this node can interoperate with. Nodes below them are
+// refused when they join the cluster.
+const (
+	MinProtocolVersion = 1
+	MinSchemaVersion   = SchemaVersionV3
+)
+
+const clusterVersionFile = "cluster_version.json"
+
+// clusterVersion is the replicated state stored in clusterVersionFile.
+type clusterVersion struct {
+	ActiveSchemaVersion int `json:"activeSchemaVersion"`
+}
+
+// actionSchemaVersions maps action types introduced or changed after
+// MinSchemaVersion to a function returning the schema version an action of
+// that type needs. Such actions are refused until the cluster's active schema
+// version reaches that version, so that every node applies them the same way.
+var actionSchemaVersions = map[string]func(BaseAction) int{
+	// Bulk finalization (v4) does not score the game, so its GAME_FINALIZE
+	// has no finalScore. Older nodes would report such games as 0-0.
+	ActionGameFinalize: func(a BaseAction) int {
+		var p struct {
+			FinalScore json.RawMessage `json:"finalScore"`
+		}
+		if json.Unmarshal(a.Payload, &p) != nil || len(p.FinalScore) == 0 || string(p.FinalScore) == "null" {
+			return SchemaVersionV4
+		}
+		return MinSchemaVersion
+	},
+}
+
+// ActiveSchemaVersion returns the cluster-wide schema version. Actions and
+// reducer semantics newer than this version must not be used.
+func (f *FSM) ActiveSchemaVersion() int {
+	if v := int(f.activeSchemaVersion.Load()); v > 0 {
+		return v
+	}
+	return MinSchemaVersion
+}
+
+// applySetActiveSchemaVersion raises the active schema version. It never
+// goes down, so replaying an old command is harmless.
+func (f *FSM) applySetActiveSchemaVersion(v int) error {
+	if v <= f.ActiveSchemaVersion() {
+		return nil
+	}
+	if v > CurrentSchemaVersion {
+		// The leader only raises the version once every node reported
+		// support for it, so this node was downgraded since.
+		fsmLog.Error("active schema version is newer than this node supports", "active", v, "supported", CurrentSchemaVersion)
+	}
+	f.activeSchemaVersion.Store(int64(v))
+	return f.saveClusterVersion()
+}
+
+func (f *FSM) saveClusterVersion() error {
+	if f.storage == nil {
+		return nil
+	}
+	return f.storage.SaveDataFile(clusterVersionFile, clusterVersion{ActiveSchemaVersion: int(f.activeSchemaVersion.Load())})
+}
+
+func (f *FSM) loadClusterVersion() {
+	var cv clusterVersion
+	if err := f.storage.ReadDataFile(clusterVersionFile, &cv); err != nil {
+		if !os.IsNotExist(err) {
+			fsmLog.Error("failed to read cluster version", "file", clusterVersionFile, "err", err)
+		}
+		return
+	}
+	f.activeSchemaVersion.Store(int64(cv.ActiveSchemaVersion))
+}
+
+// checkJoinCompatibility returns an error if a node with the given versions
+// cannot take part in a cluster whose active schema version is active.
+func checkJoinCompatibility(protoVer, schemaVer, active int) error {
+	if protoVer == 0 || schemaVer == 0 {
+		return fmt.Errorf("node did not report its protocol and schema versions")
+	}
+	if protoVer < MinProtocolVersion {
+		return fmt.Errorf("protocol version %d is older than the minimum supported version %d", protoVer, MinProtocolVersion)
+	}
+	if schemaVer < active {
+		return fmt.Errorf("schema version %d is older than the cluster's active schema version %d", schemaVer, active)
+	}
+	return nil
+}
+
+// supportedSchemaVersion returns the highest schema version supported by
+// every server, as recorded in their metadata, capped at this node's own
+// version. It returns 0 if a server has not reported its version.
+func supportedSchemaVersion(servers []raft.Server, meta func(id string) *NodeMeta) int {
+	v := CurrentSchemaVersion
+	for _, s := range servers {
+		m := meta(string(s.ID))
+		if m == nil || m.SchemaVersion == 0 {
+			return 0
+		}
+		v = min(v, m.SchemaVersion)
+	}
+	return v
+}
+
+// raiseActiveSchemaVersion proposes a new active schema version once all
+// the servers in the configuration support it. Non-voters apply the log
+// too, so they count as well. It is called periodically on the leader.
+func (rm *RaftManager) raiseActiveSchemaVersion() {
+	cfg := rm.Raft.GetConfiguration()
+	if err := cfg.Error(); err != nil {
+		return
+	}
+	v := supportedSchemaVersion(cfg.Configuration().Servers, rm.FSM.GetNodeMeta)
+	active := rm.FSM.ActiveSchemaVersion()
+	if v <= active {
+		return
+	}
+	raftLog.Info("raising active schema version", "from", active, "to", v)
+	if _, err := rm.Propose(RaftCommand{Type: CmdSetActiveSchemaVersion, SchemaVersion: v}); err != nil {
+		raftLog.Warn("failed to raise active schema version", "err", err)
+	}
+}
+
+// checkActionSchemaVersion returns an error if the action needs a schema
+// version newer than active.
+func checkActionSchemaVersion(raw json.RawMessage, active int) error {
+	var a BaseAction
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return fmt.Errorf("malformed action JSON")
+	}
+	need := a.SchemaVersion
+	if f := actionSchemaVersions[a.Type]; f != nil {
+		need = max(need, f(a))
+	}
+	if need > active {
+		return fmt.Errorf("action %s requires schema version %d but the active schema version is %d", a.Type, need, active)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/c2FmZQ/storage/crypto"
+	"github.com/hashicorp/raft"
+)
+
+func TestCheckJoinCompatibility(t *testing.T) {
+	for _, tc := range []struct {
+		proto, schema, active int
+		ok                    bool
+	}{
+		{CurrentProtocolVersion, CurrentSchemaVersion, CurrentSchemaVersion, true},
+		{CurrentProtocolVersion, CurrentSchemaVersion + 1, CurrentSchemaVersion, true},
+		{0, CurrentSchemaVersion, CurrentSchemaVersion, false},
+		{CurrentProtocolVersion, 0, CurrentSchemaVersion, false},
+		{CurrentProtocolVersion, 3, 4, false},
+	} {
+		if err := checkJoinCompatibility(tc.proto, tc.schema, tc.active); (err == nil) != tc.ok {
+			t.Errorf("checkJoinCompatibility(%d, %d, %d) = %v, want ok=%v", tc.proto, tc.schema, tc.active, err, tc.ok)
+		}
+	}
+}
+
+func TestSupportedSchemaVersion(t *testing.T) {
+	metas := map[string]*NodeMeta{
+		"a": {NodeID: "a", SchemaVersion: CurrentSchemaVersion},
+		"b": {NodeID: "b", SchemaVersion: CurrentSchemaVersion + 1},
+		"c": {NodeID: "c", SchemaVersion: 2},
+		"d": {NodeID: "d"},
+	}
+	meta := func(id string) *NodeMeta { return metas[id] }
+	servers := func(ids ...string) []raft.Server {
+		var out []raft.Server
+		for _, id := range ids {
+			out = append(out, raft.Server{ID: raft.ServerID(id)})
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		ids  []string
+		want int
+	}{
+		{[]string{"a", "b"}, CurrentSchemaVersion}, // Capped at our own version
+		{[]string{"a", "c"}, 2},
+		{[]string{"a", "d"}, 0}, // Unknown version
+		{[]string{"a", "e"}, 0}, // No metadata
+	} {
+		if got := supportedSchemaVersion(servers(tc.ids...), meta); got != tc.want {
+			t.Errorf("supportedSchemaVersion(%v) = %d, want %d", tc.ids, got, tc.want)
+		}
+	}
+}
+
+func TestActiveSchemaVersionReplicated(t *testing.T) {
+	tmpDir := t.TempDir()
+	raftDir := filepath.Join(tmpDir, "raft")
+	s := storage.New(tmpDir, nil)
+	raftS := storage.New(raftDir, nil)
+	gs := NewGameStore(tmpDir, s)
+	ts := NewTeamStore(tmpDir, s)
+	us := NewUserIndexStore(tmpDir, s, nil)
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), nil, raftS, us)
+
+	if v := fsm.ActiveSchemaVersion(); v != MinSchemaVersion {
+		t.Fatalf("initial active schema version = %d, want %d", v, MinSchemaVersion)
+	}
+	apply := func(v int) {
+		data, _ := json.Marshal(RaftCommand{Type: CmdSetActiveSchemaVersion, SchemaVersion: v})
+		if err, _ := fsm.Apply(&raft.Log{Data: data}).(error); err != nil {
+			t.Fatalf("apply(%d): %v", v, err)
+		}
+	}
+	apply(CurrentSchemaVersion + 1)
+	apply(CurrentSchemaVersion) // Never goes down
+	if v := fsm.ActiveSchemaVersion(); v != CurrentSchemaVersion+1 {
+		t.Errorf("active schema version = %d, want %d", v, CurrentSchemaVersion+1)
+	}
+
+	// Reloaded after a restart.
+	reloaded := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), nil, raftS, us)
+	if v := reloaded.ActiveSchemaVersion(); v != CurrentSchemaVersion+1 {
+		t.Errorf("reloaded active schema version = %d, want %d", v, CurrentSchemaVersion+1)
+	}
+
+	// Carried by snapshots.
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	mk, _ := crypto.CreateAESMasterKeyForTest()
+	inner, _ := raft.NewFileSnapshotStore(raftDir, 1, io.Discard)
+	linkStore := NewLinkSnapshotStore(raftDir, tmpDir, inner, NewKeyRing(mk, "test-key"), mk)
+	sink, _ := linkStore.Create(1, 10, 1, raft.Configuration{}, 1, nil)
+	if err := snap.Persist(sink); err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+
+	tmpDir2 := t.TempDir()
+	s2 := storage.New(tmpDir2, mk)
+	gs2 := NewGameStore(tmpDir2, s2)
+	ts2 := NewTeamStore(tmpDir2, s2)
+	us2 := NewUserIndexStore(tmpDir2, s2, nil)
+	fsm2 := NewFSM(gs2, ts2, NewRegistry(gs2, ts2, us2, true), nil, storage.New(filepath.Join(tmpDir2, "raft"), mk), us2)
+	_, rc, err := linkStore.Open(sink.ID())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rc.Close()
+	if err := fsm2.Restore(rc); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if v := fsm2.ActiveSchemaVersion(); v != CurrentSchemaVersion+1 {
+		t.Errorf("restored active schema version = %d, want %d", v, CurrentSchemaVersion+1)
+	}
+}
+
+func TestJoinRejectsIncompatibleNode(t *testing.T) {
+	dataDir := t.TempDir()
+	raftDir := filepath.Join(dataDir, "raft")
+	s := storage.New(dataDir, nil)
+	gs := NewGameStore(dataDir, s)
+	ts := NewTeamStore(dataDir, s)
+	us := NewUserIndexStore(dataDir, s, nil)
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), storage.New(raftDir, nil), us)
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	raftAddr := l.Addr().String()
+	l.Close()
+	rm := NewRaftManager(raftDir, raftAddr, raftAddr, "127.0.0.1:8080", "127.0.0.1:8080", "secret", nil, fsm)
+	if err := rm.Start(true); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer rm.Shutdown()
+	for deadline := time.Now().Add(10 * time.Second); rm.Raft.State() != raft.Leader; time.Sleep(50 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no leader")
+		}
+	}
+	// Don't wait for the leader to raise the active version.
+	if err := fsm.applySetActiveSchemaVersion(CurrentSchemaVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		proto, schema int
+	}{
+		{"missing versions", 0, 0},
+		{"old schema", CurrentProtocolVersion, CurrentSchemaVersion - 1},
+	} {
+		body := fmt.Sprintf(`{"nodeId":"node2","raftAddr":"127.0.0.1:9999","httpAddr":"127.0.0.1:8888","pubKey":"dummykey","protocolVersion":%d,"schemaVersion":%d}`, tc.proto, tc.schema)
+		req := httptest.NewRequest("POST", "/api/cluster/join", bytes.NewBufferString(body))
+		req.Header.Set("X-Raft-Secret", "secret")
+		w := httptest.NewRecorder()
+		rm.handleJoin(w, req)
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: got %d %s, want 409", tc.name, w.Code, w.Body.String())
+		}
+	}
+	if cfg := rm.Raft.GetConfiguration(); cfg.Error() == nil && len(cfg.Configuration().Servers) != 1 {
+		t.Errorf("incompatible node was added: %+v", cfg.Configuration().Servers)
+	}
+}
+
+func TestCheckActionSchemaVersion(t *testing.T) {
+	actionSchemaVersions[ActionAddInning] = func(BaseAction) int { return CurrentSchemaVersion + 1 }
+	defer delete(actionSchemaVersions, ActionAddInning)
+
+	for _, tc := range []struct {
+		action string
+		ok     bool
+	}{
+		{`{"type":"PITCH"}`, true},
+		{fmt.Sprintf(`{"type":"PITCH","schemaVersion":%d}`, CurrentSchemaVersion), true},
+		{fmt.Sprintf(`{"type":"PITCH","schemaVersion":%d}`, CurrentSchemaVersion+1), false},
+		{`{"type":"ADD_INNING"}`, false},
+	} {
+		if err := checkActionSchemaVersion(json.RawMessage(tc.action), CurrentSchemaVersion); (err == nil) != tc.ok {
+			t.Errorf("checkActionSchemaVersion(%s) = %v, want ok=%v", tc.action, err, tc.ok)
+		}
+	}
+	if err := checkActionSchemaVersion(json.RawMessage(`{"type":"ADD_INNING"}`), CurrentSchemaVersion+1); err != nil {
+		t.Errorf("action rejected once its schema version is active: %v", err)
+	}
+}
+
+func TestCheckActionSchemaVersionFinalize(t *testing.T) {
+	scored := `{"type":"GAME_FINALIZE","payload":{"finalScore":{"away":1,"home":2},"stats":{}}}`
+	unscored := `{"type":"GAME_FINALIZE","payload":{"stats":{}}}`
+	for _, tc := range []struct {
+		action string
+		active int
+		ok     bool
+	}{
+		{scored, SchemaVersionV3, true},
+		{unscored, SchemaVersionV3, false},
+		{`{"type":"GAME_FINALIZE","payload":{"finalScore":null}}`, SchemaVersionV3, false},
+		{scored, SchemaVersionV4, true},
+		{unscored, SchemaVersionV4, true},
+	} {
+		if err := checkActionSchemaVersion(json.RawMessage(tc.action), tc.active); (err == nil) != tc.ok {
+			t.Errorf("checkActionSchemaVersion(%s, %d) = %v, want ok=%v", tc.action, tc.active, err, tc.ok)
+		}
+	}
+}
+
+func TestDocumentSchemaVersion(t *testing.T) {
+	// Newer schema versions only add actions; documents keep their version.
+	g := &Game{}
+	g.normalize()
+	tm := &Team{}
+	tm.normalize()
+	if g.SchemaVersion != SchemaVersionV3 || tm.SchemaVersion != SchemaVersionV3 {
+		t.Errorf("new documents stamped with v%d and v%d, want v%d", g.SchemaVersion, tm.SchemaVersion, SchemaVersionV3)
+	}
+}
@@ -0,0 +1,887 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/google/uuid"
+)
+
+// Webhook event types.
+const (
+	WebhookEventGameStarted   = "game.started"
+	WebhookEventInningEnded   = "inning.ended"
+	WebhookEventRunScored     = "run.scored"
+	WebhookEventGameFinalized = "game.finalized"
+)
+
+// Webhook scopes.
+const (
+	WebhookScopeTeam = "team"
+	WebhookScopeUser = "user"
+)
+
+const (
+	webhooksFile            = "webhooks.json"
+	webhookOutboxFile       = "webhook_outbox.json"
+	webhookMaxAttempts      = 5
+	webhookDeliveryLogSize  = 50
+	webhookQueueSize        = 1000
+	webhookWorkers          = 4
+	webhookDeliveryTimeout  = 10 * time.Second
+	webhookDefaultRetryBase = 2 * time.Second
+	webhookDrainInterval    = time.Second
+	webhookOutboxInFlight   = 100 // Outbox entries the leader delivers at a time
+)
+
+// errWebhookAddrBlocked is returned when a webhook URL resolves to an address
+// inside the cluster's network.
+var errWebhookAddrBlocked = errors.New("webhook address not allowed")
+
+// webhookBlockedPrefixes are the ranges webhooks are not delivered to in
+// addition to the private, loopback, link-local, multicast and unspecified
+// addresses: "this network", carrier-grade NAT (used by some cloud metadata
+// services), IETF protocol assignments, benchmarking and reserved addresses,
+// and NAT64 translations.
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// checkWebhookAddr refuses connections to addresses that are not public, so
+// webhooks cannot be used to reach the cluster's own services or the cloud
+// metadata endpoint. address is the "ip:port" being dialed. Loopback
+// addresses are allowed with allowLoopback, for tests.
+func checkWebhookAddr(address string, allowLoopback bool) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errWebhookAddrBlocked, address)
+	}
+	ip := ap.Addr().Unmap()
+	if ip.IsLoopback() {
+		if allowLoopback {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", errWebhookAddrBlocked, ip)
+	}
+	if ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errWebhookAddrBlocked, ip)
+	}
+	for _, p := range webhookBlockedPrefixes {
+		if p.Contains(ip) {
+			return fmt.Errorf("%w: %s", errWebhookAddrBlocked, ip)
+		}
+	}
+	return nil
+}
+
+// newWebhookClient returns the HTTP client that delivers webhooks. The
+// address is checked when each connection is dialed, after DNS resolution,
+// so a host name that resolves (or is rebound) to an internal address is
+// refused too, including on redirects. Proxies are not used, since the
+// check would only see the proxy's address.
+func newWebhookClient(allowLoopback *atomic.Bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookDeliveryTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return checkWebhookAddr(address, allowLoopback.Load())
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: webhookDeliveryTimeout, Transport: transport}
+}
+
+var webhookEventTypes = []string{
+	WebhookEventGameStarted,
+	WebhookEventInningEnded,
+	WebhookEventRunScored,
+	WebhookEventGameFinalized,
+}
+
+// Webhook is a replicated subscription that receives game lifecycle events.
+type Webhook struct {
+	ID        string   `json:"id"`
+	Scope     string   `json:"scope"`   // "team" or "user"
+	ScopeID   string   `json:"scopeId"` // Team ID or user email
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events,omitempty"` // Empty means all events
+	Disabled  bool     `json:"disabled,omitempty"`
+	CreatedBy string   `json:"createdBy"`
+	CreatedAt int64    `json:"createdAt"`
+}
+
+// Redacted returns a copy of the webhook without its signing secret.
+func (w Webhook) Redacted() Webhook {
+	w.Secret = ""
+	w.Events = append([]string(nil), w.Events...)
+	return w
+}
+
+// Validate checks that the webhook configuration is well formed.
+func (w *Webhook) Validate() error {
+	if w.ID == "" {
+		return fmt.Errorf("missing webhook id")
+	}
+	switch w.Scope {
+	case WebhookScopeTeam, WebhookScopeUser:
+	default:
+		return fmt.Errorf("invalid scope %q", w.Scope)
+	}
+	if w.ScopeID == "" {
+		return fmt.Errorf("missing scope id")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url")
+	}
+	for _, e := range w.Events {
+		if !isWebhookEventType(e) {
+			return fmt.Errorf("unknown event type %q", e)
+		}
+	}
+	return nil
+}
+
+func (w *Webhook) wants(eventType string) bool {
+	if w.Disabled {
+		return false
+	}
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func isWebhookEventType(t string) bool {
+	for _, e := range webhookEventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// canManageWebhook reports whether a user may view or modify a webhook.
+// Team webhooks require team admin access; user webhooks belong to their user.
+func canManageWebhook(userId string, w *Webhook, ts *TeamStore) bool {
+	switch w.Scope {
+	case WebhookScopeUser:
+		return normalizeEmail(w.ScopeID) == normalizeEmail(userId)
+	case WebhookScopeTeam:
+		t, err := ts.LoadTeam(w.ScopeID)
+		if err != nil || t.Status == "deleted" {
+			return false
+		}
+		return GetTeamAccess(userId, *t) >= AccessAdmin
+	}
+	return false
+}
+
+// WebhookGame is the game summary included with every event.
+type WebhookGame struct {
+	ID         string `json:"id"`
+	Date       string `json:"date,omitempty"`
+	Location   string `json:"location,omitempty"`
+	Event      string `json:"event,omitempty"`
+	Away       string `json:"away,omitempty"`
+	Home       string `json:"home,omitempty"`
+	AwayTeamID string `json:"awayTeamId,omitempty"`
+	HomeTeamID string `json:"homeTeamId,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+// WebhookEvent is the JSON body delivered to webhook endpoints.
+type WebhookEvent struct {
+	ID        string         `json:"id"`              // Stable across retries: <actionId>:<type>
+	Index     uint64         `json:"index,omitempty"` // Raft log index of the action, stable across redeliveries
+	Type      string         `json:"type"`
+	Timestamp int64          `json:"timestamp"`
+	Game      WebhookGame    `json:"game"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// WebhookDelivery records a single delivery attempt.
+type WebhookDelivery struct {
+	ID         string `json:"id"`
+	WebhookID  string `json:"webhookId"`
+	EventID    string `json:"eventId"`
+	EventType  string `json:"eventType"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Success    bool   `json:"success"`
+	Timestamp  int64  `json:"timestamp"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+type webhookJob struct {
+	hook       Webhook
+	event      WebhookEvent
+	body       []byte
+	deliveryID string
+	attempt    int
+	batch      *webhookBatch
+}
+
+// webhookBatch counts the jobs of one Dispatch call that are not finished,
+// and calls done once they all are.
+type webhookBatch struct {
+	pending atomic.Int32
+	dropped atomic.Bool
+	done    func(dropped bool)
+}
+
+// finish marks one job of the batch as finished, delivered or given up.
+func (b *webhookBatch) finish() {
+	if b != nil && b.pending.Add(-1) == 0 && b.done != nil {
+		b.done(b.dropped.Load())
+	}
+}
+
+// WebhookOutboxEntry holds the events of one applied log entry until they
+// are delivered and acknowledged.
+type WebhookOutboxEntry struct {
+	Index  uint64         `json:"index"`
+	Game   Game           `json:"game"` // Without the action log
+	Events []WebhookEvent `json:"events"`
+}
+
+// WebhookManager holds the replicated webhook configuration and outbox, and
+// delivers events. Configuration changes and outbox entries arrive through the
+// FSM; the leader delivers the outbox, see RaftManager.drainWebhooks.
+type WebhookManager struct {
+	storage *storage.Storage
+
+	mu    sync.RWMutex
+	hooks map[string]*Webhook
+
+	outboxMu sync.Mutex
+	outbox   map[uint64]*WebhookOutboxEntry
+
+	logMu      sync.Mutex
+	deliveries map[string][]WebhookDelivery
+
+	client        *http.Client
+	allowLoopback atomic.Bool
+	retryBase     time.Duration
+	queue         chan webhookJob
+	startOnce     sync.Once
+}
+
+// NewWebhookManager creates a WebhookManager and loads persisted webhooks from storage.
+func NewWebhookManager(s *storage.Storage) *WebhookManager {
+	m := &WebhookManager{
+		storage:    s,
+		hooks:      make(map[string]*Webhook),
+		outbox:     make(map[uint64]*WebhookOutboxEntry),
+		deliveries: make(map[string][]WebhookDelivery),
+		retryBase:  webhookDefaultRetryBase,
+		queue:      make(chan webhookJob, webhookQueueSize),
+	}
+	m.client = newWebhookClient(&m.allowLoopback)
+	m.load()
+	return m
+}
+
+// AllowLoopback allows deliveries to loopback addresses, which are refused
+// by default. It is meant for tests.
+func (m *WebhookManager) AllowLoopback(allow bool) {
+	m.allowLoopback.Store(allow)
+}
+
+func (m *WebhookManager) load() {
+	if m.storage == nil {
+		return
+	}
+	var outbox map[uint64]*WebhookOutboxEntry
+	if err := m.storage.ReadDataFile(webhookOutboxFile, &outbox); err == nil && outbox != nil {
+		m.outbox = outbox
+	} else if err != nil && !os.IsNotExist(err) {
+		webhookLog.Error("failed to read webhook outbox", "file", webhookOutboxFile, "err", err)
+	}
+	var hooks map[string]*Webhook
+	if err := m.storage.ReadDataFile(webhooksFile, &hooks); err != nil {
+		if !os.IsNotExist(err) {
+			webhookLog.Error("failed to read webhooks", "file", webhooksFile, "err", err)
+		}
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hooks != nil {
+		m.hooks = hooks
+	}
+}
+
+// saveLocked persists the webhook map. m.mu must be held.
+func (m *WebhookManager) saveLocked() error {
+	if m.storage == nil {
+		return nil
+	}
+	if err := m.storage.SaveDataFile(webhooksFile, m.hooks); err != nil {
+		return fmt.Errorf("failed to save webhooks: %w", err)
+	}
+	return nil
+}
+
+// Put inserts or replaces a webhook.
+func (m *WebhookManager) Put(w *Webhook) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	c := *w
+	c.Events = append([]string(nil), w.Events...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[c.ID] = &c
+	return m.saveLocked()
+}
+
+// Delete removes a webhook and its delivery log.
+func (m *WebhookManager) Delete(id string) error {
+	m.mu.Lock()
+	delete(m.hooks, id)
+	err := m.saveLocked()
+	m.mu.Unlock()
+
+	m.logMu.Lock()
+	delete(m.deliveries, id)
+	m.logMu.Unlock()
+	return err
+}
+
+// Replace swaps the full webhook set (used by snapshot restore).
+func (m *WebhookManager) Replace(hooks map[string]*Webhook) error {
+	if hooks == nil {
+		hooks = make(map[string]*Webhook)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = hooks
+	return m.saveLocked()
+}
+
+// saveOutboxLocked persists the outbox. m.outboxMu must be held.
+func (m *WebhookManager) saveOutboxLocked() error {
+	if m.storage == nil {
+		return nil
+	}
+	if err := m.storage.SaveDataFile(webhookOutboxFile, m.outbox); err != nil {
+		return fmt.Errorf("failed to save webhook outbox: %w", err)
+	}
+	return nil
+}
+
+// AddToOutbox records the events of an applied log entry for delivery.
+func (m *WebhookManager) AddToOutbox(e WebhookOutboxEntry) error {
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	m.outbox[e.Index] = &e
+	return m.saveOutboxLocked()
+}
+
+// Ack removes delivered entries from the outbox.
+func (m *WebhookManager) Ack(indexes []uint64) error {
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	n := len(m.outbox)
+	for _, i := range indexes {
+		delete(m.outbox, i)
+	}
+	if len(m.outbox) == n {
+		return nil
+	}
+	return m.saveOutboxLocked()
+}
+
+// Outbox returns the entries waiting for delivery, oldest first.
+func (m *WebhookManager) Outbox() []WebhookOutboxEntry {
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	out := make([]WebhookOutboxEntry, 0, len(m.outbox))
+	for _, e := range m.outbox {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out
+}
+
+// ReplaceOutbox swaps the full outbox (used by snapshot restore).
+func (m *WebhookManager) ReplaceOutbox(outbox map[uint64]*WebhookOutboxEntry) error {
+	if outbox == nil {
+		outbox = make(map[uint64]*WebhookOutboxEntry)
+	}
+	m.outboxMu.Lock()
+	defer m.outboxMu.Unlock()
+	m.outbox = outbox
+	return m.saveOutboxLocked()
+}
+
+// Get returns a copy of the webhook with the given ID.
+func (m *WebhookManager) Get(id string) (Webhook, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.hooks[id]
+	if !ok {
+		return Webhook{}, false
+	}
+	return *w, true
+}
+
+// List returns the webhooks registered for a scope, sorted by creation time.
+func (m *WebhookManager) List(scope, scopeID string) []Webhook {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]Webhook, 0)
+	for _, w := range m.hooks {
+		if w.Scope == scope && w.ScopeID == scopeID {
+			out = append(out, *w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Deliveries returns the most recent delivery attempts for a webhook, newest first.
+func (m *WebhookManager) Deliveries(id string) []WebhookDelivery {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+	src := m.deliveries[id]
+	out := make([]WebhookDelivery, len(src))
+	for i := range src {
+		out[i] = src[len(src)-1-i]
+	}
+	return out
+}
+
+func (m *WebhookManager) recordDelivery(d WebhookDelivery) {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+	l := append(m.deliveries[d.WebhookID], d)
+	if len(l) > webhookDeliveryLogSize {
+		l = l[len(l)-webhookDeliveryLogSize:]
+	}
+	m.deliveries[d.WebhookID] = l
+}
+
+// Matches reports whether any webhook subscribes to the events of a game.
+// userHasAccess decides whether a user-scoped webhook may see the game.
+func (m *WebhookManager) Matches(g *Game, userHasAccess func(userId string) bool) bool {
+	return len(m.matching(g, userHasAccess)) > 0
+}
+
+func (m *WebhookManager) matching(g *Game, userHasAccess func(userId string) bool) []Webhook {
+	m.mu.RLock()
+	var matched []Webhook
+	for _, w := range m.hooks {
+		switch w.Scope {
+		case WebhookScopeTeam:
+			if w.ScopeID != g.AwayTeamID && w.ScopeID != g.HomeTeamID {
+				continue
+			}
+		case WebhookScopeUser:
+			if userHasAccess == nil || !userHasAccess(w.ScopeID) {
+				continue
+			}
+		default:
+			continue
+		}
+		matched = append(matched, *w)
+	}
+	m.mu.RUnlock()
+	return matched
+}
+
+// Dispatch queues the events of a game for every matching webhook.
+// userHasAccess decides whether a user-scoped webhook may see the game.
+// done, if not nil, is called once every delivery has succeeded or was given
+// up. dropped reports whether some were not even attempted because the
+// delivery queue was full.
+func (m *WebhookManager) Dispatch(g *Game, events []WebhookEvent, userHasAccess func(userId string) bool, done func(dropped bool)) {
+	var jobs []webhookJob
+	matched := m.matching(g, userHasAccess)
+	for _, e := range events {
+		for _, w := range matched {
+			if w.wants(e.Type) {
+				jobs = append(jobs, webhookJob{hook: w, event: e, deliveryID: uuid.NewString(), attempt: 1})
+			}
+		}
+	}
+	if len(jobs) == 0 {
+		if done != nil {
+			done(false)
+		}
+		return
+	}
+	m.startOnce.Do(m.start)
+
+	batch := &webhookBatch{done: done}
+	batch.pending.Store(int32(len(jobs)))
+	bodies := make(map[string][]byte)
+	for _, job := range jobs {
+		body, ok := bodies[job.event.ID]
+		if !ok {
+			var err error
+			if body, err = json.Marshal(job.event); err != nil {
+				webhookLog.Error("failed to marshal event", "event", job.event.ID, "err", err)
+			}
+			bodies[job.event.ID] = body
+		}
+		job.batch = batch
+		if body == nil {
+			batch.finish()
+			continue
+		}
+		job.body = body
+		m.enqueue(job)
+	}
+}
+
+func (m *WebhookManager) enqueue(job webhookJob) {
+	select {
+	case m.queue <- job:
+	default:
+		webhookLog.Warn("queue full, dropping event", "event", job.event.ID, "webhook", job.hook.ID)
+		if job.batch != nil {
+			job.batch.dropped.Store(true)
+		}
+		defer job.batch.finish()
+		m.recordDelivery(WebhookDelivery{
+			ID:        job.deliveryID,
+			WebhookID: job.hook.ID,
+			EventID:   job.event.ID,
+			EventType: job.event.Type,
+			Attempt:   job.attempt,
+			Error:     "delivery queue full",
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+func (m *WebhookManager) start() {
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for job := range m.queue {
+				m.deliver(job)
+			}
+		}()
+	}
+}
+
+// signWebhookBody returns the value of the X-Skorekeeper-Signature header.
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *WebhookManager) deliver(job webhookJob) {
+	start := time.Now()
+	d := WebhookDelivery{
+		ID:        job.deliveryID,
+		WebhookID: job.hook.ID,
+		EventID:   job.event.ID,
+		EventType: job.event.Type,
+		Attempt:   job.attempt,
+		Timestamp: start.UnixMilli(),
+	}
+
+	retry := true
+	req, err := http.NewRequest(http.MethodPost, job.hook.URL, bytes.NewReader(job.body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Skorekeeper-Webhook/"+CurrentAppVersion)
+		req.Header.Set("X-Skorekeeper-Event", job.event.Type)
+		req.Header.Set("X-Skorekeeper-Delivery", job.deliveryID)
+		if job.hook.Secret != "" {
+			req.Header.Set("X-Skorekeeper-Signature", signWebhookBody(job.hook.Secret, job.body))
+		}
+		var resp *http.Response
+		resp, err = m.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			d.StatusCode = resp.StatusCode
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				d.Success = true
+			} else {
+				err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+				// Client errors are permanent, except for timeouts and rate limiting.
+				if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+					resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+					retry = false
+				}
+			}
+		}
+	} else {
+		retry = false
+	}
+	if errors.Is(err, errWebhookAddrBlocked) {
+		retry = false
+	}
+	d.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		d.Error = err.Error()
+	}
+	m.recordDelivery(d)
+
+	if d.Success || !retry || job.attempt >= webhookMaxAttempts {
+		if !d.Success {
+			webhookLog.Warn("giving up on event", "event", job.event.ID, "webhook", job.hook.ID, "attempts", job.attempt, "err", err)
+		}
+		job.batch.finish()
+		return
+	}
+
+	backoff := m.retryBase << (job.attempt - 1)
+	job.attempt++
+	time.AfterFunc(backoff, func() {
+		// Pick up configuration changes (e.g. deletion or URL edits) made since the first attempt.
+		current, ok := m.Get(job.hook.ID)
+		if !ok || !current.wants(job.event.Type) {
+			job.batch.finish()
+			return
+		}
+		job.hook = current
+		m.enqueue(job)
+	})
+}
+
+// extractWebhookEvents derives lifecycle events from actions newly appended to a game.
+// newCount is the number of actions at the end of the log that were just applied.
+//
+// The server does not run the scoring reducer, so events are derived from action
+// payloads: a run is scored when a runner outcome is "Score", a runner safely
+// advances from third, or the batter reaches Home; and a half-inning ends when play first moves past the furthest half-inning
+// reached so far. UNDO actions do not retract events that were already sent.
+func extractWebhookEvents(g *Game, newCount int) []WebhookEvent {
+	if newCount <= 0 || len(g.ActionLog) == 0 {
+		return nil
+	}
+	if newCount > len(g.ActionLog) {
+		newCount = len(g.ActionLog)
+	}
+	first := len(g.ActionLog) - newCount
+
+	summary := WebhookGame{
+		ID:         g.ID,
+		Date:       g.Date,
+		Location:   g.Location,
+		Event:      g.Event,
+		Away:       g.Away,
+		Home:       g.Home,
+		AwayTeamID: g.AwayTeamID,
+		HomeTeamID: g.HomeTeamID,
+		Status:     g.Status,
+	}
+
+	// Furthest half-inning reached before the new actions.
+	maxHalf := -1
+	for _, raw := range g.ActionLog[:first] {
+		if h, ok := actionHalfInning(raw); ok && h > maxHalf {
+			maxHalf = h
+		}
+	}
+
+	var events []WebhookEvent
+	for _, raw := range g.ActionLog[first:] {
+		var action BaseAction
+		if err := json.Unmarshal(raw, &action); err != nil {
+			continue
+		}
+		ts := action.Timestamp
+		if ts == 0 {
+			ts = time.Now().UnixMilli()
+		}
+		newEvent := func(eventType string, data map[string]any) WebhookEvent {
+			return WebhookEvent{
+				ID:        action.ID + ":" + eventType,
+				Type:      eventType,
+				Timestamp: ts,
+				Game:      summary,
+				Data:      data,
+			}
+		}
+
+		if h, ok := actionHalfInning(raw); ok {
+			if maxHalf >= 0 && h > maxHalf {
+				inning, team := halfInningParts(maxHalf)
+				events = append(events, newEvent(WebhookEventInningEnded, map[string]any{
+					"inning": inning,
+					"team":   team,
+				}))
+			}
+			if h > maxHalf {
+				maxHalf = h
+			}
+		}
+
+		switch action.Type {
+		case ActionGameStart:
+			events = append(events, newEvent(WebhookEventGameStarted, nil))
+		case ActionGameFinalize:
+			var p struct {
+				FinalScore *struct {
+					Away int `json:"away"`
+					Home int `json:"home"`
+				} `json:"finalScore"`
+			}
+			json.Unmarshal(action.Payload, &p)
+			var data map[string]any
+			if p.FinalScore != nil {
+				data = map[string]any{"finalScore": map[string]int{"away": p.FinalScore.Away, "home": p.FinalScore.Home}}
+			}
+			events = append(events, newEvent(WebhookEventGameFinalized, data))
+		case ActionPlayResult, ActionRunnerAdvance, ActionRunnerBatchUpdate:
+			if runs, team, inning := countRunsScored(action.Payload); runs > 0 {
+				events = append(events, newEvent(WebhookEventRunScored, map[string]any{
+					"runs":   runs,
+					"team":   team,
+					"inning": inning,
+				}))
+			}
+		}
+	}
+	return events
+}
+
+type playContext struct {
+	ActiveCtx *struct {
+		I int `json:"i"`
+	} `json:"activeCtx"`
+	ActiveTeam string `json:"activeTeam"`
+}
+
+// actionHalfInning returns an ordinal for the half-inning an action was recorded in.
+func actionHalfInning(raw json.RawMessage) (int, bool) {
+	var a struct {
+		Payload playContext `json:"payload"`
+	}
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return 0, false
+	}
+	p := a.Payload
+	if p.ActiveCtx == nil || p.ActiveCtx.I < 1 {
+		return 0, false
+	}
+	half := p.ActiveCtx.I * 2
+	switch p.ActiveTeam {
+	case "away":
+	case "home":
+		half++
+	default:
+		return 0, false
+	}
+	return half, true
+}
+
+func halfInningParts(half int) (int, string) {
+	if half%2 == 1 {
+		return half / 2, "home"
+	}
+	return half / 2, "away"
+}
+
+// isSafeRunnerAction mirrors the safe advance actions of RUNNER_BATCH_UPDATE in reducer.js.
+func isSafeRunnerAction(action string) bool {
+	switch action {
+	case "SB", "Adv", "Place", "BK", "Score":
+		return true
+	}
+	return strings.HasPrefix(action, "CR") || strings.HasPrefix(action, "E")
+}
+
+// countRunsScored counts runners that crossed the plate in a scoring action payload.
+func countRunsScored(payload json.RawMessage) (int, string, int) {
+	var p struct {
+		playContext
+		BipState *struct {
+			Base string `json:"base"`
+		} `json:"bipState"`
+		Runners []struct {
+			Outcome string `json:"outcome"`
+		} `json:"runners"`
+		RunnerAdvancements []struct {
+			Outcome string `json:"outcome"`
+		} `json:"runnerAdvancements"`
+		Updates []struct {
+			Action string `json:"action"`
+			Base   int    `json:"base"`
+		} `json:"updates"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return 0, "", 0
+	}
+	runs := 0
+	if p.BipState != nil && p.BipState.Base == "Home" {
+		runs++
+	}
+	for _, r := range p.Runners {
+		if r.Outcome == "Score" {
+			runs++
+		}
+	}
+	for _, r := range p.RunnerAdvancements {
+		if r.Outcome == "Score" {
+			runs++
+		}
+	}
+	for _, u := range p.Updates {
+		// A safe advance from third base crosses the plate.
+		if u.Base == 2 && isSafeRunnerAction(u.Action) {
+			runs++
+		}
+	}
+	inning := 0
+	if p.ActiveCtx != nil {
+		inning = p.ActiveCtx.I
+	}
+	return runs, p.ActiveTeam, inning
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2026 TTBT Enterprises LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c2FmZQ/storage"
+	"github.com/hashicorp/raft"
+)
+
+func TestExtractWebhookEvents(t *testing.T) {
+	g := &Game{
+		ID: "game-1",
+		ActionLog: []json.RawMessage{
+			json.RawMessage(`{"id":"a1","type":"GAME_START","payload":{}}`),
+			json.RawMessage(`{"id":"a2","type":"PITCH","payload":{"activeCtx":{"i":1},"activeTeam":"away"}}`),
+			json.RawMessage(`{"id":"a3","type":"RUNNER_ADVANCE","payload":{"activeCtx":{"i":1},"activeTeam":"away","runners":[{"outcome":"Score"},{"outcome":"To 3rd"}]}}`),
+			json.RawMessage(`{"id":"a4","type":"PLAY_RESULT","payload":{"activeCtx":{"i":1},"activeTeam":"home","bipState":{"base":"Home"},"runnerAdvancements":[{"outcome":"Score"}]}}`),
+			json.RawMessage(`{"id":"a5","type":"RUNNER_BATCH_UPDATE","payload":{"activeCtx":{"i":1},"activeTeam":"away","updates":[{"action":"SB","base":2}]}}`),
+			json.RawMessage(`{"id":"a6","type":"GAME_FINALIZE","payload":{"finalScore":{"away":2,"home":2},"stats":{}}}`),
+		},
+	}
+
+	events := extractWebhookEvents(g, len(g.ActionLog))
+	var got []string
+	for _, e := range events {
+		got = append(got, e.ID)
+	}
+	want := []string{
+		"a1:game.started",
+		"a3:run.scored",
+		"a4:inning.ended",
+		"a4:run.scored",
+		"a5:run.scored",
+		"a6:game.finalized",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if runs := events[3].Data["runs"]; runs != 2 {
+		t.Errorf("expected 2 runs on home run with runner, got %v", runs)
+	}
+	if inning := events[2].Data["team"]; inning != "away" {
+		t.Errorf("expected top half to end, got %v", inning)
+	}
+
+	// Only the tail is considered; returning to an earlier half does not end an inning.
+	tail := extractWebhookEvents(g, 2)
+	if len(tail) != 2 || tail[0].ID != "a5:run.scored" || tail[1].ID != "a6:game.finalized" {
+		t.Errorf("unexpected tail events: %+v", tail)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	tempDir := t.TempDir()
+	s := storage.New(tempDir, nil)
+	gs := NewGameStore(tempDir, s)
+	ts := NewTeamStore(tempDir, s)
+	us := NewUserIndexStore(tempDir, s, nil)
+	reg := NewRegistry(gs, ts, us, true)
+	fsm := NewFSM(gs, ts, reg, NewHubManager(), s, us)
+	fsm.webhooks.retryBase = 10 * time.Millisecond
+	fsm.webhooks.AllowLoopback(true)
+
+	type received struct {
+		event     string
+		signature string
+		body      []byte
+	}
+	var mu sync.Mutex
+	var got []received
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		got = append(got, received{r.Header.Get("X-Skorekeeper-Event"), r.Header.Get("X-Skorekeeper-Signature"), body})
+	}))
+	defer srv.Close()
+
+	teamId := "team-hooks"
+	apply := func(cmd RaftCommand) {
+		t.Helper()
+		data, _ := json.Marshal(cmd)
+		if resp := fsm.Apply(&raft.Log{Data: data}); resp != nil {
+			if err, ok := resp.(error); ok && err != nil {
+				t.Fatalf("Apply %s failed: %v", cmd.Type, err)
+			}
+		}
+	}
+
+	apply(RaftCommand{Type: CmdUpdateWebhook, Webhook: &Webhook{
+		ID:      "hook-1",
+		Scope:   WebhookScopeTeam,
+		ScopeID: teamId,
+		URL:     srv.URL,
+		Secret:  "s3cret",
+		Events:  []string{WebhookEventGameStarted},
+	}})
+	// Unrelated team: must not fire.
+	apply(RaftCommand{Type: CmdUpdateWebhook, Webhook: &Webhook{
+		ID:      "hook-2",
+		Scope:   WebhookScopeTeam,
+		ScopeID: "other-team",
+		URL:     srv.URL,
+	}})
+
+	start := `{"id":"act-1","type":"GAME_START","payload":{"id":"game-hooks","homeTeamId":"` + teamId + `","away":"A","home":"B"}}`
+	apply(RaftCommand{Type: CmdApplyAction, Action: &ActionPayload{GameID: "game-hooks", Action: json.RawMessage(start)}})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// The delivery log is written after the response is read.
+		if len(fsm.webhooks.Deliveries("hook-1")) >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 successful delivery, got %d (calls=%d)", len(got), calls)
+	}
+	if got[0].event != WebhookEventGameStarted {
+		t.Errorf("unexpected event header %q", got[0].event)
+	}
+	if want := signWebhookBody("s3cret", got[0].body); got[0].signature != want {
+		t.Errorf("signature = %q, want %q", got[0].signature, want)
+	}
+	var e WebhookEvent
+	if err := json.Unmarshal(got[0].body, &e); err != nil {
+		t.Fatalf("bad body: %v", err)
+	}
+	if e.ID != "act-1:game.started" || e.Game.ID != "game-hooks" || e.Game.HomeTeamID != teamId {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	deliveries := fsm.webhooks.Deliveries("hook-1")
+	if len(deliveries) != 2 || !deliveries[0].Success || deliveries[1].Success || deliveries[1].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected delivery log: %+v", deliveries)
+	}
+	if len(fsm.webhooks.Deliveries("hook-2")) != 0 {
+		t.Errorf("webhook for unrelated team should not fire")
+	}
+
+	// Configuration survives a restart.
+	reloaded := NewWebhookManager(s)
+	if h, ok := reloaded.Get("hook-1"); !ok || h.Secret != "s3cret" {
+		t.Errorf("webhook not persisted: %+v", h)
+	}
+
+	apply(RaftCommand{Type: CmdDeleteWebhook, ID: "hook-1"})
+	if _, ok := fsm.webhooks.Get("hook-1"); ok {
+		t.Errorf("webhook not deleted")
+	}
+}
+
+func TestWebhookValidate(t *testing.T) {
+	ok := Webhook{ID: "x", Scope: WebhookScopeUser, ScopeID: "u@example.com", URL: "https://example.com/hook"}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid webhook rejected: %v", err)
+	}
+	for name, w := range map[string]Webhook{
+		"scope":  {ID: "x", Scope: "global", ScopeID: "u", URL: "https://example.com"},
+		"scheme": {ID: "x", Scope: WebhookScopeUser, ScopeID: "u", URL: "ftp://example.com"},
+		"event":  {ID: "x", Scope: WebhookScopeUser, ScopeID: "u", URL: "https://example.com", Events: []string{"pitch"}},
+	} {
+		if err := w.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestWebhookOutbox(t *testing.T) {
+	dataDir := t.TempDir()
+	raftDir := filepath.Join(dataDir, "raft")
+	s := storage.New(dataDir, nil)
+	gs := NewGameStore(dataDir, s)
+	ts := NewTeamStore(dataDir, s)
+	us := NewUserIndexStore(dataDir, s, nil)
+	fsm := NewFSM(gs, ts, NewRegistry(gs, ts, us, true), NewHubManager(), storage.New(raftDir, nil), us)
+	rm := startTestRaft(t, raftDir, fsm)
+	fsm.webhooks.AllowLoopback(true)
+
+	var mu sync.Mutex
+	got := make(map[string]uint64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e WebhookEvent
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		defer mu.Unlock()
+		got[e.ID] = e.Index
+	}))
+	defer srv.Close()
+
+	teamId := "team-hooks"
+	if _, err := rm.Propose(RaftCommand{Type: CmdUpdateWebhook, Webhook: &Webhook{ID: "hook-1", Scope: WebhookScopeTeam, ScopeID: teamId, URL: srv.URL}}); err != nil {
+		t.Fatalf("Propose webhook: %v", err)
+	}
+	start := func(gameId, actionId, homeTeamId string) *ActionPayload {
+		return &ActionPayload{GameID: gameId, Action: json.RawMessage(`{"id":"` + actionId + `","type":"GAME_START","payload":{"id":"` + gameId + `","homeTeamId":"` + homeTeamId + `"}}`)}
+	}
+	applyAt := func(index uint64, nodeId string, payload *ActionPayload) {
+		t.Helper()
+		data, _ := json.Marshal(RaftCommand{Type: CmdApplyAction, NodeID: nodeId, Action: payload})
+		if err, _ := fsm.ApplyBatch([]*raft.Log{{Index: index, Type: raft.LogCommand, Data: data}})[0].(error); err != nil {
+			t.Fatalf("ApplyBatch: %v", err)
+		}
+	}
+
+	// Events nobody subscribes to are not recorded.
+	last := rm.Raft.LastIndex()
+	applyAt(last+1000, "other-node", start("game-unwatched", "act-unwatched", "other-team"))
+	if n := len(fsm.webhooks.Outbox()); n != 0 {
+		t.Fatalf("outbox has %d entries, want 0", n)
+	}
+
+	// The leader delivers every entry, whoever proposed it.
+	applyAt(last+1001, "other-node", start("game-other", "act-other", teamId))
+	if _, err := rm.Propose(RaftCommand{Type: CmdApplyAction, Action: start("game-proposed", "act-proposed", teamId)}); err != nil {
+		t.Fatalf("Propose action: %v", err)
+	}
+
+	// Delivered entries are acknowledged through Raft.
+	deadline := time.Now().Add(10 * time.Second)
+	for len(fsm.webhooks.Outbox()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(fsm.webhooks.Outbox()); n != 0 {
+		t.Errorf("outbox has %d entries after delivery", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got["act-other:game.started"] != last+1001 || got["act-proposed:game.started"] == 0 {
+		t.Errorf("delivered %v, want act-other at index %d and act-proposed", got, last+1001)
+	}
+}
+
+func TestCheckWebhookAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34:443":        true,
+		"[2606:2800:220:1::1]:443": true,
+		"127.0.0.1:80":             false,
+		"[::1]:80":                 false,
+		"10.1.2.3:80":              false,
+		"172.16.0.1:80":            false,
+		"192.168.1.1:80":           false,
+		"169.254.169.254:80":       false,
+		"100.100.100.200:80":       false,
+		"0.0.0.0:80":               false,
+		"[::ffff:10.0.0.1]:80":     false,
+		"[fd00::1]:80":             false,
+		"[fe80::1]:80":             false,
+		"localhost:80":             false,
+	} {
+		if err := checkWebhookAddr(addr, false); (err == nil) != want {
+			t.Errorf("checkWebhookAddr(%q) = %v, want allowed=%v", addr, err, want)
+		}
+	}
+	if err := checkWebhookAddr("127.0.0.1:80", true); err != nil {
+		t.Errorf("loopback with allowLoopback: %v", err)
+	}
+	if err := checkWebhookAddr("10.1.2.3:80", true); err == nil {
+		t.Error("private address allowed with allowLoopback")
+	}
+}
+
+func TestWebhookDeliveryToInternalAddress(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	// A name that resolves to loopback is refused like the address itself.
+	url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	m := NewWebhookManager(nil)
+	m.deliver(webhookJob{
+		hook:       Webhook{ID: "hook-1", URL: url},
+		event:      WebhookEvent{ID: "e1", Type: WebhookEventGameStarted},
+		body:       []byte(`{}`),
+		deliveryID: "d1",
+		attempt:    1,
+	})
+	d := m.Deliveries("hook-1")
+	if called || len(d) != 1 || d[0].Success || !strings.Contains(d[0].Error, "not allowed") {
+		t.Errorf("delivery to loopback: called=%v log=%+v", called, d)
+	}
+}
@@ -16,11 +16,11 @@ package backend
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -31,6 +31,9 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -71,6 +74,7 @@ const (
 	MsgTypeSyncUpdate = "SYNC_UPDATE"
 	MsgTypeConflict   = "CONFLICT"
 	MsgTypeError      = "ERROR"
+	MsgTypeReconnect  = "RECONNECT" // The node is draining; connect to another one
 )
 
 // Message represents a WebSocket message
@@ -91,11 +95,13 @@ const (
 	ReqTypeHTTPSave   = "HTTP_SAVE"
 	ReqTypeHTTPAction = "HTTP_ACTION"
 	ReqTypeBroadcast  = "BROADCAST"
+	ReqTypeDrain      = "DRAIN"
 )
 
 // HubRequest represents a request to the Hub
 type HubRequest struct {
 	Type          string
+	Ctx           context.Context  // Carries the trace span of the originating request, if any
 	Client        *wsClient        // For WS requests
 	UserId        string           // For HTTP requests
 	Headers       http.Header      // For forwarding cookies/auth
@@ -108,10 +114,19 @@ type HubRequest struct {
 	Force         bool             // For HTTP Save (Force Overwrite)
 }
 
+// context returns the trace context of the request.
+func (req HubRequest) context() context.Context {
+	if req.Ctx != nil {
+		return req.Ctx
+	}
+	return context.Background()
+}
+
 // HubResponse represents a response from the Hub
 type HubResponse struct {
 	Data  []byte // For HTTP Load
 	Error error  // For HTTP Save/Load errors
+	Count int    // For Drain: number of clients told to reconnect
 }
 
 // Hub maintains the set of active clients and broadcasts messages to the clients.
@@ -131,6 +146,9 @@ type Hub struct {
 	// Unregister requests from clients.
 	unregister chan *wsClient
 
+	// Closed when run returns.
+	done chan struct{}
+
 	// In-memory state
 	gameData *Game
 	teamData *Team
@@ -153,6 +171,7 @@ func newHub(id string, isTeam bool, gs *GameStore, ts *TeamStore, r *Registry, h
 		requests:     make(chan HubRequest, 64), // Buffered to prevent dropping FSM updates
 		register:     make(chan *wsClient),
 		unregister:   make(chan *wsClient),
+		done:         make(chan struct{}),
 		clients:      make(map[*wsClient]bool),
 		lastConflict: make(map[string]time.Time),
 		gs:           gs,
@@ -164,6 +183,7 @@ func newHub(id string, isTeam bool, gs *GameStore, ts *TeamStore, r *Registry, h
 }
 
 func (h *Hub) run() {
+	defer close(h.done)
 	idleTimer := time.NewTicker(5 * time.Minute)
 	defer idleTimer.Stop()
 
@@ -179,6 +199,10 @@ func (h *Hub) run() {
 				h.hm.DecConnectionCount()
 			}
 		case req := <-h.requests:
+			if req.Type == ReqTypeDrain {
+				req.Reply <- HubResponse{Count: h.drainClients()}
+				continue
+			}
 			if h.isTeam {
 				h.ensureTeamLoaded(req.Reply)
 			} else {
@@ -208,9 +232,9 @@ func (h *Hub) run() {
 			case ReqTypeHTTPLoad:
 				h.handleHTTPLoad(req.Reply)
 			case ReqTypeHTTPSave:
-				h.handleHTTPSave(req.Payload, req.Reply, req.Force)
+				h.handleHTTPSave(req.context(), req.Payload, req.UserId, req.Reply, req.Force)
 			case ReqTypeBroadcast:
-				h.handleBroadcast(req.Payload, req.SkipBroadcast, req.NumActions)
+				h.handleBroadcast(req.context(), req.Payload, req.SkipBroadcast, req.NumActions)
 			}
 		case <-idleTimer.C:
 			if len(h.clients) == 0 {
@@ -221,10 +245,29 @@ func (h *Hub) run() {
 	}
 }
 
-func (h *Hub) handleBroadcast(data []byte, skipBroadcast bool, numActions int) {
+// drainClients tells every client to reconnect to another node and closes its
+// connection once that message is written.
+func (h *Hub) drainClients() int {
+	n := len(h.clients)
+	for c := range h.clients {
+		c.sendJSON(Message{Type: MsgTypeReconnect, Error: "Server is draining for maintenance"})
+		delete(h.clients, c)
+		close(c.send)
+		h.hm.DecConnectionCount()
+	}
+	return n
+}
+
+func (h *Hub) handleBroadcast(ctx context.Context, data []byte, skipBroadcast bool, numActions int) {
+	_, span := tracer().Start(ctx, "Hub.broadcast", trace.WithAttributes(
+		attribute.String("skorekeeper.game_id", h.resourceId),
+		attribute.Int("skorekeeper.clients", len(h.clients)),
+	))
+	defer span.End()
+
 	var g Game
 	if err := json.Unmarshal(data, &g); err != nil {
-		log.Printf("handleBroadcast: Error unmarshaling game data: %v", err)
+		hubLog.ErrorContext(ctx, "broadcast: failed to unmarshal game data", "err", err)
 		return
 	}
 
@@ -262,7 +305,7 @@ func (h *Hub) ensureLoaded(reply chan HubResponse) {
 			h.gameData = &Game{ID: h.resourceId}
 			return
 		}
-		log.Printf("Hub: Error loading game %s: %v", h.resourceId, err)
+		hubLog.Error("failed to load game", "gameId", h.resourceId, "err", err)
 		if reply != nil {
 			reply <- HubResponse{Error: err}
 		}
@@ -282,7 +325,7 @@ func (h *Hub) ensureTeamLoaded(reply chan HubResponse) {
 			h.teamData = &Team{ID: h.resourceId}
 			return
 		}
-		log.Printf("Hub: Error loading team %s: %v", h.resourceId, err)
+		hubLog.Error("failed to load team", "teamId", h.resourceId, "err", err)
 		if reply != nil {
 			reply <- HubResponse{Error: err}
 		}
@@ -297,6 +340,8 @@ type HubManager struct {
 	mu                sync.Mutex
 	rm                *RaftManager
 	activeConnections atomic.Int64
+	draining          atomic.Bool
+	primary           string // Set on read replicas: the URL where scorers must go
 }
 
 func NewHubManager() *HubManager {
@@ -323,6 +368,78 @@ func (hm *HubManager) GetTotalConnectionCount() int {
 	return int(hm.activeConnections.Load())
 }
 
+// SetReadOnly makes the hubs refuse actions, directing scorers to primary.
+// It is used on read replicas.
+func (hm *HubManager) SetReadOnly(primary string) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	hm.primary = primary
+}
+
+// ReadOnlyPrimary returns the URL of the primary cluster when the hubs are
+// read-only, or "".
+func (hm *HubManager) ReadOnlyPrimary() string {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.primary
+}
+
+// SetDraining sets whether new WebSocket connections are refused.
+func (hm *HubManager) SetDraining(draining bool) {
+	hm.draining.Store(draining)
+}
+
+// Draining reports whether new WebSocket connections are refused.
+func (hm *HubManager) Draining() bool {
+	return hm.draining.Load()
+}
+
+// Drain tells the clients of every hub to reconnect to another node. It
+// returns once each hub has finished the requests queued before the drain,
+// with the number of clients that were told to reconnect.
+func (hm *HubManager) Drain(ctx context.Context) (int, error) {
+	hm.mu.Lock()
+	hubs := make([]*Hub, 0, len(hm.hubs))
+	for _, h := range hm.hubs {
+		hubs = append(hubs, h)
+	}
+	hm.mu.Unlock()
+
+	var total int
+	for _, h := range hubs {
+		reply := make(chan HubResponse, 1)
+		select {
+		case h.requests <- HubRequest{Type: ReqTypeDrain, Ctx: ctx, Reply: reply}:
+		case <-h.done:
+			continue
+		case <-ctx.Done():
+			return total, ctx.Err()
+		}
+		select {
+		case resp := <-reply:
+			total += resp.Count
+		case <-h.done: // Exited while idle
+		case <-ctx.Done():
+			return total, ctx.Err()
+		}
+	}
+	return total, nil
+}
+
+// HubCounts returns the number of active game and team hubs.
+func (hm *HubManager) HubCounts() (games, teams int) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	for key := range hm.hubs {
+		if strings.HasPrefix(key, "team:") {
+			teams++
+		} else {
+			games++
+		}
+	}
+	return games, teams
+}
+
 func (hm *HubManager) GetHub(id string, isTeam bool, gs *GameStore, ts *TeamStore, r *Registry) *Hub {
 	hm.mu.Lock()
 	defer hm.mu.Unlock()
@@ -359,7 +476,7 @@ func (hm *HubManager) Clear() {
 	hm.hubs = make(map[string]*Hub)
 }
 
-func (hm *HubManager) BroadcastToGame(gameId string, data []byte, skipBroadcast bool, numActions int) {
+func (hm *HubManager) BroadcastToGame(ctx context.Context, gameId string, data []byte, skipBroadcast bool, numActions int) {
 	hm.mu.Lock()
 	defer hm.mu.Unlock()
 
@@ -372,13 +489,14 @@ func (hm *HubManager) BroadcastToGame(gameId string, data []byte, skipBroadcast
 	select {
 	case hub.requests <- HubRequest{
 		Type:          ReqTypeBroadcast,
+		Ctx:           ctx,
 		Payload:       data,
 		SkipBroadcast: skipBroadcast,
 		NumActions:    numActions,
 	}:
 	default:
 		// Handle full channel - for now, we just log it or drop it to prevent blocking Raft FSM
-		log.Printf("Warning: Hub channel full, dropping broadcast for game %s", gameId)
+		hubLog.WarnContext(ctx, "hub channel full, dropping broadcast", "gameId", gameId)
 	}
 }
 
@@ -404,7 +522,10 @@ type wsClient struct {
 // readPump pumps messages from the websocket connection to the hub.
 func (c *wsClient) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done: // Drained clients may outlive an idle hub
+		}
 		c.conn.Close()
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
@@ -415,7 +536,7 @@ func (c *wsClient) readPump() {
 		err := c.conn.ReadJSON(&msg)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("error: %v", err)
+				hubLog.Warn("unexpected websocket close", "userId", maskEmail(c.userId), "err", err)
 			}
 			break
 		}
@@ -426,7 +547,7 @@ func (c *wsClient) readPump() {
 		case "PING":
 			c.sendJSON(Message{Type: "PONG"})
 		default:
-			log.Printf("Unknown message type: %s", msg.Type)
+			hubLog.Warn("unknown message type", "type", msg.Type)
 			c.sendJSON(Message{Type: MsgTypeError, Error: "Unknown message type"})
 		}
 	}
@@ -475,12 +596,12 @@ func (h *Hub) handleWSJoin(c *wsClient, msg Message) {
 	if len(h.gameData.ActionLog) > 0 || h.gameData.OwnerID != "" {
 		access := GetGameAccess(c.userId, *h.gameData, h.r.teamStore)
 		if access < AccessRead {
-			log.Printf("Forbidden: User %s attempted to join game %s without permissions", maskEmail(c.userId), h.resourceId)
+			hubLog.Warn("forbidden: join without permission", "userId", maskEmail(c.userId), "gameId", h.resourceId)
 			c.sendJSON(Message{Type: MsgTypeError, Error: "Forbidden: You do not have access to this game"})
 			return
 		}
 	} else if msg.LastRevision != "" {
-		log.Printf("Conflict: Client joining game %s with revision %s, but game empty on server", h.resourceId, msg.LastRevision)
+		hubLog.Info("conflict: join with revision of empty game", "gameId", h.resourceId, "revision", msg.LastRevision)
 		c.sendJSON(Message{Type: MsgTypeConflict, Error: "Game not found on server", BaseRevision: ""})
 		return
 	}
@@ -509,12 +630,19 @@ func (h *Hub) handleWSJoin(c *wsClient, msg Message) {
 }
 
 func (h *Hub) handleHTTPAction(req HubRequest) {
-	response, broadcasts, err := h.processAction(req.Message, req.UserId)
+	ctx, span := tracer().Start(req.context(), "Hub.processAction", trace.WithAttributes(
+		attribute.String("skorekeeper.game_id", h.resourceId),
+		attribute.Int("skorekeeper.actions", max(len(req.Message.Actions), 1)),
+	))
+	defer span.End()
+
+	response, broadcasts, err := h.processAction(ctx, req.Message, req.UserId)
 	if err != nil {
 		if errors.Is(err, ErrNotLeader) {
-			h.forwardToLeader(req)
+			h.forwardToLeader(ctx, req)
 			return
 		}
+		spanError(span, err)
 		if req.Reply != nil {
 			req.Reply <- HubResponse{Error: err}
 		}
@@ -533,8 +661,47 @@ func (h *Hub) handleHTTPAction(req HubRequest) {
 	}
 }
 
-func (h *Hub) forwardToLeader(req HubRequest) {
+// submitAction sends an HTTP action request to the Hub and waits for it to be
+// applied. It returns 0 on success, or an HTTP status and an error message.
+func (h *Hub) submitAction(ctx context.Context, req HubRequest) (int, string) {
+	reply := make(chan HubResponse, 1)
+	req.Type = ReqTypeHTTPAction
+	req.Ctx = ctx
+	req.Reply = reply
+	select {
+	case h.requests <- req:
+	case <-ctx.Done():
+		return http.StatusServiceUnavailable, ctx.Err().Error()
+	}
+	var resp HubResponse
+	select {
+	case resp = <-reply:
+	case <-ctx.Done():
+		return http.StatusServiceUnavailable, ctx.Err().Error()
+	}
+	var msg Message
+	if resp.Error == nil {
+		resp.Error = json.Unmarshal(resp.Data, &msg)
+	}
+	switch {
+	case resp.Error != nil:
+		return http.StatusInternalServerError, resp.Error.Error()
+	case msg.Type == MsgTypeConflict:
+		return http.StatusConflict, msg.Error
+	case msg.Type == MsgTypeError && strings.HasPrefix(msg.Error, "Forbidden"):
+		return http.StatusForbidden, msg.Error
+	case msg.Type == MsgTypeError:
+		return http.StatusBadRequest, msg.Error
+	}
+	return 0, ""
+}
+
+func (h *Hub) forwardToLeader(ctx context.Context, req HubRequest) {
+	ctx, span := tracer().Start(ctx, "Hub.forwardToLeader", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	leaderAddr := h.rm.GetLeaderHTTPAddr()
+	span.SetAttributes(attribute.String("skorekeeper.leader", leaderAddr))
 
 	// Prevent forwarding to self if split-brain or stale metadata
 	if leaderAddr == h.rm.ClusterAdvertise {
@@ -587,17 +754,23 @@ func (h *Hub) forwardToLeader(req HubRequest) {
 	if h.rm.Secret != "" {
 		forwardReq.Header.Set("X-Raft-Secret", h.rm.Secret)
 	}
+	tracePropagator.Inject(ctx, propagation.HeaderCarrier(forwardReq.Header))
+	if id := requestIDFromContext(ctx); id != "" {
+		forwardReq.Header.Set(requestIDHeader, id)
+	}
 
 	// Use secure mTLS transport for internal forwarding
 	client := h.rm.GetHTTPClient()
 	resp, err := client.Do(forwardReq)
 	if err != nil {
+		spanError(span, err)
 		if req.Reply != nil {
 			req.Reply <- HubResponse{Error: err}
 		}
 		return
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
@@ -613,7 +786,10 @@ func (h *Hub) forwardToLeader(req HubRequest) {
 	}
 }
 
-func (h *Hub) processAction(msg Message, userId string) (response *Message, broadcasts []Message, err error) {
+func (h *Hub) processAction(ctx context.Context, msg Message, userId string) (response *Message, broadcasts []Message, err error) {
+	if primary := h.hm.ReadOnlyPrimary(); primary != "" {
+		return &Message{Type: MsgTypeError, Error: "This server is a read-only replica. Score at " + primary}, nil, nil
+	}
 	var actions []json.RawMessage
 	if len(msg.Actions) > 0 {
 		if len(msg.Actions) > 100 {
@@ -621,13 +797,13 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 		}
 		actions = msg.Actions
 		if err := ValidateActions(actions); err != nil {
-			log.Printf("Invalid actions payload from user %s: %v", maskEmail(userId), err)
+			hubLog.WarnContext(ctx, "invalid actions payload", "userId", maskEmail(userId), "err", err)
 			return &Message{Type: MsgTypeError, Error: "Malformed actions: " + err.Error()}, nil, nil
 		}
 	} else {
 		actions = []json.RawMessage{msg.Action}
 		if err := ValidateAction(msg.Action); err != nil {
-			log.Printf("Invalid action payload from user %s: %v", maskEmail(userId), err)
+			hubLog.WarnContext(ctx, "invalid action payload", "userId", maskEmail(userId), "err", err)
 			return &Message{Type: MsgTypeError, Error: "Malformed action: " + err.Error()}, nil, nil
 		}
 	}
@@ -671,7 +847,7 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 			}
 		} else {
 			if actionAccess < AccessWrite {
-				log.Printf("Forbidden: User %s attempted to write action %s to game %s", maskEmail(userId), meta.Type, h.resourceId)
+				hubLog.WarnContext(ctx, "forbidden: write without permission", "userId", maskEmail(userId), "action", meta.Type, "gameId", h.resourceId)
 				if userId == "" {
 					return &Message{Type: MsgTypeError, Error: "Unauthenticated: Login required"}, nil, nil
 				} else {
@@ -682,7 +858,7 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 	}
 
 	if !gameExists && !isGameStart {
-		log.Printf("Conflict: User %s sending action for non-existent game %s", maskEmail(userId), h.resourceId)
+		hubLog.InfoContext(ctx, "conflict: action for non-existent game", "userId", maskEmail(userId), "gameId", h.resourceId)
 		return &Message{Type: MsgTypeConflict, Error: "Game not found on server", BaseRevision: ""}, nil, nil
 	}
 
@@ -691,6 +867,18 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 		return nil, nil, ErrNotLeader
 	}
 
+	// New action semantics are only accepted once every node supports them.
+	activeVersion := CurrentSchemaVersion
+	if h.rm != nil {
+		activeVersion = h.rm.FSM.ActiveSchemaVersion()
+	}
+	for _, raw := range actions {
+		if err := checkActionSchemaVersion(raw, activeVersion); err != nil {
+			hubLog.WarnContext(ctx, "action rejected: schema version not active", "userId", maskEmail(userId), "err", err)
+			return &Message{Type: MsgTypeError, Error: err.Error()}, nil, nil
+		}
+	}
+
 	currentServerRevision := getCurrentRevision(h.gameData.ActionLog)
 
 	if len(h.gameData.ActionLog) > 0 && msg.BaseRevision != currentServerRevision {
@@ -725,7 +913,7 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 				}
 				if !found {
 					// Base not found in log: Real Conflict (Fork)
-					log.Printf("Conflict: Base revision %s not found in log (Head: %s) for user %s", msg.BaseRevision, currentServerRevision, maskEmail(userId))
+					hubLog.InfoContext(ctx, "conflict: base revision not found", "base", msg.BaseRevision, "head", currentServerRevision, "userId", maskEmail(userId))
 					h.conflictMu.Lock()
 					defer h.conflictMu.Unlock()
 					h.lastConflict[userId] = time.Now()
@@ -786,8 +974,9 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 			Type:   CmdApplyAction,
 			ID:     h.resourceId,
 			Action: actionPayload,
+			UserID: userId,
 		}
-		if _, err := h.rm.Propose(cmd); err != nil {
+		if _, err := h.rm.ProposeContext(ctx, cmd); err != nil {
 			return nil, nil, err
 		}
 		// Success!
@@ -828,12 +1017,12 @@ func (h *Hub) processAction(msg Message, userId string) (response *Message, broa
 	// Collect broadcast messages
 	var msgs []Message
 	if len(msg.Actions) > 0 {
-		log.Printf("Broadcasting batch of %d actions for game %s", len(msg.Actions), h.resourceId)
+		hubLog.DebugContext(ctx, "broadcasting actions", "count", len(msg.Actions), "gameId", h.resourceId)
 		for _, a := range msg.Actions {
 			msgs = append(msgs, Message{Type: MsgTypeAction, Action: a})
 		}
 	} else if msg.Action != nil {
-		log.Printf("Broadcasting single action for game %s", h.resourceId)
+		hubLog.DebugContext(ctx, "broadcasting action", "gameId", h.resourceId)
 		msgs = append(msgs, Message{Type: MsgTypeAction, Action: msg.Action})
 	}
 
@@ -862,7 +1051,7 @@ func (h *Hub) handleHTTPLoad(reply chan HubResponse) {
 	reply <- HubResponse{Data: data, Error: err}
 }
 
-func (h *Hub) handleHTTPSave(payload []byte, reply chan HubResponse, force bool) {
+func (h *Hub) handleHTTPSave(ctx context.Context, payload []byte, userId string, reply chan HubResponse, force bool) {
 	if h.rm != nil {
 		cmdType := CmdSaveGame
 		var cmd RaftCommand
@@ -877,6 +1066,7 @@ func (h *Hub) handleHTTPSave(payload []byte, reply chan HubResponse, force bool)
 				ID:       h.resourceId,
 				TeamData: &raw,
 				Force:    force,
+				UserID:   userId,
 			}
 		} else {
 			raw := json.RawMessage(payload)
@@ -885,10 +1075,11 @@ func (h *Hub) handleHTTPSave(payload []byte, reply chan HubResponse, force bool)
 				ID:       h.resourceId,
 				GameData: &raw,
 				Force:    force,
+				UserID:   userId,
 			}
 		}
 
-		if _, err := h.rm.Propose(cmd); err != nil {
+		if _, err := h.rm.ProposeContext(ctx, cmd); err != nil {
 			reply <- HubResponse{Error: err}
 			return
 		}
@@ -968,7 +1159,7 @@ func getActionsSince(log []json.RawMessage, revision string) []json.RawMessage {
 }
 
 // ServeWS handles websocket requests from the peer.
-func ServeWS(gs *GameStore, ts *TeamStore, r *Registry, hm *HubManager, w http.ResponseWriter, r_req *http.Request, debugf func(string, ...any)) {
+func ServeWS(gs *GameStore, ts *TeamStore, r *Registry, hm *HubManager, w http.ResponseWriter, r_req *http.Request) {
 	userId := getUserID(r_req)
 
 	gameId := r_req.URL.Query().Get("gameId")
@@ -977,9 +1168,17 @@ func ServeWS(gs *GameStore, ts *TeamStore, r *Registry, hm *HubManager, w http.R
 		return
 	}
 
+	// A draining node sends its clients elsewhere; the load balancer
+	// retries another node.
+	if hm.Draining() {
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "Server is draining", http.StatusServiceUnavailable)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r_req, nil)
 	if err != nil {
-		log.Println(err)
+		hubLog.Warn("websocket upgrade failed", "err", err)
 		return
 	}
 
@@ -63,7 +63,7 @@ import { TeamController } from './TeamController.js';
 import { DashboardController } from './DashboardController.js';
 import { ActiveGameController } from './ActiveGameController.js';
 import { ProfileController } from './ProfileController.js';
-import { parseQuery, buildQuery } from '../utils/searchParser.js';
+import { parseQuery, buildQuery, matchesQuery } from '../utils/searchParser.js';
 import { PullToRefresh } from '../ui/pullToRefresh.js';
 
 /**
@@ -5620,82 +5620,55 @@ export class AppController {
             // If I use `team:Yankees` in the query, _matchesGame won't find `f.key === 'team'`.
             // So I should implement a local matcher here.
 
-            const matchesStats = (g, q) => {
+            const matchesStats = (g, q) => matchesQuery(q.expr, term => {
                 // 1. Free Text
-                for (const token of q.tokens) {
-                    const t = token.toLowerCase();
-                    const match = (g.event || '').toLowerCase().includes(t) ||
-                                  (g.location || '').toLowerCase().includes(t) ||
-                                  (g.away || '').toLowerCase().includes(t) ||
-                                  (g.home || '').toLowerCase().includes(t);
-                    if (!match) {
-                        return false;
-                    }
+                if (term.op === 'text') {
+                    const t = term.text.toLowerCase();
+                    return (g.event || '').toLowerCase().includes(t) ||
+                           (g.location || '').toLowerCase().includes(t) ||
+                           (g.away || '').toLowerCase().includes(t) ||
+                           (g.home || '').toLowerCase().includes(t);
                 }
                 // 2. Filters
-                for (const f of q.filters) {
-                    const val = f.value.toLowerCase();
-                    if (f.key === 'event' && !(g.event || '').toLowerCase().includes(val)) {
-                        return false;
-                    }
-                    if (f.key === 'location' && !(g.location || '').toLowerCase().includes(val)) {
-                        return false;
-                    }
-                    // "team" key matches either name or ID
-                    if (f.key === 'team') {
+                const f = term.filter;
+                const val = f.value.toLowerCase();
+                switch (f.key) {
+                    case 'event':
+                        return (g.event || '').toLowerCase().includes(val);
+                    case 'location':
+                        return (g.location || '').toLowerCase().includes(val);
+                    case 'team': {
+                        // "team" key matches either name or ID
                         const matchesName = (g.away || '').toLowerCase().includes(val) || (g.home || '').toLowerCase().includes(val);
                         // val is lowercased above. IDs should be compared case-insensitively or assuming lower.
                         // We check direct match for ID.
                         const matchesId = (g.awayTeamId === f.value) || (g.homeTeamId === f.value) || (g.awayTeamId === val) || (g.homeTeamId === val);
-                        if (!matchesName && !matchesId) {
-                            return false;
-                        }
-                    }
-
-                    if (f.key === 'away' && !(g.away || '').toLowerCase().includes(val)) {
-                        return false;
+                        return matchesName || matchesId;
                     }
-                    if (f.key === 'home' && !(g.home || '').toLowerCase().includes(val)) {
-                        return false;
-                    }
-
-                    if (f.key === 'date') {
+                    case 'away':
+                        return (g.away || '').toLowerCase().includes(val);
+                    case 'home':
+                        return (g.home || '').toLowerCase().includes(val);
+                    case 'date': {
                         const d = g.date || '';
-                        if (f.operator === '=') {
-                            if (!d.startsWith(f.value)) {
-                                return false;
-                            }
-                        }
-                        else if (f.operator === '>=') {
-                            if (!(d >= f.value)) {
-                                return false;
-                            }
-                        }
-                        else if (f.operator === '<=') {
-                            if (!(d <= f.value)) {
-                                return false;
-                            }
-                        }
-                        else if (f.operator === '>') {
-                            if (!(d > f.value)) {
-                                return false;
-                            }
-                        }
-                        else if (f.operator === '<') {
-                            if (!(d < f.value)) {
-                                return false;
-                            }
-                        }
-                        else if (f.operator === '..') {
-                            const maxVal = f.maxValue + '~';
-                            if (!(d >= f.value && d <= maxVal)) {
-                                return false;
-                            }
+                        switch (f.operator) {
+                            case '=':
+                                return d.startsWith(f.value);
+                            case '>=':
+                                return d >= f.value;
+                            case '<=':
+                                return d <= f.value;
+                            case '>':
+                                return d > f.value;
+                            case '<':
+                                return d < f.value;
+                            case '..':
+                                return d >= f.value && d <= f.maxValue + '~';
                         }
                     }
                 }
                 return true;
-            };
+            });
 
             const finalFiltered = accessibleGames.filter(g => matchesStats(g, parsedQ));
 
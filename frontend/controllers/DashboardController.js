@@ -18,7 +18,7 @@ import {
     SyncStatusRemoteOnly,
     SyncStatusLocalOnly,
 } from '../constants.js';
-import { parseQuery } from '../utils/searchParser.js';
+import { parseQuery, matchesQuery, compareNumber } from '../utils/searchParser.js';
 import { PullToRefresh } from '../ui/pullToRefresh.js';
 
 export class DashboardController {
@@ -353,73 +353,104 @@ export class DashboardController {
     }
 
     _matchesGame(g, parsedQ) {
-        // 1. Free Text (AND)
-        for (const token of parsedQ.tokens) {
-            const t = token.toLowerCase();
-            const match = (g.event || '').toLowerCase().includes(t) ||
-                          (g.location || '').toLowerCase().includes(t) ||
-                          (g.away || '').toLowerCase().includes(t) ||
-                          (g.home || '').toLowerCase().includes(t);
-            if (!match) {
-                return false;
-            }
+        return matchesQuery(parsedQ.expr, term => this._matchesGameTerm(g, term));
+    }
+
+    /**
+     * Matches one free text or filter term of a search query.
+     * @param {object} g The game.
+     * @param {object} term A 'text' or 'filter' node of the query expression.
+     * @returns {boolean}
+     */
+    _matchesGameTerm(g, term) {
+        if (term.op === 'text') {
+            const t = term.text.toLowerCase();
+            return (g.event || '').toLowerCase().includes(t) ||
+                   (g.location || '').toLowerCase().includes(t) ||
+                   (g.away || '').toLowerCase().includes(t) ||
+                   (g.home || '').toLowerCase().includes(t);
         }
-        // 2. Filters
-        for (const f of parsedQ.filters) {
-            if (f.key === 'is') {
-                continue;
-            }
-            const val = f.value.toLowerCase();
 
-            if (f.key === 'event' && !(g.event || '').toLowerCase().includes(val)) {
-                return false;
+        const f = term.filter;
+        const val = f.value.toLowerCase();
+        switch (f.key) {
+            case 'event':
+                return (g.event || '').toLowerCase().includes(val);
+            case 'location':
+                return (g.location || '').toLowerCase().includes(val);
+            case 'away':
+                return (g.away || '').toLowerCase().includes(val);
+            case 'home':
+                return (g.home || '').toLowerCase().includes(val);
+            case 'team': {
+                const matchesName = (g.away || '').toLowerCase().includes(val) || (g.home || '').toLowerCase().includes(val);
+                return matchesName || g.awayTeamId === val || g.homeTeamId === val;
             }
-            if (f.key === 'location' && !(g.location || '').toLowerCase().includes(val)) {
-                return false;
-            }
-            if (f.key === 'away' && !(g.away || '').toLowerCase().includes(val)) {
-                return false;
-            }
-            if (f.key === 'home' && !(g.home || '').toLowerCase().includes(val)) {
-                return false;
-            }
-
-            if (f.key === 'date') {
-                const d = g.date || '';
+            case 'status':
+                return (g.status || 'ongoing') === val;
+            case 'player':
+                return this._gamePlayers(g).some(p => (p.name || '').toLowerCase().includes(val));
+            case 'number': {
+                const numberOf = p => String(p.number || '').trim().replace(/^#/, '');
                 if (f.operator === '=') {
-                    if (!d.startsWith(f.value)) {
-                        return false;
-                    }
-                }
-                else if (f.operator === '>=') {
-                    if (!(d >= f.value)) {
-                        return false;
-                    }
+                    const num = val.replace(/^#/, '');
+                    return this._gamePlayers(g).some(p => numberOf(p) === num);
                 }
-                else if (f.operator === '<=') {
-                    if (!(d <= f.value)) {
-                        return false;
-                    }
-                }
-                else if (f.operator === '>') {
-                    if (!(d > f.value)) {
-                        return false;
-                    }
-                }
-                else if (f.operator === '<') {
-                    if (!(d < f.value)) {
-                        return false;
-                    }
+                const cmp = { ...f, value: val.replace(/^#/, ''), maxValue: f.maxValue.replace(/^#/, '') };
+                return this._gamePlayers(g).some(p => numberOf(p) !== '' && compareNumber(Number(numberOf(p)), cmp));
+            }
+            case 'owner':
+            case 'role': {
+                // Team roles are only known to the server; local games match
+                // on the owner and the game's own permissions.
+                const me = (this.app.auth.getUser()?.email || '').toLowerCase();
+                const owner = (g.ownerId || '').toLowerCase();
+                if (f.key === 'owner') {
+                    return owner === (val === 'me' ? me : val);
                 }
-                else if (f.operator === '..') {
-                    // Inclusive range: use ~ to make upper bound cover suffixes
-                    const maxVal = f.maxValue + '~';
-                    if (!(d >= f.value && d <= maxVal)) {
-                        return false;
-                    }
+                const perm = owner && owner === me ? 'admin' : g.permissions?.users?.[me];
+                const roles = { owner: owner && owner === me, admin: perm === 'admin', scorekeeper: perm === 'write', spectator: perm === 'read' };
+                return Boolean(me && roles[val]);
+            }
+            case 'date': {
+                const d = g.date || '';
+                switch (f.operator) {
+                    case '=':
+                        return d.startsWith(f.value);
+                    case '>=':
+                        return d >= f.value;
+                    case '<=':
+                        return d <= f.value;
+                    case '>':
+                        return d > f.value;
+                    case '<':
+                        return d < f.value;
+                    case '..':
+                        // Inclusive range: use ~ to make upper bound cover suffixes
+                        return d >= f.value && d <= f.maxValue + '~';
                 }
+                return true;
             }
         }
+        // Unknown keys and flags (is:local) match.
         return true;
     }
+
+    /**
+     * Returns the players of both sides of a game: starters, substitutes and bench.
+     * @param {object} g The game.
+     * @returns {Array<object>} The players, with duplicates.
+     */
+    _gamePlayers(g) {
+        const players = [];
+        for (const slots of Object.values(g.roster || {})) {
+            for (const slot of slots || []) {
+                players.push(slot.starter || {}, slot.current || {}, ...(slot.history || []));
+            }
+        }
+        for (const subs of Object.values(g.subs || {})) {
+            players.push(...(subs || []));
+        }
+        return players;
+    }
 }
@@ -21,7 +21,7 @@ import {
     SyncStatusError,
     SyncStatusRemoteOnly,
 } from '../constants.js';
-import { parseQuery } from '../utils/searchParser.js';
+import { parseQuery, matchesQuery } from '../utils/searchParser.js';
 import { PullToRefresh } from '../ui/pullToRefresh.js';
 
 export class TeamController {
@@ -469,22 +469,16 @@ export class TeamController {
     }
 
     _matchesTeam(t, parsedQ) {
-        // 1. Free Text (AND)
-        for (const token of parsedQ.tokens) {
-            if (!(t.name || '').toLowerCase().includes(token.toLowerCase())) {
-                return false;
+        return matchesQuery(parsedQ.expr, term => {
+            if (term.op === 'text') {
+                return (t.name || '').toLowerCase().includes(term.text.toLowerCase());
             }
-        }
-        // 2. Filters
-        for (const f of parsedQ.filters) {
-            if (f.key === 'is') {
-                continue;
-            }
-            if (f.key === 'name' && !(t.name || '').toLowerCase().includes(f.value.toLowerCase())) {
-                return false;
+            if (term.filter.key === 'name') {
+                return (t.name || '').toLowerCase().includes(term.filter.value.toLowerCase());
             }
-        }
-        return true;
+            // Unknown keys and flags (is:local) match.
+            return true;
+        });
     }
 
     async loadMore() {
@@ -643,6 +643,19 @@ export class SyncManager {
                 }
                 break;
 
+            case 'RECONNECT': {
+                // The node is draining for maintenance and refuses new connections,
+                // so the load balancer sends us to another node. Spread the reconnects.
+                console.log('WS: Server asked to reconnect:', msg.error);
+                this.disconnect(false);
+                this.setStatus('connecting');
+                const delay = Math.random() * this.BASE_RECONNECT_DELAY_MS * 2;
+                this.reconnectTimer = setTimeout(() => {
+                    this.connect(this.gameId, null);
+                }, delay);
+                break;
+            }
+
             case 'CONFLICT':
                 console.warn('WS Sync Conflict:', msg);
                 this.isSyncingHistory = false;
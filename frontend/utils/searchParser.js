@@ -13,75 +13,232 @@
 // limitations under the License.
 
 /**
- * Parses a search query string into structured filters and free text tokens.
+ * Parses a search query string into an expression tree, and the filters and
+ * free text tokens that every result must match (those that aren't negated or
+ * alternatives).
  * Supports:
  * - key:value
  * - key:"value with spaces"
  * - flags: is:local
- * - date operators: date:>=2025
- * - date range: date:2025-01..2025-02
+ * - comparison operators: date:>=2025, number:<10
+ * - ranges: date:2025-01..2025-02
+ * - implicit AND, and OR, which binds tighter: "a OR b c" is "(a OR b) AND c"
+ * - parenthesized groups
+ * - negation: -event:scrimmage, -word, -(a OR b)
+ *
+ * Expression nodes are { op: 'and'|'or'|'not', children } or
+ * { op: 'filter', filter } or { op: 'text', text }. The expression of an
+ * empty query is null.
  *
  * @param {string} queryString
- * @returns {object} { tokens: [], filters: [{ key, value, operator, maxValue }] }
+ * @returns {object} { tokens: [], filters: [{ key, value, operator, maxValue }], expr }
  */
 export function parseQuery(queryString) {
-    if (!queryString) {
-        return { tokens: [], filters: [] };
-    }
-
-    const tokens = tokenize(queryString);
     const result = {
         tokens: [],
         filters: [],
+        expr: null,
     };
+    if (!queryString) {
+        return result;
+    }
 
-    for (const token of tokens) {
-        const parts = token.split(':');
-        if (parts.length >= 2 && parts[0]) {
-            const key = parts.shift().toLowerCase();
-            const rawVal = parts.join(':'); // Rejoin rest in case value has colons (unlikely for our simple syntax but safe)
+    const parser = { tokens: tokenize(queryString), pos: 0 };
+    result.expr = parseAnd(parser, false);
 
-            if (!key || !rawVal) {
-                result.tokens.push(removeQuotes(token));
-                continue;
-            }
+    const collect = (e) => {
+        if (!e) {
+            return;
+        }
+        if (e.op === 'and') {
+            e.children.forEach(collect);
+        } else if (e.op === 'filter') {
+            result.filters.push(e.filter);
+        } else if (e.op === 'text') {
+            result.tokens.push(e.text);
+        }
+    };
+    collect(result.expr);
+
+    return result;
+}
 
-            // Parse Value/Operator
-            let value = rawVal;
-            let operator = '=';
-            let maxValue = '';
-
-            if (value.includes('..')) {
-                const rangeParts = value.split('..');
-                operator = '..';
-                value = rangeParts[0];
-                maxValue = rangeParts[1] || '';
-            } else if (value.startsWith('>=')) {
-                operator = '>=';
-                value = value.substring(2);
-            } else if (value.startsWith('<=')) {
-                operator = '<=';
-                value = value.substring(2);
-            } else if (value.startsWith('>')) {
-                operator = '>';
-                value = value.substring(1);
-            } else if (value.startsWith('<')) {
-                operator = '<';
-                value = value.substring(1);
+/**
+ * Evaluates a query expression.
+ * @param {object|null} expr The expression from parseQuery. Null matches everything.
+ * @param {function(object): boolean} matchTerm Matches a filter or text node.
+ * @returns {boolean}
+ */
+export function matchesQuery(expr, matchTerm) {
+    if (!expr) {
+        return true;
+    }
+    switch (expr.op) {
+        case 'and':
+            return expr.children.every(c => matchesQuery(c, matchTerm));
+        case 'or':
+            return expr.children.some(c => matchesQuery(c, matchTerm));
+        case 'not':
+            return !matchesQuery(expr.children[0], matchTerm);
+        default:
+            return matchTerm(expr);
+    }
+}
+
+// Parses a sequence of terms up to the end of the input or, in a group, up to
+// the closing parenthesis, which is left to the caller. Unbalanced
+// parentheses and dangling operators are ignored.
+function parseAnd(p, inGroup) {
+    const children = [];
+    while (p.pos < p.tokens.length) {
+        const token = p.tokens[p.pos];
+        if (token === ')') {
+            if (inGroup) {
+                break;
             }
+            p.pos++;
+            continue;
+        }
+        if (token === 'AND' || token === 'OR') {
+            p.pos++;
+            continue;
+        }
+        const e = parseOr(p);
+        if (e) {
+            children.push(e);
+        }
+    }
+    return simplify('and', children);
+}
 
-            result.filters.push({
+function parseOr(p) {
+    const children = [];
+    for (;;) {
+        const e = parseUnary(p);
+        if (e) {
+            children.push(e);
+        }
+        if (p.tokens[p.pos] === 'OR') {
+            p.pos++;
+            continue;
+        }
+        return simplify('or', children);
+    }
+}
+
+function parseUnary(p) {
+    if (p.pos >= p.tokens.length) {
+        return null;
+    }
+    const token = p.tokens[p.pos];
+    if (token === ')' || token === 'AND' || token === 'OR') {
+        return null;
+    }
+    p.pos++;
+    if (token === '(') {
+        const e = parseAnd(p, true);
+        p.pos++; // ')'
+        return e;
+    }
+    if (token === '-') {
+        return negate(parseUnary(p));
+    }
+    if (token.startsWith('-')) {
+        return negate(parseTerm(token.substring(1)));
+    }
+    return parseTerm(token);
+}
+
+function negate(e) {
+    return e ? { op: 'not', children: [e] } : null;
+}
+
+function simplify(op, children) {
+    if (children.length === 0) {
+        return null;
+    }
+    if (children.length === 1) {
+        return children[0];
+    }
+    return { op, children };
+}
+
+// Parses a key:value filter or free text.
+function parseTerm(token) {
+    const parts = token.split(':');
+    if (parts.length >= 2 && parts[0]) {
+        const key = parts.shift().toLowerCase();
+        const rawVal = parts.join(':'); // Rejoin rest in case value has colons (unlikely for our simple syntax but safe)
+
+        if (!key || !rawVal) {
+            return { op: 'text', text: removeQuotes(token) };
+        }
+
+        // Parse Value/Operator
+        let value = rawVal;
+        let operator = '=';
+        let maxValue = '';
+
+        if (value.includes('..')) {
+            const rangeParts = value.split('..');
+            operator = '..';
+            value = rangeParts[0];
+            maxValue = rangeParts[1] || '';
+        } else if (value.startsWith('>=')) {
+            operator = '>=';
+            value = value.substring(2);
+        } else if (value.startsWith('<=')) {
+            operator = '<=';
+            value = value.substring(2);
+        } else if (value.startsWith('>')) {
+            operator = '>';
+            value = value.substring(1);
+        } else if (value.startsWith('<')) {
+            operator = '<';
+            value = value.substring(1);
+        }
+
+        return {
+            op: 'filter',
+            filter: {
                 key,
                 value: removeQuotes(value),
                 operator,
                 maxValue: removeQuotes(maxValue),
-            });
-        } else {
-            result.tokens.push(removeQuotes(token));
-        }
+            },
+        };
     }
+    return { op: 'text', text: removeQuotes(token) };
+}
 
-    return result;
+/**
+ * Compares a number with a filter's value: =, >, >=, <, <= or a '..' range.
+ * @param {number} n
+ * @param {object} f The filter.
+ * @returns {boolean} False when the filter's values aren't numbers.
+ */
+export function compareNumber(n, f) {
+    const v = Number(f.value);
+    if (f.value === '' || Number.isNaN(v)) {
+        return false;
+    }
+    switch (f.operator) {
+        case '=':
+            return n === v;
+        case '>':
+            return n > v;
+        case '>=':
+            return n >= v;
+        case '<':
+            return n < v;
+        case '<=':
+            return n <= v;
+        case '..': {
+            const max = Number(f.maxValue);
+            return f.maxValue !== '' && !Number.isNaN(max) && n >= v && n <= max;
+        }
+    }
+    return false;
 }
 
 /**
@@ -134,6 +291,13 @@ function tokenize(input) {
                     tokens.push(currentToken);
                     currentToken = '';
                 }
+            } else if (char === '(' || char === ')') {
+                // Parentheses are tokens of their own: "-(a" is "-", "(", "a".
+                if (currentToken.length > 0) {
+                    tokens.push(currentToken);
+                    currentToken = '';
+                }
+                tokens.push(char);
             } else if (char === '"' || char === '\'') {
                 inQuote = true;
                 quoteChar = char;
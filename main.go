@@ -18,10 +18,12 @@ import (
 	"context"
 	"crypto/tls"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -33,7 +35,7 @@ import (
 var (
 	addr              = flag.String("addr", ":8080", "The TCP address to listen to")
 	useMockAuth       = flag.Bool("use-mock-auth", false, "Use Mock Authentication. For testing purposes only.")
-	debugMode         = flag.Bool("debug", false, "Enable debug mode")
+	debugMode         = flag.Bool("debug", false, "Enable debug mode (same as --log-level=debug)")
 	raftEnabled       = flag.Bool("raft", false, "Enable Raft consensus")
 	raftBind          = flag.String("raft-bind", ":8081", "Address for Raft TCP transport")
 	raftAdvertise     = flag.String("raft-advertise", "", "Public address for Raft traffic (REQUIRED)")
@@ -41,6 +43,9 @@ var (
 	clusterAddr       = flag.String("cluster-addr", ":9090", "Address for internal secure cluster API (mTLS)")
 	raftSecret        = flag.String("raft-secret", "", "Shared secret for cluster authentication")
 	raftBootstrap     = flag.Bool("raft-bootstrap", false, "Bootstrap the Raft cluster (only for first node)")
+	raftRecover       = flag.Bool("raft-recover", false, "Recover a cluster that permanently lost its quorum, with this node as the only voter (see docs/RAFT.md)")
+	replicaOf         = flag.String("replica-of", "", "Run as a read replica of the cluster at this public URL, e.g. https://scores.example.com; requires --raft-secret (see docs/RAFT.md)")
+	replicaAdvertise  = flag.String("replica-advertise", "", "Public URL of this read replica, shown in the cluster status")
 	dataDir           = flag.String("data-dir", "data", "Directory for game and team data")
 	tlsCert           = flag.String("tls-cert", "", "Path to main HTTP TLS certificate")
 	tlsKey            = flag.String("tls-key", "", "Path to main HTTP TLS key")
@@ -51,29 +56,69 @@ var (
 	forceRebuild      = flag.Bool("force-rebuild", false, "Force rebuild of Registry indices on startup")
 	snapshotThreshold = flag.Uint64("snapshot-threshold", 0, "Number of logs before snapshotting (default: 8192)")
 	trailingLogs      = flag.Uint64("trailing-logs", 0, "Number of logs to retain after snapshotting (default: 1024)")
+	metricsToken      = flag.String("metrics-token", "", "Bearer token required to scrape /metrics (default: no authentication)")
+	otlpEndpoint      = flag.String("otlp-endpoint", "", "OTLP/HTTP collector URL for trace export, e.g. http://localhost:4318 (default: tracing disabled)")
+	smtpRelay         = flag.String("smtp-relay", "", "SMTP relay (host:port) for alert emails, e.g. localhost:25 (default: email alerts disabled)")
+	alertFrom         = flag.String("alert-from", "skorekeeper@localhost", "From address of alert emails")
+	webhookLoopback   = flag.Bool("webhook-allow-loopback", false, "Allow webhooks and alert webhooks to be delivered to loopback addresses. For testing purposes only.")
+	traceSampleRatio  = flag.Float64("trace-sample-ratio", 1.0, "Fraction of new traces to sample when --otlp-endpoint is set")
+	logFormat         = flag.String("log-format", "text", "Log output format: text or json")
+	logLevel          = flag.String("log-level", "info", "Default log level: debug, info, warn or error")
+	backupTarget      = flag.String("backup-target", "", "Directory or s3://bucket/prefix for scheduled cluster backups; the passphrase is read from SK_BACKUP_KEY (default: backups disabled)")
+	backupInterval    = flag.Duration("backup-interval", 6*time.Hour, "Time between scheduled backups; 0 disables them")
+	backupKeep        = flag.Int("backup-keep", 28, "Number of backups to keep; 0 keeps all")
+	backupMaxAge      = flag.Duration("backup-max-age", 0, "Delete backups older than this, e.g. 720h (default: no age limit)")
+	backupS3Endpoint  = flag.String("backup-s3-endpoint", "", "S3-compatible endpoint, e.g. http://minio:9000 (default: AWS); credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	backupS3Region    = flag.String("backup-s3-region", "us-east-1", "Region of the S3 backup bucket")
+	restoreBackup     = flag.String("restore-backup", "", "Initialize a new cluster from this backup in --backup-target, or \"latest\" (requires --raft-bootstrap)")
+	logLevels         = flag.String("log-levels", "", "Per-subsystem log levels, e.g. raft=debug,auth=warn (subsystems: "+strings.Join(backend.LogSubsystems(), ", ")+")")
 )
 
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 // main starts the web server and registers the API handlers.
 func main() {
 	flag.Parse()
 
+	level := *logLevel
+	if *debugMode && !isFlagSet("log-level") {
+		level = "debug"
+	}
+	if err := backend.SetupLogging(backend.LogOptions{Format: *logFormat, Level: level, Levels: *logLevels}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	if *raftEnabled {
 		if *raftAdvertise == "" {
-			log.Fatal("--raft-advertise is required when Raft is enabled")
+			fatal("--raft-advertise is required when Raft is enabled")
 		}
 		if *clusterAdvertise == "" {
-			log.Fatal("--cluster-advertise is required when Raft is enabled")
+			fatal("--cluster-advertise is required when Raft is enabled")
 		}
 		if *raftSecret == "" {
-			log.Fatal("--raft-secret is required when Raft is enabled")
+			fatal("--raft-secret is required when Raft is enabled")
 		}
 	}
+	if *raftRecover && (!*raftEnabled || *raftBootstrap || *restoreBackup != "") {
+		fatal("--raft-recover requires --raft and cannot be used with --raft-bootstrap or --restore-backup")
+	}
+	if *replicaOf != "" && (*raftEnabled || *raftSecret == "" || *backupTarget != "") {
+		fatal("--replica-of requires --raft-secret and cannot be used with --raft or --backup-target")
+	}
+	if *restoreBackup != "" && (!*raftEnabled || !*raftBootstrap || *backupTarget == "") {
+		fatal("--restore-backup requires --raft, --raft-bootstrap, and --backup-target")
+	}
 
 	var mainTLSCert *tls.Certificate
 	if *tlsCert != "" && *tlsKey != "" {
 		cert, err := tls.LoadX509KeyPair(*tlsCert, *tlsKey)
 		if err != nil {
-			log.Fatalf("Failed to load main TLS cert/key: %v", err)
+			fatal("failed to load main TLS cert/key", "err", err)
 		}
 		mainTLSCert = &cert
 	}
@@ -89,31 +134,63 @@ func main() {
 		masterKey, err = crypto.ReadMasterKey([]byte(passphrase), keyFile)
 		if err != nil {
 			if os.IsNotExist(err) {
-				log.Println("Initializing new master encryption key...")
+				slog.Info("initializing new master encryption key")
 				masterKey, err = crypto.CreateMasterKey()
 				if err != nil {
-					log.Fatalf("Failed to create master key: %v", err)
+					fatal("failed to create master key", "err", err)
 				}
 				if err := masterKey.Save([]byte(passphrase), keyFile); err != nil {
-					log.Fatalf("Failed to save master key: %v", err)
+					fatal("failed to save master key", "err", err)
 				}
 			} else {
-				log.Fatalf("Failed to read master key: %v", err)
+				fatal("failed to read master key", "err", err)
 			}
 		} else {
-			log.Println("Loaded master encryption key.")
+			slog.Info("loaded master encryption key")
 		}
 	} else {
 		keyFile := filepath.Join(*dataDir, "master.key")
 		if _, err := os.Stat(keyFile); err == nil {
-			log.Fatalf("Critical Security Error: %s exists but SK_MASTER_KEY is not set. Refusing to start in unencrypted mode to prevent data corruption or exposure.", keyFile)
+			fatal("master key exists but SK_MASTER_KEY is not set; refusing to start in unencrypted mode to prevent data corruption or exposure", "file", keyFile)
+		}
+		slog.Warn("no SK_MASTER_KEY provided, data will be stored UNENCRYPTED")
+	}
+
+	var backups *backend.BackupManager
+	if *backupTarget != "" {
+		if !*raftEnabled || masterKey == nil {
+			fatal("--backup-target requires --raft and SK_MASTER_KEY")
+		}
+		var err error
+		backups, err = backend.NewBackupManager(backend.BackupConfig{
+			Target:      *backupTarget,
+			Interval:    *backupInterval,
+			Keep:        *backupKeep,
+			MaxAge:      *backupMaxAge,
+			Passphrase:  os.Getenv("SK_BACKUP_KEY"),
+			TempDir:     *dataDir,
+			S3Endpoint:  *backupS3Endpoint,
+			S3Region:    *backupS3Region,
+			S3AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			S3SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		})
+		if err != nil {
+			fatal("invalid backup configuration", "err", err)
 		}
-		log.Println("Warning: No SK_MASTER_KEY provided. Data will be stored UNENCRYPTED.")
 	}
 
 	store := storage.New(*dataDir, masterKey)
 	store.EnableCompression(true)
 
+	shutdownTracing := func(context.Context) error { return nil }
+	if *otlpEndpoint != "" {
+		var err error
+		if shutdownTracing, err = backend.SetupTracing(*otlpEndpoint, *traceSampleRatio); err != nil {
+			fatal("failed to set up tracing", "err", err)
+		}
+		slog.Info("exporting traces", "endpoint", *otlpEndpoint)
+	}
+
 	server, err := backend.StartServer(backend.Options{
 		Addr:                  *addr,
 		ClusterAdvertise:      *clusterAdvertise,
@@ -129,6 +206,9 @@ func main() {
 		RaftAdvertise:         *raftAdvertise,
 		RaftSecret:            *raftSecret,
 		RaftBootstrap:         *raftBootstrap,
+		RaftRecover:           *raftRecover,
+		ReplicaOf:             *replicaOf,
+		ReplicaAdvertise:      *replicaAdvertise,
 		UseProductionTimeouts: true,
 		AuthCookieName:        *authCookieName,
 		AuthJWKSURL:           *authJWKSURL,
@@ -137,9 +217,15 @@ func main() {
 		ForceRebuild:          *forceRebuild,
 		SnapshotThreshold:     *snapshotThreshold,
 		TrailingLogs:          *trailingLogs,
+		Backups:               backups,
+		RestoreBackup:         *restoreBackup,
+		MetricsToken:          *metricsToken,
+		SMTPRelay:             *smtpRelay,
+		AlertFrom:             *alertFrom,
+		WebhookAllowLoopback:  *webhookLoopback,
 	})
 	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatal("failed to start server", "err", err)
 	}
 
 	// Wait for interrupt signal
@@ -147,13 +233,27 @@ func main() {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 
-	log.Println("Shutting down...")
+	slog.Info("shutting down")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Shutdown error: %v", err)
+		slog.Error("shutdown failed", "err", err)
 	} else {
-		log.Println("Gracefully stopped.")
+		slog.Info("gracefully stopped")
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("trace export shutdown failed", "err", err)
+	}
+}
+
+// isFlagSet reports whether the named flag was set on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
@@ -14,6 +14,7 @@ describe('Admin Dashboard', () => {
             <div class="nav-tabs">
                 <div class="nav-tab active" data-tab="policy"></div>
                 <div class="nav-tab" data-tab="monitoring"></div>
+                <div class="nav-tab" data-tab="alerts"></div>
             </div>
             <div id="tab-policy" class="tab-content active">
                 <div id="loading"></div>
@@ -47,6 +48,26 @@ describe('Admin Dashboard', () => {
                 <canvas id="chart-elections"></canvas>
                 <canvas id="chart-gap"></canvas>
             </div>
+            <div id="tab-alerts" class="tab-content">
+                <button id="btnRefreshAlerts"></button>
+                <div id="activeAlerts"></div>
+                <div id="alertRulesList"></div>
+                <form id="alertForm">
+                    <input type="hidden" id="alertId">
+                    <input id="alertName">
+                    <select id="alertKind"><option value="no_leader">No leader</option><option value="disk_usage">Disk</option></select>
+                    <input id="alertThreshold">
+                    <input id="alertWindow">
+                    <input id="alertFor">
+                    <select id="alertNode"><option value="">All nodes</option></select>
+                    <input id="alertUrl">
+                    <input id="alertEmail">
+                    <input type="checkbox" id="alertDisabled">
+                    <div id="alertSecret"></div>
+                    <button type="submit" id="btnSaveAlert"></button>
+                </form>
+                <div id="alertHistory"></div>
+            </div>
             <div id="status"></div>
         `;
 
@@ -196,6 +217,48 @@ describe('Admin Dashboard', () => {
         expect(global.fetch).toHaveBeenCalledWith('/api/cluster/metrics');
     });
 
+    test('should load, edit and save alert rules', async() => {
+        global.fetch.mockImplementation((url, opts) => {
+            if (url === '/api/admin/alerts' && !opts) {
+                return Promise.resolve({
+                    ok: true,
+                    json: () => Promise.resolve({
+                        rules: [{ id: 'r1', name: 'Disk <full>', kind: 'disk_usage', threshold: 90, email: ['ops@example.com'] }],
+                        active: [{ ruleId: 'r1', rule: 'Disk <full>', kind: 'disk_usage', subject: 'n1', value: 95, threshold: 90, since: 1000 }],
+                        history: [],
+                        nodes: { n1: 'n1:9090' },
+                    }),
+                });
+            }
+            if (url === '/api/admin/alerts') {
+                return Promise.resolve({ ok: true, json: () => Promise.resolve({ id: 'r1', name: 'Disk' }) });
+            }
+            return Promise.resolve({ ok: false });
+        });
+
+        document.querySelector('[data-tab="alerts"]').click();
+        await new Promise(resolve => setTimeout(resolve, 0));
+
+        expect(document.getElementById('activeAlerts').textContent).toContain('95.0%');
+        const rules = document.getElementById('alertRulesList');
+        expect(rules.children.length).toBe(1);
+        expect(rules.innerHTML).toContain('Disk &lt;full&gt;');
+        expect(document.getElementById('alertNode').options.length).toBe(2);
+
+        Element.prototype.scrollIntoView = jest.fn(); // Not implemented by jsdom
+        rules.querySelector('[data-action="edit-rule"]').click();
+        expect(document.getElementById('alertId').value).toBe('r1');
+        expect(document.getElementById('alertKind').value).toBe('disk_usage');
+        document.getElementById('alertThreshold').value = '80';
+
+        document.getElementById('alertForm').dispatchEvent(new Event('submit'));
+        await new Promise(resolve => setTimeout(resolve, 0));
+
+        const post = global.fetch.mock.calls.find(([url, opts]) => url === '/api/admin/alerts' && opts);
+        const body = JSON.parse(post[1].body);
+        expect(body).toMatchObject({ id: 'r1', kind: 'disk_usage', threshold: 80, email: ['ops@example.com'] });
+    });
+
     test('should update hash when switching tabs', async() => {
         // Initial state (policy)
         await new Promise(resolve => setTimeout(resolve, 0));
@@ -73,6 +73,13 @@ describe('Cluster Dashboard', () => {
             if (url === '/api/cluster/remove') {
                 return Promise.resolve({ ok: true });
             }
+            if (url.startsWith('/api/cluster/drain?nodeId=')) {
+                const nodeId = decodeURIComponent(url.split('=')[1]);
+                const drain = nodeId === 'node2' ?
+                    { nodeId, state: 'drained', clientsMigrated: 3, connections: 0 } :
+                    { nodeId, clientsMigrated: 0, connections: 5 };
+                return Promise.resolve({ ok: true, json: () => Promise.resolve(drain) });
+            }
             return Promise.reject(new Error('Unknown URL: ' + url));
         });
 
@@ -201,4 +208,28 @@ describe('Cluster Dashboard', () => {
         }));
         expect(mockAlert).toHaveBeenCalledWith(expect.stringContaining('removed'));
     });
+
+    test('should show drain progress and drain a node', async() => {
+        document.getElementById('secret').value = 'correct-secret';
+        document.getElementById('btn-access-dashboard').click();
+        await new Promise(resolve => setTimeout(resolve, 0));
+
+        const rows = document.getElementById('nodes-table-body').children;
+        expect(rows[0].textContent).not.toContain('Drain:');
+        expect(rows[0].textContent).toContain('Drain');
+        expect(rows[1].textContent).toContain('Drain: drained, 3 clients migrated');
+        expect(rows[1].textContent).toContain('Undrain');
+
+        mockConfirm.mockReturnValue(true);
+        await window.drainNode('node1', true);
+        expect(mockFetch).toHaveBeenCalledWith('/api/cluster/drain?nodeId=node1', expect.objectContaining({
+            method: 'POST',
+            headers: expect.objectContaining({ 'X-Raft-Secret': 'correct-secret' }),
+        }));
+
+        await window.drainNode('node2', false);
+        expect(mockFetch).toHaveBeenCalledWith('/api/cluster/drain?nodeId=node2', expect.objectContaining({
+            method: 'DELETE',
+        }));
+    });
 });
@@ -1,4 +1,4 @@
-import { parseQuery, buildQuery } from '../../frontend/utils/searchParser.js';
+import { parseQuery, buildQuery, matchesQuery, compareNumber } from '../../frontend/utils/searchParser.js';
 
 describe('Search Parser', () => {
     test('parses simple free text', () => {
@@ -34,6 +34,47 @@ describe('Search Parser', () => {
         expect(res.tokens).toEqual(['stadium']);
     });
 
+    test('parses boolean expressions', () => {
+        const res = parseQuery('home:Tigers OR away:Tigers -event:scrimmage');
+        expect(res.expr.op).toBe('and');
+        expect(res.expr.children[0].op).toBe('or');
+        expect(res.expr.children[0].children.map(c => c.filter.key)).toEqual(['home', 'away']);
+        expect(res.expr.children[1]).toEqual({
+            op: 'not',
+            children: [{ op: 'filter', filter: { key: 'event', value: 'scrimmage', operator: '=', maxValue: '' } }],
+        });
+        // Only the terms that all results must match.
+        expect(res.filters).toEqual([]);
+        expect(res.tokens).toEqual([]);
+    });
+
+    test('keeps required terms of boolean expressions', () => {
+        const res = parseQuery('(a OR b) -c (d date:2025)');
+        expect(res.tokens).toEqual(['d']);
+        expect(res.filters).toEqual([{ key: 'date', value: '2025', operator: '=', maxValue: '' }]);
+    });
+
+    test('evaluates expressions', () => {
+        const words = new Set(['a', 'b']);
+        const match = q => matchesQuery(parseQuery(q).expr, term => words.has(term.text));
+        expect(match('')).toBe(true);
+        expect(match('a b')).toBe(true);
+        expect(match('a c')).toBe(false);
+        expect(match('c OR b')).toBe(true);
+        expect(match('-(a OR c)')).toBe(false);
+        expect(match('(c OR -a) OR (b -c)')).toBe(true);
+        expect(match('(a OR c')).toBe(true);
+    });
+
+    test('compares numbers', () => {
+        const filter = q => parseQuery(q).filters[0];
+        expect(compareNumber(11, filter('runs:>10'))).toBe(true);
+        expect(compareNumber(10, filter('runs:>10'))).toBe(false);
+        expect(compareNumber(9, filter('innings:>=9'))).toBe(true);
+        expect(compareNumber(5, filter('runs:1..5'))).toBe(true);
+        expect(compareNumber(1, filter('runs:many'))).toBe(false);
+    });
+
     test('buildQuery reconstructs string', () => {
         const obj = {
             tokens: ['stadium'],
@@ -313,5 +313,18 @@ describe('SyncManager Rate Limiting & Batching', () => {
             syncManager.handleMessage(conflictData);
             expect(mockOnConflict).toHaveBeenCalledWith(conflictData);
         });
+
+        test('handleMessage(RECONNECT) should reconnect after a short delay', async() => {
+            jest.useFakeTimers();
+            await syncManager.connect('game-1');
+            const oldSocket = syncManager.socket;
+            syncManager.handleMessage({ type: 'RECONNECT', error: 'Server is draining for maintenance' });
+            expect(syncManager.socket).toBeNull();
+            expect(syncManager.shouldReconnect).toBe(true);
+            jest.advanceTimersByTime(2 * syncManager.BASE_RECONNECT_DELAY_MS);
+            expect(syncManager.socket).toBeInstanceOf(MockWebSocket);
+            expect(syncManager.socket).not.toBe(oldSocket);
+            jest.useRealTimers();
+        });
     });
 });
\ No newline at end of file